@@ -0,0 +1,316 @@
+// Package client is a Go client of the REST API of the statistics service,
+// for the other ClassConnect services. Its types mirror the JSON the
+// handlers answer with, so callers don't decode maps.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// BasePath is where the service mounts the API.
+const BasePath = "/stats"
+
+// maxErrorBody bounds the part of an error response read into Error.
+const maxErrorBody = 4096
+
+const dateLayout = "2006-01-02"
+
+// Client calls the API at BaseURL, the address of the service without
+// BasePath, e.g. "http://localhost:8080".
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func New(baseURL string, timeout time.Duration) *Client {
+	return &Client{BaseURL: baseURL, HTTPClient: &http.Client{Timeout: timeout}}
+}
+
+// Error is a response outside 2xx. Message is the "error" or "result" of
+// the body, or the body itself when it is not JSON.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("stats api: status %d: %s", e.StatusCode, e.Message)
+}
+
+// RangeOptions picks the range and grouping of a time series. The zero
+// value asks for the whole history in a single "all_time" period.
+type RangeOptions struct {
+	// Start and End are sent as dates, in their own location. Either may
+	// be zero to leave the range open.
+	Start, End time.Time
+	// GroupBy is a unit ("day", "week", ...), an interval such as
+	// "3 days" or "term".
+	GroupBy string
+	// TZ is the IANA zone the dates and periods are in; UTC when empty.
+	TZ string
+	// WeekStart is "iso" (Monday) or "sunday".
+	WeekStart string
+	// Institution is the academic calendar of GroupBy "term".
+	Institution string
+}
+
+func (o RangeOptions) values() url.Values {
+	v := url.Values{}
+	setDate(v, "start_date", o.Start)
+	setDate(v, "end_date", o.End)
+	setString(v, "group_by", o.GroupBy)
+	setString(v, "tz", o.TZ)
+	setString(v, "week_start", o.WeekStart)
+	setString(v, "institution", o.Institution)
+	return v
+}
+
+// Fill values of SeriesOptions.
+const (
+	FillNull     = "null"
+	FillZero     = "zero"
+	FillPrevious = "previous"
+)
+
+// SeriesOptions completes an average series; see the README section on
+// complete series.
+type SeriesOptions struct {
+	RangeOptions
+	// Fill returns every period of the range, filling the empty ones.
+	Fill string
+	// Rolling adds the average of the last Rolling periods.
+	Rolling int
+	// Cumulative adds the average of every period so far.
+	Cumulative bool
+}
+
+func (o SeriesOptions) values() url.Values {
+	v := o.RangeOptions.values()
+	setString(v, "fill", o.Fill)
+	if o.Rolling > 0 {
+		v.Set("rolling", strconv.Itoa(o.Rolling))
+	}
+	if o.Cumulative {
+		v.Set("cumulative", "true")
+	}
+	return v
+}
+
+// ListOptions pages through and filters a list of students. The zero value
+// asks for the first 50 by descending average.
+type ListOptions struct {
+	Limit int
+	// Cursor is the Pagination.NextCursor of the previous page.
+	Cursor string
+	// Sort is "average", "count" or "student_id".
+	Sort string
+	// Order is "asc" or "desc".
+	Order      string
+	MinAverage *float64
+	MaxAverage *float64
+	// OnTime averages only the submissions made on time.
+	OnTime bool
+	// GradedAfter leaves out the grades recorded before that date.
+	GradedAfter time.Time
+}
+
+func (o ListOptions) values() url.Values {
+	v := url.Values{}
+	if o.Limit > 0 {
+		v.Set("limit", strconv.Itoa(o.Limit))
+	}
+	setString(v, "cursor", o.Cursor)
+	setString(v, "sort", o.Sort)
+	setString(v, "order", o.Order)
+	if o.MinAverage != nil {
+		v.Set("min_average", strconv.FormatFloat(*o.MinAverage, 'f', -1, 64))
+	}
+	if o.MaxAverage != nil {
+		v.Set("max_average", strconv.FormatFloat(*o.MaxAverage, 'f', -1, 64))
+	}
+	if o.OnTime {
+		v.Set("on_time", "true")
+	}
+	setDate(v, "graded_after", o.GradedAfter)
+	return v
+}
+
+func setString(v url.Values, key, value string) {
+	if value != "" {
+		v.Set(key, value)
+	}
+}
+
+func setDate(v url.Values, key string, value time.Time) {
+	if !value.IsZero() {
+		v.Set(key, value.Format(dateLayout))
+	}
+}
+
+// AddGrade sends a grade of a course. It returns the stored grade when the
+// service runs with WRITE_MODE=sync, and nil when the grade was queued.
+func (c *Client) AddGrade(ctx context.Context, grade Grade) (*Grade, error) {
+	var stored Grade
+	status, err := c.do(ctx, http.MethodPost, "/student/grade", nil, grade, &stored)
+	if err != nil || status != http.StatusCreated {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// AddGradeTask is AddGrade for the grades of a task.
+func (c *Client) AddGradeTask(ctx context.Context, grade GradeTask) (*GradeTask, error) {
+	var stored GradeTask
+	status, err := c.do(ctx, http.MethodPost, "/student/task/grade", nil, grade, &stored)
+	if err != nil || status != http.StatusCreated {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// StudentCourseAverage returns the average of a student in a course. A
+// student without grades in the course is an Error with status 404.
+func (c *Client) StudentCourseAverage(ctx context.Context, studentID, courseID string) (*StudentCourseStats, error) {
+	var stats StudentCourseStats
+	if _, err := c.do(ctx, http.MethodGet, "/student/"+url.PathEscape(studentID)+"/course/"+url.PathEscape(courseID), nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// StudentTaskAverage returns the average of a student in a task.
+func (c *Client) StudentTaskAverage(ctx context.Context, studentID, courseID, taskID string) (*StudentTaskStats, error) {
+	var stats StudentTaskStats
+	path := "/student/" + url.PathEscape(studentID) + "/course/" + url.PathEscape(courseID) + "/task/" + url.PathEscape(taskID)
+	if _, err := c.do(ctx, http.MethodGet, path, nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (c *Client) StudentAveragesOverTime(ctx context.Context, studentID string, opts SeriesOptions) (*StudentAveragesOverTime, error) {
+	var averages StudentAveragesOverTime
+	if _, err := c.do(ctx, http.MethodGet, "/student/"+url.PathEscape(studentID)+"/average", opts.values(), nil, &averages); err != nil {
+		return nil, err
+	}
+	return &averages, nil
+}
+
+func (c *Client) CourseAveragesOverTime(ctx context.Context, courseID string, opts SeriesOptions) (*CourseAveragesOverTime, error) {
+	var averages CourseAveragesOverTime
+	if _, err := c.do(ctx, http.MethodGet, "/course/"+url.PathEscape(courseID)+"/average", opts.values(), nil, &averages); err != nil {
+		return nil, err
+	}
+	return &averages, nil
+}
+
+// StudentCourseTasksAverage returns the task average of a student in a
+// course along with a page of the other students.
+func (c *Client) StudentCourseTasksAverage(ctx context.Context, studentID, courseID string, opts ListOptions) (*StudentCourseTasksAverage, error) {
+	var averages StudentCourseTasksAverage
+	path := "/student/" + url.PathEscape(studentID) + "/course/" + url.PathEscape(courseID) + "/task/average"
+	if _, err := c.do(ctx, http.MethodGet, path, opts.values(), nil, &averages); err != nil {
+		return nil, err
+	}
+	return &averages, nil
+}
+
+// TaskAverages returns the average of a task and a page of its students.
+func (c *Client) TaskAverages(ctx context.Context, courseID, taskID string, opts ListOptions) (*TaskAverages, error) {
+	var averages TaskAverages
+	path := "/course/" + url.PathEscape(courseID) + "/task/" + url.PathEscape(taskID) + "/averages"
+	if _, err := c.do(ctx, http.MethodGet, path, opts.values(), nil, &averages); err != nil {
+		return nil, err
+	}
+	return &averages, nil
+}
+
+func (c *Client) CourseOnTimePercentage(ctx context.Context, courseID string, opts RangeOptions) (*OnTimePercentage, error) {
+	var percentage OnTimePercentage
+	if _, err := c.do(ctx, http.MethodGet, "/course/"+url.PathEscape(courseID)+"/on_time_percentage", opts.values(), nil, &percentage); err != nil {
+		return nil, err
+	}
+	return &percentage, nil
+}
+
+func (c *Client) StudentOnTimePercentage(ctx context.Context, courseID, studentID string, opts RangeOptions) (*OnTimePercentage, error) {
+	var percentage OnTimePercentage
+	path := "/course/" + url.PathEscape(courseID) + "/student/" + url.PathEscape(studentID) + "/on_time_percentage"
+	if _, err := c.do(ctx, http.MethodGet, path, opts.values(), nil, &percentage); err != nil {
+		return nil, err
+	}
+	return &percentage, nil
+}
+
+// do sends a request to path under BasePath with body as JSON, if any, and
+// decodes a 2xx response into out. It returns the response status.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (int, error) {
+	target := c.BaseURL + BasePath + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, responseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("stats api: decoding %s %s: %w", method, path, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// responseError reads the message of a failed response. The endpoints
+// answer either {"error": "..."} or {"result": "...", "status": 400}.
+func responseError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	var body struct {
+		Error  string          `json:"error"`
+		Result json.RawMessage `json:"result"`
+	}
+	message := string(bytes.TrimSpace(raw))
+	if json.Unmarshal(raw, &body) == nil {
+		var result string
+		switch {
+		case body.Error != "":
+			message = body.Error
+		case json.Unmarshal(body.Result, &result) == nil && result != "":
+			message = result
+		}
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: message}
+}
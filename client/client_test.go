@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/routes"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve runs the real routes of the service, so the tests catch any drift
+// between these types and what the handlers answer.
+func serve(t *testing.T, deps routes.Dependencies) *Client {
+	gin.SetMode(gin.TestMode)
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	deps.DB = db
+
+	router := gin.New()
+	routes.Register(router, deps)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return New(server.URL, 5*time.Second)
+}
+
+func float(value float64) *float64 {
+	return &value
+}
+
+func TestBasePath(t *testing.T) {
+	assert.Equal(t, routes.BasePath, BasePath)
+}
+
+func TestCourseAveragesOverTime(t *testing.T) {
+	original := database.GetCourseAveragesOverTime
+	defer func() { database.GetCourseAveragesOverTime = original }()
+
+	database.GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
+		assert.Equal(t, "c1", courseID)
+		assert.Equal(t, "week", grouping.String())
+		assert.Equal(t, "America/Argentina/Buenos_Aires", grouping.Location.String())
+		assert.Equal(t, database.SeriesOptions{Fill: FillNull, Rolling: 2}, series)
+		return []database.PeriodAverage{
+			{Period: "2026-03-02T00:00:00-03:00", AverageGrade: float(8), GradeCount: 2, RollingAverage: float(8)},
+			{Period: "2026-03-09T00:00:00-03:00", GradeCount: 0},
+		}, nil
+	}
+	c := serve(t, routes.Dependencies{})
+
+	resp, err := c.CourseAveragesOverTime(context.Background(), "c1", SeriesOptions{
+		RangeOptions: RangeOptions{
+			Start:   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			End:     time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
+			GroupBy: "week",
+			TZ:      "America/Argentina/Buenos_Aires",
+		},
+		Fill:    FillNull,
+		Rolling: 2,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "c1", resp.CourseID)
+	assert.Equal(t, "week", resp.GroupBy)
+	assert.Equal(t, "America/Argentina/Buenos_Aires", resp.TimeRange.TZ)
+	assert.Equal(t, time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC), resp.TimeRange.Start.UTC())
+	assert.Equal(t, []PeriodAverage{
+		{Period: "2026-03-02T00:00:00-03:00", AverageGrade: float(8), GradeCount: 2, RollingAverage: float(8)},
+		{Period: "2026-03-09T00:00:00-03:00"},
+	}, resp.Averages)
+}
+
+func TestTaskAverages(t *testing.T) {
+	getAverages, getSummary := database.GetAveragesForTask, database.GetTaskSummary
+	defer func() { database.GetAveragesForTask, database.GetTaskSummary = getAverages, getSummary }()
+
+	database.GetAveragesForTask = func(ctx context.Context, DB *sql.DB, courseID, taskID string, opts database.ListOptions) (*database.Page, error) {
+		assert.Equal(t, 1, opts.Limit)
+		assert.Equal(t, database.SortCount, opts.Sort)
+		assert.Equal(t, 6.0, *opts.MinAverage)
+		assert.True(t, opts.OnTimeOnly)
+		assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), opts.GradedAfter)
+		return &database.Page{
+			Items:      []database.StudentAverage{{StudentID: "s1", AverageGrade: 9, GradeCount: 3}},
+			Total:      2,
+			NextCursor: "next",
+		}, nil
+	}
+	database.GetTaskSummary = func(ctx context.Context, DB *sql.DB, courseID, taskID string) (float64, int, int, error) {
+		return 8, 4, 3, nil
+	}
+	c := serve(t, routes.Dependencies{})
+
+	resp, err := c.TaskAverages(context.Background(), "c1", "t1", ListOptions{
+		Limit:       1,
+		Sort:        "count",
+		MinAverage:  float(6),
+		OnTime:      true,
+		GradedAfter: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 8.0, resp.GroupAverage)
+	assert.Equal(t, []StudentAverage{{StudentID: "s1", AverageGrade: 9, GradeCount: 3}}, resp.Students)
+	require.NotNil(t, resp.Pagination.NextCursor)
+	assert.Equal(t, Pagination{Limit: 1, Total: 2, NextCursor: resp.Pagination.NextCursor}, resp.Pagination)
+	assert.Equal(t, "next", *resp.Pagination.NextCursor)
+}
+
+func TestStudentCourseTasksAverage(t *testing.T) {
+	getAverage, getOthers := database.GetStudentCourseTasksAverage, database.GetOtherStudentsCourseAverages
+	defer func() {
+		database.GetStudentCourseTasksAverage, database.GetOtherStudentsCourseAverages = getAverage, getOthers
+	}()
+
+	database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
+		return 0, http.StatusNotFound, nil
+	}
+	database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID, courseID string, opts database.ListOptions) (*database.Page, error) {
+		return &database.Page{Items: []database.StudentAverage{{StudentID: "s2", AverageGrade: 7, TaskCount: 2}}, Total: 1}, nil
+	}
+	c := serve(t, routes.Dependencies{})
+
+	resp, err := c.StudentCourseTasksAverage(context.Background(), "s1", "c1", ListOptions{})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, resp.Warning)
+	assert.Equal(t, []StudentAverage{{StudentID: "s2", AverageGrade: 7, TaskCount: 2}}, resp.OtherStudents)
+	assert.Equal(t, Pagination{Limit: database.DefaultListLimit, Total: 1}, resp.Pagination)
+}
+
+func TestStudentOnTimePercentage(t *testing.T) {
+	original := database.GetOnTimeSubmissionPercentageForStudent
+	defer func() { database.GetOnTimeSubmissionPercentageForStudent = original }()
+
+	database.GetOnTimeSubmissionPercentageForStudent = func(ctx context.Context, DB *sql.DB, courseID, studentID string, startTime, endTime time.Time, grouping database.TimeGrouping) ([]database.OnTimeStat, error) {
+		assert.Equal(t, database.UnitTerm, grouping.Unit)
+		assert.Equal(t, "fiuba", grouping.Institution)
+		return []database.OnTimeStat{{Period: "2026-1C", OnTimeCount: 3, TotalCount: 4, Percentage: 75}}, nil
+	}
+	c := serve(t, routes.Dependencies{})
+
+	resp, err := c.StudentOnTimePercentage(context.Background(), "c1", "s1", RangeOptions{GroupBy: "term", Institution: "fiuba"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "s1", resp.StudentID)
+	assert.Equal(t, "term", resp.GroupBy)
+	assert.Equal(t, []OnTimeStat{{Period: "2026-1C", OnTimeCount: 3, TotalCount: 4, Percentage: 75}}, resp.Data)
+}
+
+func TestErrors(t *testing.T) {
+	original := database.GetAvgGradeForStudent
+	defer func() { database.GetAvgGradeForStudent = original }()
+
+	database.GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
+		return 0, http.StatusNotFound, nil
+	}
+	c := serve(t, routes.Dependencies{})
+
+	_, err := c.StudentCourseAverage(context.Background(), "s1", "c1")
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr), err)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "No grades found for the student in the course", apiErr.Message)
+
+	_, err = c.CourseAveragesOverTime(context.Background(), "c1", SeriesOptions{RangeOptions: RangeOptions{GroupBy: "fortnight"}})
+	require.True(t, errors.As(err, &apiErr), err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "invalid grouping")
+}
+
+type fakeEnqueuer struct{}
+
+func (fakeEnqueuer) Enqueue(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
+	return time.Minute, nil
+}
+
+func TestAddGrade(t *testing.T) {
+	original := service.InsertGrade
+	defer func() { service.InsertGrade = original }()
+
+	createdAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		g.CreatedAt = createdAt
+		return g, nil
+	}
+
+	stored, err := serve(t, routes.Dependencies{SyncWrites: true}).AddGrade(context.Background(), Grade{StudentID: "s1", CourseID: "c1", Grade: 7})
+	require.NoError(t, err)
+	assert.Equal(t, &Grade{StudentID: "s1", CourseID: "c1", Grade: 7, CreatedAt: createdAt}, stored)
+
+	stored, err = serve(t, routes.Dependencies{Enqueuer: fakeEnqueuer{}}).AddGrade(context.Background(), Grade{StudentID: "s1", CourseID: "c1", Grade: 7})
+	require.NoError(t, err)
+	assert.Nil(t, stored, "queued")
+}
@@ -0,0 +1,135 @@
+package client
+
+import "time"
+
+// Grade is the body of AddGrade. CreatedAt is optional; the service uses
+// the time it stores the grade when it is zero.
+type Grade struct {
+	StudentID string    `json:"student_id"`
+	CourseID  string    `json:"course_id"`
+	Grade     float64   `json:"grade"`
+	OnTime    bool      `json:"on_time"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GradeTask is the body of AddGradeTask.
+type GradeTask struct {
+	StudentID string    `json:"student_id"`
+	CourseID  string    `json:"course_id"`
+	TaskID    string    `json:"task_id"`
+	Grade     float64   `json:"grade"`
+	OnTime    bool      `json:"on_time"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TimeRange is the range a time series covers, as answered by the service.
+type TimeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	TZ    string    `json:"tz"`
+}
+
+// PeriodAverage is a period of an average series.
+type PeriodAverage struct {
+	// Period is the start of the period in RFC 3339, the name of an
+	// academic term or "all_time".
+	Period string `json:"period"`
+	// AverageGrade is nil for the empty periods filled with FillNull.
+	AverageGrade *float64 `json:"average_grade"`
+	GradeCount   int      `json:"grade_count"`
+	// RollingAverage and CumulativeAverage are only set when the series
+	// asks for them, once there are grades in their window.
+	RollingAverage    *float64 `json:"rolling_average,omitempty"`
+	CumulativeAverage *float64 `json:"cumulative_average,omitempty"`
+}
+
+// StudentAveragesOverTime is the answer of StudentAveragesOverTime.
+type StudentAveragesOverTime struct {
+	StudentID string          `json:"student_id"`
+	Averages  []PeriodAverage `json:"averages"`
+	TimeRange TimeRange       `json:"time_range"`
+	GroupBy   string          `json:"group_by"`
+}
+
+// CourseAveragesOverTime is the answer of CourseAveragesOverTime.
+type CourseAveragesOverTime struct {
+	CourseID  string          `json:"course_id"`
+	Averages  []PeriodAverage `json:"averages"`
+	TimeRange TimeRange       `json:"time_range"`
+	GroupBy   string          `json:"group_by"`
+}
+
+// OnTimeStat counts the task grades of a period submitted on time.
+type OnTimeStat struct {
+	Period      string  `json:"period"`
+	OnTimeCount int     `json:"on_time_count"`
+	TotalCount  int     `json:"total_count"`
+	Percentage  float64 `json:"percentage"`
+}
+
+// OnTimePercentage is the answer of CourseOnTimePercentage and
+// StudentOnTimePercentage; StudentID is only set by the latter.
+type OnTimePercentage struct {
+	CourseID  string       `json:"course_id"`
+	StudentID string       `json:"student_id,omitempty"`
+	Data      []OnTimeStat `json:"data"`
+	TimeRange TimeRange    `json:"time_range"`
+	GroupBy   string       `json:"group_by"`
+}
+
+// StudentAverage is an item of a list of students. The students of
+// StudentCourseTasksAverage set TaskCount and those of TaskAverages set
+// GradeCount.
+type StudentAverage struct {
+	StudentID    string  `json:"student_id"`
+	AverageGrade float64 `json:"average_grade"`
+	TaskCount    int     `json:"task_count,omitempty"`
+	GradeCount   int     `json:"grade_count,omitempty"`
+}
+
+// Pagination tells how to fetch the following page of a list.
+type Pagination struct {
+	Limit int `json:"limit"`
+	// Total is the number of students matching the filters across all
+	// pages.
+	Total int `json:"total"`
+	// NextCursor is the Cursor of the following page; nil on the last one.
+	NextCursor *string `json:"next_cursor"`
+}
+
+// StudentCourseTasksAverage is the answer of StudentCourseTasksAverage.
+type StudentCourseTasksAverage struct {
+	StudentID      string           `json:"student_id"`
+	CourseID       string           `json:"course_id"`
+	StudentAverage float64          `json:"student_average"`
+	OtherStudents  []StudentAverage `json:"other_students"`
+	Pagination     Pagination       `json:"pagination"`
+	// Warning is set when the student has no grades in the course.
+	Warning string `json:"warning,omitempty"`
+}
+
+// TaskAverages is the answer of TaskAverages.
+type TaskAverages struct {
+	CourseID     string           `json:"course_id"`
+	TaskID       string           `json:"task_id"`
+	GroupAverage float64          `json:"group_average"`
+	Students     []StudentAverage `json:"students"`
+	Pagination   Pagination       `json:"pagination"`
+}
+
+// StudentCourseStats is the answer of StudentCourseAverage.
+type StudentCourseStats struct {
+	CourseID string `json:"course_id"`
+	Result   struct {
+		AverageGrade float64 `json:"average_grade"`
+	} `json:"result"`
+}
+
+// StudentTaskStats is the answer of StudentTaskAverage.
+type StudentTaskStats struct {
+	CourseID string `json:"course_id"`
+	TaskID   string `json:"task_id"`
+	Result   struct {
+		AverageGrade float64 `json:"average_grade"`
+	} `json:"result"`
+}
@@ -0,0 +1,107 @@
+// Package cache keeps rendered responses of the statistics endpoints. The
+// API reads and fills it; the worker drops the entries a grade affects once
+// the grade is committed.
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+)
+
+// ErrMiss is returned by Store.Get when the key isn't cached.
+var ErrMiss = errors.New("cache miss")
+
+// Invalidator drops every entry stored under any of the given tags.
+type Invalidator interface {
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+// Store holds cached values. Each value is stored under a set of tags (the
+// course and student it was computed from) so writes can invalidate
+// everything they affect without knowing the keys.
+//
+// Every tag has a version that Invalidate bumps. A value is computed after
+// reading the versions of its tags and Set only stores it if they are still
+// current, so a response computed from data a write has since changed (or
+// read from a replica that hasn't seen the write yet) isn't cached after
+// the write invalidated its tags.
+type Store interface {
+	Invalidator
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Versions returns the current version of each of tags.
+	Versions(ctx context.Context, tags []string) ([]int64, error)
+	// Set stores value under tags unless any of them moved past the
+	// versions given, one per tag; it is then a no-op.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string, versions []int64) error
+}
+
+// CourseTag is the tag of every entry computed from a course's grades.
+func CourseTag(courseID string) string {
+	return "course:" + courseID
+}
+
+// StudentTag is the tag of every entry computed from a student's grades.
+func StudentTag(studentID string) string {
+	return "student:" + studentID
+}
+
+// InstitutionTag is the tag of every entry grouped by the academic
+// calendar of an institution.
+func InstitutionTag(institutionID string) string {
+	return "institution:" + institutionID
+}
+
+// GradeTags are the tags a new grade of studentID in courseID invalidates.
+func GradeTags(studentID, courseID string) []string {
+	return []string{CourseTag(courseID), StudentTag(studentID)}
+}
+
+// Fallback uses Primary (Redis) and switches to Secondary (the in-process
+// LRU) for any call Primary fails. Secondary can't see invalidations made
+// by other processes, so its entries are only as fresh as their TTL.
+type Fallback struct {
+	Primary   Store
+	Secondary Store
+}
+
+func (f Fallback) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := f.Primary.Get(ctx, key)
+	if err == nil || errors.Is(err, ErrMiss) {
+		return value, err
+	}
+	observeError(ctx, "get", err)
+	return f.Secondary.Get(ctx, key)
+}
+
+func (f Fallback) Versions(ctx context.Context, tags []string) ([]int64, error) {
+	versions, err := f.Primary.Versions(ctx, tags)
+	if err == nil {
+		return versions, nil
+	}
+	observeError(ctx, "versions", err)
+	return f.Secondary.Versions(ctx, tags)
+}
+
+func (f Fallback) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string, versions []int64) error {
+	err := f.Primary.Set(ctx, key, value, ttl, tags, versions)
+	if err == nil {
+		return nil
+	}
+	observeError(ctx, "set", err)
+	return f.Secondary.Set(ctx, key, value, ttl, tags, versions)
+}
+
+// Invalidate drops the tags from both stores, so nothing stale is served
+// from the LRU if Redis goes away later.
+func (f Fallback) Invalidate(ctx context.Context, tags ...string) error {
+	return errors.Join(f.Primary.Invalidate(ctx, tags...), f.Secondary.Invalidate(ctx, tags...))
+}
+
+func observeError(ctx context.Context, operation string, err error) {
+	metrics.CacheErrorsTotal.WithLabelValues(operation).Inc()
+	slog.WarnContext(ctx, "response cache unavailable, using local fallback", "operation", operation, "error", err)
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenStore fails every call, like Redis while it is unreachable.
+type brokenStore struct{}
+
+func (brokenStore) Get(context.Context, string) ([]byte, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (brokenStore) Versions(context.Context, []string) ([]int64, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (brokenStore) Set(context.Context, string, []byte, time.Duration, []string, []int64) error {
+	return errors.New("connection refused")
+}
+
+func (brokenStore) Invalidate(context.Context, ...string) error {
+	return errors.New("connection refused")
+}
+
+func TestLRU_GetSet(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+
+	_, err := lru.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	require.NoError(t, lru.Set(ctx, "a", []byte("1"), time.Minute, nil, nil))
+	value, err := lru.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+
+	require.NoError(t, lru.Set(ctx, "a", []byte("1"), time.Minute, nil, nil))
+	require.NoError(t, lru.Set(ctx, "b", []byte("2"), time.Minute, nil, nil))
+	_, err := lru.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, lru.Set(ctx, "c", []byte("3"), time.Minute, nil, nil))
+
+	_, err = lru.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss, "b was the least recently used")
+	_, err = lru.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lru.Len())
+}
+
+func TestLRU_Expires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	lru := NewLRU(10)
+	lru.now = func() time.Time { return now }
+
+	require.NoError(t, lru.Set(ctx, "a", []byte("1"), time.Minute, nil, nil))
+	now = now.Add(time.Minute)
+
+	_, err := lru.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+	assert.Equal(t, 0, lru.Len())
+}
+
+func TestLRU_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(10)
+
+	require.NoError(t, lru.Set(ctx, "course", []byte("1"), time.Minute, []string{CourseTag("c1")}, []int64{0}))
+	require.NoError(t, lru.Set(ctx, "student", []byte("2"), time.Minute, GradeTags("s1", "c1"), []int64{0, 0}))
+	require.NoError(t, lru.Set(ctx, "other", []byte("3"), time.Minute, GradeTags("s2", "c2"), []int64{0, 0}))
+
+	require.NoError(t, lru.Invalidate(ctx, CourseTag("c1")))
+
+	_, err := lru.Get(ctx, "course")
+	assert.ErrorIs(t, err, ErrMiss)
+	_, err = lru.Get(ctx, "student")
+	assert.ErrorIs(t, err, ErrMiss)
+	_, err = lru.Get(ctx, "other")
+	assert.NoError(t, err)
+	assert.NotContains(t, lru.tags, StudentTag("s1"), "tags of removed entries are cleaned up")
+}
+
+func TestLRU_SkipsSetAfterInvalidate(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(10)
+	tags := GradeTags("s1", "c1")
+
+	versions, err := lru.Versions(ctx, tags)
+	require.NoError(t, err)
+	// A grade lands while the response is being computed
+	require.NoError(t, lru.Invalidate(ctx, StudentTag("s1")))
+	require.NoError(t, lru.Set(ctx, "student", []byte("stale"), time.Minute, tags, versions))
+
+	_, err = lru.Get(ctx, "student")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	versions, err = lru.Versions(ctx, tags)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 1}, versions)
+	require.NoError(t, lru.Set(ctx, "student", []byte("fresh"), time.Minute, tags, versions))
+	value, err := lru.Get(ctx, "student")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("fresh"), value)
+}
+
+func TestLRU_ZeroCapacityStoresNothing(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(0)
+
+	require.NoError(t, lru.Set(ctx, "a", []byte("1"), time.Minute, nil, nil))
+	_, err := lru.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+}
+
+func TestFallback_UsesSecondaryWhenPrimaryFails(t *testing.T) {
+	ctx := context.Background()
+	local := NewLRU(10)
+	store := Fallback{Primary: brokenStore{}, Secondary: local}
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), time.Minute, []string{CourseTag("c1")}, []int64{0}))
+	value, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+
+	err = store.Invalidate(ctx, CourseTag("c1"))
+	assert.Error(t, err, "the primary failure is reported")
+	assert.Equal(t, 0, local.Len(), "the secondary is invalidated anyway")
+}
+
+func TestFallback_PrimaryMissIsNotRetried(t *testing.T) {
+	ctx := context.Background()
+	local := NewLRU(10)
+	require.NoError(t, local.Set(ctx, "a", []byte("stale"), time.Minute, nil, nil))
+
+	store := Fallback{Primary: NewLRU(10), Secondary: local}
+	_, err := store.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+}
+
+func TestRedisStore_Keys(t *testing.T) {
+	assert.Equal(t, "service_stats:cache:tag:course:c1", tagKey(CourseTag("c1")))
+	assert.Equal(t, "service_stats:cache:version:course:c1", versionKey(CourseTag("c1")))
+}
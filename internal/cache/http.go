@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Options configures Handler.
+type Options struct {
+	// TTL bounds how long an entry is served when no write invalidates it.
+	TTL time.Duration
+	// MaxAge goes out in Cache-Control. Zero asks clients to revalidate
+	// with If-None-Match on every request.
+	MaxAge time.Duration
+}
+
+// CacheControl is the Cache-Control header of cached responses.
+func (o Options) CacheControl() string {
+	if o.MaxAge <= 0 {
+		return "private, no-cache"
+	}
+	return fmt.Sprintf("private, max-age=%d", int(o.MaxAge.Seconds()))
+}
+
+// entry is a rendered response as stored in the cache.
+type entry struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+	Body        []byte `json:"body"`
+}
+
+// uncacheableKey marks in the gin context a response Handler must not store.
+const uncacheableKey = "cache.uncacheable"
+
+// DontStore keeps the response to c out of the cache, for a handler that
+// can't tell whether it read every write the tag versions account for.
+func DontStore(c *gin.Context) {
+	c.Set(uncacheableKey, true)
+}
+
+// Handler serves handler's 200 responses from store, keyed by path and
+// query string and tagged with the course_id and student_id path
+// parameters. A response is only stored if none of its tags was
+// invalidated while handler ran, and the handler didn't call DontStore. Every response gets an ETag, and a
+// request whose If-None-Match matches it gets a 304 without a body.
+func Handler(store Store, opts Options, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		route := c.FullPath()
+		key := Key(c.Request)
+
+		if raw, err := store.Get(ctx, key); err == nil {
+			var cached entry
+			if err := json.Unmarshal(raw, &cached); err == nil {
+				metrics.CacheRequestsTotal.WithLabelValues(route, "hit").Inc()
+				c.Header("X-Cache", "HIT")
+				respond(c, cached, opts)
+				return
+			}
+			slog.WarnContext(ctx, "discarding unreadable cache entry", "key", key)
+		}
+
+		metrics.CacheRequestsTotal.WithLabelValues(route, "miss").Inc()
+
+		// Read before computing the response, so a write that lands while
+		// it's being computed keeps it out of the cache
+		tags := Tags(c)
+		versions, versionsErr := store.Versions(ctx, tags)
+		if versionsErr != nil {
+			slog.WarnContext(ctx, "error reading cache tag versions", "key", key, "error", versionsErr)
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		func() {
+			// Restored on panic too, so gin.Recovery can still answer
+			defer func() { c.Writer = writer.ResponseWriter }()
+			handler(c)
+		}()
+
+		rendered := entry{
+			Status:      writer.status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		}
+		c.Header("X-Cache", "MISS")
+
+		// Errors and not-found answers are not cached
+		if rendered.Status != http.StatusOK {
+			c.Data(rendered.Status, rendered.ContentType, rendered.Body)
+			return
+		}
+
+		rendered.ETag = ETag(rendered.Body)
+		if raw, err := json.Marshal(rendered); err == nil && versionsErr == nil && !c.GetBool(uncacheableKey) {
+			if err := store.Set(ctx, key, raw, opts.TTL, tags, versions); err != nil {
+				slog.WarnContext(ctx, "error caching response", "key", key, "error", err)
+			}
+		}
+		respond(c, rendered, opts)
+	}
+}
+
+func respond(c *gin.Context, cached entry, opts Options) {
+	c.Header("ETag", cached.ETag)
+	c.Header("Cache-Control", opts.CacheControl())
+
+	if MatchesETag(c.GetHeader("If-None-Match"), cached.ETag) {
+		metrics.CacheRequestsTotal.WithLabelValues(c.FullPath(), "not_modified").Inc()
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Data(cached.Status, cached.ContentType, cached.Body)
+}
+
+// Key identifies a request by path and query string. Query parameters are
+// sorted, so their order doesn't split the cache.
+func Key(r *http.Request) string {
+	sum := sha256.Sum256([]byte(r.URL.Path + "?" + r.URL.Query().Encode()))
+	return "response:" + hex.EncodeToString(sum[:16])
+}
+
+// Tags are the tags of the response to c: its course and student, when the
+// route has them as path parameters, and the institution whose calendar
+// group_by=term uses.
+func Tags(c *gin.Context) []string {
+	var tags []string
+	if courseID := c.Param("course_id"); courseID != "" {
+		tags = append(tags, CourseTag(courseID))
+	}
+	if studentID := c.Param("student_id"); studentID != "" {
+		tags = append(tags, StudentTag(studentID))
+	}
+	if institutionID := c.Query("institution"); institutionID != "" {
+		tags = append(tags, InstitutionTag(institutionID))
+	}
+	return tags
+}
+
+// ETag is a strong validator for body.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// MatchesETag reports whether an If-None-Match header matches etag, using
+// the weak comparison RFC 9110 prescribes for it.
+func MatchesETag(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds the handler's response so it can be cached and given
+// an ETag before anything reaches the client.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
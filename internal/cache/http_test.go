@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRouter(store Store, status int, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.GET("/course/:course_id/student/:student_id", Handler(store, Options{TTL: time.Minute}, func(c *gin.Context) {
+		*calls++
+		if c.Query("panic") != "" {
+			panic("boom")
+		}
+		c.JSON(status, gin.H{"course_id": c.Param("course_id"), "calls": *calls})
+	}))
+	return router
+}
+
+func get(router http.Handler, url string, header http.Header) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_CachesOKResponses(t *testing.T) {
+	store := NewLRU(10)
+	calls := 0
+	router := setupRouter(store, http.StatusOK, &calls)
+
+	first := get(router, "/course/c1/student/s1?b=2&a=1", nil)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+	assert.Equal(t, "application/json; charset=utf-8", first.Header().Get("Content-Type"))
+	assert.Equal(t, "private, no-cache", first.Header().Get("Cache-Control"))
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// Same query in another order is the same entry
+	second := get(router, "/course/c1/student/s1?a=1&b=2", nil)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "HIT", second.Header().Get("X-Cache"))
+	assert.Equal(t, etag, second.Header().Get("ETag"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", second.Header().Get("Content-Type"))
+	assert.Equal(t, 1, calls)
+
+	get(router, "/course/c1/student/s1?a=2", nil)
+	assert.Equal(t, 2, calls, "other query parameters are another entry")
+}
+
+func TestHandler_NotModified(t *testing.T) {
+	store := NewLRU(10)
+	calls := 0
+	router := setupRouter(store, http.StatusOK, &calls)
+
+	etag := get(router, "/course/c1/student/s1", nil).Header().Get("ETag")
+
+	w := get(router, "/course/c1/student/s1", http.Header{"If-None-Match": {`"other", ` + etag}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	w = get(router, "/course/c1/student/s1", http.Header{"If-None-Match": {`"other"`}})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandler_NotModifiedOnMiss(t *testing.T) {
+	calls := 0
+	router := setupRouter(NewLRU(10), http.StatusOK, &calls)
+
+	etag := get(router, "/course/c1/student/s1", nil).Header().Get("ETag")
+
+	// Another replica of the API renders the same body, so the ETag matches
+	// even without the entry
+	other := setupRouter(NewLRU(10), http.StatusOK, new(int))
+	w := get(other, "/course/c1/student/s1", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
+
+func TestHandler_DoesNotCacheErrors(t *testing.T) {
+	store := NewLRU(10)
+	calls := 0
+	router := setupRouter(store, http.StatusInternalServerError, &calls)
+
+	w := get(router, "/course/c1/student/s1", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), `"course_id":"c1"`)
+	assert.Empty(t, w.Header().Get("ETag"))
+
+	get(router, "/course/c1/student/s1", nil)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestHandler_RecoversFromPanics(t *testing.T) {
+	calls := 0
+	router := setupRouter(NewLRU(10), http.StatusOK, &calls)
+
+	w := get(router, "/course/c1/student/s1?panic=1", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestHandler_InvalidatedByTags(t *testing.T) {
+	store := NewLRU(10)
+	calls := 0
+	router := setupRouter(store, http.StatusOK, &calls)
+
+	get(router, "/course/c1/student/s1", nil)
+	require.NoError(t, store.Invalidate(context.Background(), StudentTag("s1")))
+
+	w := get(router, "/course/c1/student/s1", nil)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, 2, calls)
+}
+
+func TestHandler_InvalidatedByInstitution(t *testing.T) {
+	store := NewLRU(10)
+	calls := 0
+	router := setupRouter(store, http.StatusOK, &calls)
+
+	get(router, "/course/c1/student/s1?group_by=term&institution=fiuba", nil)
+	get(router, "/course/c1/student/s1", nil)
+	require.NoError(t, store.Invalidate(context.Background(), InstitutionTag("fiuba")))
+
+	w := get(router, "/course/c1/student/s1?group_by=term&institution=fiuba", nil)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	w = get(router, "/course/c1/student/s1", nil)
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"), "responses without the institution are kept")
+	assert.Equal(t, 3, calls)
+}
+
+func TestHandler_SkipsResponsesInvalidatedWhileComputed(t *testing.T) {
+	store := NewLRU(10)
+	calls := 0
+	router := gin.New()
+	router.GET("/course/:course_id", Handler(store, Options{TTL: time.Minute}, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			// A grade of the course is committed before this answer is sent
+			require.NoError(t, store.Invalidate(context.Background(), CourseTag("c1")))
+		}
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	}))
+
+	get(router, "/course/c1", nil)
+	w := get(router, "/course/c1", nil)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	w = get(router, "/course/c1", nil)
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.Equal(t, 2, calls)
+}
+
+func TestHandler_DontStore(t *testing.T) {
+	store := NewLRU(10)
+	calls := 0
+	router := gin.New()
+	router.GET("/course/:course_id", Handler(store, Options{TTL: time.Minute}, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			// Read from a replica behind the primary
+			DontStore(c)
+		}
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	}))
+
+	w := get(router, "/course/c1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	w = get(router, "/course/c1", nil)
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	w = get(router, "/course/c1", nil)
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.Equal(t, 2, calls)
+}
+
+func TestHandler_UsesFallbackWhenRedisIsDown(t *testing.T) {
+	calls := 0
+	router := setupRouter(Fallback{Primary: brokenStore{}, Secondary: NewLRU(10)}, http.StatusOK, &calls)
+
+	get(router, "/course/c1/student/s1", nil)
+	w := get(router, "/course/c1/student/s1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.Equal(t, 1, calls)
+}
+
+func TestOptions_CacheControl(t *testing.T) {
+	assert.Equal(t, "private, no-cache", Options{}.CacheControl())
+	assert.Equal(t, "private, max-age=30", Options{MaxAge: 30 * time.Second}.CacheControl())
+}
+
+func TestMatchesETag(t *testing.T) {
+	assert.True(t, MatchesETag(`"abc"`, `"abc"`))
+	assert.True(t, MatchesETag(`W/"abc"`, `"abc"`))
+	assert.True(t, MatchesETag(`*`, `"abc"`))
+	assert.True(t, MatchesETag(`"x", "abc"`, `"abc"`))
+	assert.False(t, MatchesETag(``, `"abc"`))
+	assert.False(t, MatchesETag(`"abd"`, `"abc"`))
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process Store bounded by number of entries.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is the most recently used
+	items    map[string]*list.Element
+	tags     map[string]map[string]struct{}
+	versions map[string]int64
+	now      func() time.Time
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	tags      []string
+	expiresAt time.Time
+}
+
+// NewLRU returns an LRU holding at most capacity entries.
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		tags:     make(map[string]map[string]struct{}),
+		versions: make(map[string]int64),
+		now:      time.Now,
+	}
+}
+
+func (l *LRU) Get(_ context.Context, key string) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.items[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	entry := element.Value.(*lruEntry)
+	if !l.now().Before(entry.expiresAt) {
+		l.remove(element)
+		return nil, ErrMiss
+	}
+	l.order.MoveToFront(element)
+	return entry.value, nil
+}
+
+func (l *LRU) Versions(_ context.Context, tags []string) ([]int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	versions := make([]int64, len(tags))
+	for i, tag := range tags {
+		versions[i] = l.versions[tag]
+	}
+	return versions, nil
+}
+
+func (l *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration, tags []string, versions []int64) error {
+	if l.capacity <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.current(tags, versions) {
+		return nil
+	}
+
+	if element, ok := l.items[key]; ok {
+		l.remove(element)
+	}
+
+	entry := &lruEntry{key: key, value: value, tags: tags, expiresAt: l.now().Add(ttl)}
+	l.items[key] = l.order.PushFront(entry)
+	for _, tag := range tags {
+		if l.tags[tag] == nil {
+			l.tags[tag] = make(map[string]struct{})
+		}
+		l.tags[tag][key] = struct{}{}
+	}
+
+	for l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+	}
+	return nil
+}
+
+func (l *LRU) Invalidate(_ context.Context, tags ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range l.tags[tag] {
+			if element, ok := l.items[key]; ok {
+				l.remove(element)
+			}
+		}
+		delete(l.tags, tag)
+		l.versions[tag]++
+	}
+	return nil
+}
+
+// Len returns the number of entries, expired ones included.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// current reports whether tags are still at versions. Callers hold mu.
+func (l *LRU) current(tags []string, versions []int64) bool {
+	if len(versions) != len(tags) {
+		return false
+	}
+	for i, tag := range tags {
+		if l.versions[tag] != versions[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// remove drops element from the list, the index and its tags. Callers hold mu.
+func (l *LRU) remove(element *list.Element) {
+	entry := l.order.Remove(element).(*lruEntry)
+	delete(l.items, entry.key)
+	for _, tag := range entry.tags {
+		delete(l.tags[tag], entry.key)
+		if len(l.tags[tag]) == 0 {
+			delete(l.tags, tag)
+		}
+	}
+}
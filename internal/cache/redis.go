@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the cache next to the asynq keys in the same Redis.
+const keyPrefix = "service_stats:cache:"
+
+// invalidateScript deletes every key listed in the tag sets passed as KEYS,
+// then the sets themselves, and bumps the version of each tag. KEYS holds
+// the set and the version key of each tag in turn. It runs atomically, so
+// a concurrent Set can't slip a key in between.
+var invalidateScript = redis.NewScript(`
+for t = 1, #KEYS, 2 do
+	local keys = redis.call('SMEMBERS', KEYS[t])
+	for i = 1, #keys, 500 do
+		redis.call('DEL', unpack(keys, i, math.min(i + 499, #keys)))
+	end
+	redis.call('DEL', KEYS[t])
+	redis.call('INCR', KEYS[t + 1])
+end
+return 0
+`)
+
+// setScript stores an entry unless a tag was invalidated since its versions
+// were read. KEYS holds the entry, then the set and the version key of each
+// tag; ARGV the value, the TTL in milliseconds and the version of each tag.
+var setScript = redis.NewScript(`
+for t = 2, #KEYS, 2 do
+	if (tonumber(redis.call('GET', KEYS[t + 1])) or 0) ~= tonumber(ARGV[t / 2 + 2]) then
+		return 0
+	end
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+for t = 2, #KEYS, 2 do
+	-- The set outlives the newest entry in it; older members that already
+	-- expired are harmless to DEL
+	redis.call('SADD', KEYS[t], KEYS[1])
+	redis.call('PEXPIRE', KEYS[t], ARGV[2])
+end
+return 1
+`)
+
+// RedisStore is a Store backed by Redis. Each tag is a set holding the keys
+// stored under it.
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStore stores the entries through client. The client is shared,
+// so closing it is left to the caller.
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (r *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+// Versions reads the version keys of tags. They never expire: there is one
+// per course and student, and one dropped between Versions and Set would
+// let a stale entry in.
+func (r *RedisStore) Versions(ctx context.Context, tags []string) ([]int64, error) {
+	versions := make([]int64, len(tags))
+	if len(tags) == 0 {
+		return versions, nil
+	}
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = versionKey(tag)
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		if versions[i], err = strconv.ParseInt(fmt.Sprint(value), 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid version of tag %q: %w", tags[i], err)
+		}
+	}
+	return versions, nil
+}
+
+func (r *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string, versions []int64) error {
+	if len(versions) != len(tags) {
+		return fmt.Errorf("got %d versions for %d tags", len(versions), len(tags))
+	}
+	keys := []string{keyPrefix + key}
+	args := []interface{}{value, ttl.Milliseconds()}
+	for i, tag := range tags {
+		keys = append(keys, tagKey(tag), versionKey(tag))
+		args = append(args, versions[i])
+	}
+	return setScript.Run(ctx, r.client, keys, args...).Err()
+}
+
+func (r *RedisStore) Invalidate(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, 2*len(tags))
+	for _, tag := range tags {
+		keys = append(keys, tagKey(tag), versionKey(tag))
+	}
+	return invalidateScript.Run(ctx, r.client, keys).Err()
+}
+
+func tagKey(tag string) string {
+	return keyPrefix + "tag:" + tag
+}
+
+func versionKey(tag string) string {
+	return keyPrefix + "version:" + tag
+}
@@ -0,0 +1,600 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/logging"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/tracing"
+
+	"github.com/hibiken/asynq"
+	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv is the environment variable holding the path of the optional YAML
+// configuration file.
+const FileEnv = "CONFIG_FILE"
+
+// Config is the configuration shared by the API and the worker. Every field
+// with an env tag can be set from that environment variable; nested structs
+// map to the sections of the YAML file.
+//
+// Precedence, from lowest to highest: defaults, YAML file, .env file,
+// process environment. godotenv never overrides variables that are already
+// set, so the process environment always wins over .env.
+type Config struct {
+	HTTP      HTTP      `yaml:"http"`
+	GRPC      GRPC      `yaml:"grpc"`
+	Database  Database  `yaml:"database"`
+	Redis     Redis     `yaml:"redis"`
+	Worker    Worker    `yaml:"worker"`
+	Readiness Readiness `yaml:"readiness"`
+	Writes    Writes    `yaml:"writes"`
+	Outbox    Outbox    `yaml:"outbox"`
+	Events    Events    `yaml:"events"`
+	Webhooks  Webhooks  `yaml:"webhooks"`
+	Realtime  Realtime  `yaml:"realtime"`
+	GraphQL   GraphQL   `yaml:"graphql"`
+	Cache     Cache     `yaml:"cache"`
+	Logging   Logging   `yaml:"logging"`
+	Tracing   Tracing   `yaml:"tracing"`
+	NewRelic  NewRelic  `yaml:"new_relic"`
+	Features  Features  `yaml:"features"`
+	Admin     Admin     `yaml:"admin"`
+
+	// ShutdownTimeout is how long in-flight requests and active tasks get to
+	// finish after SIGTERM.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+
+	// Sources lists where values were loaded from, for the startup dump.
+	Sources []string `yaml:"-"`
+}
+
+// HTTP configures the API listener.
+type HTTP struct {
+	Host              string        `yaml:"host" env:"HTTP_HOST"`
+	Port              int           `yaml:"port" env:"HTTP_PORT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+}
+
+// Addr is the host:port the API listens on.
+func (h HTTP) Addr() string {
+	return net.JoinHostPort(h.Host, strconv.Itoa(h.Port))
+}
+
+// GRPC configures the gRPC listener, which serves the same statistics as
+// the HTTP one on its own port.
+type GRPC struct {
+	Enabled bool   `yaml:"enabled" env:"GRPC_ENABLED"`
+	Host    string `yaml:"host" env:"GRPC_HOST"`
+	Port    int    `yaml:"port" env:"GRPC_PORT"`
+}
+
+// Addr is the host:port the gRPC server listens on.
+func (g GRPC) Addr() string {
+	return net.JoinHostPort(g.Host, strconv.Itoa(g.Port))
+}
+
+// Database configures the PostgreSQL connection and its pool.
+type Database struct {
+	URL             string        `yaml:"url" env:"SERVICE_STATS_POSTGRES_URL" secret:"dsn"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	// QueryTimeout bounds each repository call; zero disables it.
+	QueryTimeout time.Duration `yaml:"query_timeout" env:"DB_QUERY_TIMEOUT"`
+
+	// ReplicaURL enables routing of read-only API queries to a replica.
+	ReplicaURL string `yaml:"replica_url" env:"SERVICE_STATS_POSTGRES_REPLICA_URL" secret:"dsn"`
+	// ReplicaMaxLag is the replication lag past which reads go back to the
+	// primary; zero accepts any lag.
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag" env:"DB_REPLICA_MAX_LAG"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL"`
+}
+
+// Pool returns the pool settings in the form database.InitDB expects.
+func (d Database) Pool() database.PoolConfig {
+	return database.PoolConfig{
+		MaxOpenConns:    d.MaxOpenConns,
+		MaxIdleConns:    d.MaxIdleConns,
+		ConnMaxLifetime: d.ConnMaxLifetime,
+		ConnMaxIdleTime: d.ConnMaxIdleTime,
+	}
+}
+
+// Redis configures the connection used by asynq.
+type Redis struct {
+	Host     string `yaml:"host" env:"ASYNC_QUEUE_HOST"`
+	Port     int    `yaml:"port" env:"ASYNC_QUEUE_PORT"`
+	Username string `yaml:"username" env:"REDIS_USERNAME"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	DB       int    `yaml:"db" env:"REDIS_DB"`
+
+	TLS                   bool   `yaml:"tls" env:"REDIS_TLS"`
+	TLSServerName         string `yaml:"tls_server_name" env:"REDIS_TLS_SERVER_NAME"`
+	TLSInsecureSkipVerify bool   `yaml:"tls_insecure_skip_verify" env:"REDIS_TLS_INSECURE_SKIP_VERIFY"`
+}
+
+// Addr is the host:port of the Redis server.
+func (r Redis) Addr() string {
+	return net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+}
+
+// ClientOpt builds the asynq connection options, shared by the client, the
+// inspector and the worker server.
+func (r Redis) ClientOpt() asynq.RedisClientOpt {
+	opt := asynq.RedisClientOpt{
+		Addr:     r.Addr(),
+		Username: r.Username,
+		Password: r.Password,
+		DB:       r.DB,
+	}
+	if r.TLS {
+		opt.TLSConfig = &tls.Config{
+			ServerName:         r.TLSServerName,
+			InsecureSkipVerify: r.TLSInsecureSkipVerify,
+			MinVersion:         tls.VersionTLS12,
+		}
+	}
+	return opt
+}
+
+// Client connects to Redis with the options of ClientOpt. The response
+// cache, the event stream and the realtime updates share it.
+func (r Redis) Client() redis.UniversalClient {
+	return r.ClientOpt().MakeRedisClient().(redis.UniversalClient)
+}
+
+// Worker configures the asynq server.
+type Worker struct {
+	Concurrency    int    `yaml:"concurrency" env:"WORKER_CONCURRENCY"`
+	Queues         Queues `yaml:"queues" env:"WORKER_QUEUES"`
+	StrictPriority bool   `yaml:"strict_priority" env:"WORKER_STRICT_PRIORITY"`
+	// EnqueueQueue is the queue the API and the worker itself enqueue
+	// tasks to. It must be one of Queues, or nothing would process them.
+	EnqueueQueue string `yaml:"enqueue_queue" env:"WORKER_ENQUEUE_QUEUE"`
+	// MetricsPort serves /metrics and the health probes of the worker.
+	MetricsPort int `yaml:"metrics_port" env:"WORKER_METRICS_PORT"`
+}
+
+// Queues maps queue names to their priority. In the environment it is
+// written as "critical:6,default:3".
+type Queues map[string]int
+
+// UnmarshalText parses the "name:priority,..." form. A name without a
+// priority gets 1.
+func (q *Queues) UnmarshalText(text []byte) error {
+	queues := Queues{}
+	for _, item := range strings.Split(string(text), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, priority, found := strings.Cut(item, ":")
+		name = strings.TrimSpace(name)
+		value := 1
+		if found {
+			parsed, err := strconv.Atoi(strings.TrimSpace(priority))
+			if err != nil {
+				return fmt.Errorf("invalid priority for queue %q: %w", name, err)
+			}
+			value = parsed
+		}
+		queues[name] = value
+	}
+	*q = queues
+	return nil
+}
+
+// String returns the queues in the same form UnmarshalText accepts, sorted
+// by name.
+func (q Queues) String() string {
+	names := make([]string, 0, len(q))
+	for name := range q {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	items := make([]string, len(names))
+	for i, name := range names {
+		items[i] = fmt.Sprintf("%s:%d", name, q[name])
+	}
+	return strings.Join(items, ",")
+}
+
+// Readiness configures the readiness probe.
+type Readiness struct {
+	CheckTimeout    time.Duration `yaml:"check_timeout" env:"READINESS_CHECK_TIMEOUT"`
+	MaxPendingTasks int           `yaml:"max_pending_tasks" env:"READINESS_MAX_PENDING_TASKS"`
+	MaxQueueLatency time.Duration `yaml:"max_queue_latency" env:"READINESS_MAX_QUEUE_LATENCY"`
+}
+
+// Write modes of the API.
+const (
+	// WriteModeAsync enqueues accepted grades for the worker.
+	WriteModeAsync = "async"
+	// WriteModeSync stores grades before answering, without Redis or the
+	// worker.
+	WriteModeSync = "sync"
+)
+
+// Writes configures how the API stores the grades it accepts.
+type Writes struct {
+	Mode string `yaml:"mode" env:"WRITE_MODE"`
+}
+
+// Sync reports whether the API stores grades itself.
+func (w Writes) Sync() bool {
+	return w.Mode == WriteModeSync
+}
+
+// Outbox configures the outbox mode of the API. When enabled, accepted
+// grades are written to the task_outbox table instead of Redis and a relay
+// moves them to the queue, so a Redis outage doesn't lose them.
+type Outbox struct {
+	Enabled bool `yaml:"enabled" env:"OUTBOX_ENABLED"`
+	// RelayInterval is how often the relay looks for tasks to enqueue.
+	RelayInterval time.Duration `yaml:"relay_interval" env:"OUTBOX_RELAY_INTERVAL"`
+	// BatchSize is how many tasks the relay enqueues at a time.
+	BatchSize int `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+}
+
+// Events configures the intake of events published by other services.
+// The webhook is served while WebhookSecret is set, and the Redis stream
+// is read while Stream is set.
+type Events struct {
+	WebhookSecret string `yaml:"webhook_secret" env:"EVENTS_WEBHOOK_SECRET" secret:"true"`
+	// WebhookTolerance is how far the timestamp of a delivery may be from
+	// the clock of the API.
+	WebhookTolerance time.Duration `yaml:"webhook_tolerance" env:"EVENTS_WEBHOOK_TOLERANCE"`
+
+	Stream      string `yaml:"stream" env:"EVENTS_STREAM"`
+	StreamGroup string `yaml:"stream_group" env:"EVENTS_STREAM_GROUP"`
+	// StreamClaimIdle is how long an entry that failed stays pending before
+	// it is read again.
+	StreamClaimIdle time.Duration `yaml:"stream_claim_idle" env:"EVENTS_STREAM_CLAIM_IDLE"`
+}
+
+// Webhooks configures the webhooks sent to the subscriptions registered
+// through the admin API.
+type Webhooks struct {
+	Enabled bool `yaml:"enabled" env:"WEBHOOKS_ENABLED"`
+	// Timeout bounds each request to a subscription.
+	Timeout time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT"`
+}
+
+// Realtime configures the SSE streams. The worker publishes an update to
+// Channel for each committed grade and the API replicas relay it to their
+// clients.
+type Realtime struct {
+	Enabled bool   `yaml:"enabled" env:"REALTIME_ENABLED"`
+	Channel string `yaml:"channel" env:"REALTIME_CHANNEL"`
+	// Heartbeat is how often an idle stream gets a comment, so proxies
+	// don't close it.
+	Heartbeat time.Duration `yaml:"heartbeat" env:"REALTIME_HEARTBEAT"`
+}
+
+// GraphQL configures the GraphQL endpoint. Queries deeper or more complex
+// than the limits are rejected before they run.
+type GraphQL struct {
+	Enabled bool `yaml:"enabled" env:"GRAPHQL_ENABLED"`
+	// MaxDepth counts introspection fields too, and the introspection
+	// query of GraphiQL nests 15 fields deep.
+	MaxDepth int `yaml:"max_depth" env:"GRAPHQL_MAX_DEPTH"`
+	// MaxComplexity bounds the number of fields a query resolves, counting
+	// each field once per item of the lists around it.
+	MaxComplexity int `yaml:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY"`
+}
+
+// Cache configures the response cache of the statistics endpoints.
+type Cache struct {
+	Enabled bool `yaml:"enabled" env:"CACHE_ENABLED"`
+	// TTL bounds how long a response is served when no grade invalidates it.
+	TTL time.Duration `yaml:"ttl" env:"CACHE_TTL"`
+	// MaxAge is the max-age sent to clients; zero makes them revalidate
+	// with the ETag on every request.
+	MaxAge time.Duration `yaml:"max_age" env:"CACHE_MAX_AGE"`
+	// LocalSize is how many responses the API keeps in memory while Redis
+	// is unavailable.
+	LocalSize int `yaml:"local_size" env:"CACHE_LOCAL_SIZE"`
+}
+
+// Logging mirrors logging.Config.
+type Logging struct {
+	Level       string `yaml:"level" env:"LOG_LEVEL"`
+	Format      string `yaml:"format" env:"LOG_FORMAT"`
+	RedactLevel string `yaml:"redact_level" env:"LOG_REDACT_LEVEL"`
+}
+
+// Config returns the settings in the form logging.Init expects.
+func (l Logging) Config() logging.Config {
+	return logging.Config{Level: l.Level, Format: l.Format, RedactLevel: l.RedactLevel}
+}
+
+// Tracing configures OpenTelemetry. The OTLP exporter itself still reads the
+// standard OTEL_EXPORTER_OTLP_* variables.
+type Tracing struct {
+	ServiceName string `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	Exporter    string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+}
+
+// NewRelic configures the New Relic agent.
+type NewRelic struct {
+	LicenseKey string `yaml:"license_key" env:"NEW_RELIC_LICENSE_KEY" secret:"true"`
+	AppName    string `yaml:"app_name" env:"NEW_RELIC_APP_NAME"`
+}
+
+// Enabled reports whether the agent should start: only with a license key.
+func (n NewRelic) Enabled() bool {
+	return n.LicenseKey != ""
+}
+
+// Features holds toggles for optional parts of the API.
+type Features struct {
+	// Docs serves the OpenAPI document and Swagger UI.
+	Docs bool `yaml:"docs" env:"FEATURE_DOCS"`
+	// Metrics serves the Prometheus endpoint.
+	Metrics bool `yaml:"metrics" env:"FEATURE_METRICS"`
+}
+
+// Admin configures the administration endpoints. They are only served
+// while Token is set.
+type Admin struct {
+	// Token must be sent as "Authorization: Bearer <token>".
+	Token string `yaml:"token" env:"ADMIN_TOKEN" secret:"true"`
+}
+
+// Default returns the configuration used when nothing overrides it.
+// serviceName is the default OTEL_SERVICE_NAME of the calling binary.
+func Default(serviceName string) *Config {
+	return &Config{
+		HTTP: HTTP{
+			Host:              "0.0.0.0",
+			Port:              8080,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+		},
+		// The optional subsystems start disabled; the deployments enable
+		// them explicitly
+		GRPC: GRPC{
+			Host: "0.0.0.0",
+			Port: 9090,
+		},
+		Database: Database{
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			QueryTimeout:    5 * time.Second,
+
+			ReplicaMaxLag:        30 * time.Second,
+			ReplicaCheckInterval: 5 * time.Second,
+		},
+		Redis: Redis{
+			Host: "localhost",
+			Port: 6379,
+		},
+		Worker: Worker{
+			Concurrency:  10,
+			Queues:       Queues{"default": 1},
+			EnqueueQueue: "default",
+			MetricsPort:  9091,
+		},
+		Readiness: Readiness{
+			CheckTimeout:    2 * time.Second,
+			MaxPendingTasks: 1000,
+			MaxQueueLatency: 10 * time.Minute,
+		},
+		Writes: Writes{
+			Mode: WriteModeAsync,
+		},
+		Outbox: Outbox{
+			RelayInterval: time.Second,
+			BatchSize:     100,
+		},
+		Events: Events{
+			WebhookTolerance: 5 * time.Minute,
+			StreamGroup:      "service_stats",
+			StreamClaimIdle:  time.Minute,
+		},
+		Webhooks: Webhooks{
+			Timeout: 10 * time.Second,
+		},
+		Realtime: Realtime{
+			Channel:   "service_stats:updates",
+			Heartbeat: 15 * time.Second,
+		},
+		GraphQL: GraphQL{
+			MaxDepth:      15,
+			MaxComplexity: 1000,
+		},
+		Cache: Cache{
+			TTL:       5 * time.Minute,
+			LocalSize: 1000,
+		},
+		Logging: Logging{
+			Level:       "info",
+			Format:      "json",
+			RedactLevel: "info",
+		},
+		Tracing: Tracing{
+			ServiceName: serviceName,
+			Exporter:    tracing.ExporterNone,
+		},
+		Features: Features{
+			Docs:    true,
+			Metrics: true,
+		},
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+// Load reads .env (if present), the YAML file named by CONFIG_FILE (if set)
+// and the process environment on top of the defaults, then validates the
+// result.
+func Load(serviceName string) (*Config, error) {
+	dotenv := true
+	if err := godotenv.Load(); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("error reading .env: %w", err)
+		}
+		dotenv = false
+	}
+
+	cfg, err := LoadFrom(serviceName, os.Getenv(FileEnv), os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	if dotenv {
+		cfg.Sources = append(cfg.Sources, ".env")
+	}
+	return cfg, nil
+}
+
+// LoadFrom builds the configuration from the defaults, the YAML file at path
+// (skipped when empty) and the variables returned by lookup.
+func LoadFrom(serviceName string, path string, lookup LookupFunc) (*Config, error) {
+	cfg := Default(serviceName)
+	cfg.Sources = []string{"defaults"}
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening config file: %w", err)
+		}
+		defer file.Close()
+
+		if err := decodeYAML(file, cfg); err != nil {
+			return nil, fmt.Errorf("error reading config file %s: %w", path, err)
+		}
+		cfg.Sources = append(cfg.Sources, path)
+	}
+
+	if err := applyEnv(cfg, lookup); err != nil {
+		return nil, err
+	}
+	cfg.Sources = append(cfg.Sources, "environment")
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// decodeYAML overlays the YAML document in r on cfg. Unknown keys are an
+// error so typos don't go unnoticed.
+func decodeYAML(r io.Reader, cfg *Config) error {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Database.URL != "", "SERVICE_STATS_POSTGRES_URL is required")
+	check(c.Database.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	check(c.Database.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME must not be negative")
+	check(c.Database.QueryTimeout >= 0, "DB_QUERY_TIMEOUT must not be negative")
+	check(c.Database.ReplicaMaxLag >= 0, "DB_REPLICA_MAX_LAG must not be negative")
+	check(c.Database.ReplicaURL == "" || c.Database.ReplicaCheckInterval > 0, "DB_REPLICA_CHECK_INTERVAL must be positive when a replica is configured")
+
+	check(c.HTTP.Host != "", "HTTP_HOST must not be empty")
+	check(validPort(c.HTTP.Port), "HTTP_PORT must be between 1 and 65535, got %d", c.HTTP.Port)
+	check(c.HTTP.ReadHeaderTimeout >= 0, "HTTP_READ_HEADER_TIMEOUT must not be negative")
+	check(c.HTTP.ReadTimeout >= 0, "HTTP_READ_TIMEOUT must not be negative")
+	check(c.HTTP.WriteTimeout >= 0, "HTTP_WRITE_TIMEOUT must not be negative")
+	check(c.HTTP.IdleTimeout >= 0, "HTTP_IDLE_TIMEOUT must not be negative")
+	check(!c.GRPC.Enabled || c.GRPC.Host != "", "GRPC_HOST must not be empty when gRPC is enabled")
+	check(!c.GRPC.Enabled || validPort(c.GRPC.Port), "GRPC_PORT must be between 1 and 65535, got %d", c.GRPC.Port)
+	check(!c.GRPC.Enabled || c.GRPC.Port != c.HTTP.Port, "GRPC_PORT must differ from HTTP_PORT")
+
+	check(c.Redis.Host != "", "ASYNC_QUEUE_HOST must not be empty")
+	check(validPort(c.Redis.Port), "ASYNC_QUEUE_PORT must be between 1 and 65535, got %d", c.Redis.Port)
+	check(c.Redis.DB >= 0, "REDIS_DB must not be negative")
+
+	check(c.Worker.Concurrency > 0, "WORKER_CONCURRENCY must be positive, got %d", c.Worker.Concurrency)
+	check(len(c.Worker.Queues) > 0, "WORKER_QUEUES must name at least one queue")
+	for name, priority := range c.Worker.Queues {
+		check(name != "", "WORKER_QUEUES has an empty queue name")
+		check(priority > 0, "WORKER_QUEUES priority for %q must be positive, got %d", name, priority)
+	}
+	_, consumed := c.Worker.Queues[c.Worker.EnqueueQueue]
+	check(consumed, "WORKER_ENQUEUE_QUEUE %q must be one of WORKER_QUEUES", c.Worker.EnqueueQueue)
+	check(validPort(c.Worker.MetricsPort), "WORKER_METRICS_PORT must be between 1 and 65535, got %d", c.Worker.MetricsPort)
+
+	check(c.Readiness.CheckTimeout > 0, "READINESS_CHECK_TIMEOUT must be positive")
+	check(c.Readiness.MaxPendingTasks >= 0, "READINESS_MAX_PENDING_TASKS must not be negative")
+	check(c.Readiness.MaxQueueLatency >= 0, "READINESS_MAX_QUEUE_LATENCY must not be negative")
+
+	check(c.Writes.Mode == WriteModeAsync || c.Writes.Mode == WriteModeSync, "WRITE_MODE must be async or sync, got %q", c.Writes.Mode)
+	check(!c.Writes.Sync() || !c.Outbox.Enabled, "OUTBOX_ENABLED only applies to WRITE_MODE=async")
+	check(c.Outbox.RelayInterval > 0, "OUTBOX_RELAY_INTERVAL must be positive")
+	check(c.Outbox.BatchSize > 0, "OUTBOX_BATCH_SIZE must be positive, got %d", c.Outbox.BatchSize)
+
+	check(c.Events.WebhookTolerance > 0, "EVENTS_WEBHOOK_TOLERANCE must be positive")
+	check(c.Events.Stream == "" || c.Events.StreamGroup != "", "EVENTS_STREAM_GROUP must not be empty when EVENTS_STREAM is set")
+	check(c.Events.StreamClaimIdle > 0, "EVENTS_STREAM_CLAIM_IDLE must be positive")
+	check(!c.Webhooks.Enabled || c.Webhooks.Timeout > 0, "WEBHOOKS_TIMEOUT must be positive when webhooks are enabled")
+	check(!c.Realtime.Enabled || c.Realtime.Channel != "", "REALTIME_CHANNEL must not be empty when realtime updates are enabled")
+	check(!c.Realtime.Enabled || c.Realtime.Heartbeat > 0, "REALTIME_HEARTBEAT must be positive when realtime updates are enabled")
+	check(!c.GraphQL.Enabled || c.GraphQL.MaxDepth > 0, "GRAPHQL_MAX_DEPTH must be positive when GraphQL is enabled")
+	check(!c.GraphQL.Enabled || c.GraphQL.MaxComplexity > 0, "GRAPHQL_MAX_COMPLEXITY must be positive when GraphQL is enabled")
+
+	check(!c.Cache.Enabled || c.Cache.TTL > 0, "CACHE_TTL must be positive when the cache is enabled")
+	check(c.Cache.MaxAge >= 0, "CACHE_MAX_AGE must not be negative")
+	check(c.Cache.LocalSize >= 0, "CACHE_LOCAL_SIZE must not be negative")
+
+	if _, err := logging.New(io.Discard, c.Logging.Config()); err != nil {
+		errs = append(errs, fmt.Errorf("invalid logging configuration: %w", err))
+	}
+
+	switch strings.ToLower(c.Tracing.Exporter) {
+	case tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterNone, "":
+	default:
+		errs = append(errs, fmt.Errorf("OTEL_TRACES_EXPORTER must be otlp, stdout or none, got %q", c.Tracing.Exporter))
+	}
+	check(c.Tracing.ServiceName != "", "OTEL_SERVICE_NAME must not be empty")
+
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
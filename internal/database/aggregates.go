@@ -0,0 +1,335 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/tracing"
+)
+
+// The aggregate tables keep sums and counts rather than averages, so a
+// grade can be added or taken back with a single upsert:
+//
+//   - student_course_stats: one row per student and course, with the
+//     grades and the task grades of that student.
+//   - course_task_stats: one row per course and task.
+//   - course_daily_stats: one row per course and UTC day. Coarser periods
+//     (week, month, year...) are summed from the days at read time.
+//   - student_course_daily_stats: one row per student, course and UTC day,
+//     for the series of a student, summed the same way.
+//
+// The worker keeps them up to date in the same transaction as the write to
+// grades/grades_tasks; RebuildAggregates recomputes them from scratch.
+const createAggregatesStatement = `
+	CREATE TABLE IF NOT EXISTS student_course_stats (
+		student_id         TEXT NOT NULL,
+		course_id          TEXT NOT NULL,
+		grade_sum          NUMERIC NOT NULL DEFAULT 0,
+		grade_count        BIGINT NOT NULL DEFAULT 0,
+		task_grade_sum     NUMERIC NOT NULL DEFAULT 0,
+		task_count         BIGINT NOT NULL DEFAULT 0,
+		task_on_time_count BIGINT NOT NULL DEFAULT 0,
+		updated_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (student_id, course_id)
+	);
+	CREATE INDEX IF NOT EXISTS student_course_stats_course_idx ON student_course_stats (course_id);
+
+	CREATE TABLE IF NOT EXISTS course_task_stats (
+		course_id     TEXT NOT NULL,
+		task_id       TEXT NOT NULL,
+		grade_sum     NUMERIC NOT NULL DEFAULT 0,
+		grade_count   BIGINT NOT NULL DEFAULT 0,
+		on_time_count BIGINT NOT NULL DEFAULT 0,
+		updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (course_id, task_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS course_daily_stats (
+		course_id          TEXT NOT NULL,
+		period_start       TIMESTAMP WITH TIME ZONE NOT NULL,
+		grade_sum          NUMERIC NOT NULL DEFAULT 0,
+		grade_count        BIGINT NOT NULL DEFAULT 0,
+		task_count         BIGINT NOT NULL DEFAULT 0,
+		task_on_time_count BIGINT NOT NULL DEFAULT 0,
+		updated_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (course_id, period_start)
+	);
+
+	CREATE INDEX IF NOT EXISTS grades_student_course_idx ON grades (student_id, course_id);
+	CREATE INDEX IF NOT EXISTS grades_tasks_student_course_task_idx ON grades_tasks (student_id, course_id, task_id);
+	CREATE INDEX IF NOT EXISTS grades_tasks_course_task_idx ON grades_tasks (course_id, task_id);
+	`
+
+// rebuildAggregatesStatement replaces the content of the aggregate tables
+// with what grades and grades_tasks hold. DELETE rather than TRUNCATE keeps
+// the old rows visible to readers until the rebuild commits.
+const rebuildAggregatesStatement = `
+	DELETE FROM student_course_stats;
+	DELETE FROM course_task_stats;
+	DELETE FROM course_daily_stats;
+
+	INSERT INTO student_course_stats (student_id, course_id, grade_sum, grade_count, task_grade_sum, task_count, task_on_time_count)
+	SELECT student_id, course_id, SUM(grade_sum), SUM(grade_count), SUM(task_grade_sum), SUM(task_count), SUM(task_on_time_count)
+	FROM (
+		SELECT student_id, course_id, SUM(grade) AS grade_sum, COUNT(*) AS grade_count,
+			0 AS task_grade_sum, 0 AS task_count, 0 AS task_on_time_count
+		FROM grades GROUP BY student_id, course_id
+		UNION ALL
+		SELECT student_id, course_id, 0, 0, SUM(grade), COUNT(*), COUNT(*) FILTER (WHERE on_time)
+		FROM grades_tasks GROUP BY student_id, course_id
+	) totals
+	GROUP BY student_id, course_id;
+
+	INSERT INTO course_task_stats (course_id, task_id, grade_sum, grade_count, on_time_count)
+	SELECT course_id, task_id, SUM(grade), COUNT(*), COUNT(*) FILTER (WHERE on_time)
+	FROM grades_tasks
+	GROUP BY course_id, task_id;
+
+	INSERT INTO course_daily_stats (course_id, period_start, grade_sum, grade_count, task_count, task_on_time_count)
+	SELECT course_id, period_start, SUM(grade_sum), SUM(grade_count), SUM(task_count), SUM(task_on_time_count)
+	FROM (
+		SELECT course_id, date_trunc('day', created_at, 'UTC') AS period_start,
+			SUM(grade) AS grade_sum, COUNT(*) AS grade_count, 0 AS task_count, 0 AS task_on_time_count
+		FROM grades WHERE created_at IS NOT NULL GROUP BY 1, 2
+		UNION ALL
+		SELECT course_id, date_trunc('day', created_at, 'UTC'), 0, 0, COUNT(*), COUNT(*) FILTER (WHERE on_time)
+		FROM grades_tasks WHERE created_at IS NOT NULL GROUP BY 1, 2
+	) days
+	GROUP BY course_id, period_start;
+	`
+
+// student_course_daily_stats came after the other aggregates, in its own
+// migration, which also fills it from grades.
+const createStudentCourseDailyStatsStatement = `
+	CREATE TABLE IF NOT EXISTS student_course_daily_stats (
+		student_id   TEXT NOT NULL,
+		course_id    TEXT NOT NULL,
+		period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+		grade_sum    NUMERIC NOT NULL DEFAULT 0,
+		grade_count  BIGINT NOT NULL DEFAULT 0,
+		updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (student_id, course_id, period_start)
+	);
+	CREATE INDEX IF NOT EXISTS student_course_daily_stats_student_idx ON student_course_daily_stats (student_id, period_start);
+	`
+
+const rebuildStudentCourseDailyStatsStatement = `
+	DELETE FROM student_course_daily_stats;
+
+	INSERT INTO student_course_daily_stats (student_id, course_id, period_start, grade_sum, grade_count)
+	SELECT student_id, course_id, date_trunc('day', created_at, 'UTC'), SUM(grade), COUNT(*)
+	FROM grades WHERE created_at IS NOT NULL
+	GROUP BY 1, 2, 3;
+	`
+
+// Incremental updates. Grades are only ever added; task grades are added
+// with sign 1 and taken back with sign -1 when they are overwritten.
+const (
+	addGradeToStudentCourse = `INSERT INTO student_course_stats (student_id, course_id, grade_sum, grade_count)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (student_id, course_id) DO UPDATE SET
+			grade_sum = student_course_stats.grade_sum + EXCLUDED.grade_sum,
+			grade_count = student_course_stats.grade_count + EXCLUDED.grade_count,
+			updated_at = now()`
+
+	addGradeToCourseDay = `INSERT INTO course_daily_stats (course_id, period_start, grade_sum, grade_count)
+		VALUES ($1, date_trunc('day', now(), 'UTC'), $2, 1)
+		ON CONFLICT (course_id, period_start) DO UPDATE SET
+			grade_sum = course_daily_stats.grade_sum + EXCLUDED.grade_sum,
+			grade_count = course_daily_stats.grade_count + EXCLUDED.grade_count,
+			updated_at = now()`
+
+	addGradeToStudentCourseDay = `INSERT INTO student_course_daily_stats (student_id, course_id, period_start, grade_sum, grade_count)
+		VALUES ($1, $2, date_trunc('day', now(), 'UTC'), $3, 1)
+		ON CONFLICT (student_id, course_id, period_start) DO UPDATE SET
+			grade_sum = student_course_daily_stats.grade_sum + EXCLUDED.grade_sum,
+			grade_count = student_course_daily_stats.grade_count + EXCLUDED.grade_count,
+			updated_at = now()`
+
+	addTaskToStudentCourse = `INSERT INTO student_course_stats (student_id, course_id, task_grade_sum, task_count, task_on_time_count)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (student_id, course_id) DO UPDATE SET
+			task_grade_sum = student_course_stats.task_grade_sum + EXCLUDED.task_grade_sum,
+			task_count = student_course_stats.task_count + EXCLUDED.task_count,
+			task_on_time_count = student_course_stats.task_on_time_count + EXCLUDED.task_on_time_count,
+			updated_at = now()`
+
+	addTaskToCourseTask = `INSERT INTO course_task_stats (course_id, task_id, grade_sum, grade_count, on_time_count)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (course_id, task_id) DO UPDATE SET
+			grade_sum = course_task_stats.grade_sum + EXCLUDED.grade_sum,
+			grade_count = course_task_stats.grade_count + EXCLUDED.grade_count,
+			on_time_count = course_task_stats.on_time_count + EXCLUDED.on_time_count,
+			updated_at = now()`
+
+	addTaskToCourseDay = `INSERT INTO course_daily_stats (course_id, period_start, task_count, task_on_time_count)
+		VALUES ($1, date_trunc('day', COALESCE($2::timestamptz, now()), 'UTC'), $3, $4)
+		ON CONFLICT (course_id, period_start) DO UPDATE SET
+			task_count = course_daily_stats.task_count + EXCLUDED.task_count,
+			task_on_time_count = course_daily_stats.task_on_time_count + EXCLUDED.task_on_time_count,
+			updated_at = now()`
+)
+
+// addGradeToAggregates counts a row just inserted into grades. Its
+// created_at is the transaction timestamp, so now() lands in the same day.
+func addGradeToAggregates(ctx context.Context, tx *sql.Tx, studentID, courseID string, grade float64) error {
+	if _, err := tx.ExecContext(ctx, addGradeToStudentCourse, studentID, courseID, grade); err != nil {
+		return fmt.Errorf("error updating student_course_stats: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, addGradeToCourseDay, courseID, grade); err != nil {
+		return fmt.Errorf("error updating course_daily_stats: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, addGradeToStudentCourseDay, studentID, courseID, grade); err != nil {
+		return fmt.Errorf("error updating student_course_daily_stats: %w", err)
+	}
+	return nil
+}
+
+// taskGrade is a row of grades_tasks as seen by the aggregates.
+type taskGrade struct {
+	StudentID string
+	CourseID  string
+	TaskID    string
+	Grade     float64
+	OnTime    bool
+	// CreatedAt is unset for a row written in this transaction, whose
+	// created_at is now().
+	CreatedAt sql.NullTime
+	// Undated rows (created_at NULL) are left out of course_daily_stats.
+	Undated bool
+}
+
+// addTaskToAggregates adds (sign 1) or removes (sign -1) a task grade from
+// every aggregate table.
+func addTaskToAggregates(ctx context.Context, tx *sql.Tx, task taskGrade, sign int) error {
+	onTime := 0
+	if task.OnTime {
+		onTime = sign
+	}
+	sum := task.Grade * float64(sign)
+
+	if _, err := tx.ExecContext(ctx, addTaskToStudentCourse, task.StudentID, task.CourseID, sum, sign, onTime); err != nil {
+		return fmt.Errorf("error updating student_course_stats: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, addTaskToCourseTask, task.CourseID, task.TaskID, sum, sign, onTime); err != nil {
+		return fmt.Errorf("error updating course_task_stats: %w", err)
+	}
+	if task.Undated {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, addTaskToCourseDay, task.CourseID, task.CreatedAt, sign, onTime); err != nil {
+		return fmt.Errorf("error updating course_daily_stats: %w", err)
+	}
+	return nil
+}
+
+// dailyAggregatesCover reports whether a query grouped by grouping over
+// [startTime, endTime] can be answered from the daily rollups. The
+// days are UTC ones, so the periods have to be UTC too, and the range has
+// to start on a UTC day boundary and end on one (endTime being the last
+// instant of a day) or today; otherwise the base tables are used.
+func dailyAggregatesCover(grouping TimeGrouping, startTime, endTime time.Time) bool {
+	if !grouping.wholeDays() || !grouping.utc() {
+		return false
+	}
+	if !startTime.IsZero() && !isUTCDayStart(startTime) {
+		return false
+	}
+	if !endTime.IsZero() && !isUTCDayStart(endTime.Add(time.Nanosecond)) {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		return !endTime.Before(today)
+	}
+	return true
+}
+
+func isUTCDayStart(t time.Time) bool {
+	return t.UTC().Truncate(24 * time.Hour).Equal(t)
+}
+
+// RebuildAggregates recomputes every aggregate table from grades and
+// grades_tasks in one transaction. Writes to the base tables wait until it
+// finishes, so no update is lost in between; reads keep being served from
+// the previous aggregates. It isn't bounded by QueryTimeout.
+func RebuildAggregates(ctx context.Context, db *sql.DB) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "RebuildAggregates")
+	defer span.End()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `LOCK TABLE grades, grades_tasks IN SHARE MODE`); err != nil {
+		return fmt.Errorf("error locking base tables: %w", err)
+	}
+
+	start := time.Now()
+	if _, err = tx.ExecContext(ctx, rebuildAggregatesStatement+rebuildStudentCourseDailyStatsStatement); err != nil {
+		return fmt.Errorf("error rebuilding aggregates: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing rebuilt aggregates: %w", err)
+	}
+
+	slog.InfoContext(ctx, "aggregates rebuilt", "duration", time.Since(start).String())
+	return nil
+}
+
+// StudentCourseStats holds the sums and counts of student_course_stats for
+// one student and course.
+type StudentCourseStats struct {
+	GradeSum        float64
+	GradeCount      int
+	TaskGradeSum    float64
+	TaskCount       int
+	TaskOnTimeCount int
+}
+
+// GetStudentCourseStats returns the aggregates of a student in a course,
+// all zero when the student has no grades there.
+var GetStudentCourseStats = func(ctx context.Context, DB *sql.DB, studentID, courseID string) (StudentCourseStats, error) {
+	ctx, finish := startQuery(ctx, "GetStudentCourseStats")
+	defer finish()
+
+	var s StudentCourseStats
+	err := DB.QueryRowContext(ctx, `
+		SELECT grade_sum::float8, grade_count, task_grade_sum::float8, task_count, task_on_time_count
+		FROM student_course_stats WHERE student_id = $1 AND course_id = $2`,
+		studentID, courseID).Scan(&s.GradeSum, &s.GradeCount, &s.TaskGradeSum, &s.TaskCount, &s.TaskOnTimeCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return StudentCourseStats{}, nil
+	}
+	return s, err
+}
+
+// CourseStats holds the sums and counts of student_course_stats over every
+// student of a course.
+type CourseStats struct {
+	Students        int
+	GradeSum        float64
+	GradeCount      int
+	TaskGradeSum    float64
+	TaskCount       int
+	TaskOnTimeCount int
+}
+
+// GetCourseStats returns the aggregates of a course, all zero when it has
+// no grades.
+var GetCourseStats = func(ctx context.Context, DB *sql.DB, courseID string) (CourseStats, error) {
+	ctx, finish := startQuery(ctx, "GetCourseStats")
+	defer finish()
+
+	var s CourseStats
+	err := DB.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(grade_sum), 0)::float8, COALESCE(SUM(grade_count), 0),
+			COALESCE(SUM(task_grade_sum), 0)::float8, COALESCE(SUM(task_count), 0), COALESCE(SUM(task_on_time_count), 0)
+		FROM student_course_stats WHERE course_id = $1`,
+		courseID).Scan(&s.Students, &s.GradeSum, &s.GradeCount, &s.TaskGradeSum, &s.TaskCount, &s.TaskOnTimeCount)
+	return s, err
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyAggregatesCover(t *testing.T) {
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	endOfDay := day.Add(24*time.Hour - time.Nanosecond)
+	buenosAires, err := time.LoadLocation("America/Argentina/Buenos_Aires")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		grouping TimeGrouping
+		start    time.Time
+		end      time.Time
+		want     bool
+	}{
+		{"whole history", TimeGrouping{}, time.Time{}, time.Time{}, true},
+		{"whole days by week", TimeGrouping{Unit: "week"}, day, endOfDay, true},
+		{"sunday weeks", TimeGrouping{Unit: "week", WeekStart: WeekStartSunday}, day, endOfDay, true},
+		{"open ended", TimeGrouping{Unit: "month"}, day, time.Time{}, true},
+		{"until now", TimeGrouping{Unit: "day"}, day, time.Now(), true},
+		{"explicit UTC", TimeGrouping{Unit: "day", Location: time.UTC}, day, endOfDay, true},
+		{"hourly buckets", TimeGrouping{Unit: "hour"}, day, endOfDay, false},
+		{"whole day intervals", TimeGrouping{Interval: "3 days"}, day, endOfDay, true},
+		{"hourly intervals", TimeGrouping{Interval: "36 hours"}, day, endOfDay, false},
+		{"academic terms", TimeGrouping{Unit: UnitTerm, Institution: "fiuba"}, day, endOfDay, true},
+		{"start mid-day", TimeGrouping{Unit: "day"}, day.Add(time.Hour), endOfDay, false},
+		{"start mid-day in another zone", TimeGrouping{Unit: "day"}, time.Date(2026, 3, 2, 0, 0, 0, 0, time.FixedZone("ART", -3*3600)), endOfDay, false},
+		{"end mid-day in the past", TimeGrouping{Unit: "day"}, day, day.Add(12 * time.Hour), false},
+		{"days of another zone", TimeGrouping{Unit: "day", Location: buenosAires}, time.Time{}, time.Time{}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, dailyAggregatesCover(tc.grouping, tc.start, tc.end))
+		})
+	}
+}
+
+func TestGetCourseAveragesOverTime_FromAggregates(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 31, 23, 59, 59, 999999999, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT DATE_TRUNC($2, period_start AT TIME ZONE $3::text) AT TIME ZONE $3::text AS period, SUM(grade_sum) / SUM(grade_count) AS average_grade, SUM(grade_count) AS grade_count FROM course_daily_stats WHERE course_id = $1 AND period_start >= $4 AND period_start <= $5 GROUP BY period HAVING SUM(grade_count) > 0 ORDER BY period`).
+		WithArgs("course1", "week", "UTC", start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"period", "average_grade", "grade_count"}).
+			AddRow(start, 7.5, 4))
+	mock.ExpectRollback()
+
+	results, err := GetCourseAveragesOverTime(context.Background(), db, "course1", start, end, TimeGrouping{Unit: "week"}, SeriesOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 7.5, *results[0].AverageGrade)
+	assert.Equal(t, 4, results[0].GradeCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStudentAveragesOverTime_FromAggregates(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 31, 23, 59, 59, 999999999, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT DATE_TRUNC($2, period_start AT TIME ZONE $3::text) AT TIME ZONE $3::text AS period, SUM(grade_sum) / SUM(grade_count) AS average_grade, SUM(grade_count) AS grade_count FROM student_course_daily_stats WHERE student_id = $1 AND period_start >= $4 AND period_start <= $5 GROUP BY period HAVING SUM(grade_count) > 0 ORDER BY period`).
+		WithArgs("student1", "month", "UTC", start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"period", "average_grade", "grade_count"}).
+			AddRow(start, 8.0, 3))
+	mock.ExpectRollback()
+
+	results, err := GetStudentAveragesOverTime(context.Background(), db, "student1", start, end, TimeGrouping{Unit: "month"}, SeriesOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 8.0, *results[0].AverageGrade)
+	assert.Equal(t, 3, results[0].GradeCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTaskSummary(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	query := `SELECT grade_sum / grade_count, grade_count, on_time_count FROM course_task_stats WHERE course_id = $1 AND task_id = $2 AND grade_count > 0`
+
+	mock.ExpectQuery(query).
+		WithArgs("course1", "task1").
+		WillReturnRows(sqlmock.NewRows([]string{"avg", "grade_count", "on_time_count"}).AddRow(8.25, 4, 3))
+	average, count, onTime, err := GetTaskSummary(context.Background(), db, "course1", "task1")
+	require.NoError(t, err)
+	assert.Equal(t, 8.25, average)
+	assert.Equal(t, 4, count)
+	assert.Equal(t, 3, onTime)
+
+	// A task nobody handed in yet has no row
+	mock.ExpectQuery(query).
+		WithArgs("course1", "task2").
+		WillReturnRows(sqlmock.NewRows([]string{"avg", "grade_count", "on_time_count"}))
+	average, count, onTime, err = GetTaskSummary(context.Background(), db, "course1", "task2")
+	require.NoError(t, err)
+	assert.Zero(t, average)
+	assert.Zero(t, count)
+	assert.Zero(t, onTime)
+
+	mock.ExpectQuery(query).
+		WithArgs("course1", "task3").
+		WillReturnError(errors.New("db down"))
+	_, _, _, err = GetTaskSummary(context.Background(), db, "course1", "task3")
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRebuildAggregates(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`LOCK TABLE grades, grades_tasks IN SHARE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(rebuildAggregatesStatement + rebuildStudentCourseDailyStatsStatement).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	require.NoError(t, RebuildAggregates(context.Background(), db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRebuildAggregates_RollsBackOnError(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`LOCK TABLE grades, grades_tasks IN SHARE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(rebuildAggregatesStatement + rebuildStudentCourseDailyStatsStatement).WillReturnError(errors.New("disk full"))
+	mock.ExpectRollback()
+
+	err := RebuildAggregates(context.Background(), db)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStudentCourseStats_NoRows(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT grade_sum::float8, grade_count, task_grade_sum::float8, task_count, task_on_time_count FROM student_course_stats WHERE student_id = $1 AND course_id = $2`).
+		WithArgs("student1", "course1").
+		WillReturnRows(sqlmock.NewRows([]string{"grade_sum", "grade_count", "task_grade_sum", "task_count", "task_on_time_count"}))
+
+	stats, err := GetStudentCourseStats(context.Background(), db, "student1", "course1")
+	require.NoError(t, err)
+	assert.Equal(t, StudentCourseStats{}, stats)
+}
+
+func TestGetCourseStats(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT COUNT(*), COALESCE(SUM(grade_sum), 0)::float8, COALESCE(SUM(grade_count), 0), COALESCE(SUM(task_grade_sum), 0)::float8, COALESCE(SUM(task_count), 0), COALESCE(SUM(task_on_time_count), 0) FROM student_course_stats WHERE course_id = $1`).
+		WithArgs("course1").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "grade_sum", "grade_count", "task_grade_sum", "task_count", "task_on_time_count"}).
+			AddRow(2, 15.0, 2, 24.0, 3, 2))
+
+	stats, err := GetCourseStats(context.Background(), db, "course1")
+	require.NoError(t, err)
+	assert.Equal(t, CourseStats{Students: 2, GradeSum: 15, GradeCount: 2, TaskGradeSum: 24, TaskCount: 3, TaskOnTimeCount: 2}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
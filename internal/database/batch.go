@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+
+	"github.com/lib/pq"
+)
+
+// The *Batch functions read the aggregates of many keys in one query, for
+// the GraphQL loaders. Keys without rows are left out of the returned map,
+// which callers read as all zero.
+
+// StudentCourse identifies a student in a course.
+type StudentCourse struct {
+	StudentID string
+	CourseID  string
+}
+
+// CourseTask identifies a task of a course.
+type CourseTask struct {
+	CourseID string
+	TaskID   string
+}
+
+// TaskStats holds the sums and counts of course_task_stats for one task.
+type TaskStats struct {
+	GradeSum    float64
+	GradeCount  int
+	OnTimeCount int
+}
+
+// unzipStudentCourses splits keys into the two arrays unnest pairs back.
+func unzipStudentCourses(keys []StudentCourse) (pq.StringArray, pq.StringArray) {
+	students := make(pq.StringArray, len(keys))
+	courses := make(pq.StringArray, len(keys))
+	for i, key := range keys {
+		students[i], courses[i] = key.StudentID, key.CourseID
+	}
+	return students, courses
+}
+
+// GetStudentCourseStatsBatch is GetStudentCourseStats for many students
+// and courses.
+var GetStudentCourseStatsBatch = func(ctx context.Context, DB *sql.DB, keys []StudentCourse) (map[StudentCourse]StudentCourseStats, error) {
+	ctx, finish := startQuery(ctx, "GetStudentCourseStatsBatch")
+	defer finish()
+
+	students, courses := unzipStudentCourses(keys)
+	rows, err := DB.QueryContext(ctx, `
+		SELECT student_id, course_id, grade_sum::float8, grade_count, task_grade_sum::float8, task_count, task_on_time_count
+		FROM student_course_stats
+		WHERE (student_id, course_id) IN (SELECT * FROM unnest($1::text[], $2::text[]))`,
+		students, courses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[StudentCourse]StudentCourseStats, len(keys))
+	for rows.Next() {
+		var key StudentCourse
+		var s StudentCourseStats
+		if err := rows.Scan(&key.StudentID, &key.CourseID, &s.GradeSum, &s.GradeCount, &s.TaskGradeSum, &s.TaskCount, &s.TaskOnTimeCount); err != nil {
+			return nil, err
+		}
+		stats[key] = s
+	}
+	return stats, rows.Err()
+}
+
+// GetCourseStatsBatch is GetCourseStats for many courses.
+var GetCourseStatsBatch = func(ctx context.Context, DB *sql.DB, courseIDs []string) (map[string]CourseStats, error) {
+	ctx, finish := startQuery(ctx, "GetCourseStatsBatch")
+	defer finish()
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT course_id, COUNT(*), SUM(grade_sum)::float8, SUM(grade_count),
+			SUM(task_grade_sum)::float8, SUM(task_count), SUM(task_on_time_count)
+		FROM student_course_stats WHERE course_id = ANY($1::text[])
+		GROUP BY course_id`,
+		pq.StringArray(courseIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]CourseStats, len(courseIDs))
+	for rows.Next() {
+		var courseID string
+		var s CourseStats
+		if err := rows.Scan(&courseID, &s.Students, &s.GradeSum, &s.GradeCount, &s.TaskGradeSum, &s.TaskCount, &s.TaskOnTimeCount); err != nil {
+			return nil, err
+		}
+		stats[courseID] = s
+	}
+	return stats, rows.Err()
+}
+
+// GetTaskStatsBatch reads course_task_stats for many tasks.
+var GetTaskStatsBatch = func(ctx context.Context, DB *sql.DB, keys []CourseTask) (map[CourseTask]TaskStats, error) {
+	ctx, finish := startQuery(ctx, "GetTaskStatsBatch")
+	defer finish()
+
+	courses := make(pq.StringArray, len(keys))
+	tasks := make(pq.StringArray, len(keys))
+	for i, key := range keys {
+		courses[i], tasks[i] = key.CourseID, key.TaskID
+	}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT course_id, task_id, grade_sum::float8, grade_count, on_time_count
+		FROM course_task_stats
+		WHERE (course_id, task_id) IN (SELECT * FROM unnest($1::text[], $2::text[]))`,
+		courses, tasks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[CourseTask]TaskStats, len(keys))
+	for rows.Next() {
+		var key CourseTask
+		var s TaskStats
+		if err := rows.Scan(&key.CourseID, &key.TaskID, &s.GradeSum, &s.GradeCount, &s.OnTimeCount); err != nil {
+			return nil, err
+		}
+		stats[key] = s
+	}
+	return stats, rows.Err()
+}
+
+// GetGradesBatch returns the grades of many students in their courses,
+// oldest first. Course grades come with an empty TaskID.
+var GetGradesBatch = func(ctx context.Context, DB *sql.DB, keys []StudentCourse) (map[StudentCourse][]model.GradeTask, error) {
+	ctx, finish := startQuery(ctx, "GetGradesBatch")
+	defer finish()
+
+	students, courses := unzipStudentCourses(keys)
+	rows, err := DB.QueryContext(ctx, `
+		SELECT student_id, course_id, '' AS task_id, grade::float8, on_time, created_at FROM grades
+		WHERE (student_id, course_id) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+		UNION ALL
+		SELECT student_id, course_id, task_id, grade::float8, on_time, created_at FROM grades_tasks
+		WHERE (student_id, course_id) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+		ORDER BY created_at`,
+		students, courses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grades := make(map[StudentCourse][]model.GradeTask, len(keys))
+	for rows.Next() {
+		var g model.GradeTask
+		if err := rows.Scan(&g.StudentID, &g.CourseID, &g.TaskID, &g.Grade, &g.OnTime, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		key := StudentCourse{StudentID: g.StudentID, CourseID: g.CourseID}
+		grades[key] = append(grades[key], g)
+	}
+	return grades, rows.Err()
+}
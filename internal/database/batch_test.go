@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStudentCourseStatsBatch(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT student_id, course_id, grade_sum::float8, grade_count, task_grade_sum::float8, task_count, task_on_time_count FROM student_course_stats WHERE (student_id, course_id) IN (SELECT * FROM unnest($1::text[], $2::text[]))`).
+		WithArgs(pq.StringArray{"s1", "s2"}, pq.StringArray{"c1", "c1"}).
+		WillReturnRows(sqlmock.NewRows([]string{"student_id", "course_id", "grade_sum", "grade_count", "task_grade_sum", "task_count", "task_on_time_count"}).
+			AddRow("s1", "c1", 15.0, 2, 24.0, 3, 2))
+
+	stats, err := GetStudentCourseStatsBatch(context.Background(), db, []StudentCourse{{"s1", "c1"}, {"s2", "c1"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[StudentCourse]StudentCourseStats{
+		{"s1", "c1"}: {GradeSum: 15, GradeCount: 2, TaskGradeSum: 24, TaskCount: 3, TaskOnTimeCount: 2},
+	}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCourseStatsBatch(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT course_id, COUNT(*), SUM(grade_sum)::float8, SUM(grade_count), SUM(task_grade_sum)::float8, SUM(task_count), SUM(task_on_time_count) FROM student_course_stats WHERE course_id = ANY($1::text[]) GROUP BY course_id`).
+		WithArgs(pq.StringArray{"c1", "c2"}).
+		WillReturnRows(sqlmock.NewRows([]string{"course_id", "count", "grade_sum", "grade_count", "task_grade_sum", "task_count", "task_on_time_count"}).
+			AddRow("c2", 2, 15.0, 2, 16.0, 2, 1))
+
+	stats, err := GetCourseStatsBatch(context.Background(), db, []string{"c1", "c2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]CourseStats{
+		"c2": {Students: 2, GradeSum: 15, GradeCount: 2, TaskGradeSum: 16, TaskCount: 2, TaskOnTimeCount: 1},
+	}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTaskStatsBatch_Error(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT course_id, task_id, grade_sum::float8, grade_count, on_time_count FROM course_task_stats WHERE (course_id, task_id) IN (SELECT * FROM unnest($1::text[], $2::text[]))`).
+		WithArgs(pq.StringArray{"c1"}, pq.StringArray{"t1"}).
+		WillReturnError(errors.New("boom"))
+
+	_, err := GetTaskStatsBatch(context.Background(), db, []CourseTask{{"c1", "t1"}})
+	assert.EqualError(t, err, "boom")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGradesBatch(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	first := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	mock.ExpectQuery(`SELECT student_id, course_id, '' AS task_id, grade::float8, on_time, created_at FROM grades WHERE (student_id, course_id) IN (SELECT * FROM unnest($1::text[], $2::text[])) UNION ALL SELECT student_id, course_id, task_id, grade::float8, on_time, created_at FROM grades_tasks WHERE (student_id, course_id) IN (SELECT * FROM unnest($1::text[], $2::text[])) ORDER BY created_at`).
+		WithArgs(pq.StringArray{"s1"}, pq.StringArray{"c1"}).
+		WillReturnRows(sqlmock.NewRows([]string{"student_id", "course_id", "task_id", "grade", "on_time", "created_at"}).
+			AddRow("s1", "c1", "", 7.0, true, first).
+			AddRow("s1", "c1", "t1", 9.0, false, second))
+
+	grades, err := GetGradesBatch(context.Background(), db, []StudentCourse{{"s1", "c1"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[StudentCourse][]model.GradeTask{
+		{"s1", "c1"}: {
+			{StudentID: "s1", CourseID: "c1", Grade: 7, OnTime: true, CreatedAt: first},
+			{StudentID: "s1", CourseID: "c1", TaskID: "t1", Grade: 9, CreatedAt: second},
+		},
+	}, grades)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGradeDistribution(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT (FLOOR(grade / $1::float8) * $1::float8)::float8 AS bucket, COUNT(*) FROM grades_tasks WHERE course_id = $2 AND task_id = $3 GROUP BY bucket ORDER BY bucket`).
+		WithArgs(2.5, "c1", "t1").
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).
+			AddRow(5.0, 3).
+			AddRow(7.5, 1))
+
+	buckets, err := GetGradeDistribution(context.Background(), db, "c1", "t1", 2.5)
+	require.NoError(t, err)
+	assert.Equal(t, []GradeBucket{{From: 5, To: 7.5, Count: 3}, {From: 7.5, To: 10, Count: 1}}, buckets)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGradeDistribution_InvalidBucketSize(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	_, err := GetGradeDistribution(context.Background(), db, "c1", "", 0)
+	assert.ErrorIs(t, err, ErrInvalidBucketSize)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,19 +1,47 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"service_stats/internal/model"
 	"time"
 
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+
 	_ "github.com/lib/pq"
 )
 
 var DB *sql.DB
 
-func InitDB(posgresUrl string) (*sql.DB, error) {
+// PoolConfig sizes the connection pool. Zero values keep the database/sql
+// defaults.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// apply sets the pool limits on db.
+func (p PoolConfig) apply(db *sql.DB) {
+	if p.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(p.MaxOpenConns)
+	}
+	if p.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(p.MaxIdleConns)
+	}
+	if p.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	}
+	if p.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+	}
+}
+
+func InitDB(posgresUrl string, pool PoolConfig) (*sql.DB, error) {
 	var err error
 
 	DB, err = sql.Open("postgres", posgresUrl)
@@ -21,32 +49,14 @@ func InitDB(posgresUrl string) (*sql.DB, error) {
 		return nil, fmt.Errorf("error connecting to the database: %w", err)
 	}
 
+	pool.apply(DB)
+
 	if err = DB.Ping(); err != nil {
 		return nil, fmt.Errorf("error pinging the database: %w", err)
 	}
 
-	statement := `
-	CREATE TABLE IF NOT EXISTS grades (
-		id SERIAL PRIMARY KEY,
-		student_id TEXT NOT NULL,
-		course_id  TEXT NOT NULL,
-		grade      NUMERIC NOT NULL,
-		on_time    BOOLEAN NOT NULL DEFAULT TRUE,
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-	);
-	CREATE TABLE IF NOT EXISTS grades_tasks (
-		id SERIAL PRIMARY KEY,
-		student_id TEXT NOT NULL,
-		course_id  TEXT NOT NULL,
-		task_id    TEXT NOT NULL,
-		grade      NUMERIC NOT NULL,
-		on_time    BOOLEAN NOT NULL DEFAULT TRUE,
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	if _, err = DB.Exec(statement); err != nil {
-		return nil, fmt.Errorf("error creating tables: %w", err)
+	if err = Migrate(context.Background(), DB); err != nil {
+		return nil, fmt.Errorf("error running migrations: %w", err)
 	}
 
 	return DB, nil
@@ -99,214 +109,284 @@ func InitDB(posgresUrl string) (*sql.DB, error) {
 	return DB, nil
 }*/
 
-var InsertGrade = func(db *sql.DB, grade model.Grade) (err error) {
-	tx, err := db.Begin()
+// InsertGrade inserts a grade and returns it as stored, with its created_at.
+var InsertGrade = func(ctx context.Context, db *sql.DB, grade model.Grade) (stored model.Grade, err error) {
+	ctx, finish := startQuery(ctx, "InsertGrade")
+	defer finish()
+
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("[Service Stats] Error starting transaction: %v", err)
-		return err
+		slog.ErrorContext(ctx, "error starting transaction", "error", err)
+		return model.Grade{}, err
 	}
 
 	defer func() {
 		if err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				log.Printf("[Service Stats] Error rolling back transaction: %v", rollbackErr)
+			if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+				slog.ErrorContext(ctx, "error rolling back transaction", "error", rollbackErr)
 			}
 		} else {
 			if commitErr := tx.Commit(); commitErr != nil {
-				log.Printf("[Service Stats] Error committing transaction: %v", commitErr)
+				slog.ErrorContext(ctx, "error committing transaction", "error", commitErr)
 				err = commitErr
 			}
 		}
 	}()
 
-	statement := `INSERT INTO grades (student_id, course_id, grade, on_time) VALUES ($1, $2, $3, $4)`
-	_, err = tx.Exec(statement, grade.StudentID, grade.CourseID, grade.Grade, grade.OnTime)
+	statement := `INSERT INTO grades (student_id, course_id, grade, on_time) VALUES ($1, $2, $3, $4) RETURNING created_at`
+	err = tx.QueryRowContext(ctx, statement, grade.StudentID, grade.CourseID, grade.Grade, grade.OnTime).Scan(&grade.CreatedAt)
 	if err != nil {
-		log.Printf("[Service Stats] Error inserting grade: %v", err)
-		return err
+		slog.ErrorContext(ctx, "error inserting grade", "error", err)
+		return model.Grade{}, err
 	}
 
-	return nil
+	err = addGradeToAggregates(ctx, tx, grade.StudentID, grade.CourseID, grade.Grade)
+	if err != nil {
+		slog.ErrorContext(ctx, "error updating aggregates", "error", err)
+		return model.Grade{}, err
+	}
+
+	return grade, nil
 }
 
-var GetAvgGradeForStudent = func(db *sql.DB, studentID string, courseID string) (float64, int, error) {
-	tx, err := db.Begin()
+var GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID string, courseID string) (float64, int, error) {
+	ctx, finish := startQuery(ctx, "GetAvgGradeForStudent")
+	defer finish()
+
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("[Service Stats] Error starting transaction: %v", err)
+		slog.ErrorContext(ctx, "error starting transaction", "error", err)
 		return 0, http.StatusInternalServerError, err
 	}
 
 	defer func() {
 		if err == nil {
 			if commitErr := tx.Commit(); commitErr != nil {
-				log.Printf("[Service Stats] Error committing transaction: %v", commitErr)
+				slog.ErrorContext(ctx, "error committing transaction", "error", commitErr)
 				err = commitErr
 			}
 		} else {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				log.Printf("[Service Stats] Error rolling back transaction: %v", rollbackErr)
+			if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+				slog.ErrorContext(ctx, "error rolling back transaction", "error", rollbackErr)
 			}
 		}
 	}()
 
 	var avgGrade float64
-	statement := `SELECT AVG(grade) FROM grades WHERE student_id = $1 AND course_id = $2 GROUP BY student_id, course_id`
+	statement := `SELECT grade_sum / grade_count FROM student_course_stats WHERE student_id = $1 AND course_id = $2 AND grade_count > 0`
 
-	err = tx.QueryRow(statement, studentID, courseID).Scan(&avgGrade)
+	err = tx.QueryRowContext(ctx, statement, studentID, courseID).Scan(&avgGrade)
 
 	if err == sql.ErrNoRows {
-		log.Printf("[Service Stats] No grades found for student %s in course %s", studentID, courseID)
+		slog.DebugContext(ctx, "no grades found", "student_id", studentID, "course_id", courseID)
 		return 0.0, http.StatusNotFound, nil
 	}
 
 	if err != nil {
-		log.Printf("[Service Stats] Error getting average grade for student %s in course %s: %v", studentID, courseID, err)
+		slog.ErrorContext(ctx, "error getting average grade", "student_id", studentID, "course_id", courseID, "error", err)
 		return 0, http.StatusInternalServerError, err
 	}
 
 	return avgGrade, http.StatusOK, nil
 }
 
-// GetStudentAveragesOverTime returns student's grade averages over time
-var GetStudentAveragesOverTime = func(DB *sql.DB, studentID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
-	tx, err := DB.Begin()
+// GetStudentAveragesOverTime returns student's grade averages over time.
+// series adds the periods without grades and running averages.
+var GetStudentAveragesOverTime = func(ctx context.Context, DB *sql.DB, studentID string, startTime, endTime time.Time, grouping TimeGrouping, series SeriesOptions) ([]PeriodAverage, error) {
+	ctx, finish := startQuery(ctx, "GetStudentAveragesOverTime")
+	defer finish()
+
+	if err := series.Validate(grouping, startTime, endTime); err != nil {
+		return nil, err
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	var query string
-	var args []interface{}
+	buckets, args := grouping.bind([]interface{}{studentID})
+	if err := buckets.loadTerms(ctx, tx); err != nil {
+		return nil, err
+	}
 
 	baseQuery := `
 		SELECT 
-			DATE_TRUNC($1, created_at) AS period,
+			` + buckets.period("created_at") + ` AS period,
 			AVG(grade) AS average_grade,
 			COUNT(*) AS grade_count
 		FROM grades
-		WHERE student_id = $2
+		WHERE student_id = $1
 	`
+	timeColumn := "created_at"
+	groupClause := " GROUP BY period ORDER BY period"
 
-	args = append(args, groupBy, studentID)
-	argPos := 3
+	// Whole days are served from the daily rollup instead of scanning grades
+	if dailyAggregatesCover(grouping, startTime, endTime) {
+		baseQuery = `
+		SELECT
+			` + buckets.period("period_start") + ` AS period,
+			SUM(grade_sum) / SUM(grade_count) AS average_grade,
+			SUM(grade_count) AS grade_count
+		FROM student_course_daily_stats
+		WHERE student_id = $1
+	`
+		timeColumn = "period_start"
+		groupClause = " GROUP BY period HAVING SUM(grade_count) > 0 ORDER BY period"
+	}
 
 	if !startTime.IsZero() {
-		baseQuery += fmt.Sprintf(" AND created_at >= $%d", argPos)
 		args = append(args, startTime)
-		argPos++
+		baseQuery += fmt.Sprintf(" AND %s >= $%d", timeColumn, len(args))
 	}
 	if !endTime.IsZero() {
-		baseQuery += fmt.Sprintf(" AND created_at <= $%d", argPos)
 		args = append(args, endTime)
-		argPos++
+		baseQuery += fmt.Sprintf(" AND %s <= $%d", timeColumn, len(args))
 	}
 
-	query = baseQuery + " GROUP BY period ORDER BY period"
+	query := baseQuery + groupClause
+	return queryAveragesOverTime(ctx, tx, query, args, buckets, startTime, endTime, series)
+}
 
-	rows, err := tx.Query(query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+// GetCourseAveragesOverTime returns course's grade averages over time.
+// series adds the periods without grades and running averages.
+var GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping TimeGrouping, series SeriesOptions) ([]PeriodAverage, error) {
+	ctx, finish := startQuery(ctx, "GetCourseAveragesOverTime")
+	defer finish()
 
-	var results []map[string]interface{}
-	for rows.Next() {
-		var period time.Time
-		var avgGrade float64
-		var count int
-		if err := rows.Scan(&period, &avgGrade, &count); err != nil {
-			return nil, err
-		}
-		results = append(results, map[string]interface{}{
-			"period":        period.Format(time.RFC3339),
-			"average_grade": avgGrade,
-			"grade_count":   count,
-		})
+	if err := series.Validate(grouping, startTime, endTime); err != nil {
+		return nil, err
 	}
-	return results, nil
-}
 
-// GetCourseAveragesOverTime returns course's grade averages over time
-var GetCourseAveragesOverTime = func(DB *sql.DB, courseID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
-	tx, err := DB.Begin()
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	var query string
-	var args []interface{}
+	buckets, args := grouping.bind([]interface{}{courseID})
+	if err := buckets.loadTerms(ctx, tx); err != nil {
+		return nil, err
+	}
 
 	baseQuery := `
 		SELECT 
-			DATE_TRUNC($1, created_at) AS period,
+			` + buckets.period("created_at") + ` AS period,
 			AVG(grade) AS average_grade,
 			COUNT(*) AS grade_count
 		FROM grades
-		WHERE course_id = $2
+		WHERE course_id = $1
 	`
+	timeColumn := "created_at"
+	groupClause := " GROUP BY period ORDER BY period"
 
-	args = append(args, groupBy, courseID)
-	argPos := 3
+	// Whole days are served from the daily rollup instead of scanning grades
+	if dailyAggregatesCover(grouping, startTime, endTime) {
+		baseQuery = `
+		SELECT
+			` + buckets.period("period_start") + ` AS period,
+			SUM(grade_sum) / SUM(grade_count) AS average_grade,
+			SUM(grade_count) AS grade_count
+		FROM course_daily_stats
+		WHERE course_id = $1
+	`
+		timeColumn = "period_start"
+		groupClause = " GROUP BY period HAVING SUM(grade_count) > 0 ORDER BY period"
+	}
 
 	if !startTime.IsZero() {
-		baseQuery += fmt.Sprintf(" AND created_at >= $%d", argPos)
 		args = append(args, startTime)
-		argPos++
+		baseQuery += fmt.Sprintf(" AND %s >= $%d", timeColumn, len(args))
 	}
 	if !endTime.IsZero() {
-		baseQuery += fmt.Sprintf(" AND created_at <= $%d", argPos)
 		args = append(args, endTime)
-		argPos++
+		baseQuery += fmt.Sprintf(" AND %s <= $%d", timeColumn, len(args))
 	}
 
-	query = baseQuery + " GROUP BY period ORDER BY period"
+	query := baseQuery + groupClause
+	return queryAveragesOverTime(ctx, tx, query, args, buckets, startTime, endTime, series)
+}
+
+// queryAveragesOverTime runs query, which returns period, average_grade and
+// grade_count bucketed by buckets, completing it as series says.
+func queryAveragesOverTime(ctx context.Context, tx *sql.Tx, query string, args []interface{}, buckets bucketing, startTime, endTime time.Time, series SeriesOptions) ([]PeriodAverage, error) {
+	if series.enabled() {
+		query, args = series.wrap(query, args, buckets, startTime, endTime)
+	}
 
-	rows, err := tx.Query(query, args...)
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var results []map[string]interface{}
+	if series.enabled() {
+		return series.scanSeries(rows, buckets)
+	}
+
+	var results []PeriodAverage
 	for rows.Next() {
-		var period time.Time
+		var period interface{}
 		var avgGrade float64
 		var count int
 		if err := rows.Scan(&period, &avgGrade, &count); err != nil {
 			return nil, err
 		}
-		results = append(results, map[string]interface{}{
-			"period":        period.Format(time.RFC3339),
-			"average_grade": avgGrade,
-			"grade_count":   count,
+		label, ok := buckets.label(period)
+		if !ok {
+			// Grades outside every academic term
+			continue
+		}
+		results = append(results, PeriodAverage{
+			Period:       label,
+			AverageGrade: &avgGrade,
+			GradeCount:   count,
 		})
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return results, nil
 }
 
-// InsertGradeTask inserts a new grade task
-var InsertGradeTask = func(DB *sql.DB, grade model.GradeTask) error {
-	tx, err := DB.Begin()
+// InsertGradeTask inserts a new grade task and returns it as stored
+var InsertGradeTask = func(ctx context.Context, DB *sql.DB, grade model.GradeTask) (model.GradeTask, error) {
+	ctx, finish := startQuery(ctx, "InsertGradeTask")
+	defer finish()
+
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return model.GradeTask{}, err
 	}
 	defer tx.Rollback()
 
 	statement := `INSERT INTO grades_tasks (student_id, course_id, task_id, grade, on_time)
-				  VALUES ($1, $2, $3, $4, $5)`
-	_, err = tx.Exec(statement, grade.StudentID, grade.CourseID, grade.TaskID, grade.Grade, grade.OnTime)
+				  VALUES ($1, $2, $3, $4, $5) RETURNING created_at`
+	err = tx.QueryRowContext(ctx, statement, grade.StudentID, grade.CourseID, grade.TaskID, grade.Grade, grade.OnTime).Scan(&grade.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "error inserting grade task", "error", err)
+		return model.GradeTask{}, err
+	}
+
+	err = addTaskToAggregates(ctx, tx, newTaskGrade(grade), 1)
 	if err != nil {
-		log.Printf("[Service Stats] Error inserting grade task: %v", err)
-		return err
+		slog.ErrorContext(ctx, "error updating aggregates", "error", err)
+		return model.GradeTask{}, err
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return model.GradeTask{}, err
+	}
+	return grade, nil
 }
 
 // GetAvgGradeTaskForStudent returns student's average in one task
-var GetAvgGradeTaskForStudent = func(DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
-	tx, err := DB.Begin()
+var GetAvgGradeTaskForStudent = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
+	ctx, finish := startQuery(ctx, "GetAvgGradeTaskForStudent")
+	defer finish()
+
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, http.StatusInternalServerError, err
 	}
@@ -317,13 +397,13 @@ var GetAvgGradeTaskForStudent = func(DB *sql.DB, studentID string, courseID stri
 				  WHERE student_id = $1 AND course_id = $2 AND task_id = $3
 				  GROUP BY student_id, course_id, task_id`
 
-	err = tx.QueryRow(statement, studentID, courseID, taskID).Scan(&avgGrade)
+	err = tx.QueryRowContext(ctx, statement, studentID, courseID, taskID).Scan(&avgGrade)
 	if err == sql.ErrNoRows {
-		log.Printf("[Service Stats] No grades found for student %s in course %s task %s", studentID, courseID, taskID)
+		slog.DebugContext(ctx, "no task grades found", "student_id", studentID, "course_id", courseID, "task_id", taskID)
 		return 0.0, http.StatusNotFound, nil
 	}
 	if err != nil {
-		log.Printf("[Service Stats] Error getting average grade for task: %v", err)
+		slog.ErrorContext(ctx, "error getting average grade for task", "error", err)
 		return 0, http.StatusInternalServerError, err
 	}
 
@@ -331,17 +411,20 @@ var GetAvgGradeTaskForStudent = func(DB *sql.DB, studentID string, courseID stri
 }
 
 // GetStudentCourseTasksAverage returns average for student in all course tasks
-var GetStudentCourseTasksAverage = func(DB *sql.DB, studentID string, courseID string) (float64, int, error) {
-	tx, err := DB.Begin()
+var GetStudentCourseTasksAverage = func(ctx context.Context, DB *sql.DB, studentID string, courseID string) (float64, int, error) {
+	ctx, finish := startQuery(ctx, "GetStudentCourseTasksAverage")
+	defer finish()
+
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, http.StatusInternalServerError, err
 	}
 	defer tx.Rollback()
 
 	var avgGrade float64
-	statement := `SELECT AVG(grade) FROM grades_tasks WHERE student_id = $1 AND course_id = $2`
+	statement := `SELECT task_grade_sum / task_count FROM student_course_stats WHERE student_id = $1 AND course_id = $2 AND task_count > 0`
 
-	err = tx.QueryRow(statement, studentID, courseID).Scan(&avgGrade)
+	err = tx.QueryRowContext(ctx, statement, studentID, courseID).Scan(&avgGrade)
 	if err == sql.ErrNoRows {
 		return 0.0, http.StatusNotFound, nil
 	}
@@ -352,94 +435,95 @@ var GetStudentCourseTasksAverage = func(DB *sql.DB, studentID string, courseID s
 	return avgGrade, http.StatusOK, nil
 }
 
-// GetOtherStudentsCourseAverages returns averages for all other students in a course
-var GetOtherStudentsCourseAverages = func(DB *sql.DB, studentID string, courseID string) ([]map[string]interface{}, error) {
-	tx, err := DB.Begin()
+// GetOtherStudentsCourseAverages returns a page of the averages of the other students in a course
+var GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts ListOptions) (*Page, error) {
+	ctx, finish := startQuery(ctx, "GetOtherStudentsCourseAverages")
+	defer finish()
+
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	query := `
+	args := []interface{}{courseID, studentID}
+	source := `
+		SELECT
+			student_id,
+			(task_grade_sum / task_count)::float8 AS average_grade,
+			task_count AS item_count
+		FROM student_course_stats
+		WHERE course_id = $1 AND student_id != $2 AND task_count > 0`
+
+	// The aggregates can't tell on-time grades or dates apart
+	if opts.fromBaseTable() {
+		var conditions string
+		conditions, args = opts.gradeFilters(args)
+		source = `
 		SELECT
 			student_id,
-			AVG(grade) as average_grade,
-			COUNT(*) as task_count
+			AVG(grade)::float8 AS average_grade,
+			COUNT(*) AS item_count
 		FROM grades_tasks
-		WHERE course_id = $1 AND student_id != $2
-		GROUP BY student_id
-		ORDER BY average_grade DESC
-	`
-
-	rows, err := tx.Query(query, courseID, studentID)
-	if err != nil {
-		return nil, err
+		WHERE course_id = $1 AND student_id != $2` + conditions + `
+		GROUP BY student_id`
 	}
-	defer rows.Close()
 
-	var results []map[string]interface{}
-	for rows.Next() {
-		var sID string
-		var avgGrade float64
-		var taskCount int
-		if err := rows.Scan(&sID, &avgGrade, &taskCount); err != nil {
-			return nil, err
-		}
-		results = append(results, map[string]interface{}{
-			"student_id":    sID,
-			"average_grade": avgGrade,
-			"task_count":    taskCount,
-		})
+	return listStudents(ctx, tx, source, args, true, opts)
+}
+
+// GetTaskSummary returns the average, number of grades and on-time
+// submissions of a task across the whole course, from course_task_stats.
+var GetTaskSummary = func(ctx context.Context, DB *sql.DB, courseID string, taskID string) (float64, int, int, error) {
+	ctx, finish := startQuery(ctx, "GetTaskSummary")
+	defer finish()
+
+	var average float64
+	var count, onTime int
+	statement := `SELECT grade_sum / grade_count, grade_count, on_time_count FROM course_task_stats
+				  WHERE course_id = $1 AND task_id = $2 AND grade_count > 0`
+
+	err := DB.QueryRowContext(ctx, statement, courseID, taskID).Scan(&average, &count, &onTime)
+	if err == sql.ErrNoRows {
+		return 0, 0, 0, nil
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting task summary", "course_id", courseID, "task_id", taskID, "error", err)
+		return 0, 0, 0, err
 	}
 
-	return results, nil
+	return average, count, onTime, nil
 }
 
-// GetAveragesForTask returns averages for all students in a task
-var GetAveragesForTask = func(DB *sql.DB, courseID string, taskID string) ([]map[string]interface{}, error) {
-	tx, err := DB.Begin()
+// GetAveragesForTask returns a page of the averages of the students in a task
+var GetAveragesForTask = func(ctx context.Context, DB *sql.DB, courseID string, taskID string, opts ListOptions) (*Page, error) {
+	ctx, finish := startQuery(ctx, "GetAveragesForTask")
+	defer finish()
+
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	query := `
+	conditions, args := opts.gradeFilters([]interface{}{courseID, taskID})
+	source := `
 		SELECT
 			student_id,
-			AVG(grade) as average_grade,
-			COUNT(*) as grade_count
+			AVG(grade)::float8 AS average_grade,
+			COUNT(*) AS item_count
 		FROM grades_tasks
-		WHERE course_id = $1 AND task_id = $2
-		GROUP BY student_id
-		ORDER BY average_grade DESC
-	`
+		WHERE course_id = $1 AND task_id = $2` + conditions + `
+		GROUP BY student_id`
 
-	rows, err := tx.Query(query, courseID, taskID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var results []map[string]interface{}
-	for rows.Next() {
-		var sID string
-		var avgGrade float64
-		var gradeCount int
-		if err := rows.Scan(&sID, &avgGrade, &gradeCount); err != nil {
-			return nil, err
-		}
-		results = append(results, map[string]interface{}{
-			"student_id":    sID,
-			"average_grade": avgGrade,
-			"grade_count":   gradeCount,
-		})
-	}
-
-	return results, nil
+	return listStudents(ctx, tx, source, args, false, opts)
 }
 
-var GetOnTimeSubmissionPercentageForCourse = func(DB *sql.DB, courseID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
-	tx, err := DB.Begin()
+var GetOnTimeSubmissionPercentageForCourse = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping TimeGrouping) ([]OnTimeStat, error) {
+	ctx, finish := startQuery(ctx, "GetOnTimeSubmissionPercentageForCourse")
+	defer finish()
+
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -447,88 +531,79 @@ var GetOnTimeSubmissionPercentageForCourse = func(DB *sql.DB, courseID string, s
 		_ = tx.Rollback() // rollback is safe even if already committed
 	}()
 
-	var query string
-	var args []interface{}
+	// Whole days are served from the daily rollup instead of scanning
+	// grades_tasks
+	fromAggregates := dailyAggregatesCover(grouping, startTime, endTime)
 
-	if groupBy == "" {
-		baseQuery := `
-			SELECT
-				'all_time' AS period,
+	timeColumn := "created_at"
+	counts := `
 				COUNT(*) FILTER (WHERE on_time = true) AS on_time_count,
 				COUNT(*) AS total_count,
 				COALESCE((COUNT(*) FILTER (WHERE on_time = true) * 100.0 / NULLIF(COUNT(*), 0)), 0) AS percentage
-			FROM grades_tasks
-			WHERE course_id = $1
-		`
-		args = append(args, courseID)
-		argPos := 2
-
-		if !startTime.IsZero() {
-			baseQuery += fmt.Sprintf(" AND created_at >= $%d", argPos)
-			args = append(args, startTime)
-			argPos++
-		}
-
-		if !endTime.IsZero() {
-			baseQuery += fmt.Sprintf(" AND created_at <= $%d", argPos)
-			args = append(args, endTime)
-		}
+			FROM grades_tasks`
+	if fromAggregates {
+		timeColumn = "period_start"
+		counts = `
+				COALESCE(SUM(task_on_time_count), 0) AS on_time_count,
+				COALESCE(SUM(task_count), 0) AS total_count,
+				COALESCE((SUM(task_on_time_count) * 100.0 / NULLIF(SUM(task_count), 0)), 0) AS percentage
+			FROM course_daily_stats`
+	}
+
+	buckets, args := grouping.bind([]interface{}{courseID})
+	if err := buckets.loadTerms(ctx, tx); err != nil {
+		return nil, err
+	}
 
-		query = baseQuery
-	} else {
-		baseQuery := `
+	baseQuery := `
 			SELECT
-				DATE_TRUNC($1, created_at) AS period,
-				COUNT(*) FILTER (WHERE on_time = true) AS on_time_count,
-				COUNT(*) AS total_count,
-				COALESCE((COUNT(*) FILTER (WHERE on_time = true) * 100.0 / NULLIF(COUNT(*), 0)), 0) AS percentage
-			FROM grades_tasks
-			WHERE course_id = $2
+				` + buckets.period(timeColumn) + ` AS period,` + counts + `
+			WHERE course_id = $1
 		`
 
-		args = append(args, groupBy, courseID)
-		argPos := 3
+	if !startTime.IsZero() {
+		args = append(args, startTime)
+		baseQuery += fmt.Sprintf(" AND %s >= $%d", timeColumn, len(args))
+	}
 
-		if !startTime.IsZero() {
-			baseQuery += fmt.Sprintf(" AND created_at >= $%d", argPos)
-			args = append(args, startTime)
-			argPos++
-		}
+	if !endTime.IsZero() {
+		args = append(args, endTime)
+		baseQuery += fmt.Sprintf(" AND %s <= $%d", timeColumn, len(args))
+	}
 
-		if !endTime.IsZero() {
-			baseQuery += fmt.Sprintf(" AND created_at <= $%d", argPos)
-			args = append(args, endTime)
+	// Without grouping a single all_time row is returned, even if empty
+	query := baseQuery
+	if !grouping.IsZero() {
+		query += " GROUP BY period"
+		if fromAggregates {
+			query += " HAVING SUM(task_count) > 0"
 		}
-
-		query = baseQuery + " GROUP BY period ORDER BY period"
+		query += " ORDER BY period"
 	}
 
-	rows, err := tx.Query(query, args...)
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var results []map[string]interface{}
+	var results []OnTimeStat
 	for rows.Next() {
 		var period interface{}
-		var onTimeCount, totalCount int
-		var percentage float64
+		var stat OnTimeStat
 
-		if err := rows.Scan(&period, &onTimeCount, &totalCount, &percentage); err != nil {
+		if err := rows.Scan(&period, &stat.OnTimeCount, &stat.TotalCount, &stat.Percentage); err != nil {
 			return nil, err
 		}
 
-		if t, ok := period.(time.Time); ok {
-			period = t.Format(time.RFC3339)
+		label, ok := buckets.label(period)
+		if !ok {
+			// Tasks outside every academic term
+			continue
 		}
+		stat.Period = label
 
-		results = append(results, map[string]interface{}{
-			"period":        period,
-			"on_time_count": onTimeCount,
-			"total_count":   totalCount,
-			"percentage":    percentage,
-		})
+		results = append(results, stat)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -539,8 +614,11 @@ var GetOnTimeSubmissionPercentageForCourse = func(DB *sql.DB, courseID string, s
 }
 
 // GetOnTimeSubmissionPercentageForStudent devuelve el porcentaje de tareas entregadas a tiempo para un estudiante en un curso
-var GetOnTimeSubmissionPercentageForStudent = func(DB *sql.DB, courseID, studentID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
-	tx, err := DB.Begin()
+var GetOnTimeSubmissionPercentageForStudent = func(ctx context.Context, DB *sql.DB, courseID, studentID string, startTime, endTime time.Time, grouping TimeGrouping) ([]OnTimeStat, error) {
+	ctx, finish := startQuery(ctx, "GetOnTimeSubmissionPercentageForStudent")
+	defer finish()
+
+	tx, err := DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -548,88 +626,60 @@ var GetOnTimeSubmissionPercentageForStudent = func(DB *sql.DB, courseID, student
 		_ = tx.Rollback() // Safe rollback in case of early return or failure
 	}()
 
-	var query string
-	var args []interface{}
+	buckets, args := grouping.bind([]interface{}{courseID, studentID})
+	if err := buckets.loadTerms(ctx, tx); err != nil {
+		return nil, err
+	}
 
-	if groupBy == "" {
-		baseQuery := `
+	baseQuery := `
 			SELECT
-				'all_time' AS period,
+				` + buckets.period("created_at") + ` AS period,
 				COUNT(*) FILTER (WHERE on_time = true) AS on_time_count,
 				COUNT(*) AS total_count,
 				COALESCE((COUNT(*) FILTER (WHERE on_time = true) * 100.0 / NULLIF(COUNT(*), 0)), 0) AS percentage
 			FROM grades_tasks
 			WHERE course_id = $1 AND student_id = $2
 		`
-		args = append(args, courseID, studentID)
-		argPos := 3
 
-		if !startTime.IsZero() {
-			baseQuery += fmt.Sprintf(" AND created_at >= $%d", argPos)
-			args = append(args, startTime)
-			argPos++
-		}
-
-		if !endTime.IsZero() {
-			baseQuery += fmt.Sprintf(" AND created_at <= $%d", argPos)
-			args = append(args, endTime)
-		}
-
-		query = baseQuery
-	} else {
-		baseQuery := `
-			SELECT
-				DATE_TRUNC($1, created_at) AS period,
-				COUNT(*) FILTER (WHERE on_time = true) AS on_time_count,
-				COUNT(*) AS total_count,
-				COALESCE((COUNT(*) FILTER (WHERE on_time = true) * 100.0 / NULLIF(COUNT(*), 0)), 0) AS percentage
-			FROM grades_tasks
-			WHERE course_id = $2 AND student_id = $3
-		`
-
-		args = append(args, groupBy, courseID, studentID)
-		argPos := 4
-
-		if !startTime.IsZero() {
-			baseQuery += fmt.Sprintf(" AND created_at >= $%d", argPos)
-			args = append(args, startTime)
-			argPos++
-		}
+	if !startTime.IsZero() {
+		args = append(args, startTime)
+		baseQuery += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
 
-		if !endTime.IsZero() {
-			baseQuery += fmt.Sprintf(" AND created_at <= $%d", argPos)
-			args = append(args, endTime)
-		}
+	if !endTime.IsZero() {
+		args = append(args, endTime)
+		baseQuery += fmt.Sprintf(" AND created_at <= $%d", len(args))
+	}
 
-		query = baseQuery + " GROUP BY period ORDER BY period"
+	// Without grouping a single all_time row is returned, even if empty
+	query := baseQuery
+	if !grouping.IsZero() {
+		query += " GROUP BY period ORDER BY period"
 	}
 
-	rows, err := tx.Query(query, args...)
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var results []map[string]interface{}
+	var results []OnTimeStat
 	for rows.Next() {
 		var period interface{}
-		var onTimeCount, totalCount int
-		var percentage float64
+		var stat OnTimeStat
 
-		if err := rows.Scan(&period, &onTimeCount, &totalCount, &percentage); err != nil {
+		if err := rows.Scan(&period, &stat.OnTimeCount, &stat.TotalCount, &stat.Percentage); err != nil {
 			return nil, err
 		}
 
-		if t, ok := period.(time.Time); ok {
-			period = t.Format(time.RFC3339)
+		label, ok := buckets.label(period)
+		if !ok {
+			// Tasks outside every academic term
+			continue
 		}
+		stat.Period = label
 
-		results = append(results, map[string]interface{}{
-			"period":        period,
-			"on_time_count": onTimeCount,
-			"total_count":   totalCount,
-			"percentage":    percentage,
-		})
+		results = append(results, stat)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -640,21 +690,26 @@ var GetOnTimeSubmissionPercentageForStudent = func(DB *sql.DB, courseID, student
 }
 
 // CheckGradeTaskExists verifica si ya existe un registro para esta combinación
-var CheckGradeTaskExists = func(DB *sql.DB, studentID, courseID, taskID string) (bool, error) {
+var CheckGradeTaskExists = func(ctx context.Context, DB *sql.DB, studentID, courseID, taskID string) (bool, error) {
+	ctx, finish := startQuery(ctx, "CheckGradeTaskExists")
+	defer finish()
 
-	tx, err_db_begin := DB.Begin()
+	tx, err_db_begin := DB.BeginTx(ctx, nil)
 
 	if err_db_begin != nil {
-		log.Printf("[Service Stats] Error starting transaction: %v", err_db_begin)
+		slog.ErrorContext(ctx, "error starting transaction", "error", err_db_begin)
 		return false, err_db_begin
 	}
+	// Read-only, so there is nothing to commit; rolling back returns the
+	// connection to the pool
+	defer tx.Rollback()
 
 	var exists bool
 	query := `SELECT EXISTS(
         SELECT 1 FROM grades_tasks
         WHERE student_id = $1 AND course_id = $2 AND task_id = $3
     )`
-	err := tx.QueryRow(query, studentID, courseID, taskID).Scan(&exists)
+	err := tx.QueryRowContext(ctx, query, studentID, courseID, taskID).Scan(&exists)
 
 	if err != nil {
 		return false, err
@@ -662,20 +717,34 @@ var CheckGradeTaskExists = func(DB *sql.DB, studentID, courseID, taskID string)
 	return exists, nil
 }
 
-// UpdateGradeTask actualiza un registro existente
-var UpdateGradeTask = func(DB *sql.DB, grade model.GradeTask) error {
-	tx, err := DB.Begin()
+// UpdateGradeTask actualiza un registro existente y lo devuelve como quedó
+// guardado
+var UpdateGradeTask = func(ctx context.Context, DB *sql.DB, grade model.GradeTask) (model.GradeTask, error) {
+	ctx, finish := startQuery(ctx, "UpdateGradeTask")
+	defer finish()
+
+	tx, err := DB.BeginTx(ctx, nil)
 
 	if err != nil {
-		log.Printf("[Service Stats] Failed to begin transaction: %v", err)
-		return err
+		slog.ErrorContext(ctx, "error starting transaction", "error", err)
+		return model.GradeTask{}, err
+	}
+	defer tx.Rollback()
+
+	// The rows being overwritten have to be taken out of the aggregates, so
+	// read (and lock) them first
+	previous, err := selectTaskGradesForUpdate(ctx, tx, grade.StudentID, grade.CourseID, grade.TaskID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error reading grade task", "error", err)
+		return model.GradeTask{}, err
 	}
 
 	statement := `UPDATE grades_tasks
                  SET grade = $4, on_time = $5, created_at = NOW()
-                 WHERE student_id = $1 AND course_id = $2 AND task_id = $3`
+                 WHERE student_id = $1 AND course_id = $2 AND task_id = $3
+                 RETURNING created_at`
 
-	_, err = tx.Exec(
+	rows, err := tx.QueryContext(ctx,
 		statement,
 		grade.StudentID,
 		grade.CourseID,
@@ -683,17 +752,75 @@ var UpdateGradeTask = func(DB *sql.DB, grade model.GradeTask) error {
 		grade.Grade,
 		grade.OnTime,
 	)
+	if err == nil {
+		// Every updated row gets the same NOW()
+		for rows.Next() {
+			if err = rows.Scan(&grade.CreatedAt); err != nil {
+				break
+			}
+		}
+		if closeErr := rows.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = rows.Err()
+		}
+	}
 
 	if err != nil {
-		log.Printf("[Service Stats] Error executing update: %v", err)
-		_ = tx.Rollback()
-		return err
+		slog.ErrorContext(ctx, "error updating grade task", "error", err)
+		return model.GradeTask{}, err
+	}
+
+	for _, old := range previous {
+		if err := addTaskToAggregates(ctx, tx, old, -1); err != nil {
+			slog.ErrorContext(ctx, "error updating aggregates", "error", err)
+			return model.GradeTask{}, err
+		}
+		if err := addTaskToAggregates(ctx, tx, newTaskGrade(grade), 1); err != nil {
+			slog.ErrorContext(ctx, "error updating aggregates", "error", err)
+			return model.GradeTask{}, err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		log.Printf("[Service Stats] Failed to commit transaction: %v", err)
-		return err
+		slog.ErrorContext(ctx, "error committing transaction", "error", err)
+		return model.GradeTask{}, err
+	}
+
+	return grade, nil
+}
+
+// selectTaskGradesForUpdate returns the grades_tasks rows of a student's
+// task, locked until tx ends.
+func selectTaskGradesForUpdate(ctx context.Context, tx *sql.Tx, studentID, courseID, taskID string) ([]taskGrade, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT grade, on_time, created_at FROM grades_tasks
+		WHERE student_id = $1 AND course_id = $2 AND task_id = $3
+		FOR UPDATE`, studentID, courseID, taskID)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	var result []taskGrade
+	for rows.Next() {
+		row := taskGrade{StudentID: studentID, CourseID: courseID, TaskID: taskID}
+		if err := rows.Scan(&row.Grade, &row.OnTime, &row.CreatedAt); err != nil {
+			return nil, err
+		}
+		row.Undated = !row.CreatedAt.Valid
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// newTaskGrade describes a grade task written in the current transaction.
+func newTaskGrade(grade model.GradeTask) taskGrade {
+	return taskGrade{
+		StudentID: grade.StudentID,
+		CourseID:  grade.CourseID,
+		TaskID:    grade.TaskID,
+		Grade:     grade.Grade,
+		OnTime:    grade.OnTime,
+	}
 }
@@ -1,13 +1,15 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"service_stats/internal/model"
 	"testing"
 	"time"
 
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,7 +31,7 @@ func TestInitDB(t *testing.T) {
 	mock.ExpectBegin()
 
 	// Correct regex matching your actual SQL query with $1, $2 and GROUP BY
-	query := `SELECT AVG(grade) FROM grades WHERE student_id = $1 AND course_id = $2 GROUP BY student_id, course_id`
+	query := `SELECT grade_sum / grade_count FROM student_course_stats WHERE student_id = $1 AND course_id = $2 AND grade_count > 0`
 
 	rows := sqlmock.NewRows([]string{"avg_grade"}).AddRow(85.0)
 	mock.ExpectQuery(query).
@@ -39,7 +41,7 @@ func TestInitDB(t *testing.T) {
 	// Expect the transaction to commit
 	mock.ExpectCommit()
 
-	avgGrade, code, err := GetAvgGradeForStudent(db, "student1", "course1")
+	avgGrade, code, err := GetAvgGradeForStudent(context.Background(), db, "student1", "course1")
 	if err != nil {
 		t.Errorf("error was not expected while getting average grade: %s", err)
 	}
@@ -62,7 +64,11 @@ func TestInsertGrade(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectBegin()
-	mock.ExpectExec(`INSERT INTO grades`).WithArgs("student1", "course1", 95.0, true).WillReturnResult(sqlmock.NewResult(1, 1))
+	createdAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`INSERT INTO grades`).WithArgs("student1", "course1", 95.0, true).WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+	mock.ExpectExec(`INSERT INTO student_course_stats`).WithArgs("student1", "course1", 95.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO course_daily_stats`).WithArgs("course1", 95.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO student_course_daily_stats`).WithArgs("student1", "course1", 95.0).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
 	grade := model.Grade{
@@ -72,9 +78,12 @@ func TestInsertGrade(t *testing.T) {
 		OnTime:    true,
 	}
 
-	if err := InsertGrade(db, grade); err != nil {
+	stored, err := InsertGrade(context.Background(), db, grade)
+	if err != nil {
 		t.Errorf("error was not expected while inserting grade: %s", err)
 	}
+	grade.CreatedAt = createdAt
+	assert.Equal(t, grade, stored)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
@@ -92,7 +101,7 @@ func TestGetAvgGradeForStudent(t *testing.T) {
 	mock.ExpectBegin()
 
 	// Correct regex matching your actual SQL query with $1, $2 and GROUP BY
-	query := `SELECT AVG\(grade\) FROM grades WHERE student_id = \$1 AND course_id = \$2 GROUP BY student_id, course_id`
+	query := `SELECT grade_sum / grade_count FROM student_course_stats WHERE student_id = \$1 AND course_id = \$2 AND grade_count > 0`
 
 	rows := sqlmock.NewRows([]string{"avg_grade"}).AddRow(85.0)
 	mock.ExpectQuery(query).
@@ -102,7 +111,7 @@ func TestGetAvgGradeForStudent(t *testing.T) {
 	// Expect the transaction to commit
 	mock.ExpectCommit()
 
-	avgGrade, code, err := GetAvgGradeForStudent(db, "student1", "course1")
+	avgGrade, code, err := GetAvgGradeForStudent(context.Background(), db, "student1", "course1")
 	if err != nil {
 		t.Errorf("error was not expected while getting average grade: %s", err)
 	}
@@ -126,24 +135,24 @@ func TestGetCourseAveragesOverTime(t *testing.T) {
 
 	// Make sure SQL string exactly matches your production SQL including whitespace.
 	query := `SELECT 
-			DATE_TRUNC($1, created_at) AS period,
+			DATE_TRUNC($2, created_at AT TIME ZONE $3::text) AT TIME ZONE $3::text AS period,
 			AVG(grade) AS average_grade,
 			COUNT(*) AS grade_count
 		FROM grades
-		WHERE course_id = $2 AND created_at >= $3 AND created_at <= $4 GROUP BY period ORDER BY period`
+		WHERE course_id = $1 AND created_at >= $4 AND created_at <= $5 GROUP BY period ORDER BY period`
 
 	mock.ExpectBegin()
 	rows := sqlmock.NewRows([]string{"period", "average_grade", "grade_count"}).
 		AddRow(start, 8.5, 10)
 	mock.ExpectQuery(query).
-		WithArgs(groupBy, courseID, start, end).
+		WithArgs(courseID, groupBy, "UTC", start, end).
 		WillReturnRows(rows)
 	mock.ExpectRollback()
 
-	results, err := GetCourseAveragesOverTime(db, courseID, start, end, groupBy)
+	results, err := GetCourseAveragesOverTime(context.Background(), db, courseID, start, end, TimeGrouping{Unit: groupBy}, SeriesOptions{})
 	assert.NoError(t, err)
 	assert.Len(t, results, 1)
-	assert.Equal(t, 8.5, results[0]["average_grade"])
+	assert.Equal(t, 8.5, *results[0].AverageGrade)
 }
 
 func TestInsertGradeTask(t *testing.T) {
@@ -158,14 +167,20 @@ func TestInsertGradeTask(t *testing.T) {
 		OnTime:    true,
 	}
 
+	createdAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
 	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO grades_tasks (student_id, course_id, task_id, grade, on_time) VALUES ($1, $2, $3, $4, $5)").
+	mock.ExpectQuery("INSERT INTO grades_tasks (student_id, course_id, task_id, grade, on_time) VALUES ($1, $2, $3, $4, $5) RETURNING created_at").
 		WithArgs(grade.StudentID, grade.CourseID, grade.TaskID, grade.Grade, grade.OnTime).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+	mock.ExpectExec(addTaskToStudentCourse).WithArgs("stu1", "c1", 9.0, 1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(addTaskToCourseTask).WithArgs("c1", "t1", 9.0, 1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(addTaskToCourseDay).WithArgs("c1", sql.NullTime{}, 1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
-	err := InsertGradeTask(db, grade)
+	stored, err := InsertGradeTask(context.Background(), db, grade)
 	assert.NoError(t, err)
+	assert.Equal(t, createdAt, stored.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestGetAvgGradeTaskForStudent(t *testing.T) {
@@ -180,7 +195,7 @@ func TestGetAvgGradeTaskForStudent(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow(8.0))
 	mock.ExpectRollback()
 
-	avg, code, err := GetAvgGradeTaskForStudent(db, "stu1", "c1", "t1")
+	avg, code, err := GetAvgGradeTaskForStudent(context.Background(), db, "stu1", "c1", "t1")
 	assert.NoError(t, err)
 	assert.Equal(t, 8.0, avg)
 	assert.Equal(t, 200, code)
@@ -191,75 +206,17 @@ func TestGetStudentCourseTasksAverage(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT AVG(grade) FROM grades_tasks WHERE student_id = $1 AND course_id = $2").
+	mock.ExpectQuery("SELECT task_grade_sum / task_count FROM student_course_stats WHERE student_id = $1 AND course_id = $2 AND task_count > 0").
 		WithArgs("stu1", "c1").
 		WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow(7.5))
 	mock.ExpectRollback()
 
-	avg, code, err := GetStudentCourseTasksAverage(db, "stu1", "c1")
+	avg, code, err := GetStudentCourseTasksAverage(context.Background(), db, "stu1", "c1")
 	assert.NoError(t, err)
 	assert.Equal(t, 7.5, avg)
 	assert.Equal(t, 200, code)
 }
 
-func TestGetOtherStudentsCourseAverages(t *testing.T) {
-	db, mock := setupDB(t)
-	defer db.Close()
-
-	query := `
-		SELECT
-			student_id,
-			AVG(grade) as average_grade,
-			COUNT(*) as task_count
-		FROM grades_tasks
-		WHERE course_id = $1 AND student_id != $2
-		GROUP BY student_id
-		ORDER BY average_grade DESC
-	`
-	mock.ExpectBegin()
-	mock.ExpectQuery(query).
-		WithArgs("c1", "stu1").
-		WillReturnRows(sqlmock.NewRows([]string{"student_id", "average_grade", "task_count"}).
-			AddRow("stu2", 6.0, 2))
-	mock.ExpectRollback()
-
-	res, err := GetOtherStudentsCourseAverages(db, "stu1", "c1")
-	assert.NoError(t, err)
-	assert.Len(t, res, 1)
-	assert.Equal(t, "stu2", res[0]["student_id"])
-	assert.Equal(t, 6.0, res[0]["average_grade"])
-	assert.Equal(t, 2, res[0]["task_count"])
-}
-
-func TestGetAveragesForTask(t *testing.T) {
-	db, mock := setupDB(t)
-	defer db.Close()
-
-	query := `
-	SELECT
-		student_id,
-		AVG(grade) as average_grade,
-		COUNT(*) as grade_count
-	FROM grades_tasks
-	WHERE course_id = $1 AND task_id = $2
-	GROUP BY student_id
-	ORDER BY average_grade DESC
-	`
-	mock.ExpectBegin()
-	mock.ExpectQuery(query).
-		WithArgs("c1", "t1").
-		WillReturnRows(sqlmock.NewRows([]string{"student_id", "average_grade", "grade_count"}).
-			AddRow("stu1", 9.5, 1))
-	mock.ExpectRollback()
-
-	res, err := GetAveragesForTask(db, "c1", "t1")
-	assert.NoError(t, err)
-	assert.Len(t, res, 1)
-	assert.Equal(t, "stu1", res[0]["student_id"])
-	assert.Equal(t, 9.5, res[0]["average_grade"])
-	assert.Equal(t, 1, res[0]["grade_count"])
-}
-
 func TestGetOnTimeSubmissionPercentageForCourse(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -273,13 +230,13 @@ func TestGetOnTimeSubmissionPercentageForCourse(t *testing.T) {
 			AddRow("all_time", 8, 10, 80.0))
 	mock.ExpectCommit()
 
-	res, err := GetOnTimeSubmissionPercentageForCourse(db, "course1", time.Time{}, time.Time{}, "")
+	res, err := GetOnTimeSubmissionPercentageForCourse(context.Background(), db, "course1", time.Time{}, time.Time{}, TimeGrouping{})
 	assert.NoError(t, err)
 	assert.Len(t, res, 1)
-	assert.Equal(t, "all_time", res[0]["period"])
-	assert.Equal(t, int(8), res[0]["on_time_count"])
-	assert.Equal(t, int(10), res[0]["total_count"])
-	assert.Equal(t, 80.0, res[0]["percentage"])
+	assert.Equal(t, "all_time", res[0].Period)
+	assert.Equal(t, int(8), res[0].OnTimeCount)
+	assert.Equal(t, int(10), res[0].TotalCount)
+	assert.Equal(t, 80.0, res[0].Percentage)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -297,13 +254,13 @@ func TestGetOnTimeSubmissionPercentageForStudent(t *testing.T) {
 			AddRow("all_time", 4, 5, 80.0))
 	mock.ExpectCommit()
 
-	res, err := GetOnTimeSubmissionPercentageForStudent(db, "course1", "student1", time.Time{}, time.Time{}, "")
+	res, err := GetOnTimeSubmissionPercentageForStudent(context.Background(), db, "course1", "student1", time.Time{}, time.Time{}, TimeGrouping{})
 	assert.NoError(t, err)
 	assert.Len(t, res, 1)
-	assert.Equal(t, "all_time", res[0]["period"])
-	assert.Equal(t, int(4), res[0]["on_time_count"])
-	assert.Equal(t, int(5), res[0]["total_count"])
-	assert.Equal(t, 80.0, res[0]["percentage"])
+	assert.Equal(t, "all_time", res[0].Period)
+	assert.Equal(t, int(4), res[0].OnTimeCount)
+	assert.Equal(t, int(5), res[0].TotalCount)
+	assert.Equal(t, 80.0, res[0].Percentage)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -320,12 +277,12 @@ func TestInsertGrade_DBError(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO grades (student_id, course_id, grade, on_time) VALUES ($1, $2, $3, $4)").
+	mock.ExpectQuery("INSERT INTO grades (student_id, course_id, grade, on_time) VALUES ($1, $2, $3, $4) RETURNING created_at").
 		WithArgs(grade.StudentID, grade.CourseID, grade.Grade, grade.OnTime).
 		WillReturnError(fmt.Errorf("db error"))
 	mock.ExpectRollback()
 
-	err := InsertGrade(db, grade)
+	_, err := InsertGrade(context.Background(), db, grade)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "db error")
 }
@@ -335,13 +292,13 @@ func TestGetAvgGradeForStudent_NoRows(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectBegin()
-	query := `SELECT AVG(grade) FROM grades WHERE student_id = $1 AND course_id = $2 GROUP BY student_id, course_id`
+	query := `SELECT grade_sum / grade_count FROM student_course_stats WHERE student_id = $1 AND course_id = $2 AND grade_count > 0`
 	mock.ExpectQuery(query).
 		WithArgs("studentX", "courseX").
 		WillReturnRows(sqlmock.NewRows([]string{"avg_grade"})) // no rows
 	mock.ExpectCommit()
 
-	grade, code, err := GetAvgGradeForStudent(db, "studentX", "courseX")
+	grade, code, err := GetAvgGradeForStudent(context.Background(), db, "studentX", "courseX")
 	assert.Equal(t, 404, code)
 	assert.Equal(t, 0.0, grade)
 	assert.NoError(t, err)
@@ -360,12 +317,12 @@ func TestInsertGradeTask_Failure(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO grades_tasks (student_id, course_id, task_id, grade, on_time) VALUES ($1, $2, $3, $4, $5)").
+	mock.ExpectQuery("INSERT INTO grades_tasks (student_id, course_id, task_id, grade, on_time) VALUES ($1, $2, $3, $4, $5) RETURNING created_at").
 		WithArgs(grade.StudentID, grade.CourseID, grade.TaskID, grade.Grade, grade.OnTime).
 		WillReturnError(fmt.Errorf("db error"))
 	mock.ExpectRollback()
 
-	err := InsertGradeTask(db, grade)
+	_, err := InsertGradeTask(context.Background(), db, grade)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "db error")
 }
@@ -382,52 +339,27 @@ func TestGetAvgGradeTaskForStudent_DBError(t *testing.T) {
 		WillReturnError(fmt.Errorf("query failed"))
 	mock.ExpectRollback()
 
-	avg, code, err := GetAvgGradeTaskForStudent(db, "stu1", "c1", "t1")
+	avg, code, err := GetAvgGradeTaskForStudent(context.Background(), db, "stu1", "c1", "t1")
 	assert.Error(t, err)
 	assert.Equal(t, 500, code)
 	assert.Equal(t, 0.0, avg)
 }
 
-func TestGetOtherStudentsCourseAverages_Empty(t *testing.T) {
-	db, mock := setupDB(t)
-	defer db.Close()
-
-	query := `
-		SELECT
-			student_id,
-			AVG(grade) as average_grade,
-			COUNT(*) as task_count
-		FROM grades_tasks
-		WHERE course_id = $1 AND student_id != $2
-		GROUP BY student_id
-		ORDER BY average_grade DESC
-	`
-	mock.ExpectBegin()
-	mock.ExpectQuery(query).
-		WithArgs("c1", "stu1").
-		WillReturnRows(sqlmock.NewRows([]string{"student_id", "average_grade", "task_count"}))
-	mock.ExpectRollback()
-
-	res, err := GetOtherStudentsCourseAverages(db, "stu1", "c1")
-	assert.NoError(t, err)
-	assert.Len(t, res, 0)
-}
-
 func TestGetOnTimeSubmissionPercentageForCourse_ZeroTotal(t *testing.T) {
 	db, mock := setupDB(t)
 	defer db.Close()
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT 'all_time' AS period, COUNT(*) FILTER (WHERE on_time = true) AS on_time_count, COUNT(*) AS total_count, COALESCE((COUNT(*) FILTER (WHERE on_time = true) * 100.0 / NULLIF(COUNT(*), 0)), 0) AS percentage FROM grades_tasks WHERE course_id = $1").
+	mock.ExpectQuery("SELECT 'all_time' AS period, COALESCE(SUM(task_on_time_count), 0) AS on_time_count, COALESCE(SUM(task_count), 0) AS total_count, COALESCE((SUM(task_on_time_count) * 100.0 / NULLIF(SUM(task_count), 0)), 0) AS percentage FROM course_daily_stats WHERE course_id = $1").
 		WithArgs("course1").
 		WillReturnRows(sqlmock.NewRows([]string{"period", "on_time_count", "total_count", "percentage"}).
 			AddRow("all_time", 0, 0, 0.0))
 	mock.ExpectCommit()
 
-	res, err := GetOnTimeSubmissionPercentageForCourse(db, "course1", time.Time{}, time.Time{}, "")
+	res, err := GetOnTimeSubmissionPercentageForCourse(context.Background(), db, "course1", time.Time{}, time.Time{}, TimeGrouping{})
 	assert.NoError(t, err)
 	assert.Len(t, res, 1)
-	assert.Equal(t, 0.0, res[0]["percentage"])
+	assert.Equal(t, 0.0, res[0].Percentage)
 }
 
 func TestGetCourseAveragesOverTime_MultipleRows(t *testing.T) {
@@ -440,26 +372,26 @@ func TestGetCourseAveragesOverTime_MultipleRows(t *testing.T) {
 	end := time.Now()
 
 	query := `SELECT 
-			DATE_TRUNC($1, created_at) AS period,
+			DATE_TRUNC($2, created_at AT TIME ZONE $3::text) AT TIME ZONE $3::text AS period,
 			AVG(grade) AS average_grade,
 			COUNT(*) AS grade_count
 		FROM grades
-		WHERE course_id = $2 AND created_at >= $3 AND created_at <= $4 GROUP BY period ORDER BY period`
+		WHERE course_id = $1 AND created_at >= $4 AND created_at <= $5 GROUP BY period ORDER BY period`
 
 	mock.ExpectBegin()
 	rows := sqlmock.NewRows([]string{"period", "average_grade", "grade_count"}).
 		AddRow(start.Add(24*time.Hour), 7.5, 5).
 		AddRow(start.Add(48*time.Hour), 8.0, 8)
 	mock.ExpectQuery(query).
-		WithArgs(groupBy, courseID, start, end).
+		WithArgs(courseID, groupBy, "UTC", start, end).
 		WillReturnRows(rows)
 	mock.ExpectRollback()
 
-	results, err := GetCourseAveragesOverTime(db, courseID, start, end, groupBy)
+	results, err := GetCourseAveragesOverTime(context.Background(), db, courseID, start, end, TimeGrouping{Unit: groupBy}, SeriesOptions{})
 	assert.NoError(t, err)
 	assert.Len(t, results, 2)
-	assert.Equal(t, 7.5, results[0]["average_grade"])
-	assert.Equal(t, 8.0, results[1]["average_grade"])
+	assert.Equal(t, 7.5, *results[0].AverageGrade)
+	assert.Equal(t, 8.0, *results[1].AverageGrade)
 }
 
 func TestGetOnTimeSubmissionPercentageForCourse_NoResults(t *testing.T) {
@@ -467,12 +399,12 @@ func TestGetOnTimeSubmissionPercentageForCourse_NoResults(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectBegin()
-	mock.ExpectQuery("SELECT 'all_time' AS period, COUNT(*) FILTER (WHERE on_time = true) AS on_time_count, COUNT(*) AS total_count, COALESCE((COUNT(*) FILTER (WHERE on_time = true) * 100.0 / NULLIF(COUNT(*), 0)), 0) AS percentage FROM grades_tasks WHERE course_id = $1").
+	mock.ExpectQuery("SELECT 'all_time' AS period, COALESCE(SUM(task_on_time_count), 0) AS on_time_count, COALESCE(SUM(task_count), 0) AS total_count, COALESCE((SUM(task_on_time_count) * 100.0 / NULLIF(SUM(task_count), 0)), 0) AS percentage FROM course_daily_stats WHERE course_id = $1").
 		WithArgs("course1").
 		WillReturnRows(sqlmock.NewRows([]string{"period", "on_time_count", "total_count", "percentage"})) // no rows
 	mock.ExpectCommit()
 
-	results, err := GetOnTimeSubmissionPercentageForCourse(db, "course1", time.Time{}, time.Time{}, "")
+	results, err := GetOnTimeSubmissionPercentageForCourse(context.Background(), db, "course1", time.Time{}, time.Time{}, TimeGrouping{})
 	assert.NoError(t, err)
 	assert.Len(t, results, 0)
 }
@@ -487,7 +419,7 @@ func TestGetOnTimeSubmissionPercentageForStudent_DBError(t *testing.T) {
 		WillReturnError(fmt.Errorf("db error"))
 	mock.ExpectRollback()
 
-	results, err := GetOnTimeSubmissionPercentageForStudent(db, "course1", "student1", time.Time{}, time.Time{}, "")
+	results, err := GetOnTimeSubmissionPercentageForStudent(context.Background(), db, "course1", "student1", time.Time{}, time.Time{}, TimeGrouping{})
 	assert.Error(t, err)
 	assert.Nil(t, results)
 }
@@ -505,66 +437,16 @@ func TestInsertGradeTask_DBError(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO grades_tasks (student_id, course_id, task_id, grade, on_time) VALUES ($1, $2, $3, $4, $5)").
+	mock.ExpectQuery("INSERT INTO grades_tasks (student_id, course_id, task_id, grade, on_time) VALUES ($1, $2, $3, $4, $5) RETURNING created_at").
 		WithArgs(grade.StudentID, grade.CourseID, grade.TaskID, grade.Grade, grade.OnTime).
 		WillReturnError(fmt.Errorf("db error"))
 	mock.ExpectRollback()
 
-	err := InsertGradeTask(db, grade)
+	_, err := InsertGradeTask(context.Background(), db, grade)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "db error")
 }
 
-func TestGetOtherStudentsCourseAverages_EmptyResult(t *testing.T) {
-	db, mock := setupDB(t)
-	defer db.Close()
-
-	query := `
-		SELECT
-			student_id,
-			AVG(grade) as average_grade,
-			COUNT(*) as task_count
-		FROM grades_tasks
-		WHERE course_id = $1 AND student_id != $2
-		GROUP BY student_id
-		ORDER BY average_grade DESC
-	`
-	mock.ExpectBegin()
-	mock.ExpectQuery(query).
-		WithArgs("c1", "stu1").
-		WillReturnRows(sqlmock.NewRows([]string{"student_id", "average_grade", "task_count"})) // no rows
-	mock.ExpectRollback()
-
-	res, err := GetOtherStudentsCourseAverages(db, "stu1", "c1")
-	assert.NoError(t, err)
-	assert.Len(t, res, 0)
-}
-
-func TestGetAveragesForTask_DBError(t *testing.T) {
-	db, mock := setupDB(t)
-	defer db.Close()
-
-	query := `
-	SELECT
-		student_id,
-		AVG(grade) as average_grade,
-		COUNT(*) as grade_count
-	FROM grades_tasks
-	WHERE course_id = $1 AND task_id = $2
-	GROUP BY student_id
-	ORDER BY average_grade DESC
-	`
-	mock.ExpectBegin()
-	mock.ExpectQuery(query).
-		WithArgs("c1", "t1").
-		WillReturnError(fmt.Errorf("db error"))
-	mock.ExpectRollback()
-
-	res, err := GetAveragesForTask(db, "c1", "t1")
-	assert.Error(t, err)
-	assert.Nil(t, res)
-}
-
 func TestGetStudentAveragesOverTime(t *testing.T) {
 	db, mock := setupDB(t)
 	defer db.Close()
@@ -577,7 +459,7 @@ func TestGetStudentAveragesOverTime(t *testing.T) {
 	end := time.Date(2025, 6, 27, 0, 0, 0, 0, time.UTC)
 
 	// Expect the query that will be executed inside the function
-	query := `SELECT DATE_TRUNC($1, created_at) AS period, AVG(grade) AS average_grade, COUNT(*) AS grade_count FROM grades WHERE student_id = $2 AND created_at >= $3 AND created_at <= $4 GROUP BY period ORDER BY period`
+	query := `SELECT DATE_TRUNC($2, created_at AT TIME ZONE $3::text) AT TIME ZONE $3::text AS period, AVG(grade) AS average_grade, COUNT(*) AS grade_count FROM grades WHERE student_id = $1 AND created_at >= $4 AND created_at <= $5 GROUP BY period ORDER BY period`
 
 	// Simulated rows returned from DB
 	rows := sqlmock.NewRows([]string{"period", "average_grade", "grade_count"}).
@@ -585,22 +467,22 @@ func TestGetStudentAveragesOverTime(t *testing.T) {
 		AddRow(end, 90.0, 1)
 
 	mock.ExpectQuery(query).
-		WithArgs(groupBy, studentID, start, end).
+		WithArgs(studentID, groupBy, "UTC", start, end).
 		WillReturnRows(rows)
 
 	mock.ExpectRollback()
 
-	results, err := GetStudentAveragesOverTime(db, studentID, start, end, groupBy)
+	results, err := GetStudentAveragesOverTime(context.Background(), db, studentID, start, end, TimeGrouping{Unit: groupBy}, SeriesOptions{})
 	require.NoError(t, err)
 	require.Len(t, results, 2)
 
-	assert.Equal(t, "2025-06-20T00:00:00Z", results[0]["period"])
-	assert.Equal(t, 87.5, results[0]["average_grade"])
-	assert.Equal(t, int(2), results[0]["grade_count"])
+	assert.Equal(t, "2025-06-20T00:00:00Z", results[0].Period)
+	assert.Equal(t, 87.5, *results[0].AverageGrade)
+	assert.Equal(t, int(2), results[0].GradeCount)
 
-	assert.Equal(t, "2025-06-27T00:00:00Z", results[1]["period"])
-	assert.Equal(t, 90.0, results[1]["average_grade"])
-	assert.Equal(t, int(1), results[1]["grade_count"])
+	assert.Equal(t, "2025-06-27T00:00:00Z", results[1].Period)
+	assert.Equal(t, 90.0, *results[1].AverageGrade)
+	assert.Equal(t, int(1), results[1].GradeCount)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -611,7 +493,7 @@ func TestInsertGrade_RollbackOnExecError(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO grades").
+	mock.ExpectQuery("INSERT INTO grades").
 		WithArgs("student1", "course1", 90.0, true).
 		WillReturnError(errors.New("insert failed"))
 	mock.ExpectRollback()
@@ -623,7 +505,7 @@ func TestInsertGrade_RollbackOnExecError(t *testing.T) {
 		OnTime:    true,
 	}
 
-	err = InsertGrade(db, grade)
+	_, err = InsertGrade(context.Background(), db, grade)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "insert failed")
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -635,9 +517,12 @@ func TestInsertGrade_CommitError(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO grades").
+	mock.ExpectQuery("INSERT INTO grades").
 		WithArgs("student1", "course1", 90.0, true).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectExec("INSERT INTO student_course_stats").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO course_daily_stats").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO student_course_daily_stats").WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit().WillReturnError(errors.New("commit failed"))
 
 	grade := model.Grade{
@@ -647,7 +532,7 @@ func TestInsertGrade_CommitError(t *testing.T) {
 		OnTime:    true,
 	}
 
-	err = InsertGrade(db, grade)
+	_, err = InsertGrade(context.Background(), db, grade)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "commit failed")
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -672,15 +557,15 @@ func TestGetOnTimeSubmissionPercentageForCourse_WithStartAndEndTime(t *testing.T
 
 	mock.ExpectCommit()
 
-	results, err := GetOnTimeSubmissionPercentageForCourse(db, "course123", startTime, endTime, "")
+	results, err := GetOnTimeSubmissionPercentageForCourse(context.Background(), db, "course123", startTime, endTime, TimeGrouping{})
 	assert.NoError(t, err)
 	assert.Len(t, results, 1)
 
 	result := results[0]
-	assert.Equal(t, "2023-01-01T00:00:00Z", result["period"])
-	assert.Equal(t, int(10), result["on_time_count"])
-	assert.Equal(t, int(20), result["total_count"])
-	assert.Equal(t, 50.0, result["percentage"])
+	assert.Equal(t, "2023-01-01T00:00:00Z", result.Period)
+	assert.Equal(t, int(10), result.OnTimeCount)
+	assert.Equal(t, int(20), result.TotalCount)
+	assert.Equal(t, 50.0, result.Percentage)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -698,8 +583,8 @@ func TestGetOnTimeSubmissionPercentageForCourse_WithGroupByStartEndTime(t *testi
 	mock.ExpectBegin()
 
 	// Pattern match the query using regex (be lenient on whitespace)
-	mock.ExpectQuery(`SELECT\s+DATE_TRUNC\(\$1,\s+created_at\)\s+AS\s+period,\s+COUNT\(\*\)\s+FILTER\s+\(WHERE\s+on_time\s+=\s+true\)\s+AS\s+on_time_count,\s+COUNT\(\*\)\s+AS\s+total_count,\s+COALESCE\(\(COUNT\(\*\)\s+FILTER\s+\(WHERE\s+on_time\s+=\s+true\)\s+\*\s+100\.0\s+/\s+NULLIF\(COUNT\(\*\),\s+0\)\),\s+0\)\s+AS\s+percentage\s+FROM\s+grades_tasks\s+WHERE\s+course_id\s+=\s+\$\d+\s+AND\s+created_at\s+>=\s+\$\d+\s+AND\s+created_at\s+<=\s+\$\d+\s+GROUP\s+BY\s+period\s+ORDER\s+BY\s+period`).
-		WithArgs(groupBy, courseID, startTime, endTime).
+	mock.ExpectQuery(`SELECT\s+DATE_TRUNC\(\$2,\s+created_at AT TIME ZONE \$3::text\)\s+AT TIME ZONE \$3::text\s+AS\s+period,\s+COUNT\(\*\)\s+FILTER\s+\(WHERE\s+on_time\s+=\s+true\)\s+AS\s+on_time_count,\s+COUNT\(\*\)\s+AS\s+total_count,\s+COALESCE\(\(COUNT\(\*\)\s+FILTER\s+\(WHERE\s+on_time\s+=\s+true\)\s+\*\s+100\.0\s+/\s+NULLIF\(COUNT\(\*\),\s+0\)\),\s+0\)\s+AS\s+percentage\s+FROM\s+grades_tasks\s+WHERE\s+course_id\s+=\s+\$\d+\s+AND\s+created_at\s+>=\s+\$\d+\s+AND\s+created_at\s+<=\s+\$\d+\s+GROUP\s+BY\s+period\s+ORDER\s+BY\s+period`).
+		WithArgs(courseID, groupBy, "UTC", startTime, endTime).
 		WillReturnRows(sqlmock.NewRows([]string{"period", "on_time_count", "total_count", "percentage"}).
 			AddRow(time.Date(2023, 6, 10, 0, 0, 0, 0, time.UTC), 5, 10, 50.0).
 			AddRow(time.Date(2023, 6, 20, 0, 0, 0, 0, time.UTC), 7, 14, 50.0),
@@ -707,19 +592,19 @@ func TestGetOnTimeSubmissionPercentageForCourse_WithGroupByStartEndTime(t *testi
 
 	mock.ExpectCommit()
 
-	results, err := GetOnTimeSubmissionPercentageForCourse(db, courseID, startTime, endTime, groupBy)
+	results, err := GetOnTimeSubmissionPercentageForCourse(context.Background(), db, courseID, startTime, endTime, TimeGrouping{Unit: groupBy})
 	assert.NoError(t, err)
 	assert.Len(t, results, 2)
 
-	assert.Equal(t, "2023-06-10T00:00:00Z", results[0]["period"])
-	assert.Equal(t, int(5), results[0]["on_time_count"])
-	assert.Equal(t, int(10), results[0]["total_count"])
-	assert.Equal(t, 50.0, results[0]["percentage"])
+	assert.Equal(t, "2023-06-10T00:00:00Z", results[0].Period)
+	assert.Equal(t, int(5), results[0].OnTimeCount)
+	assert.Equal(t, int(10), results[0].TotalCount)
+	assert.Equal(t, 50.0, results[0].Percentage)
 
-	assert.Equal(t, "2023-06-20T00:00:00Z", results[1]["period"])
-	assert.Equal(t, int(7), results[1]["on_time_count"])
-	assert.Equal(t, int(14), results[1]["total_count"])
-	assert.Equal(t, 50.0, results[1]["percentage"])
+	assert.Equal(t, "2023-06-20T00:00:00Z", results[1].Period)
+	assert.Equal(t, int(7), results[1].OnTimeCount)
+	assert.Equal(t, int(14), results[1].TotalCount)
+	assert.Equal(t, 50.0, results[1].Percentage)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -737,9 +622,9 @@ func TestGetOnTimeSubmissionPercentageForStudent_WithGroupByOnly(t *testing.T) {
 
 	mock.ExpectBegin()
 
-	// We expect 3 parameters: groupBy, courseID, studentID
-	mock.ExpectQuery(`SELECT\s+DATE_TRUNC\(\$1,\s*created_at\)\s+AS\s+period,\s+COUNT\(\*\)\s+FILTER\s+\(WHERE\s+on_time\s+=\s+true\)\s+AS\s+on_time_count,\s+COUNT\(\*\)\s+AS\s+total_count,\s+COALESCE\(\(COUNT\(\*\)\s+FILTER\s+\(WHERE\s+on_time\s+=\s+true\)\s+\*\s+100\.0\s*/\s*NULLIF\(COUNT\(\*\),\s*0\)\),\s*0\)\s+AS\s+percentage\s+FROM\s+grades_tasks\s+WHERE\s+course_id\s+=\s+\$\d+\s+AND\s+student_id\s+=\s+\$\d+\s+GROUP\s+BY\s+period\s+ORDER\s+BY\s+period`).
-		WithArgs(groupBy, courseID, studentID).
+	// We expect 4 parameters: courseID, studentID, groupBy, zone
+	mock.ExpectQuery(`SELECT\s+DATE_TRUNC\(\$3,\s*created_at AT TIME ZONE \$4::text\)\s+AT TIME ZONE \$4::text\s+AS\s+period,\s+COUNT\(\*\)\s+FILTER\s+\(WHERE\s+on_time\s+=\s+true\)\s+AS\s+on_time_count,\s+COUNT\(\*\)\s+AS\s+total_count,\s+COALESCE\(\(COUNT\(\*\)\s+FILTER\s+\(WHERE\s+on_time\s+=\s+true\)\s+\*\s+100\.0\s*/\s*NULLIF\(COUNT\(\*\),\s*0\)\),\s*0\)\s+AS\s+percentage\s+FROM\s+grades_tasks\s+WHERE\s+course_id\s+=\s+\$\d+\s+AND\s+student_id\s+=\s+\$\d+\s+GROUP\s+BY\s+period\s+ORDER\s+BY\s+period`).
+		WithArgs(courseID, studentID, groupBy, "UTC").
 		WillReturnRows(sqlmock.NewRows([]string{"period", "on_time_count", "total_count", "percentage"}).
 			AddRow(time.Date(2023, 6, 3, 0, 0, 0, 0, time.UTC), 8, 10, 80.0).
 			AddRow(time.Date(2023, 6, 10, 0, 0, 0, 0, time.UTC), 9, 12, 75.0),
@@ -747,19 +632,19 @@ func TestGetOnTimeSubmissionPercentageForStudent_WithGroupByOnly(t *testing.T) {
 
 	mock.ExpectCommit()
 
-	results, err := GetOnTimeSubmissionPercentageForStudent(db, courseID, studentID, startTime, endTime, groupBy)
+	results, err := GetOnTimeSubmissionPercentageForStudent(context.Background(), db, courseID, studentID, startTime, endTime, TimeGrouping{Unit: groupBy})
 	assert.NoError(t, err)
 	assert.Len(t, results, 2)
 
-	assert.Equal(t, "2023-06-03T00:00:00Z", results[0]["period"])
-	assert.Equal(t, int(8), results[0]["on_time_count"])
-	assert.Equal(t, int(10), results[0]["total_count"])
-	assert.Equal(t, 80.0, results[0]["percentage"])
+	assert.Equal(t, "2023-06-03T00:00:00Z", results[0].Period)
+	assert.Equal(t, int(8), results[0].OnTimeCount)
+	assert.Equal(t, int(10), results[0].TotalCount)
+	assert.Equal(t, 80.0, results[0].Percentage)
 
-	assert.Equal(t, "2023-06-10T00:00:00Z", results[1]["period"])
-	assert.Equal(t, int(9), results[1]["on_time_count"])
-	assert.Equal(t, int(12), results[1]["total_count"])
-	assert.Equal(t, 75.0, results[1]["percentage"])
+	assert.Equal(t, "2023-06-10T00:00:00Z", results[1].Period)
+	assert.Equal(t, int(9), results[1].OnTimeCount)
+	assert.Equal(t, int(12), results[1].TotalCount)
+	assert.Equal(t, 75.0, results[1].Percentage)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -784,7 +669,7 @@ func TestGetOnTimeSubmissionPercentageForStudent_WithGroupByOnly(t *testing.T) {
 	// If you do want to test rollback/commit, you’d need to modify your function.
 
 	// Call your function
-	exists, err := CheckGradeTaskExists(db, "student123", "course456", "task789")
+	exists, err := CheckGradeTaskExists(context.Background(), db, "student123", "course456", "task789")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -821,6 +706,7 @@ func TestCheckGradeTaskExists(t *testing.T) {
 				mock.ExpectQuery(`SELECT EXISTS\(`).
 					WithArgs("stu1", "course1", "task1").
 					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectRollback()
 			},
 		},
 		{
@@ -835,6 +721,7 @@ func TestCheckGradeTaskExists(t *testing.T) {
 				mock.ExpectQuery(`SELECT EXISTS\(`).
 					WithArgs("stu2", "course2", "task2").
 					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectRollback()
 			},
 		},
 		{
@@ -849,6 +736,7 @@ func TestCheckGradeTaskExists(t *testing.T) {
 				mock.ExpectQuery(`SELECT EXISTS\(`).
 					WithArgs("stu3", "course3", "task3").
 					WillReturnError(errors.New("query failed"))
+				mock.ExpectRollback()
 			},
 		},
 		{
@@ -874,7 +762,7 @@ func TestCheckGradeTaskExists(t *testing.T) {
 
 			tc.setupMock(mock)
 
-			exists, err := CheckGradeTaskExists(db, tc.studentID, tc.courseID, tc.taskID)
+			exists, err := CheckGradeTaskExists(context.Background(), db, tc.studentID, tc.courseID, tc.taskID)
 
 			if (err != nil) != tc.expectError {
 				t.Errorf("expected error = %v, got %v", tc.expectError, err)
@@ -892,6 +780,8 @@ func TestCheckGradeTaskExists(t *testing.T) {
 }
 
 func TestUpdateGradeTask(t *testing.T) {
+	createdAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
 	tests := []struct {
 		name        string
 		grade       model.GradeTask
@@ -910,9 +800,32 @@ func TestUpdateGradeTask(t *testing.T) {
 			expectError: false,
 			setupMock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`UPDATE grades_tasks`).
+				mock.ExpectQuery(`SELECT grade, on_time, created_at FROM grades_tasks`).
+					WithArgs("stu1", "course1", "task1").
+					WillReturnRows(sqlmock.NewRows([]string{"grade", "on_time", "created_at"}).
+						AddRow(80.0, false, createdAt))
+				mock.ExpectQuery(`UPDATE grades_tasks`).
 					WithArgs("stu1", "course1", "task1", 95.0, true).
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt.Add(time.Hour)))
+				// The previous grade leaves the aggregates and the new one enters
+				mock.ExpectExec(`INSERT INTO student_course_stats`).
+					WithArgs("stu1", "course1", -80.0, -1, 0).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO course_task_stats`).
+					WithArgs("course1", "task1", -80.0, -1, 0).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO course_daily_stats`).
+					WithArgs("course1", sql.NullTime{Time: createdAt, Valid: true}, -1, 0).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO student_course_stats`).
+					WithArgs("stu1", "course1", 95.0, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO course_task_stats`).
+					WithArgs("course1", "task1", 95.0, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO course_daily_stats`).
+					WithArgs("course1", sql.NullTime{}, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
 			},
 		},
@@ -942,7 +855,10 @@ func TestUpdateGradeTask(t *testing.T) {
 			expectError: true,
 			setupMock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`UPDATE grades_tasks`).
+				mock.ExpectQuery(`SELECT grade, on_time, created_at FROM grades_tasks`).
+					WithArgs("stu3", "course3", "task3").
+					WillReturnRows(sqlmock.NewRows([]string{"grade", "on_time", "created_at"}))
+				mock.ExpectQuery(`UPDATE grades_tasks`).
 					WithArgs("stu3", "course3", "task3", 70.0, true).
 					WillReturnError(errors.New("update failed"))
 				mock.ExpectRollback()
@@ -960,9 +876,12 @@ func TestUpdateGradeTask(t *testing.T) {
 			expectError: true,
 			setupMock: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec(`UPDATE grades_tasks`).
+				mock.ExpectQuery(`SELECT grade, on_time, created_at FROM grades_tasks`).
+					WithArgs("stu4", "course4", "task4").
+					WillReturnRows(sqlmock.NewRows([]string{"grade", "on_time", "created_at"}))
+				mock.ExpectQuery(`UPDATE grades_tasks`).
 					WithArgs("stu4", "course4", "task4", 60.0, false).
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
 				mock.ExpectCommit().WillReturnError(errors.New("commit failed"))
 			},
 		},
@@ -978,7 +897,7 @@ func TestUpdateGradeTask(t *testing.T) {
 
 			tc.setupMock(mock)
 
-			err = UpdateGradeTask(db, tc.grade)
+			_, err = UpdateGradeTask(context.Background(), db, tc.grade)
 
 			if (err != nil) != tc.expectError {
 				t.Errorf("expected error = %v, got %v", tc.expectError, err)
@@ -990,3 +909,15 @@ func TestUpdateGradeTask(t *testing.T) {
 		})
 	}
 }
+
+func TestPoolConfigApply(t *testing.T) {
+	db, _ := setupDB(t)
+	defer db.Close()
+
+	PoolConfig{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetime: time.Minute}.apply(db)
+	assert.Equal(t, 7, db.Stats().MaxOpenConnections)
+
+	// Zero values leave the current settings alone
+	PoolConfig{}.apply(db)
+	assert.Equal(t, 7, db.Stats().MaxOpenConnections)
+}
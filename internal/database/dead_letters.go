@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Statuses of a dead letter. Only pending ones can be edited, replayed or
+// discarded.
+const (
+	DeadLetterPending   = "pending"
+	DeadLetterReplayed  = "replayed"
+	DeadLetterDiscarded = "discarded"
+)
+
+const createDeadLetterTasksStatement = `
+	CREATE TABLE IF NOT EXISTS dead_letter_tasks (
+		id         BIGSERIAL PRIMARY KEY,
+		task_id    TEXT NOT NULL UNIQUE,
+		queue      TEXT NOT NULL,
+		task_type  TEXT NOT NULL,
+		payload    BYTEA NOT NULL,
+		error      TEXT NOT NULL,
+		retried    INTEGER NOT NULL DEFAULT 0,
+		permanent  BOOLEAN NOT NULL DEFAULT FALSE,
+		status     TEXT NOT NULL DEFAULT 'pending',
+		failed_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS dead_letter_tasks_status_idx ON dead_letter_tasks (status, id);
+	`
+
+var (
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	// ErrDeadLetterResolved is returned when changing a dead letter that
+	// was already replayed or discarded.
+	ErrDeadLetterResolved = errors.New("dead letter already resolved")
+)
+
+// DeadLetter is a task the worker gave up on, kept so it can be fixed and
+// replayed.
+type DeadLetter struct {
+	ID       int64
+	TaskID   string
+	Queue    string
+	TaskType string
+	Payload  []byte
+	Error    string
+	// Retried is how many times the task was retried before giving up.
+	Retried int
+	// Permanent is set when the task failed with an error retrying can't
+	// fix, such as an invalid payload.
+	Permanent bool
+	Status    string
+	FailedAt  time.Time
+	UpdatedAt time.Time
+}
+
+const deadLetterColumns = `id, task_id, queue, task_type, payload, error, retried, permanent, status, failed_at, updated_at`
+
+func scanDeadLetter(row interface{ Scan(...interface{}) error }) (*DeadLetter, error) {
+	var d DeadLetter
+	err := row.Scan(&d.ID, &d.TaskID, &d.Queue, &d.TaskType, &d.Payload, &d.Error, &d.Retried, &d.Permanent, &d.Status, &d.FailedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// RecordDeadLetter stores a task archived by the worker. Recording the same
+// asynq task twice is a no-op.
+var RecordDeadLetter = func(ctx context.Context, DB *sql.DB, d DeadLetter) error {
+	ctx, finish := startQuery(ctx, "RecordDeadLetter")
+	defer finish()
+
+	_, err := DB.ExecContext(ctx, `
+		INSERT INTO dead_letter_tasks (task_id, queue, task_type, payload, error, retried, permanent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (task_id) DO NOTHING`,
+		d.TaskID, d.Queue, d.TaskType, d.Payload, d.Error, d.Retried, d.Permanent)
+	return err
+}
+
+// ListDeadLetters returns up to limit dead letters with the given status
+// (any status when empty), newest first. beforeID pages through the list:
+// only dead letters with a smaller id are returned, zero meaning no bound.
+var ListDeadLetters = func(ctx context.Context, DB *sql.DB, status string, limit int, beforeID int64) ([]DeadLetter, error) {
+	ctx, finish := startQuery(ctx, "ListDeadLetters")
+	defer finish()
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT `+deadLetterColumns+` FROM dead_letter_tasks
+		WHERE ($1 = '' OR status = $1) AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3`, status, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deadLetters := []DeadLetter{}
+	for rows.Next() {
+		d, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, *d)
+	}
+	return deadLetters, rows.Err()
+}
+
+// GetDeadLetter returns a dead letter by id, or ErrDeadLetterNotFound.
+var GetDeadLetter = func(ctx context.Context, DB *sql.DB, id int64) (*DeadLetter, error) {
+	ctx, finish := startQuery(ctx, "GetDeadLetter")
+	defer finish()
+
+	d, err := scanDeadLetter(DB.QueryRowContext(ctx, `SELECT `+deadLetterColumns+` FROM dead_letter_tasks WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeadLetterNotFound
+	}
+	return d, err
+}
+
+// UpdateDeadLetterPayload replaces the payload a pending dead letter will
+// be replayed with.
+var UpdateDeadLetterPayload = func(ctx context.Context, DB *sql.DB, id int64, payload []byte) (*DeadLetter, error) {
+	ctx, finish := startQuery(ctx, "UpdateDeadLetterPayload")
+	defer finish()
+
+	return updatePendingDeadLetter(ctx, DB, id, `payload = $2`, payload)
+}
+
+// ResolveDeadLetter moves a pending dead letter to status, which is
+// DeadLetterReplayed or DeadLetterDiscarded. Replaying claims the dead
+// letter this way before enqueueing it, so two replays can't both succeed.
+var ResolveDeadLetter = func(ctx context.Context, DB *sql.DB, id int64, status string) (*DeadLetter, error) {
+	ctx, finish := startQuery(ctx, "ResolveDeadLetter")
+	defer finish()
+
+	return updatePendingDeadLetter(ctx, DB, id, `status = $2`, status)
+}
+
+// ReopenDeadLetter puts a dead letter back to pending, for a replay whose
+// enqueue failed.
+var ReopenDeadLetter = func(ctx context.Context, DB *sql.DB, id int64) error {
+	ctx, finish := startQuery(ctx, "ReopenDeadLetter")
+	defer finish()
+
+	_, err := DB.ExecContext(ctx, `UPDATE dead_letter_tasks SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id, DeadLetterPending)
+	return err
+}
+
+func updatePendingDeadLetter(ctx context.Context, DB *sql.DB, id int64, set string, value interface{}) (*DeadLetter, error) {
+	d, err := scanDeadLetter(DB.QueryRowContext(ctx, `
+		UPDATE dead_letter_tasks SET `+set+`, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = '`+DeadLetterPending+`'
+		RETURNING `+deadLetterColumns, id, value))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return d, err
+	}
+
+	// Nothing was updated: tell a missing dead letter from a resolved one
+	var exists bool
+	if err := DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM dead_letter_tasks WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrDeadLetterNotFound
+	}
+	return nil, ErrDeadLetterResolved
+}
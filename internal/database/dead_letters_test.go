@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var deadLetterRowColumns = []string{"id", "task_id", "queue", "task_type", "payload", "error", "retried", "permanent", "status", "failed_at", "updated_at"}
+
+func TestRecordDeadLetter(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(`INSERT INTO dead_letter_tasks (task_id, queue, task_type, payload, error, retried, permanent) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (task_id) DO NOTHING`).
+		WithArgs("abc", "default", "task:add_grade", []byte(`{"student_id":"s1"}`), "db error", 5, false).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := RecordDeadLetter(context.Background(), db, DeadLetter{
+		TaskID: "abc", Queue: "default", TaskType: "task:add_grade",
+		Payload: []byte(`{"student_id":"s1"}`), Error: "db error", Retried: 5,
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListDeadLetters(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	failedAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT id, task_id, queue, task_type, payload, error, retried, permanent, status, failed_at, updated_at FROM dead_letter_tasks WHERE ($1 = '' OR status = $1) AND ($2 = 0 OR id < $2) ORDER BY id DESC LIMIT $3`).
+		WithArgs("pending", int64(10), 2).
+		WillReturnRows(sqlmock.NewRows(deadLetterRowColumns).
+			AddRow(9, "t9", "default", "task:add_grade", []byte(`{}`), "boom", 3, false, "pending", failedAt, failedAt).
+			AddRow(7, "t7", "default", "task:add_grade", []byte(`{}`), "bad json", 0, true, "pending", failedAt, failedAt))
+
+	deadLetters, err := ListDeadLetters(context.Background(), db, DeadLetterPending, 2, 10)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 2)
+	assert.Equal(t, int64(9), deadLetters[0].ID)
+	assert.True(t, deadLetters[1].Permanent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDeadLetter_NotFound(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT id, task_id, queue, task_type, payload, error, retried, permanent, status, failed_at, updated_at FROM dead_letter_tasks WHERE id = $1`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(deadLetterRowColumns))
+
+	_, err := GetDeadLetter(context.Background(), db, 3)
+	assert.ErrorIs(t, err, ErrDeadLetterNotFound)
+}
+
+func TestResolveDeadLetter(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`UPDATE dead_letter_tasks SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = 'pending' RETURNING id, task_id, queue, task_type, payload, error, retried, permanent, status, failed_at, updated_at`).
+		WithArgs(int64(3), DeadLetterReplayed).
+		WillReturnRows(sqlmock.NewRows(deadLetterRowColumns).
+			AddRow(3, "t3", "default", "task:add_grade", []byte(`{}`), "boom", 3, false, "replayed", now, now))
+
+	d, err := ResolveDeadLetter(context.Background(), db, 3, DeadLetterReplayed)
+	require.NoError(t, err)
+	assert.Equal(t, DeadLetterReplayed, d.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveDeadLetter_NotPending(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	for _, tc := range []struct {
+		exists bool
+		want   error
+	}{
+		{true, ErrDeadLetterResolved},
+		{false, ErrDeadLetterNotFound},
+	} {
+		mock.ExpectQuery(`UPDATE dead_letter_tasks SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = 'pending' RETURNING id, task_id, queue, task_type, payload, error, retried, permanent, status, failed_at, updated_at`).
+			WithArgs(int64(3), DeadLetterDiscarded).
+			WillReturnRows(sqlmock.NewRows(deadLetterRowColumns))
+		mock.ExpectQuery(`SELECT EXISTS (SELECT 1 FROM dead_letter_tasks WHERE id = $1)`).
+			WithArgs(int64(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tc.exists))
+
+		_, err := ResolveDeadLetter(context.Background(), db, 3, DeadLetterDiscarded)
+		assert.ErrorIs(t, err, tc.want)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// MinBucketSize bounds how narrow the buckets of a distribution may be.
+const MinBucketSize = 0.01
+
+// ErrInvalidBucketSize is returned for a bucket size below MinBucketSize.
+var ErrInvalidBucketSize = errors.New("invalid bucket size")
+
+// GradeBucket counts the grades in [From, To).
+type GradeBucket struct {
+	From  float64
+	To    float64
+	Count int
+}
+
+// GetGradeDistribution counts the grades of a course, or of one of its
+// tasks when taskID is set, in buckets of bucketSize. Only the buckets with
+// grades are returned, lowest first.
+var GetGradeDistribution = func(ctx context.Context, DB *sql.DB, courseID, taskID string, bucketSize float64) ([]GradeBucket, error) {
+	ctx, finish := startQuery(ctx, "GetGradeDistribution")
+	defer finish()
+
+	if bucketSize < MinBucketSize {
+		return nil, fmt.Errorf("%w: must be at least %g", ErrInvalidBucketSize, MinBucketSize)
+	}
+
+	source, args := "grades WHERE course_id = $2", []interface{}{bucketSize, courseID}
+	if taskID != "" {
+		source, args = "grades_tasks WHERE course_id = $2 AND task_id = $3", append(args, taskID)
+	}
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT (FLOOR(grade / $1::float8) * $1::float8)::float8 AS bucket, COUNT(*)
+		FROM `+source+`
+		GROUP BY bucket ORDER BY bucket`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []GradeBucket{}
+	for rows.Next() {
+		var b GradeBucket
+		if err := rows.Scan(&b.From, &b.Count); err != nil {
+			return nil, err
+		}
+		b.To = b.From + bucketSize
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
@@ -0,0 +1,301 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Week starts accepted by TimeGrouping.
+const (
+	// WeekStartISO starts weeks on Monday, like DATE_TRUNC.
+	WeekStartISO    = "iso"
+	WeekStartSunday = "sunday"
+)
+
+// UnitTerm groups by the academic terms of an institution.
+const UnitTerm = "term"
+
+// MaxIntervalCount bounds the N of a custom "N days" interval.
+const MaxIntervalCount = 1000
+
+// truncUnits are the DATE_TRUNC units accepted by group_by.
+var truncUnits = map[string]bool{
+	"minute": true, "hour": true, "day": true, "week": true,
+	"month": true, "quarter": true, "year": true,
+}
+
+// intervalUnits are the units of custom intervals. date_bin can't bin by
+// months or years, so those only exist as standard units.
+var intervalUnits = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+var intervalPattern = regexp.MustCompile(`^(\d+)\s*(minute|hour|day|week)s?$`)
+
+// ErrInvalidGrouping wraps every problem with a TimeGrouping, so handlers
+// can answer 400.
+var ErrInvalidGrouping = errors.New("invalid grouping")
+
+// TimeGrouping says how the over-time queries bucket grades into periods.
+// The zero value puts every grade in a single "all_time" period.
+type TimeGrouping struct {
+	// Unit is a DATE_TRUNC unit such as "day" or "week", or UnitTerm.
+	Unit string
+	// Interval is a custom period length such as "3 days", binned with
+	// date_bin. It is only set when Unit is empty.
+	Interval string
+	// Institution whose academic terms are the periods when Unit is
+	// UnitTerm.
+	Institution string
+	// Location is the zone periods start and are labelled in; nil means UTC.
+	Location *time.Location
+	// WeekStart is WeekStartISO (default) or WeekStartSunday.
+	WeekStart string
+}
+
+// NewTimeGrouping builds a TimeGrouping from request parameters. groupBy is
+// a standard unit, an interval like "3 days", "term" or empty; tz is an
+// IANA zone name, empty meaning UTC; institution picks the academic
+// calendar for "term".
+func NewTimeGrouping(groupBy, tz, weekStart, institution string) (TimeGrouping, error) {
+	g := TimeGrouping{WeekStart: strings.ToLower(weekStart)}
+
+	groupBy = strings.ToLower(strings.TrimSpace(groupBy))
+	switch {
+	case groupBy == "":
+	case truncUnits[groupBy]:
+		g.Unit = groupBy
+	case groupBy == UnitTerm:
+		if institution == "" {
+			return g, fmt.Errorf("%w: group_by=term needs an institution", ErrInvalidGrouping)
+		}
+		g.Unit = UnitTerm
+		g.Institution = institution
+	default:
+		match := intervalPattern.FindStringSubmatch(groupBy)
+		if match == nil {
+			return g, fmt.Errorf("%w: group_by must be minute, hour, day, week, month, quarter, year, term or an interval like \"3 days\"", ErrInvalidGrouping)
+		}
+		count, err := strconv.Atoi(match[1])
+		if err != nil || count < 1 || count > MaxIntervalCount {
+			return g, fmt.Errorf("%w: an interval has between 1 and %d %ss", ErrInvalidGrouping, MaxIntervalCount, match[2])
+		}
+		unit := match[2]
+		if count != 1 {
+			unit += "s"
+		}
+		g.Interval = fmt.Sprintf("%d %s", count, unit)
+	}
+
+	switch g.WeekStart {
+	case "", WeekStartISO, WeekStartSunday:
+	default:
+		return g, fmt.Errorf("%w: week_start must be iso or sunday", ErrInvalidGrouping)
+	}
+
+	if tz != "" {
+		loc, err := time.LoadLocation(tz)
+		// "Local" would be the server's zone, which means nothing to clients
+		if err != nil || tz == "Local" {
+			return g, fmt.Errorf("%w: unknown time zone %q", ErrInvalidGrouping, tz)
+		}
+		g.Location = loc
+	}
+	return g, nil
+}
+
+// IsZero reports whether g puts every grade in a single period.
+func (g TimeGrouping) IsZero() bool {
+	return g.Unit == "" && g.Interval == ""
+}
+
+// String is the normalized group_by value of g.
+func (g TimeGrouping) String() string {
+	if g.Interval != "" {
+		return g.Interval
+	}
+	return g.Unit
+}
+
+func (g TimeGrouping) location() *time.Location {
+	if g.Location == nil {
+		return time.UTC
+	}
+	return g.Location
+}
+
+// utc reports whether periods are UTC ones, the only ones the daily
+// aggregates can be summed into.
+func (g TimeGrouping) utc() bool {
+	return g.location().String() == "UTC"
+}
+
+// intervalLength is the length of a custom interval.
+func (g TimeGrouping) intervalLength() time.Duration {
+	fields := strings.Fields(g.Interval)
+	if len(fields) != 2 {
+		return 0
+	}
+	count, _ := strconv.Atoi(fields[0])
+	return time.Duration(count) * intervalUnits[strings.TrimSuffix(fields[1], "s")]
+}
+
+// wholeDays reports whether every period is made of whole days.
+func (g TimeGrouping) wholeDays() bool {
+	switch {
+	case g.IsZero(), g.Unit == UnitTerm:
+		return true
+	case g.Interval != "":
+		return g.intervalLength()%(24*time.Hour) == 0
+	default:
+		return g.Unit != "minute" && g.Unit != "hour"
+	}
+}
+
+func (g TimeGrouping) sundayWeeks() bool {
+	return g.WeekStart == WeekStartSunday && g.Unit == "week"
+}
+
+// bucketing is a TimeGrouping bound to the placeholders of one query.
+type bucketing struct {
+	TimeGrouping
+	unit, zone, stride, institution string
+	// terms names the academic terms by their first day
+	terms map[string]string
+}
+
+// bind appends the values the period expressions of g need to args.
+func (g TimeGrouping) bind(args []interface{}) (bucketing, []interface{}) {
+	b := bucketing{TimeGrouping: g}
+	if g.IsZero() {
+		return b, args
+	}
+	placeholder := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	switch {
+	case g.Unit == UnitTerm:
+		b.institution = placeholder(g.Institution)
+	case g.Interval != "":
+		b.stride = placeholder(g.Interval) + "::interval"
+	default:
+		b.unit = placeholder(g.Unit)
+	}
+	b.zone = placeholder(g.location().String()) + "::text"
+	return b, args
+}
+
+// period returns the SQL expression for the period column falls in.
+func (b bucketing) period(column string) string {
+	switch {
+	case b.IsZero():
+		return "'all_time'"
+	case b.Unit == UnitTerm:
+		// Terms run from their first to their last day, both included, in
+		// the requested zone. Grades outside every term have no period.
+		return fmt.Sprintf("(SELECT starts_on FROM academic_terms WHERE institution_id = %s AND %s >= starts_on::timestamp AT TIME ZONE %s AND %[2]s < (ends_on + 1)::timestamp AT TIME ZONE %[3]s)",
+			b.institution, column, b.zone)
+	}
+	return b.localPeriod(column) + " AT TIME ZONE " + b.zone
+}
+
+// localPeriod is like period but returns the start of the period as a
+// timestamp without time zone, the wall-clock time in the grouping's zone.
+// It is only defined for units and intervals.
+func (b bucketing) localPeriod(column string) string {
+	local := column + " AT TIME ZONE " + b.zone
+	if b.Interval != "" {
+		// 2001-01-01 is a Monday and 2000-12-31 a Sunday, so intervals of
+		// whole weeks start on the requested day
+		origin := "TIMESTAMP '2001-01-01'"
+		if b.WeekStart == WeekStartSunday {
+			origin = "TIMESTAMP '2000-12-31'"
+		}
+		return "date_bin(" + b.stride + ", " + local + ", " + origin + ")"
+	}
+	if b.sundayWeeks() {
+		// Shifting by a day makes DATE_TRUNC's Monday weeks start on Sunday
+		return "(DATE_TRUNC(" + b.unit + ", " + local + " + INTERVAL '1 day') - INTERVAL '1 day')"
+	}
+	return "DATE_TRUNC(" + b.unit + ", " + local + ")"
+}
+
+// loadTerms reads the names of the academic terms when grouping by them.
+func (b *bucketing) loadTerms(ctx context.Context, tx *sql.Tx) error {
+	if b.Unit != UnitTerm {
+		return nil
+	}
+	terms, err := listAcademicTerms(ctx, tx, b.Institution)
+	if err != nil {
+		return err
+	}
+	if len(terms) == 0 {
+		return fmt.Errorf("%w: institution %q has no academic terms", ErrInvalidGrouping, b.Institution)
+	}
+	b.terms = map[string]string{}
+	for _, term := range terms {
+		b.terms[term.StartsOn.Format(dateLayout)] = term.Name
+	}
+	return nil
+}
+
+// label renders a period scanned from a column made by period: the term
+// name, the start of the period in the grouping's zone or "all_time". It
+// returns false for the grades outside every term.
+func (b bucketing) label(period interface{}) (string, bool) {
+	switch value := period.(type) {
+	case time.Time:
+		if b.Unit == UnitTerm {
+			name, ok := b.terms[value.Format(dateLayout)]
+			return name, ok
+		}
+		return value.In(b.location()).Format(time.RFC3339), true
+	case []byte:
+		return string(value), true
+	case string:
+		return value, true
+	case nil:
+		return "", false
+	}
+	return fmt.Sprint(period), true
+}
+
+// ParseDateRange parses the YYYY-MM-DD dates of a request into the range
+// they cover in loc (UTC when nil): from the start of start to the end of
+// end, which may not last 24 hours on a daylight saving change. An empty
+// start is the zero time, meaning no lower bound; an empty end is now.
+func ParseDateRange(start, end string, loc *time.Location) (time.Time, time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var startTime time.Time
+	if start != "" {
+		var err error
+		if startTime, err = time.ParseInLocation(dateLayout, start, loc); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	endTime := time.Now().In(loc)
+	if end != "" {
+		day, err := time.ParseInLocation(dateLayout, end, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		endTime = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	return startTime, endTime, nil
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTimeGrouping(t *testing.T) {
+	valid := []struct {
+		groupBy string
+		want    TimeGrouping
+	}{
+		{"", TimeGrouping{}},
+		{"Week", TimeGrouping{Unit: "week"}},
+		{"quarter", TimeGrouping{Unit: "quarter"}},
+		{"3 days", TimeGrouping{Interval: "3 days"}},
+		{"1 weeks", TimeGrouping{Interval: "1 week"}},
+		{"90minutes", TimeGrouping{Interval: "90 minutes"}},
+	}
+	for _, tc := range valid {
+		g, err := NewTimeGrouping(tc.groupBy, "", "", "")
+		require.NoError(t, err, tc.groupBy)
+		assert.Equal(t, tc.want, g, tc.groupBy)
+	}
+
+	g, err := NewTimeGrouping("term", "America/Argentina/Buenos_Aires", "", "fiuba")
+	require.NoError(t, err)
+	assert.Equal(t, UnitTerm, g.Unit)
+	assert.Equal(t, "fiuba", g.Institution)
+	assert.Equal(t, "America/Argentina/Buenos_Aires", g.Location.String())
+
+	g, err = NewTimeGrouping("week", "", "Sunday", "")
+	require.NoError(t, err)
+	assert.Equal(t, WeekStartSunday, g.WeekStart)
+
+	invalid := []struct{ groupBy, tz, weekStart, institution string }{
+		{"fortnight", "", "", ""},
+		{"day; DROP TABLE grades", "", "", ""},
+		{"3 months", "", "", ""},
+		{"0 days", "", "", ""},
+		{"1001 days", "", "", ""},
+		{"term", "", "", ""},
+		{"day", "Mars/Olympus", "", ""},
+		{"day", "Local", "", ""},
+		{"day", "-03:00", "", ""},
+		{"week", "", "saturday", ""},
+	}
+	for _, tc := range invalid {
+		_, err := NewTimeGrouping(tc.groupBy, tc.tz, tc.weekStart, tc.institution)
+		assert.ErrorIs(t, err, ErrInvalidGrouping, "%+v", tc)
+	}
+}
+
+func TestTimeGrouping_Bind(t *testing.T) {
+	b, args := TimeGrouping{}.bind([]interface{}{"c1"})
+	assert.Equal(t, "'all_time'", b.period("created_at"))
+	assert.Equal(t, []interface{}{"c1"}, args)
+
+	b, args = TimeGrouping{Unit: "week"}.bind([]interface{}{"c1"})
+	assert.Equal(t, "DATE_TRUNC($2, created_at AT TIME ZONE $3::text) AT TIME ZONE $3::text", b.period("created_at"))
+	assert.Equal(t, []interface{}{"c1", "week", "UTC"}, args)
+
+	b, _ = TimeGrouping{Unit: "week", WeekStart: WeekStartSunday}.bind([]interface{}{"c1"})
+	assert.Equal(t, "(DATE_TRUNC($2, created_at AT TIME ZONE $3::text + INTERVAL '1 day') - INTERVAL '1 day') AT TIME ZONE $3::text", b.period("created_at"))
+
+	b, _ = TimeGrouping{Unit: "month", WeekStart: WeekStartSunday}.bind(nil)
+	assert.Equal(t, "DATE_TRUNC($1, created_at AT TIME ZONE $2::text) AT TIME ZONE $2::text", b.period("created_at"), "only weeks are shifted")
+
+	b, args = TimeGrouping{Interval: "2 weeks", WeekStart: WeekStartSunday}.bind(nil)
+	assert.Equal(t, "date_bin($1::interval, created_at AT TIME ZONE $2::text, TIMESTAMP '2000-12-31') AT TIME ZONE $2::text", b.period("created_at"))
+	assert.Equal(t, []interface{}{"2 weeks", "UTC"}, args)
+
+	b, args = TimeGrouping{Unit: UnitTerm, Institution: "fiuba"}.bind([]interface{}{"c1"})
+	assert.Equal(t, "(SELECT starts_on FROM academic_terms WHERE institution_id = $2 AND created_at >= starts_on::timestamp AT TIME ZONE $3::text AND created_at < (ends_on + 1)::timestamp AT TIME ZONE $3::text)", b.period("created_at"))
+	assert.Equal(t, []interface{}{"c1", "fiuba", "UTC"}, args)
+}
+
+func TestTimeGrouping_WholeDays(t *testing.T) {
+	assert.True(t, TimeGrouping{}.wholeDays())
+	assert.True(t, TimeGrouping{Unit: "month"}.wholeDays())
+	assert.True(t, TimeGrouping{Interval: "2 weeks"}.wholeDays())
+	assert.True(t, TimeGrouping{Interval: "48 hours"}.wholeDays())
+	assert.True(t, TimeGrouping{Interval: "1 week"}.wholeDays())
+	assert.False(t, TimeGrouping{Interval: "36 hours"}.wholeDays())
+	assert.False(t, TimeGrouping{Unit: "hour"}.wholeDays())
+}
+
+func TestParseDateRange_DaylightSaving(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// 2026-03-08 only has 23 hours in New York
+	start, end, err := ParseDateRange("2026-03-08", "2026-03-08", newYork)
+	require.NoError(t, err)
+	assert.Equal(t, 23*time.Hour, end.Add(time.Nanosecond).Sub(start))
+}
+
+func TestGetStudentAveragesOverTime_InZone(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	grouping, err := NewTimeGrouping("day", "America/Argentina/Buenos_Aires", "", "")
+	require.NoError(t, err)
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, grouping.Location)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT DATE_TRUNC($2, created_at AT TIME ZONE $3::text) AT TIME ZONE $3::text AS period, AVG(grade) AS average_grade, COUNT(*) AS grade_count FROM grades WHERE student_id = $1 AND created_at >= $4 GROUP BY period ORDER BY period`).
+		WithArgs("student1", "day", "America/Argentina/Buenos_Aires", start).
+		WillReturnRows(sqlmock.NewRows([]string{"period", "average_grade", "grade_count"}).
+			// The driver hands timestamptz back in UTC
+			AddRow(time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC), 7.0, 1))
+	mock.ExpectRollback()
+
+	results, err := GetStudentAveragesOverTime(context.Background(), db, "student1", start, time.Time{}, grouping, SeriesOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "2026-03-02T00:00:00-03:00", results[0].Period)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStudentAveragesOverTime_AllTime(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT 'all_time' AS period, SUM(grade_sum) / SUM(grade_count) AS average_grade, SUM(grade_count) AS grade_count FROM student_course_daily_stats WHERE student_id = $1 GROUP BY period HAVING SUM(grade_count) > 0 ORDER BY period`).
+		WithArgs("student1").
+		WillReturnRows(sqlmock.NewRows([]string{"period", "average_grade", "grade_count"}).
+			AddRow([]byte("all_time"), 7.5, 4))
+	mock.ExpectRollback()
+
+	results, err := GetStudentAveragesOverTime(context.Background(), db, "student1", time.Time{}, time.Time{}, TimeGrouping{}, SeriesOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "all_time", results[0].Period)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCourseAveragesOverTime_ByAcademicTerm(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	firstTerm := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	secondTerm := time.Date(2026, 8, 10, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT name, starts_on, ends_on FROM academic_terms WHERE institution_id = $1 ORDER BY starts_on`).
+		WithArgs("fiuba").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "starts_on", "ends_on"}).
+			AddRow("2026-1C", firstTerm, time.Date(2026, 7, 11, 0, 0, 0, 0, time.UTC)).
+			AddRow("2026-2C", secondTerm, time.Date(2026, 12, 12, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectQuery(`SELECT (SELECT starts_on FROM academic_terms WHERE institution_id = $2 AND period_start >= starts_on::timestamp AT TIME ZONE $3::text AND period_start < (ends_on + 1)::timestamp AT TIME ZONE $3::text) AS period, SUM(grade_sum) / SUM(grade_count) AS average_grade, SUM(grade_count) AS grade_count FROM course_daily_stats WHERE course_id = $1 GROUP BY period HAVING SUM(grade_count) > 0 ORDER BY period`).
+		WithArgs("course1", "fiuba", "UTC").
+		WillReturnRows(sqlmock.NewRows([]string{"period", "average_grade", "grade_count"}).
+			AddRow(firstTerm, 7.0, 10).
+			AddRow(secondTerm, 8.0, 5).
+			AddRow(nil, 4.0, 1))
+	mock.ExpectRollback()
+
+	grouping := TimeGrouping{Unit: UnitTerm, Institution: "fiuba"}
+	results, err := GetCourseAveragesOverTime(context.Background(), db, "course1", time.Time{}, time.Time{}, grouping, SeriesOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 2, "grades outside every term are left out")
+	assert.Equal(t, "2026-1C", results[0].Period)
+	assert.Equal(t, "2026-2C", results[1].Period)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCourseAveragesOverTime_InstitutionWithoutTerms(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT name, starts_on, ends_on FROM academic_terms WHERE institution_id = $1 ORDER BY starts_on`).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "starts_on", "ends_on"}))
+	mock.ExpectRollback()
+
+	grouping := TimeGrouping{Unit: UnitTerm, Institution: "unknown"}
+	_, err := GetCourseAveragesOverTime(context.Background(), db, "course1", time.Time{}, time.Time{}, grouping, SeriesOptions{})
+	assert.ErrorIs(t, err, ErrInvalidGrouping)
+}
+
+func TestGetOnTimeSubmissionPercentageForStudent_ByInterval(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	period := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT date_bin($3::interval, created_at AT TIME ZONE $4::text, TIMESTAMP '2001-01-01') AT TIME ZONE $4::text AS period, COUNT(*) FILTER (WHERE on_time = true) AS on_time_count, COUNT(*) AS total_count, COALESCE((COUNT(*) FILTER (WHERE on_time = true) * 100.0 / NULLIF(COUNT(*), 0)), 0) AS percentage FROM grades_tasks WHERE course_id = $1 AND student_id = $2 GROUP BY period ORDER BY period`).
+		WithArgs("course1", "student1", "3 days", "UTC").
+		WillReturnRows(sqlmock.NewRows([]string{"period", "on_time_count", "total_count", "percentage"}).
+			AddRow(period, 1, 2, 50.0))
+	mock.ExpectCommit()
+
+	results, err := GetOnTimeSubmissionPercentageForStudent(context.Background(), db, "course1", "student1", time.Time{}, time.Time{}, TimeGrouping{Interval: "3 days"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "2026-03-03T00:00:00Z", results[0].Period)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
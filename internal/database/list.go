@@ -0,0 +1,273 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// Sort keys accepted by the list endpoints.
+const (
+	SortAverage   = "average"
+	SortCount     = "count"
+	SortStudentID = "student_id"
+)
+
+// sortColumns maps each sort key to the column of listStudents' source.
+var sortColumns = map[string]string{
+	SortAverage:   "average_grade",
+	SortCount:     "item_count",
+	SortStudentID: "student_id",
+}
+
+// ErrInvalidListOptions wraps every problem with ListOptions, so handlers
+// can answer 400.
+var ErrInvalidListOptions = errors.New("invalid list options")
+
+// ListOptions pages, sorts and filters a list of students with their
+// averages.
+type ListOptions struct {
+	// Limit is the page size; zero means DefaultListLimit.
+	Limit int
+	// Sort is one of the Sort* keys; empty means SortAverage.
+	Sort string
+	// Order is "asc" or "desc"; empty means descending for averages and
+	// counts and ascending for student ids.
+	Order string
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+
+	MinAverage *float64
+	MaxAverage *float64
+	// OnTimeOnly averages only the submissions made on time.
+	OnTimeOnly bool
+	// GradedAfter leaves out grades recorded before it.
+	GradedAfter time.Time
+}
+
+// Page is one page of a list.
+type Page struct {
+	Items []StudentAverage
+	// Total is the number of items matching the filters across all pages.
+	Total int
+	// NextCursor fetches the following page; empty on the last one.
+	NextCursor string
+}
+
+// listCursor is the position after the last item of a page. It carries the
+// sort it was made for so it can't be replayed against another order.
+type listCursor struct {
+	Sort      string  `json:"s"`
+	Desc      bool    `json:"d"`
+	Value     float64 `json:"v,omitempty"`
+	StudentID string  `json:"id"`
+}
+
+func (c listCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (listCursor, error) {
+	var cursor listCursor
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(raw, &cursor)
+	}
+	if err != nil || cursor.StudentID == "" {
+		return listCursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+	return cursor, nil
+}
+
+// normalize fills in the defaults and validates o.
+func (o ListOptions) normalize() (ListOptions, error) {
+	if o.Limit == 0 {
+		o.Limit = DefaultListLimit
+	}
+	if o.Limit < 1 || o.Limit > MaxListLimit {
+		return o, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListOptions, MaxListLimit)
+	}
+
+	if o.Sort == "" {
+		o.Sort = SortAverage
+	}
+	if _, ok := sortColumns[o.Sort]; !ok {
+		return o, fmt.Errorf("%w: sort must be average, count or student_id", ErrInvalidListOptions)
+	}
+
+	switch o.Order {
+	case "":
+		o.Order = "desc"
+		if o.Sort == SortStudentID {
+			o.Order = "asc"
+		}
+	case "asc", "desc":
+	default:
+		return o, fmt.Errorf("%w: order must be asc or desc", ErrInvalidListOptions)
+	}
+
+	if o.MinAverage != nil && o.MaxAverage != nil && *o.MinAverage > *o.MaxAverage {
+		return o, fmt.Errorf("%w: min_average is greater than max_average", ErrInvalidListOptions)
+	}
+	return o, nil
+}
+
+// Validate reports whether o would be accepted by a list query.
+func (o ListOptions) Validate() error {
+	opts, err := o.normalize()
+	if err != nil {
+		return err
+	}
+	if opts.Cursor != "" {
+		_, err = opts.cursor()
+	}
+	return err
+}
+
+func (o ListOptions) cursor() (listCursor, error) {
+	cursor, err := decodeCursor(o.Cursor)
+	if err != nil {
+		return cursor, err
+	}
+	if cursor.Sort != o.Sort || cursor.Desc != (o.Order == "desc") {
+		return listCursor{}, fmt.Errorf("%w: cursor belongs to another sort order", ErrInvalidListOptions)
+	}
+	return cursor, nil
+}
+
+// fromBaseTable reports whether the filters need the individual grades
+// rather than the per-student aggregates.
+func (o ListOptions) fromBaseTable() bool {
+	return o.OnTimeOnly || !o.GradedAfter.IsZero()
+}
+
+// gradeFilters returns the WHERE conditions on grades_tasks for the
+// OnTimeOnly and GradedAfter filters, numbering placeholders after args.
+func (o ListOptions) gradeFilters(args []interface{}) (string, []interface{}) {
+	var conditions string
+	if o.OnTimeOnly {
+		conditions += " AND on_time = true"
+	}
+	if !o.GradedAfter.IsZero() {
+		args = append(args, o.GradedAfter)
+		conditions += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	return conditions, args
+}
+
+// listStudents pages through source, a query returning student_id,
+// average_grade and item_count with its placeholders bound to args.
+// countsTasks tells whether item_count is the TaskCount or the GradeCount
+// of the returned items.
+func listStudents(ctx context.Context, tx *sql.Tx, source string, args []interface{}, countsTasks bool, opts ListOptions) (*Page, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	var averageFilters []string
+	if opts.MinAverage != nil {
+		args = append(args, *opts.MinAverage)
+		averageFilters = append(averageFilters, fmt.Sprintf("average_grade >= $%d", len(args)))
+	}
+	if opts.MaxAverage != nil {
+		args = append(args, *opts.MaxAverage)
+		averageFilters = append(averageFilters, fmt.Sprintf("average_grade <= $%d", len(args)))
+	}
+
+	filtered := "SELECT student_id, average_grade, item_count FROM (" + source + ") AS source"
+	if len(averageFilters) > 0 {
+		filtered += " WHERE " + strings.Join(averageFilters, " AND ")
+	}
+
+	var total int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+filtered+") AS filtered", args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	column := sortColumns[opts.Sort]
+	desc := opts.Order == "desc"
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := "SELECT student_id, average_grade, item_count FROM (" + filtered + ") AS filtered"
+	if opts.Cursor != "" {
+		cursor, err := opts.cursor()
+		if err != nil {
+			return nil, err
+		}
+		switch opts.Sort {
+		case SortStudentID:
+			args = append(args, cursor.StudentID)
+			query += fmt.Sprintf(" WHERE student_id %s $%d", comparison, len(args))
+		case SortCount:
+			args = append(args, int64(cursor.Value), cursor.StudentID)
+			query += fmt.Sprintf(" WHERE (item_count, student_id) %s ($%d, $%d)", comparison, len(args)-1, len(args))
+		default:
+			args = append(args, cursor.Value, cursor.StudentID)
+			query += fmt.Sprintf(" WHERE (average_grade, student_id) %s ($%d, $%d)", comparison, len(args)-1, len(args))
+		}
+	}
+	if column == "student_id" {
+		query += fmt.Sprintf(" ORDER BY student_id %s", direction)
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s, student_id %s", column, direction, direction)
+	}
+	// One extra row tells whether there is a next page
+	args = append(args, opts.Limit+1)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &Page{Items: []StudentAverage{}, Total: total}
+	var last listCursor
+	for rows.Next() {
+		var studentID string
+		var average float64
+		var count int
+		if err := rows.Scan(&studentID, &average, &count); err != nil {
+			return nil, err
+		}
+		if len(page.Items) == opts.Limit {
+			page.NextCursor = last.encode()
+			break
+		}
+		item := StudentAverage{StudentID: studentID, AverageGrade: average}
+		if countsTasks {
+			item.TaskCount = count
+		} else {
+			item.GradeCount = count
+		}
+		page.Items = append(page.Items, item)
+
+		last = listCursor{Sort: opts.Sort, Desc: desc, StudentID: studentID}
+		switch opts.Sort {
+		case SortAverage:
+			last.Value = average
+		case SortCount:
+			last.Value = float64(count)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
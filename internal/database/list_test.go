@@ -0,0 +1,233 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const otherStudentsSource = `SELECT student_id, (task_grade_sum / task_count)::float8 AS average_grade, task_count AS item_count
+	FROM student_course_stats WHERE course_id = $1 AND student_id != $2 AND task_count > 0`
+
+const taskAveragesSource = `SELECT student_id, AVG(grade)::float8 AS average_grade, COUNT(*) AS item_count
+	FROM grades_tasks WHERE course_id = $1 AND task_id = $2 GROUP BY student_id`
+
+// filteredQuery mirrors how listStudents wraps a source query.
+func filteredQuery(source, where string) string {
+	query := "SELECT student_id, average_grade, item_count FROM ( " + source + ") AS source"
+	if where != "" {
+		query += " WHERE " + where
+	}
+	return query
+}
+
+func countQuery(filtered string) string {
+	return "SELECT COUNT(*) FROM (" + filtered + ") AS filtered"
+}
+
+func pageQuery(filtered, rest string) string {
+	return "SELECT student_id, average_grade, item_count FROM (" + filtered + ") AS filtered " + rest
+}
+
+func float(value float64) *float64 {
+	return &value
+}
+
+func TestGetOtherStudentsCourseAverages(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	filtered := filteredQuery(otherStudentsSource, "")
+	mock.ExpectBegin()
+	mock.ExpectQuery(countQuery(filtered)).
+		WithArgs("c1", "stu1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(pageQuery(filtered, "ORDER BY average_grade DESC, student_id DESC LIMIT $3")).
+		WithArgs("c1", "stu1", DefaultListLimit+1).
+		WillReturnRows(sqlmock.NewRows([]string{"student_id", "average_grade", "item_count"}).
+			AddRow("stu2", 6.0, 2))
+	mock.ExpectRollback()
+
+	page, err := GetOtherStudentsCourseAverages(context.Background(), db, "stu1", "c1", ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "stu2", page.Items[0].StudentID)
+	assert.Equal(t, 6.0, page.Items[0].AverageGrade)
+	assert.Equal(t, 2, page.Items[0].TaskCount)
+	assert.Equal(t, 1, page.Total)
+	assert.Empty(t, page.NextCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetOtherStudentsCourseAverages_Empty(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	filtered := filteredQuery(otherStudentsSource, "")
+	mock.ExpectBegin()
+	mock.ExpectQuery(countQuery(filtered)).
+		WithArgs("c1", "stu1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(pageQuery(filtered, "ORDER BY average_grade DESC, student_id DESC LIMIT $3")).
+		WithArgs("c1", "stu1", DefaultListLimit+1).
+		WillReturnRows(sqlmock.NewRows([]string{"student_id", "average_grade", "item_count"}))
+	mock.ExpectRollback()
+
+	page, err := GetOtherStudentsCourseAverages(context.Background(), db, "stu1", "c1", ListOptions{})
+	require.NoError(t, err)
+	assert.NotNil(t, page.Items, "an empty page renders as [] rather than null")
+	assert.Len(t, page.Items, 0)
+	assert.Zero(t, page.Total)
+}
+
+func TestGetOtherStudentsCourseAverages_FiltersUseBaseTable(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	gradedAfter := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	source := `SELECT student_id, AVG(grade)::float8 AS average_grade, COUNT(*) AS item_count
+		FROM grades_tasks WHERE course_id = $1 AND student_id != $2 AND on_time = true AND created_at >= $3
+		GROUP BY student_id`
+	filtered := filteredQuery(source, "average_grade >= $4 AND average_grade <= $5")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(countQuery(filtered)).
+		WithArgs("c1", "stu1", gradedAfter, 6.0, 9.0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(pageQuery(filtered, "ORDER BY item_count ASC, student_id ASC LIMIT $6")).
+		WithArgs("c1", "stu1", gradedAfter, 6.0, 9.0, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"student_id", "average_grade", "item_count"}).
+			AddRow("stu2", 7.0, 1).
+			AddRow("stu3", 8.0, 2).
+			AddRow("stu4", 6.5, 4))
+	mock.ExpectRollback()
+
+	opts := ListOptions{
+		Limit:       2,
+		Sort:        SortCount,
+		Order:       "asc",
+		MinAverage:  float(6),
+		MaxAverage:  float(9),
+		OnTimeOnly:  true,
+		GradedAfter: gradedAfter,
+	}
+	page, err := GetOtherStudentsCourseAverages(context.Background(), db, "stu1", "c1", opts)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2, "the extra row is only used to detect the next page")
+	assert.Equal(t, 3, page.Total)
+	require.NotEmpty(t, page.NextCursor)
+
+	cursor, err := decodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, listCursor{Sort: SortCount, Desc: false, Value: 2, StudentID: "stu3"}, cursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAveragesForTask_NextPage(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	cursor := listCursor{Sort: SortAverage, Desc: true, Value: 8.5, StudentID: "stu7"}.encode()
+	filtered := filteredQuery(taskAveragesSource, "")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(countQuery(filtered)).
+		WithArgs("c1", "t1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	mock.ExpectQuery(pageQuery(filtered, "WHERE (average_grade, student_id) < ($3, $4) ORDER BY average_grade DESC, student_id DESC LIMIT $5")).
+		WithArgs("c1", "t1", 8.5, "stu7", 11).
+		WillReturnRows(sqlmock.NewRows([]string{"student_id", "average_grade", "item_count"}).
+			AddRow("stu8", 8.0, 1))
+	mock.ExpectRollback()
+
+	page, err := GetAveragesForTask(context.Background(), db, "c1", "t1", ListOptions{Limit: 10, Cursor: cursor})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, 1, page.Items[0].GradeCount)
+	assert.Equal(t, 12, page.Total)
+	assert.Empty(t, page.NextCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAveragesForTask_SortByStudentID(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	cursor := listCursor{Sort: SortStudentID, StudentID: "stu3"}.encode()
+	filtered := filteredQuery(taskAveragesSource, "")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(countQuery(filtered)).
+		WithArgs("c1", "t1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	mock.ExpectQuery(pageQuery(filtered, "WHERE student_id > $3 ORDER BY student_id ASC LIMIT $4")).
+		WithArgs("c1", "t1", "stu3", DefaultListLimit+1).
+		WillReturnRows(sqlmock.NewRows([]string{"student_id", "average_grade", "item_count"}).
+			AddRow("stu4", 5.0, 1))
+	mock.ExpectRollback()
+
+	page, err := GetAveragesForTask(context.Background(), db, "c1", "t1", ListOptions{Sort: SortStudentID, Cursor: cursor})
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAveragesForTask_DBError(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(countQuery(filteredQuery(taskAveragesSource, ""))).
+		WithArgs("c1", "t1").
+		WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	res, err := GetAveragesForTask(context.Background(), db, "c1", "t1", ListOptions{})
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
+
+func TestGetAveragesForTask_InvalidOptions(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	_, err := GetAveragesForTask(context.Background(), db, "c1", "t1", ListOptions{Sort: "name"})
+	assert.ErrorIs(t, err, ErrInvalidListOptions)
+	assert.NoError(t, mock.ExpectationsWereMet(), "nothing is queried")
+}
+
+func TestListOptions_Validate(t *testing.T) {
+	averageCursor := listCursor{Sort: SortAverage, Desc: true, Value: 7, StudentID: "s1"}.encode()
+
+	valid := []ListOptions{
+		{},
+		{Limit: MaxListLimit, Sort: SortCount, Order: "asc"},
+		{MinAverage: float(4), MaxAverage: float(4)},
+		{Cursor: averageCursor},
+	}
+	for _, opts := range valid {
+		assert.NoError(t, opts.Validate(), "%+v", opts)
+	}
+
+	invalid := []ListOptions{
+		{Limit: -1},
+		{Limit: MaxListLimit + 1},
+		{Sort: "grade"},
+		{Order: "up"},
+		{MinAverage: float(8), MaxAverage: float(4)},
+		{Cursor: "not a cursor"},
+		{Cursor: averageCursor, Order: "asc"},
+		{Cursor: averageCursor, Sort: SortCount},
+	}
+	for _, opts := range invalid {
+		assert.ErrorIs(t, opts.Validate(), ErrInvalidListOptions, "%+v", opts)
+	}
+}
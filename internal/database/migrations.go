@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// Migration is a versioned schema change. Migrations are applied in order
+// and recorded in schema_migrations, so each one runs exactly once.
+type Migration struct {
+	Version   int
+	Name      string
+	Statement string
+}
+
+// migrationsLockID is the key of the advisory lock taken while migrating, so
+// the API and the worker starting together don't race each other.
+const migrationsLockID = 724513
+
+// Migrations holds every schema change, oldest first. New migrations must be
+// appended with the next version number; applied ones must never change.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_grades_tables",
+		Statement: `
+		CREATE TABLE IF NOT EXISTS grades (
+			id SERIAL PRIMARY KEY,
+			student_id TEXT NOT NULL,
+			course_id  TEXT NOT NULL,
+			grade      NUMERIC NOT NULL,
+			on_time    BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS grades_tasks (
+			id SERIAL PRIMARY KEY,
+			student_id TEXT NOT NULL,
+			course_id  TEXT NOT NULL,
+			task_id    TEXT NOT NULL,
+			grade      NUMERIC NOT NULL,
+			on_time    BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);
+		`,
+	},
+	{
+		Version:   2,
+		Name:      "create_stats_aggregates",
+		Statement: createAggregatesStatement + rebuildAggregatesStatement,
+	},
+	{
+		Version:   3,
+		Name:      "create_academic_terms",
+		Statement: createAcademicTermsStatement,
+	},
+	{
+		Version:   4,
+		Name:      "create_dead_letter_tasks",
+		Statement: createDeadLetterTasksStatement,
+	},
+	{
+		Version:   5,
+		Name:      "create_task_outbox",
+		Statement: createTaskOutboxStatement,
+	},
+	{
+		Version:   6,
+		Name:      "create_webhooks",
+		Statement: createWebhooksStatement,
+	},
+	{
+		Version:   7,
+		Name:      "create_student_course_daily_stats",
+		Statement: createStudentCourseDailyStatsStatement + rebuildStudentCourseDailyStatsStatement,
+	},
+}
+
+const createMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	)`
+
+// Migrate applies every migration not yet recorded in schema_migrations.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	for _, migration := range Migrations {
+		if err := applyMigration(ctx, db, migration); err != nil {
+			return fmt.Errorf("error applying migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, migration Migration) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationsLockID); err != nil {
+		return err
+	}
+
+	var applied bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)`, migration.Version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied {
+		return tx.Commit()
+	}
+
+	if _, err = tx.ExecContext(ctx, migration.Statement); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "migration applied", "version", migration.Version, "name", migration.Name)
+	return nil
+}
+
+// PendingMigrations returns the migrations not yet applied to db.
+func PendingMigrations(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range Migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	for i, migration := range Migrations {
+		assert.Equal(t, i+1, migration.Version, "migration %s has an unexpected version", migration.Name)
+		assert.NotEmpty(t, migration.Name)
+		assert.NotEmpty(t, migration.Statement)
+	}
+}
+
+func TestMigrate_AppliesPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, migration := range Migrations {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs(migration.Version).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectExec(`.+`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(migration.Version, migration.Name).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+
+	assert.NoError(t, Migrate(context.Background(), db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrate_SkipsApplied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, migration := range Migrations {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT EXISTS`).WithArgs(migration.Version).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectCommit()
+	}
+
+	assert.NoError(t, Migrate(context.Background(), db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrate_FailureRollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`.+`).WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+
+	err = Migrate(context.Background(), db)
+	assert.ErrorContains(t, err, "migration 1")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPendingMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT version FROM schema_migrations`).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+
+	pending, err := PendingMigrations(context.Background(), db)
+	require.NoError(t, err)
+	assert.Len(t, pending, len(Migrations)-1)
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+// queryCanceled is the PostgreSQL error code for a statement cancelled by
+// the client, which is what lib/pq reports when the context ends mid-query.
+const queryCanceled = "57014"
+
+// QueryTimeout bounds every repository call, on top of whatever deadline
+// the caller's context already has. Zero disables it.
+var QueryTimeout = 5 * time.Second
+
+// startQuery instruments a repository function: it opens a span as a child
+// of ctx, applies QueryTimeout and returns a func that releases both and
+// records the function latency.
+func startQuery(ctx context.Context, function string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.StartDBSpan(ctx, function)
+
+	cancel := context.CancelFunc(func() {})
+	if QueryTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, QueryTimeout)
+	}
+
+	return ctx, func() {
+		cancel()
+		span.End()
+		metrics.ObserveDBQuery(function, start)
+	}
+}
+
+// IsQueryCanceled reports whether err comes from a query stopped by its
+// context, either because QueryTimeout elapsed or because the caller went
+// away.
+func IsQueryCanceled(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true
+	}
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == queryCanceled
+}
+
+// IsPermanentError reports whether err is a PostgreSQL data exception or
+// integrity constraint violation: the same write would fail again, so it
+// isn't worth retrying.
+func IsPermanentError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	class := pqErr.Code.Class()
+	return class == "22" || class == "23"
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTimeout(t *testing.T) {
+	previous := QueryTimeout
+	QueryTimeout = 20 * time.Millisecond
+	defer func() { QueryTimeout = previous }()
+
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT AVG(grade) FROM grades WHERE student_id = $1 AND course_id = $2 GROUP BY student_id, course_id`).
+		WithArgs("student1", "course1").
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"avg_grade"}).AddRow(85.0))
+	mock.ExpectRollback()
+
+	start := time.Now()
+	_, _, err := GetAvgGradeForStudent(context.Background(), db, "student1", "course1")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "the query should be cut at QueryTimeout")
+}
+
+func TestIsQueryCanceled(t *testing.T) {
+	assert.True(t, IsQueryCanceled(context.DeadlineExceeded))
+	assert.True(t, IsQueryCanceled(fmt.Errorf("wrapped: %w", context.Canceled)))
+	assert.True(t, IsQueryCanceled(&pq.Error{Code: "57014"}))
+	assert.False(t, IsQueryCanceled(&pq.Error{Code: "23505"}))
+	assert.False(t, IsQueryCanceled(errors.New("connection refused")))
+}
+
+func TestIsPermanentError(t *testing.T) {
+	assert.True(t, IsPermanentError(&pq.Error{Code: "23505"}))
+	assert.True(t, IsPermanentError(fmt.Errorf("wrapped: %w", &pq.Error{Code: "22P02"})))
+	assert.False(t, IsPermanentError(&pq.Error{Code: "57014"}))
+	assert.False(t, IsPermanentError(&pq.Error{Code: "40001"}))
+	assert.False(t, IsPermanentError(errors.New("connection refused")))
+}
+
+func TestStartQueryKeepsCallerDeadline(t *testing.T) {
+	previous := QueryTimeout
+	QueryTimeout = time.Hour
+	defer func() { QueryTimeout = previous }()
+
+	parent, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	ctx, finish := startQuery(parent, "test")
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	parentDeadline, _ := parent.Deadline()
+	assert.Equal(t, parentDeadline, deadline)
+
+	finish()
+	assert.Error(t, ctx.Err(), "finish releases the query context")
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createTaskOutboxStatement = `
+	CREATE TABLE IF NOT EXISTS task_outbox (
+		id              BIGSERIAL PRIMARY KEY,
+		task_type       TEXT NOT NULL,
+		payload         BYTEA NOT NULL,
+		process_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		last_error      TEXT NOT NULL DEFAULT '',
+		next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS task_outbox_next_attempt_idx ON task_outbox (next_attempt_at, id);
+	`
+
+// OutboxTask is a task accepted by the API and not yet handed to the queue.
+type OutboxTask struct {
+	ID       int64
+	TaskType string
+	// Payload is the encoded task envelope.
+	Payload []byte
+	// ProcessAt is when the worker should process the task.
+	ProcessAt time.Time
+	// Attempts counts the failed attempts to enqueue the task.
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// OutboxStats summarizes the tasks waiting in the outbox.
+type OutboxStats struct {
+	Pending int
+	// Retrying counts the pending tasks that failed to enqueue at least once.
+	Retrying int
+	// OldestAge is how long the oldest pending task has waited, zero when
+	// the outbox is empty.
+	OldestAge time.Duration
+}
+
+// InsertOutboxTask stores a task to be enqueued later by the relay.
+var InsertOutboxTask = func(ctx context.Context, DB *sql.DB, taskType string, payload []byte, processAt time.Time) error {
+	ctx, finish := startQuery(ctx, "InsertOutboxTask")
+	defer finish()
+
+	_, err := DB.ExecContext(ctx, `
+		INSERT INTO task_outbox (task_type, payload, process_at)
+		VALUES ($1, $2, $3)`, taskType, payload, processAt)
+	return err
+}
+
+// ClaimOutboxTasks returns up to limit tasks due for an attempt, oldest
+// first, and hides them from other relays for lease. A relay that dies
+// before deleting or rescheduling them leaves them to be claimed again once
+// the lease expires.
+var ClaimOutboxTasks = func(ctx context.Context, DB *sql.DB, limit int, lease time.Duration) ([]OutboxTask, error) {
+	ctx, finish := startQuery(ctx, "ClaimOutboxTasks")
+	defer finish()
+
+	rows, err := DB.QueryContext(ctx, `
+		UPDATE task_outbox SET next_attempt_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM task_outbox
+			WHERE next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, task_type, payload, process_at, attempts, created_at`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []OutboxTask{}
+	for rows.Next() {
+		var task OutboxTask
+		if err := rows.Scan(&task.ID, &task.TaskType, &task.Payload, &task.ProcessAt, &task.Attempts, &task.CreatedAt); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// DeleteOutboxTask removes a task once it is in the queue.
+var DeleteOutboxTask = func(ctx context.Context, DB *sql.DB, id int64) error {
+	ctx, finish := startQuery(ctx, "DeleteOutboxTask")
+	defer finish()
+
+	_, err := DB.ExecContext(ctx, `DELETE FROM task_outbox WHERE id = $1`, id)
+	return err
+}
+
+// RescheduleOutboxTask records a failed attempt to enqueue a task and makes
+// it due again after delay.
+var RescheduleOutboxTask = func(ctx context.Context, DB *sql.DB, id int64, lastError string, delay time.Duration) error {
+	ctx, finish := startQuery(ctx, "RescheduleOutboxTask")
+	defer finish()
+
+	_, err := DB.ExecContext(ctx, `
+		UPDATE task_outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond'
+		WHERE id = $1`, id, lastError, delay.Milliseconds())
+	return err
+}
+
+// GetOutboxStats counts the tasks waiting in the outbox.
+var GetOutboxStats = func(ctx context.Context, DB *sql.DB) (OutboxStats, error) {
+	ctx, finish := startQuery(ctx, "GetOutboxStats")
+	defer finish()
+
+	var stats OutboxStats
+	var oldestMS float64
+	err := DB.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE attempts > 0),
+			COALESCE(EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - MIN(created_at)) * 1000, 0)
+		FROM task_outbox`).Scan(&stats.Pending, &stats.Retrying, &oldestMS)
+	if err != nil {
+		return OutboxStats{}, err
+	}
+	stats.OldestAge = time.Duration(oldestMS) * time.Millisecond
+	return stats, nil
+}
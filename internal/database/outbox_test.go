@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertOutboxTask(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	processAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`INSERT INTO task_outbox (task_type, payload, process_at) VALUES ($1, $2, $3)`).
+		WithArgs("task:add_grade", []byte(`{"version":2}`), processAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	require.NoError(t, InsertOutboxTask(context.Background(), db, "task:add_grade", []byte(`{"version":2}`), processAt))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimOutboxTasks(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`UPDATE task_outbox SET next_attempt_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 millisecond' WHERE id IN ( SELECT id FROM task_outbox WHERE next_attempt_at <= CURRENT_TIMESTAMP ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED ) RETURNING id, task_type, payload, process_at, attempts, created_at`).
+		WithArgs(50, int64(30000)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_type", "payload", "process_at", "attempts", "created_at"}).
+			AddRow(4, "task:add_grade", []byte(`{}`), now, 2, now))
+
+	tasks, err := ClaimOutboxTasks(context.Background(), db, 50, 30*time.Second)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, int64(4), tasks[0].ID)
+	assert.Equal(t, 2, tasks[0].Attempts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRescheduleOutboxTask(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE task_outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond' WHERE id = $1`).
+		WithArgs(int64(4), "connection refused", int64(2000)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, RescheduleOutboxTask(context.Background(), db, 4, "connection refused", 2*time.Second))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetOutboxStats(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT COUNT(*), COUNT(*) FILTER (WHERE attempts > 0), COALESCE(EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - MIN(created_at)) * 1000, 0) FROM task_outbox`).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "retrying", "oldest"}).AddRow(10, 4, 1500.0))
+
+	stats, err := GetOutboxStats(context.Background(), db)
+	require.NoError(t, err)
+	assert.Equal(t, OutboxStats{Pending: 10, Retrying: 4, OldestAge: 1500 * time.Millisecond}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
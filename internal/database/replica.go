@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+)
+
+// replicaLagQuery measures how far the replica is behind. When every
+// received WAL record has been replayed the replica is caught up, even if
+// the last replayed transaction is old because the primary is idle.
+const replicaLagQuery = `SELECT CASE
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`
+
+// replayedQuery tells whether the replica has replayed the WAL up to the
+// primary position $1. Outside recovery there is nothing to replay from.
+const replayedQuery = `SELECT COALESCE(pg_last_wal_replay_lsn() >= $1::pg_lsn, false)`
+
+// OpenReplica opens the read replica connection. Unlike InitDB it doesn't
+// ping or migrate: a replica that is down at startup only means reads go to
+// the primary until it comes back.
+func OpenReplica(replicaUrl string, pool PoolConfig) (*sql.DB, error) {
+	replica, err := sql.Open("postgres", replicaUrl)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the read replica: %w", err)
+	}
+
+	pool.apply(replica)
+	return replica, nil
+}
+
+// ReplicaStatus is the outcome of the last replica check.
+type ReplicaStatus struct {
+	Configured bool
+	Healthy    bool
+	Lag        time.Duration
+	Error      error
+	CheckedAt  time.Time
+}
+
+// ReadRouter picks the connection used by read-only repository calls: the
+// replica while it answers and is within MaxLag, the primary otherwise.
+// With no replica configured every read goes to the primary.
+type ReadRouter struct {
+	Primary *sql.DB
+	Replica *sql.DB
+	MaxLag  time.Duration
+
+	mu     sync.RWMutex
+	status ReplicaStatus
+}
+
+func NewReadRouter(primary, replica *sql.DB, maxLag time.Duration) *ReadRouter {
+	return &ReadRouter{
+		Primary: primary,
+		Replica: replica,
+		MaxLag:  maxLag,
+		status:  ReplicaStatus{Configured: replica != nil},
+	}
+}
+
+// Reader returns the connection for a read-only call.
+func (r *ReadRouter) Reader() *sql.DB {
+	if r.Replica == nil {
+		return r.Primary
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.status.Healthy {
+		return r.Replica
+	}
+	return r.Primary
+}
+
+// CaughtUp reports whether the replica has replayed every transaction the
+// primary had committed when it was called, so a read from the replica
+// made afterwards sees them. Without a replica it is always true.
+func (r *ReadRouter) CaughtUp(ctx context.Context) (bool, error) {
+	if r.Replica == nil {
+		return true, nil
+	}
+
+	ctx, finish := startQuery(ctx, "ReplicaCaughtUp")
+	defer finish()
+
+	var lsn string
+	if err := r.Primary.QueryRowContext(ctx, `SELECT pg_current_wal_lsn()::text`).Scan(&lsn); err != nil {
+		return false, fmt.Errorf("error reading the primary WAL position: %w", err)
+	}
+	var caughtUp bool
+	if err := r.Replica.QueryRowContext(ctx, replayedQuery, lsn).Scan(&caughtUp); err != nil {
+		return false, fmt.Errorf("error reading the replica replay position: %w", err)
+	}
+	return caughtUp, nil
+}
+
+// Status returns the result of the last check.
+func (r *ReadRouter) Status() ReplicaStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.status
+}
+
+// Check pings the replica, measures its lag and updates the routing
+// decision. It returns the new status.
+func (r *ReadRouter) Check(ctx context.Context) ReplicaStatus {
+	if r.Replica == nil {
+		return r.Status()
+	}
+
+	status := ReplicaStatus{Configured: true, CheckedAt: time.Now()}
+
+	var lagSeconds float64
+	err := r.Replica.QueryRowContext(ctx, replicaLagQuery).Scan(&lagSeconds)
+	if err != nil {
+		status.Error = fmt.Errorf("error checking read replica: %w", err)
+	} else {
+		status.Lag = time.Duration(lagSeconds * float64(time.Second))
+		if r.MaxLag > 0 && status.Lag > r.MaxLag {
+			status.Error = fmt.Errorf("read replica is %s behind (max %s)", status.Lag, r.MaxLag)
+		}
+	}
+	status.Healthy = status.Error == nil
+
+	metrics.DBReplicaLag.Set(status.Lag.Seconds())
+	if status.Healthy {
+		metrics.DBReplicaHealthy.Set(1)
+	} else {
+		metrics.DBReplicaHealthy.Set(0)
+	}
+
+	r.mu.Lock()
+	previous := r.status
+	r.status = status
+	r.mu.Unlock()
+
+	switch {
+	case previous.Healthy && !status.Healthy:
+		slog.WarnContext(ctx, "read replica unavailable, routing reads to the primary", "error", status.Error)
+	case !previous.Healthy && status.Healthy:
+		slog.InfoContext(ctx, "routing reads to the read replica", "lag", status.Lag.String())
+	}
+
+	return status
+}
+
+// Run checks the replica every interval until ctx is done.
+func (r *ReadRouter) Run(ctx context.Context, interval time.Duration) {
+	if r.Replica == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		r.Check(checkCtx)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
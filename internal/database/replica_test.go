@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectLag(mock sqlmock.Sqlmock, seconds float64) {
+	mock.ExpectQuery(`pg_last_wal_receive_lsn`).
+		WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(seconds))
+}
+
+func TestReadRouter_WithoutReplica(t *testing.T) {
+	primary, _ := setupDB(t)
+	defer primary.Close()
+
+	router := NewReadRouter(primary, nil, time.Second)
+	router.Check(context.Background())
+
+	assert.Same(t, primary, router.Reader())
+	assert.False(t, router.Status().Configured)
+}
+
+func TestReadRouter_RoutesToHealthyReplica(t *testing.T) {
+	primary, _ := setupDB(t)
+	defer primary.Close()
+	replica, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	router := NewReadRouter(primary, replica, 10*time.Second)
+
+	// Until the first check passes, reads stay on the primary
+	assert.Same(t, primary, router.Reader())
+
+	expectLag(mock, 1.5)
+	status := router.Check(context.Background())
+
+	assert.True(t, status.Healthy)
+	assert.Equal(t, 1500*time.Millisecond, status.Lag)
+	assert.Same(t, replica, router.Reader())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReadRouter_FallsBackWhenLagging(t *testing.T) {
+	primary, _ := setupDB(t)
+	defer primary.Close()
+	replica, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	router := NewReadRouter(primary, replica, 10*time.Second)
+
+	expectLag(mock, 0)
+	router.Check(context.Background())
+	require.Same(t, replica, router.Reader())
+
+	expectLag(mock, 60)
+	status := router.Check(context.Background())
+
+	assert.False(t, status.Healthy)
+	assert.ErrorContains(t, status.Error, "behind")
+	assert.Same(t, primary, router.Reader())
+}
+
+func TestReadRouter_FallsBackWhenUnreachable(t *testing.T) {
+	primary, _ := setupDB(t)
+	defer primary.Close()
+	replica, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	router := NewReadRouter(primary, replica, 10*time.Second)
+
+	mock.ExpectQuery(`pg_last_wal_receive_lsn`).WillReturnError(errors.New("connection refused"))
+	status := router.Check(context.Background())
+	assert.False(t, status.Healthy)
+	assert.Same(t, primary, router.Reader())
+
+	// And recovers on the next successful check
+	expectLag(mock, 0)
+	router.Check(context.Background())
+	assert.Same(t, replica, router.Reader())
+}
+
+func TestReadRouter_CaughtUp(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	caughtUp, err := NewReadRouter(primary, nil, time.Second).CaughtUp(context.Background())
+	require.NoError(t, err)
+	assert.True(t, caughtUp, "the primary is always up to date")
+
+	router := NewReadRouter(primary, replica, time.Second)
+
+	primaryMock.ExpectQuery(`pg_current_wal_lsn`).WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000148"))
+	mock.ExpectQuery(`pg_last_wal_replay_lsn`).WithArgs("0/3000148").WillReturnRows(sqlmock.NewRows([]string{"replayed"}).AddRow(true))
+	caughtUp, err = router.CaughtUp(context.Background())
+	require.NoError(t, err)
+	assert.True(t, caughtUp)
+
+	primaryMock.ExpectQuery(`pg_current_wal_lsn`).WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000200"))
+	mock.ExpectQuery(`pg_last_wal_replay_lsn`).WithArgs("0/3000200").WillReturnRows(sqlmock.NewRows([]string{"replayed"}).AddRow(false))
+	caughtUp, err = router.CaughtUp(context.Background())
+	require.NoError(t, err)
+	assert.False(t, caughtUp)
+
+	primaryMock.ExpectQuery(`pg_current_wal_lsn`).WillReturnError(errors.New("connection refused"))
+	caughtUp, err = router.CaughtUp(context.Background())
+	assert.Error(t, err)
+	assert.False(t, caughtUp)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,41 @@
+package database
+
+// PeriodAverage is a period of an over-time average series.
+type PeriodAverage struct {
+	// Period is the start of the period in RFC 3339, the name of an
+	// academic term or "all_time".
+	Period string `json:"period"`
+	// AverageGrade is nil for the periods without grades of a series
+	// filled with FillNull, and before the first grade with FillPrevious.
+	AverageGrade *float64 `json:"average_grade"`
+	GradeCount   int      `json:"grade_count"`
+	// RollingAverage and CumulativeAverage are only set when the series
+	// asks for them, and stay nil while no grade is in their window.
+	RollingAverage    *float64 `json:"rolling_average,omitempty"`
+	CumulativeAverage *float64 `json:"cumulative_average,omitempty"`
+}
+
+// OnTimeStat counts the task grades of a period submitted on time.
+type OnTimeStat struct {
+	// Period is labelled like PeriodAverage.Period.
+	Period      string `json:"period"`
+	OnTimeCount int    `json:"on_time_count"`
+	TotalCount  int    `json:"total_count"`
+	// Percentage is OnTimeCount over TotalCount, from 0 to 100.
+	Percentage float64 `json:"percentage"`
+}
+
+// StudentAverage is an item of a list of students. Lists of task averages
+// set TaskCount and lists of the grades of a task set GradeCount, so the
+// JSON keeps the key each endpoint has always returned.
+type StudentAverage struct {
+	StudentID    string  `json:"student_id"`
+	AverageGrade float64 `json:"average_grade"`
+	TaskCount    int     `json:"task_count,omitempty"`
+	GradeCount   int     `json:"grade_count,omitempty"`
+}
+
+// Count is the number of grades averaged, whichever list s comes from.
+func (s StudentAverage) Count() int {
+	return s.TaskCount + s.GradeCount
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Fill modes for the periods without grades.
+const (
+	FillNull     = "null"
+	FillZero     = "zero"
+	FillPrevious = "previous"
+)
+
+// MaxSeriesPeriods bounds how many periods a gap-filled series may have.
+const MaxSeriesPeriods = 1000
+
+// seriesUnits are the units a series can be generated for, with the step
+// between periods and a lower bound of their length to estimate how many
+// periods a range has.
+var seriesUnits = map[string]struct {
+	step   string
+	length time.Duration
+}{
+	"hour":    {"1 hour", time.Hour},
+	"day":     {"1 day", 23 * time.Hour},
+	"week":    {"1 week", 7*24*time.Hour - time.Hour},
+	"month":   {"1 month", 28 * 24 * time.Hour},
+	"quarter": {"3 months", 89 * 24 * time.Hour},
+	"year":    {"1 year", 365 * 24 * time.Hour},
+}
+
+// ErrInvalidSeriesOptions wraps every problem with SeriesOptions, so
+// handlers can answer 400.
+var ErrInvalidSeriesOptions = errors.New("invalid series options")
+
+// SeriesOptions completes an over-time query with the periods that have no
+// grades and with running averages.
+type SeriesOptions struct {
+	// Fill is FillNull, FillZero or FillPrevious to return every period of
+	// the range; empty returns only the periods with grades.
+	Fill string
+	// Rolling is how many periods the moving average spans, the current one
+	// included; zero leaves it out. The window starts at startTime: the
+	// first periods average fewer than Rolling periods.
+	Rolling int
+	// Cumulative adds the average of every grade from startTime up to each
+	// period. Grades before startTime are not counted.
+	Cumulative bool
+}
+
+// enabled reports whether the query has to go through generate_series.
+func (o SeriesOptions) enabled() bool {
+	return o.Fill != "" || o.Rolling > 0 || o.Cumulative
+}
+
+// Validate reports whether o can be applied to a series grouped by grouping
+// over [startTime, endTime].
+func (o SeriesOptions) Validate(grouping TimeGrouping, startTime, endTime time.Time) error {
+	switch o.Fill {
+	case "", FillNull, FillZero, FillPrevious:
+	default:
+		return fmt.Errorf("%w: fill must be null, zero or previous", ErrInvalidSeriesOptions)
+	}
+	if o.Rolling < 0 || o.Rolling > MaxSeriesPeriods {
+		return fmt.Errorf("%w: rolling must be between 0 and %d", ErrInvalidSeriesOptions, MaxSeriesPeriods)
+	}
+	if !o.enabled() {
+		return nil
+	}
+	if startTime.IsZero() {
+		// The series would start at the first grade, maybe years of periods
+		// before the ones asked for
+		return fmt.Errorf("%w: fill, rolling and cumulative need a start_date", ErrInvalidSeriesOptions)
+	}
+
+	length := seriesUnits[grouping.Unit].length
+	if grouping.Interval != "" {
+		length = grouping.intervalLength()
+		if length >= 24*time.Hour {
+			// Intervals are binned in local time, so a day may last 23 hours
+			length -= time.Hour
+		}
+	}
+	if length == 0 {
+		return fmt.Errorf("%w: fill, rolling and cumulative need group_by hour, day, week, month, quarter, year or an interval", ErrInvalidSeriesOptions)
+	}
+	if !endTime.IsZero() && endTime.Sub(startTime)/length >= MaxSeriesPeriods {
+		return fmt.Errorf("%w: the range has more than %d periods", ErrInvalidSeriesOptions, MaxSeriesPeriods)
+	}
+	return nil
+}
+
+// wrap turns query, which returns period, average_grade and grade_count
+// bucketed by b, into one returning every period between startTime
+// and endTime (or between the first and last period with grades when they
+// are zero) along with the rolling and cumulative averages. Averages are
+// weighted by the number of grades of each period. An open end, which
+// Validate can't bound, is cut at MaxSeriesPeriods periods.
+func (o SeriesOptions) wrap(query string, args []interface{}, b bucketing, startTime, endTime time.Time) (string, []interface{}) {
+	first := "(SELECT MIN(period) FROM periods) AT TIME ZONE " + b.zone
+	if !startTime.IsZero() {
+		args = append(args, startTime)
+		first = b.localPeriod(fmt.Sprintf("$%d::timestamptz", len(args)))
+	}
+	last := "(SELECT MAX(period) FROM periods) AT TIME ZONE " + b.zone
+	if !endTime.IsZero() {
+		args = append(args, endTime)
+		last = b.localPeriod(fmt.Sprintf("$%d::timestamptz", len(args)))
+	}
+	step := b.stride
+	if step == "" {
+		args = append(args, seriesUnits[b.Unit].step)
+		step = fmt.Sprintf("$%d::interval", len(args))
+	}
+
+	weighted := "SUM(periods.average_grade * periods.grade_count) OVER %s / NULLIF(SUM(periods.grade_count) OVER %[1]s, 0)"
+	rolling, cumulative := "NULL", "NULL"
+	var windows []string
+	if o.Rolling > 0 {
+		rolling = fmt.Sprintf(weighted, "rolling")
+		windows = append(windows, fmt.Sprintf("rolling AS (ORDER BY period ROWS BETWEEN %d PRECEDING AND CURRENT ROW)", o.Rolling-1))
+	}
+	if o.Cumulative {
+		cumulative = fmt.Sprintf(weighted, "cumulative")
+		windows = append(windows, "cumulative AS (ORDER BY period ROWS UNBOUNDED PRECEDING)")
+	}
+
+	limit := ""
+	if endTime.IsZero() {
+		limit = fmt.Sprintf("\n\t\t\tLIMIT %d", MaxSeriesPeriods)
+	}
+
+	// The series is generated in local time so that days and months keep
+	// their calendar length across daylight saving changes
+	wrapped := `
+		WITH periods AS (` + query + `),
+		series AS (
+			SELECT generate_series(` + first + `, ` + last + `, ` + step + `) AT TIME ZONE ` + b.zone + ` AS period` + limit + `
+		)
+		SELECT period, periods.average_grade, COALESCE(periods.grade_count, 0) AS grade_count,
+			(` + rolling + `)::float8 AS rolling_average,
+			(` + cumulative + `)::float8 AS cumulative_average
+		FROM series LEFT JOIN periods USING (period)`
+	if len(windows) > 0 {
+		wrapped += "\n\t\tWINDOW " + strings.Join(windows, ", ")
+	}
+	return wrapped + "\n\t\tORDER BY period", args
+}
+
+// scanSeries reads the rows of a query made by wrap, filling the periods
+// without grades as o says.
+func (o SeriesOptions) scanSeries(rows *sql.Rows, b bucketing) ([]PeriodAverage, error) {
+	var results []PeriodAverage
+	var previous *float64
+	for rows.Next() {
+		var period time.Time
+		var avgGrade, rolling, cumulative sql.NullFloat64
+		var count int
+		if err := rows.Scan(&period, &avgGrade, &count, &rolling, &cumulative); err != nil {
+			return nil, err
+		}
+
+		var average *float64
+		switch {
+		case avgGrade.Valid:
+			average = &avgGrade.Float64
+			previous = average
+		case o.Fill == "":
+			// Generated only for the running averages
+			continue
+		case o.Fill == FillZero:
+			average = new(float64)
+		case o.Fill == FillPrevious:
+			average = previous
+		}
+
+		label, _ := b.label(period)
+		result := PeriodAverage{
+			Period:       label,
+			AverageGrade: average,
+			GradeCount:   count,
+		}
+		if o.Rolling > 0 {
+			result.RollingAverage = nullableFloat(rolling)
+		}
+		if o.Cumulative {
+			result.CumulativeAverage = nullableFloat(cumulative)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func nullableFloat(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
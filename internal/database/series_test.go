@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesOptions_Validate(t *testing.T) {
+	week := TimeGrouping{Unit: "week"}
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, SeriesOptions{}.Validate(TimeGrouping{}, time.Time{}, time.Time{}))
+	assert.NoError(t, SeriesOptions{Fill: FillPrevious, Rolling: 4, Cumulative: true}.Validate(week, start, end))
+	assert.NoError(t, SeriesOptions{Fill: FillZero}.Validate(TimeGrouping{Unit: "month"}, start, time.Time{}))
+
+	invalid := []struct {
+		opts     SeriesOptions
+		grouping TimeGrouping
+		start    time.Time
+	}{
+		{SeriesOptions{Fill: "linear"}, week, start},
+		{SeriesOptions{Rolling: -1}, week, start},
+		{SeriesOptions{Rolling: MaxSeriesPeriods + 1}, week, start},
+		{SeriesOptions{Fill: FillNull}, TimeGrouping{}, start},
+		{SeriesOptions{Cumulative: true}, TimeGrouping{Unit: "minute"}, start},
+		{SeriesOptions{Fill: FillNull}, TimeGrouping{Unit: "hour"}, start},
+		{SeriesOptions{Fill: FillNull}, TimeGrouping{Interval: "2 hours"}, start},
+		{SeriesOptions{Fill: FillNull}, TimeGrouping{Unit: UnitTerm, Institution: "fiuba"}, start},
+		{SeriesOptions{Rolling: 3}, week, time.Time{}},
+		{SeriesOptions{Fill: FillNull}, TimeGrouping{Unit: "day"}, end.AddDate(-3, 0, 0)},
+	}
+	for _, tc := range invalid {
+		assert.ErrorIs(t, tc.opts.Validate(tc.grouping, tc.start, end), ErrInvalidSeriesOptions, "%+v", tc)
+	}
+	assert.EqualError(t, SeriesOptions{Rolling: -1}.Validate(week, start, end), "invalid series options: rolling must be between 0 and 1000")
+}
+
+func TestGetStudentAveragesOverTime_FillAndRunningAverages(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 29, 23, 59, 59, 999999999, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WITH periods AS ( SELECT DATE_TRUNC($2, period_start AT TIME ZONE $3::text) AT TIME ZONE $3::text AS period, SUM(grade_sum) / SUM(grade_count) AS average_grade, SUM(grade_count) AS grade_count FROM student_course_daily_stats WHERE student_id = $1 AND period_start >= $4 AND period_start <= $5 GROUP BY period HAVING SUM(grade_count) > 0 ORDER BY period),
+		series AS (
+			SELECT generate_series(DATE_TRUNC($2, $6::timestamptz AT TIME ZONE $3::text), DATE_TRUNC($2, $7::timestamptz AT TIME ZONE $3::text), $8::interval) AT TIME ZONE $3::text AS period
+		)
+		SELECT period, periods.average_grade, COALESCE(periods.grade_count, 0) AS grade_count,
+			(SUM(periods.average_grade * periods.grade_count) OVER rolling / NULLIF(SUM(periods.grade_count) OVER rolling, 0))::float8 AS rolling_average,
+			(SUM(periods.average_grade * periods.grade_count) OVER cumulative / NULLIF(SUM(periods.grade_count) OVER cumulative, 0))::float8 AS cumulative_average
+		FROM series LEFT JOIN periods USING (period)
+		WINDOW rolling AS (ORDER BY period ROWS BETWEEN 1 PRECEDING AND CURRENT ROW), cumulative AS (ORDER BY period ROWS UNBOUNDED PRECEDING)
+		ORDER BY period`).
+		WithArgs("student1", "week", "UTC", start, end, start, end, "1 week").
+		WillReturnRows(sqlmock.NewRows([]string{"period", "average_grade", "grade_count", "rolling_average", "cumulative_average"}).
+			AddRow(start, nil, 0, nil, nil).
+			AddRow(start.AddDate(0, 0, 7), 8.0, 2, 8.0, 8.0).
+			AddRow(start.AddDate(0, 0, 14), nil, 0, 8.0, 8.0).
+			AddRow(start.AddDate(0, 0, 21), 5.0, 1, 5.0, 7.0))
+	mock.ExpectRollback()
+
+	series := SeriesOptions{Fill: FillPrevious, Rolling: 2, Cumulative: true}
+	results, err := GetStudentAveragesOverTime(context.Background(), db, "student1", start, end, TimeGrouping{Unit: "week"}, series)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.Nil(t, results[0].AverageGrade, "nothing to carry forward yet")
+	assert.Equal(t, 8.0, *results[1].AverageGrade)
+	assert.Equal(t, 8.0, *results[2].AverageGrade, "carried forward")
+	assert.Equal(t, 0, results[2].GradeCount)
+	assert.Equal(t, 5.0, *results[3].AverageGrade)
+	assert.Equal(t, 7.0, *results[3].CumulativeAverage)
+	assert.Nil(t, results[0].RollingAverage)
+	assert.Equal(t, "2026-03-09T00:00:00Z", results[1].Period)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCourseAveragesOverTime_FillModes(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"period", "average_grade", "grade_count", "rolling_average", "cumulative_average"}).
+			AddRow(start, 6.0, 3, nil, nil).
+			AddRow(start.AddDate(0, 1, 0), nil, 0, nil, nil)
+	}
+
+	tests := []struct {
+		fill string
+		want *float64
+	}{
+		{FillNull, nil},
+		{FillZero, float(0)},
+		{FillPrevious, float(6)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.fill, func(t *testing.T) {
+			// Regex matcher: the query itself is covered above
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			// Without an end the series stops at MaxSeriesPeriods
+			mock.ExpectQuery(`WITH periods AS .* FROM course_daily_stats .* LIMIT 1000`).WillReturnRows(rows())
+			mock.ExpectRollback()
+
+			results, err := GetCourseAveragesOverTime(context.Background(), db, "course1", start, time.Time{}, TimeGrouping{Unit: "month"}, SeriesOptions{Fill: tc.fill})
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			assert.Equal(t, tc.want, results[1].AverageGrade)
+			assert.Nil(t, results[1].RollingAverage)
+		})
+	}
+}
+
+func TestGetCourseAveragesOverTime_RollingWithoutFill(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WITH periods AS .* WINDOW rolling AS \(ORDER BY period ROWS BETWEEN 1 PRECEDING AND CURRENT ROW\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"period", "average_grade", "grade_count", "rolling_average", "cumulative_average"}).
+			AddRow(start, 6.0, 3, 6.0, nil).
+			AddRow(start.AddDate(0, 0, 7), nil, 0, 6.0, nil).
+			AddRow(start.AddDate(0, 0, 14), 9.0, 1, 9.0, nil))
+	mock.ExpectRollback()
+
+	results, err := GetCourseAveragesOverTime(context.Background(), db, "course1", start, time.Time{}, TimeGrouping{Unit: "week"}, SeriesOptions{Rolling: 2})
+	require.NoError(t, err)
+	require.Len(t, results, 2, "empty periods only count towards the window")
+	assert.Equal(t, 9.0, *results[1].RollingAverage)
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+const createAcademicTermsStatement = `
+	CREATE TABLE IF NOT EXISTS academic_terms (
+		institution_id TEXT NOT NULL,
+		name           TEXT NOT NULL,
+		starts_on      DATE NOT NULL,
+		ends_on        DATE NOT NULL,
+		PRIMARY KEY (institution_id, name),
+		UNIQUE (institution_id, starts_on),
+		CHECK (ends_on >= starts_on)
+	);
+	`
+
+// ErrInvalidAcademicTerms wraps every problem with a calendar passed to
+// ReplaceAcademicTerms, so handlers can answer 400.
+var ErrInvalidAcademicTerms = errors.New("invalid academic terms")
+
+// AcademicTerm is a named period of an institution's calendar, such as
+// "2026-1C". Both days are included.
+type AcademicTerm struct {
+	Name     string
+	StartsOn time.Time
+	EndsOn   time.Time
+}
+
+// ValidateAcademicTerms checks that terms have unique names, don't end
+// before they start and don't overlap, so every grade falls in one term at
+// most.
+func ValidateAcademicTerms(terms []AcademicTerm) error {
+	sorted := append([]AcademicTerm(nil), terms...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].StartsOn.Before(sorted[j].StartsOn) })
+
+	names := map[string]bool{}
+	for i, term := range sorted {
+		if term.Name == "" {
+			return fmt.Errorf("%w: every term needs a name", ErrInvalidAcademicTerms)
+		}
+		if names[term.Name] {
+			return fmt.Errorf("%w: term %q is repeated", ErrInvalidAcademicTerms, term.Name)
+		}
+		names[term.Name] = true
+
+		if term.EndsOn.Before(term.StartsOn) {
+			return fmt.Errorf("%w: term %q ends before it starts", ErrInvalidAcademicTerms, term.Name)
+		}
+		if i > 0 && !sorted[i-1].EndsOn.Before(term.StartsOn) {
+			return fmt.Errorf("%w: terms %q and %q overlap", ErrInvalidAcademicTerms, sorted[i-1].Name, term.Name)
+		}
+	}
+	return nil
+}
+
+// GetAcademicTerms returns the calendar of an institution, oldest term
+// first.
+var GetAcademicTerms = func(ctx context.Context, DB *sql.DB, institutionID string) ([]AcademicTerm, error) {
+	ctx, finish := startQuery(ctx, "GetAcademicTerms")
+	defer finish()
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return listAcademicTerms(ctx, tx, institutionID)
+}
+
+func listAcademicTerms(ctx context.Context, tx *sql.Tx, institutionID string) ([]AcademicTerm, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name, starts_on, ends_on FROM academic_terms WHERE institution_id = $1 ORDER BY starts_on`, institutionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms := []AcademicTerm{}
+	for rows.Next() {
+		var term AcademicTerm
+		if err := rows.Scan(&term.Name, &term.StartsOn, &term.EndsOn); err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
+
+// ReplaceAcademicTerms sets the whole calendar of an institution.
+var ReplaceAcademicTerms = func(ctx context.Context, DB *sql.DB, institutionID string, terms []AcademicTerm) error {
+	ctx, finish := startQuery(ctx, "ReplaceAcademicTerms")
+	defer finish()
+
+	if err := ValidateAcademicTerms(terms); err != nil {
+		return err
+	}
+
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM academic_terms WHERE institution_id = $1`, institutionID); err != nil {
+		return err
+	}
+	for _, term := range terms {
+		_, err := tx.ExecContext(ctx, `INSERT INTO academic_terms (institution_id, name, starts_on, ends_on) VALUES ($1, $2, $3, $4)`,
+			institutionID, term.Name, term.StartsOn.Format(dateLayout), term.EndsOn.Format(dateLayout))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
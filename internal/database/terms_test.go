@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestValidateAcademicTerms(t *testing.T) {
+	first := AcademicTerm{Name: "2026-1C", StartsOn: day(2026, 3, 9), EndsOn: day(2026, 7, 11)}
+	second := AcademicTerm{Name: "2026-2C", StartsOn: day(2026, 8, 10), EndsOn: day(2026, 12, 12)}
+
+	assert.NoError(t, ValidateAcademicTerms(nil))
+	assert.NoError(t, ValidateAcademicTerms([]AcademicTerm{second, first}), "order doesn't matter")
+	assert.NoError(t, ValidateAcademicTerms([]AcademicTerm{{Name: "summer", StartsOn: day(2026, 1, 5), EndsOn: day(2026, 1, 5)}}), "a term can last a day")
+
+	invalid := [][]AcademicTerm{
+		{{StartsOn: day(2026, 3, 9), EndsOn: day(2026, 7, 11)}},
+		{first, {Name: "2026-1C", StartsOn: day(2026, 8, 10), EndsOn: day(2026, 12, 12)}},
+		{{Name: "backwards", StartsOn: day(2026, 7, 11), EndsOn: day(2026, 3, 9)}},
+		{first, {Name: "winter", StartsOn: day(2026, 7, 11), EndsOn: day(2026, 8, 1)}},
+	}
+	for _, terms := range invalid {
+		assert.ErrorIs(t, ValidateAcademicTerms(terms), ErrInvalidAcademicTerms, "%+v", terms)
+	}
+}
+
+func TestReplaceAcademicTerms(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM academic_terms WHERE institution_id = $1`).
+		WithArgs("fiuba").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO academic_terms (institution_id, name, starts_on, ends_on) VALUES ($1, $2, $3, $4)`).
+		WithArgs("fiuba", "2026-1C", "2026-03-09", "2026-07-11").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := ReplaceAcademicTerms(context.Background(), db, "fiuba", []AcademicTerm{
+		{Name: "2026-1C", StartsOn: day(2026, 3, 9), EndsOn: day(2026, 7, 11)},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReplaceAcademicTerms_Invalid(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	err := ReplaceAcademicTerms(context.Background(), db, "fiuba", []AcademicTerm{{Name: ""}})
+	assert.ErrorIs(t, err, ErrInvalidAcademicTerms)
+	assert.NoError(t, mock.ExpectationsWereMet(), "nothing is written")
+}
+
+func TestReplaceAcademicTerms_RollsBackOnError(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM academic_terms WHERE institution_id = $1`).
+		WithArgs("fiuba").
+		WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	err := ReplaceAcademicTerms(context.Background(), db, "fiuba", nil)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAcademicTerms(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT name, starts_on, ends_on FROM academic_terms WHERE institution_id = $1 ORDER BY starts_on`).
+		WithArgs("none").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "starts_on", "ends_on"}))
+	mock.ExpectRollback()
+
+	terms, err := GetAcademicTerms(context.Background(), db, "none")
+	require.NoError(t, err)
+	assert.NotNil(t, terms, "an empty calendar renders as [] rather than null")
+	assert.Empty(t, terms)
+}
@@ -0,0 +1,256 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Statuses of a webhook delivery. A delivery stays pending while it has
+// attempts left.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+const createWebhooksStatement = `
+	CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id         BIGSERIAL PRIMARY KEY,
+		url        TEXT NOT NULL,
+		events     TEXT[] NOT NULL,
+		course_id  TEXT NOT NULL DEFAULT '',
+		threshold  DOUBLE PRECISION,
+		secret     TEXT NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id              BIGSERIAL PRIMARY KEY,
+		subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+		event_id        TEXT NOT NULL,
+		event_type      TEXT NOT NULL,
+		payload         BYTEA NOT NULL,
+		status          TEXT NOT NULL DEFAULT 'pending',
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		response_status INTEGER NOT NULL DEFAULT 0,
+		last_error      TEXT NOT NULL DEFAULT '',
+		created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, id);
+	`
+
+var ErrWebhookNotFound = errors.New("webhook subscription not found")
+
+// WebhookSubscription is a URL that receives the statistics events listed
+// in Events.
+type WebhookSubscription struct {
+	ID     int64
+	URL    string
+	Events []string
+	// CourseID limits the events to one course; empty means every course.
+	CourseID string
+	// Threshold is the average below which a student triggers
+	// student.average_below_threshold.
+	Threshold *float64
+	// Secret signs the deliveries.
+	Secret    string
+	CreatedAt time.Time
+}
+
+// WebhookDelivery is an event sent, or being sent, to a subscription.
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int64
+	EventID        string
+	EventType      string
+	// Payload is the body posted to the subscription.
+	Payload []byte
+	Status  string
+	// Attempts counts the requests made so far, and ResponseStatus and
+	// LastError describe the last one. ResponseStatus is zero when no
+	// response came back.
+	Attempts       int
+	ResponseStatus int
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+const webhookSubscriptionColumns = `id, url, events, course_id, threshold, secret, created_at`
+
+func scanWebhookSubscription(row interface{ Scan(...interface{}) error }) (*WebhookSubscription, error) {
+	var s WebhookSubscription
+	var threshold sql.NullFloat64
+	err := row.Scan(&s.ID, &s.URL, pq.Array(&s.Events), &s.CourseID, &threshold, &s.Secret, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if threshold.Valid {
+		s.Threshold = &threshold.Float64
+	}
+	return &s, nil
+}
+
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, response_status, last_error, created_at, updated_at`
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (*WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// InsertWebhookSubscription stores a subscription and returns it with its
+// id and created_at.
+var InsertWebhookSubscription = func(ctx context.Context, DB *sql.DB, s WebhookSubscription) (*WebhookSubscription, error) {
+	ctx, finish := startQuery(ctx, "InsertWebhookSubscription")
+	defer finish()
+
+	var threshold sql.NullFloat64
+	if s.Threshold != nil {
+		threshold = sql.NullFloat64{Float64: *s.Threshold, Valid: true}
+	}
+	return scanWebhookSubscription(DB.QueryRowContext(ctx, `
+		INSERT INTO webhook_subscriptions (url, events, course_id, threshold, secret)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+webhookSubscriptionColumns,
+		s.URL, pq.Array(s.Events), s.CourseID, threshold, s.Secret))
+}
+
+// ListWebhookSubscriptions returns every subscription, oldest first.
+var ListWebhookSubscriptions = func(ctx context.Context, DB *sql.DB) ([]WebhookSubscription, error) {
+	ctx, finish := startQuery(ctx, "ListWebhookSubscriptions")
+	defer finish()
+
+	rows, err := DB.QueryContext(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []WebhookSubscription{}
+	for rows.Next() {
+		s, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *s)
+	}
+	return subscriptions, rows.Err()
+}
+
+// GetWebhookSubscription returns a subscription by id, or
+// ErrWebhookNotFound.
+var GetWebhookSubscription = func(ctx context.Context, DB *sql.DB, id int64) (*WebhookSubscription, error) {
+	ctx, finish := startQuery(ctx, "GetWebhookSubscription")
+	defer finish()
+
+	s, err := scanWebhookSubscription(DB.QueryRowContext(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	return s, err
+}
+
+// DeleteWebhookSubscription removes a subscription and its delivery
+// history, or returns ErrWebhookNotFound.
+var DeleteWebhookSubscription = func(ctx context.Context, DB *sql.DB, id int64) error {
+	ctx, finish := startQuery(ctx, "DeleteWebhookSubscription")
+	defer finish()
+
+	result, err := DB.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// InsertWebhookDelivery stores a pending delivery and returns its id.
+var InsertWebhookDelivery = func(ctx context.Context, DB *sql.DB, d WebhookDelivery) (int64, error) {
+	ctx, finish := startQuery(ctx, "InsertWebhookDelivery")
+	defer finish()
+
+	var id int64
+	err := DB.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		d.SubscriptionID, d.EventID, d.EventType, d.Payload).Scan(&id)
+	return id, err
+}
+
+// GetWebhookDelivery returns a delivery by id, or sql.ErrNoRows.
+var GetWebhookDelivery = func(ctx context.Context, DB *sql.DB, id int64) (*WebhookDelivery, error) {
+	ctx, finish := startQuery(ctx, "GetWebhookDelivery")
+	defer finish()
+
+	return scanWebhookDelivery(DB.QueryRowContext(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+}
+
+// RecordWebhookAttempt counts a request made for a delivery and moves the
+// delivery to status.
+var RecordWebhookAttempt = func(ctx context.Context, DB *sql.DB, id int64, status string, responseStatus int, lastError string) error {
+	ctx, finish := startQuery(ctx, "RecordWebhookAttempt")
+	defer finish()
+
+	_, err := DB.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, response_status = $3, last_error = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		id, status, responseStatus, lastError)
+	return err
+}
+
+// FailWebhookDelivery marks as failed a delivery that can't be attempted,
+// such as one that couldn't be enqueued.
+var FailWebhookDelivery = func(ctx context.Context, DB *sql.DB, id int64, lastError string) error {
+	ctx, finish := startQuery(ctx, "FailWebhookDelivery")
+	defer finish()
+
+	_, err := DB.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET status = $2, last_error = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		id, WebhookDeliveryFailed, lastError)
+	return err
+}
+
+// ListWebhookDeliveries returns up to limit deliveries of a subscription
+// with the given status (any status when empty), newest first. beforeID
+// pages through the list like in ListDeadLetters.
+var ListWebhookDeliveries = func(ctx context.Context, DB *sql.DB, subscriptionID int64, status string, limit int, beforeID int64) ([]WebhookDelivery, error) {
+	ctx, finish := startQuery(ctx, "ListWebhookDeliveries")
+	defer finish()
+
+	rows, err := DB.QueryContext(ctx, `
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE subscription_id = $1 AND ($2 = '' OR status = $2) AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4`, subscriptionID, status, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var webhookSubscriptionRowColumns = []string{"id", "url", "events", "course_id", "threshold", "secret", "created_at"}
+
+func TestInsertWebhookSubscription(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	createdAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	threshold := 6.0
+	mock.ExpectQuery(`INSERT INTO webhook_subscriptions (url, events, course_id, threshold, secret) VALUES ($1, $2, $3, $4, $5) RETURNING id, url, events, course_id, threshold, secret, created_at`).
+		WithArgs("https://example.com/hook", pq.Array([]string{"grade.updated"}), "course1", sql.NullFloat64{Float64: 6, Valid: true}, "s3cret").
+		WillReturnRows(sqlmock.NewRows(webhookSubscriptionRowColumns).
+			AddRow(1, "https://example.com/hook", "{grade.updated}", "course1", 6.0, "s3cret", createdAt))
+
+	s, err := InsertWebhookSubscription(context.Background(), db, WebhookSubscription{
+		URL: "https://example.com/hook", Events: []string{"grade.updated"}, CourseID: "course1", Threshold: &threshold, Secret: "s3cret",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), s.ID)
+	assert.Equal(t, []string{"grade.updated"}, s.Events)
+	require.NotNil(t, s.Threshold)
+	assert.Equal(t, 6.0, *s.Threshold)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListWebhookSubscriptions(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	createdAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT id, url, events, course_id, threshold, secret, created_at FROM webhook_subscriptions ORDER BY id`).
+		WillReturnRows(sqlmock.NewRows(webhookSubscriptionRowColumns).
+			AddRow(1, "https://a.example.com", "{grade.updated,task.average_ready}", "", nil, "a", createdAt).
+			AddRow(2, "https://b.example.com", "{student.average_below_threshold}", "course1", 4.0, "b", createdAt))
+
+	subscriptions, err := ListWebhookSubscriptions(context.Background(), db)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 2)
+	assert.Equal(t, []string{"grade.updated", "task.average_ready"}, subscriptions[0].Events)
+	assert.Nil(t, subscriptions[0].Threshold)
+	assert.Equal(t, 4.0, *subscriptions[1].Threshold)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteWebhookSubscription_NotFound(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(`DELETE FROM webhook_subscriptions WHERE id = $1`).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := DeleteWebhookSubscription(context.Background(), db, 3)
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+}
+
+func TestRecordWebhookAttempt(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE webhook_deliveries SET status = $2, attempts = attempts + 1, response_status = $3, last_error = $4, updated_at = CURRENT_TIMESTAMP WHERE id = $1`).
+		WithArgs(int64(5), WebhookDeliveryPending, 500, "unexpected status 500").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, RecordWebhookAttempt(context.Background(), db, 5, WebhookDeliveryPending, 500, "unexpected status 500"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	db, mock := setupDB(t)
+	defer db.Close()
+
+	at := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT id, subscription_id, event_id, event_type, payload, status, attempts, response_status, last_error, created_at, updated_at FROM webhook_deliveries WHERE subscription_id = $1 AND ($2 = '' OR status = $2) AND ($3 = 0 OR id < $3) ORDER BY id DESC LIMIT $4`).
+		WithArgs(int64(1), "failed", int64(0), 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "event_type", "payload", "status", "attempts", "response_status", "last_error", "created_at", "updated_at"}).
+			AddRow(8, 1, "e8", "grade.updated", []byte(`{}`), "failed", 13, 500, "unexpected status 500", at, at))
+
+	deliveries, err := ListWebhookDeliveries(context.Background(), db, 1, WebhookDeliveryFailed, 50, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 13, deliveries[0].Attempts)
+	assert.Equal(t, 500, deliveries[0].ResponseStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
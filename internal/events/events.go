@@ -0,0 +1,157 @@
+// Package events ingests the domain events other ClassConnect services
+// publish. Each event is mapped to one of the task types of the queue and
+// enqueued, so a grade reported by an event is stored exactly like one
+// posted to the API. Events arrive through a Redis stream (RedisStream) or
+// signed webhooks (VerifySignature).
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+)
+
+// Event types published by the other services.
+const (
+	AssignmentGraded    = "assignment.graded"
+	AssignmentSubmitted = "assignment.submitted"
+	CourseCreated       = "course.created"
+)
+
+// unknownType labels the metrics of events without a mapper entry.
+const unknownType = "unknown"
+
+// Event is the envelope shared by every event, whatever its source.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Source     string          `json:"source"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// ErrInvalidEvent is returned for events that can never be processed, so
+// retrying them is pointless.
+var ErrInvalidEvent = errors.New("invalid event")
+
+// Decode parses an event and checks the fields every event needs.
+func Decode(data []byte) (Event, error) {
+	var e Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Event{}, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if e.ID == "" || e.Type == "" {
+		return Event{}, fmt.Errorf("%w: id and type are required", ErrInvalidEvent)
+	}
+	return e, nil
+}
+
+// Mapper turns an event into the task that stores it. A nil Mapper marks an
+// event type that is known but has nothing to store.
+type Mapper func(Event) (taskType string, payload interface{}, err error)
+
+// DefaultMappers maps the events of the other services to the existing
+// task types. Submissions and new courses don't carry a grade, so they are
+// acknowledged and dropped.
+func DefaultMappers() map[string]Mapper {
+	return map[string]Mapper{
+		AssignmentGraded:    mapAssignmentGraded,
+		AssignmentSubmitted: nil,
+		CourseCreated:       nil,
+	}
+}
+
+type assignmentGraded struct {
+	StudentID    string   `json:"student_id"`
+	CourseID     string   `json:"course_id"`
+	AssignmentID string   `json:"assignment_id"`
+	Grade        *float64 `json:"grade"`
+	OnTime       bool     `json:"on_time"`
+}
+
+func mapAssignmentGraded(e Event) (string, interface{}, error) {
+	var data assignmentGraded
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if data.StudentID == "" || data.CourseID == "" || data.AssignmentID == "" || data.Grade == nil {
+		return "", nil, fmt.Errorf("%w: student_id, course_id, assignment_id and grade are required", ErrInvalidEvent)
+	}
+	return types.TaskAddStudentGradeTask, model.GradeTask{
+		StudentID: data.StudentID,
+		CourseID:  data.CourseID,
+		TaskID:    data.AssignmentID,
+		Grade:     *data.Grade,
+		OnTime:    data.OnTime,
+	}, nil
+}
+
+// Enqueuer is the part of the task queue (or the outbox) the consumer
+// needs.
+type Enqueuer interface {
+	Enqueue(ctx context.Context, taskType string, payload interface{}) (time.Duration, error)
+}
+
+// Consumer enqueues the task each event maps to. Events may be delivered
+// more than once; that is harmless because a task grade is replaced, not
+// added, when it is stored again.
+type Consumer struct {
+	Enqueuer Enqueuer
+	Mappers  map[string]Mapper
+}
+
+func NewConsumer(enqueuer Enqueuer) *Consumer {
+	return &Consumer{Enqueuer: enqueuer, Mappers: DefaultMappers()}
+}
+
+// HandleJSON decodes an event received from source and handles it.
+func (c *Consumer) HandleJSON(ctx context.Context, source string, data []byte) error {
+	e, err := Decode(data)
+	if err != nil {
+		slog.WarnContext(ctx, "invalid event", "source", source, "error", err)
+		metrics.ObserveEvent(source, unknownType, metrics.EventInvalid)
+		return err
+	}
+	return c.Handle(ctx, source, e)
+}
+
+// Handle processes one event received from source. Events without a mapper
+// are skipped. It returns an ErrInvalidEvent error for events that must not
+// be retried; any other error is worth delivering the event again.
+func (c *Consumer) Handle(ctx context.Context, source string, e Event) error {
+	mapper, known := c.Mappers[e.Type]
+	eventType := e.Type
+	if !known {
+		// Keeps the metric labels bounded
+		eventType = unknownType
+	}
+	if mapper == nil {
+		slog.DebugContext(ctx, "event ignored", "source", source, "event_id", e.ID, "event_type", e.Type)
+		metrics.ObserveEvent(source, eventType, metrics.EventIgnored)
+		return nil
+	}
+
+	taskType, payload, err := mapper(e)
+	if err != nil {
+		slog.WarnContext(ctx, "invalid event", "source", source, "event_id", e.ID, "event_type", e.Type, "error", err)
+		metrics.ObserveEvent(source, eventType, metrics.EventInvalid)
+		return err
+	}
+
+	if _, err := c.Enqueuer.Enqueue(ctx, taskType, payload); err != nil {
+		slog.ErrorContext(ctx, "failed to enqueue event", "source", source, "event_id", e.ID, "event_type", e.Type, "error", err)
+		metrics.ObserveEvent(source, eventType, metrics.OutcomeFailure)
+		return err
+	}
+
+	slog.InfoContext(ctx, "event enqueued", "source", source, "event_id", e.ID, "event_type", e.Type, "task_type", taskType)
+	metrics.ObserveEvent(source, eventType, metrics.EventEnqueued)
+	return nil
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type enqueued struct {
+	taskType string
+	payload  interface{}
+}
+
+type fakeEnqueuer struct {
+	tasks []enqueued
+	err   error
+}
+
+func (f *fakeEnqueuer) Enqueue(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.tasks = append(f.tasks, enqueued{taskType, payload})
+	return time.Minute, nil
+}
+
+const gradedEvent = `{"id":"evt-1","type":"assignment.graded","source":"assignments","occurred_at":"2026-03-02T10:00:00Z",
+	"data":{"student_id":"s1","course_id":"c1","assignment_id":"a1","grade":0,"on_time":true}}`
+
+func TestDecode(t *testing.T) {
+	e, err := Decode([]byte(gradedEvent))
+	require.NoError(t, err)
+	assert.Equal(t, "evt-1", e.ID)
+	assert.Equal(t, AssignmentGraded, e.Type)
+	assert.Equal(t, time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), e.OccurredAt)
+
+	_, err = Decode([]byte(`{"type":"assignment.graded"}`))
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	_, err = Decode([]byte(`not json`))
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+}
+
+func TestConsumer_AssignmentGraded(t *testing.T) {
+	enqueuer := &fakeEnqueuer{}
+	before := testutil.ToFloat64(metrics.EventsConsumedTotal.WithLabelValues("test", AssignmentGraded, metrics.EventEnqueued))
+
+	require.NoError(t, NewConsumer(enqueuer).HandleJSON(context.Background(), "test", []byte(gradedEvent)))
+
+	require.Len(t, enqueuer.tasks, 1)
+	assert.Equal(t, types.TaskAddStudentGradeTask, enqueuer.tasks[0].taskType)
+	// A zero grade is a grade, not a missing one
+	assert.Equal(t, model.GradeTask{StudentID: "s1", CourseID: "c1", TaskID: "a1", Grade: 0, OnTime: true}, enqueuer.tasks[0].payload)
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.EventsConsumedTotal.WithLabelValues("test", AssignmentGraded, metrics.EventEnqueued)))
+}
+
+func TestConsumer_IgnoredEvents(t *testing.T) {
+	enqueuer := &fakeEnqueuer{}
+	consumer := NewConsumer(enqueuer)
+	before := testutil.ToFloat64(metrics.EventsConsumedTotal.WithLabelValues("test", unknownType, metrics.EventIgnored))
+
+	for _, eventType := range []string{AssignmentSubmitted, CourseCreated, "course.archived"} {
+		require.NoError(t, consumer.Handle(context.Background(), "test", Event{ID: "evt-2", Type: eventType}))
+	}
+	assert.Empty(t, enqueuer.tasks)
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.EventsConsumedTotal.WithLabelValues("test", unknownType, metrics.EventIgnored)))
+}
+
+func TestConsumer_InvalidData(t *testing.T) {
+	enqueuer := &fakeEnqueuer{}
+	err := NewConsumer(enqueuer).Handle(context.Background(), "test", Event{
+		ID:   "evt-3",
+		Type: AssignmentGraded,
+		Data: []byte(`{"student_id":"s1","course_id":"c1","assignment_id":"a1"}`),
+	})
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	assert.Empty(t, enqueuer.tasks)
+}
+
+func TestConsumer_EnqueueFailure(t *testing.T) {
+	err := NewConsumer(&fakeEnqueuer{err: errors.New("redis down")}).HandleJSON(context.Background(), "test", []byte(gradedEvent))
+	assert.EqualError(t, err, "redis down")
+	assert.NotErrorIs(t, err, ErrInvalidEvent)
+}
+
+func TestConsumer_CustomMapper(t *testing.T) {
+	enqueuer := &fakeEnqueuer{}
+	consumer := NewConsumer(enqueuer)
+	consumer.Mappers[CourseCreated] = func(e Event) (string, interface{}, error) {
+		return types.TaskAddStudentGrade, model.Grade{StudentID: "s1", CourseID: e.ID}, nil
+	}
+
+	require.NoError(t, consumer.Handle(context.Background(), "test", Event{ID: "c9", Type: CourseCreated}))
+	require.Len(t, enqueuer.tasks, 1)
+	assert.Equal(t, types.TaskAddStudentGrade, enqueuer.tasks[0].taskType)
+}
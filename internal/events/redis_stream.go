@@ -0,0 +1,162 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SourceRedisStream labels the events read from a Redis stream.
+const SourceRedisStream = "redis_stream"
+
+// streamField is the field of a stream entry holding the event JSON.
+const streamField = "event"
+
+// RedisStream reads events from a Redis stream as a member of a consumer
+// group, so the API replicas share the events between them. An entry is
+// acknowledged once its task is enqueued; entries left pending by a failure
+// or a replica that died are claimed again after ClaimIdle.
+type RedisStream struct {
+	client redis.UniversalClient
+
+	Stream string
+	Group  string
+	// Consumer names this replica within the group.
+	Consumer string
+	// Count is how many entries are read at a time.
+	Count int64
+	// Block is how long a read waits for new entries.
+	Block time.Duration
+	// ClaimIdle is how long an entry stays pending before another attempt.
+	ClaimIdle time.Duration
+}
+
+// NewRedisStream reads stream through client. The client is shared, so
+// closing it is left to the caller.
+func NewRedisStream(client redis.UniversalClient, stream, group, consumer string, claimIdle time.Duration) *RedisStream {
+	return &RedisStream{
+		client:    client,
+		Stream:    stream,
+		Group:     group,
+		Consumer:  consumer,
+		Count:     100,
+		Block:     5 * time.Second,
+		ClaimIdle: claimIdle,
+	}
+}
+
+// Run hands the entries of the stream to consumer until ctx is done. A
+// batch in progress when ctx is done is finished and acknowledged.
+func (s *RedisStream) Run(ctx context.Context, consumer *Consumer) {
+	for ctx.Err() == nil {
+		if err := s.createGroup(ctx); err != nil {
+			slog.ErrorContext(ctx, "failed to create the event stream group", "stream", s.Stream, "group", s.Group, "error", err)
+			s.wait(ctx)
+			continue
+		}
+		break
+	}
+
+	for ctx.Err() == nil {
+		if err := s.poll(ctx, consumer); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to read the event stream", "stream", s.Stream, "error", err)
+			s.wait(ctx)
+		}
+	}
+}
+
+// createGroup creates the group at the end of the stream, so the events
+// published before the first start are not replayed.
+func (s *RedisStream) createGroup(ctx context.Context) error {
+	err := s.client.XGroupCreateMkStream(ctx, s.Stream, s.Group, "$").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// poll processes the entries abandoned by earlier attempts, then waits for
+// new ones.
+func (s *RedisStream) poll(ctx context.Context, consumer *Consumer) error {
+	claimed, _, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   s.Stream,
+		Group:    s.Group,
+		Consumer: s.Consumer,
+		MinIdle:  s.ClaimIdle,
+		Start:    "0-0",
+		Count:    s.Count,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	if err := s.process(ctx, consumer, claimed); err != nil {
+		return err
+	}
+
+	streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    s.Group,
+		Consumer: s.Consumer,
+		Streams:  []string{s.Stream, ">"},
+		Count:    s.Count,
+		Block:    s.Block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, stream := range streams {
+		if err := s.process(ctx, consumer, stream.Messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *RedisStream) process(ctx context.Context, consumer *Consumer, messages []redis.XMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	ack := handleMessages(ctx, consumer, messages)
+	if len(ack) == 0 {
+		return nil
+	}
+	return s.client.XAck(ctx, s.Stream, s.Group, ack...).Err()
+}
+
+// handleMessages hands each entry to consumer and returns the IDs of the
+// ones done with: the enqueued, the skipped and the invalid ones. The rest
+// stay pending for another attempt.
+func handleMessages(ctx context.Context, consumer *Consumer, messages []redis.XMessage) []string {
+	var ack []string
+	for _, message := range messages {
+		err := handleMessage(ctx, consumer, message)
+		if err == nil || errors.Is(err, ErrInvalidEvent) {
+			ack = append(ack, message.ID)
+		}
+	}
+	return ack
+}
+
+func handleMessage(ctx context.Context, consumer *Consumer, message redis.XMessage) error {
+	raw, ok := message.Values[streamField].(string)
+	if !ok {
+		slog.WarnContext(ctx, "stream entry without an event", "entry_id", message.ID)
+		return fmt.Errorf("%w: entry %s has no %q field", ErrInvalidEvent, message.ID, streamField)
+	}
+	return consumer.HandleJSON(ctx, SourceRedisStream, []byte(raw))
+}
+
+func (s *RedisStream) wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+	}
+}
@@ -0,0 +1,34 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleMessages(t *testing.T) {
+	messages := []redis.XMessage{
+		{ID: "1-0", Values: map[string]interface{}{"event": gradedEvent}},
+		{ID: "2-0", Values: map[string]interface{}{"event": `{"id":"evt-2","type":"course.created"}`}},
+		{ID: "3-0", Values: map[string]interface{}{"event": `not json`}},
+		{ID: "4-0", Values: map[string]interface{}{"payload": gradedEvent}},
+	}
+
+	enqueuer := &fakeEnqueuer{}
+	ack := handleMessages(context.Background(), NewConsumer(enqueuer), messages)
+	assert.Equal(t, []string{"1-0", "2-0", "3-0", "4-0"}, ack, "invalid entries are acknowledged too")
+	assert.Len(t, enqueuer.tasks, 1)
+}
+
+func TestHandleMessages_LeavesFailuresPending(t *testing.T) {
+	messages := []redis.XMessage{
+		{ID: "1-0", Values: map[string]interface{}{"event": gradedEvent}},
+		{ID: "2-0", Values: map[string]interface{}{"event": `{"id":"evt-2","type":"course.created"}`}},
+	}
+
+	ack := handleMessages(context.Background(), NewConsumer(&fakeEnqueuer{err: errors.New("redis down")}), messages)
+	assert.Equal(t, []string{"2-0"}, ack)
+}
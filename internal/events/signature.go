@@ -0,0 +1,63 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SourceWebhook labels the events received by webhook.
+const SourceWebhook = "webhook"
+
+// Headers of a webhook delivery.
+const (
+	TimestampHeader = "X-Event-Timestamp"
+	SignatureHeader = "X-Event-Signature"
+)
+
+// signaturePrefix names the algorithm in SignatureHeader.
+const signaturePrefix = "sha256="
+
+var (
+	ErrMissingSignature = errors.New("missing signature")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrStaleSignature   = errors.New("timestamp outside the tolerance")
+)
+
+// Sign returns the SignatureHeader value for body sent at timestamp (unix
+// seconds): the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks the headers of a webhook delivery against body.
+// The timestamp is part of the signature and must be within tolerance of
+// now, so a captured delivery can't be replayed later.
+func VerifySignature(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	if timestamp == "" || signature == "" {
+		return ErrMissingSignature
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if skew := now.Sub(time.Unix(seconds, 0)).Abs(); skew > tolerance {
+		return ErrStaleSignature
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package events
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"id":"evt-1"}`)
+	signature := Sign("s3cret", timestamp, body)
+
+	assert.NoError(t, VerifySignature("s3cret", timestamp, signature, body, time.Minute, now.Add(30*time.Second)))
+
+	assert.ErrorIs(t, VerifySignature("s3cret", "", signature, body, time.Minute, now), ErrMissingSignature)
+	assert.ErrorIs(t, VerifySignature("s3cret", timestamp, "", body, time.Minute, now), ErrMissingSignature)
+	assert.ErrorIs(t, VerifySignature("other", timestamp, signature, body, time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature("s3cret", timestamp, signature, []byte(`{"id":"evt-2"}`), time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature("s3cret", timestamp, signature[len(signaturePrefix):], body, time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature("s3cret", timestamp, signature, body, time.Minute, now.Add(2*time.Minute)), ErrStaleSignature)
+}
@@ -0,0 +1,93 @@
+// Package graph serves the statistics over GraphQL, for clients that would
+// otherwise compose several REST calls and drop most of what they get. The
+// resolvers read through the database functions the REST handlers use, and
+// batch the per-item reads of a list with request-scoped loaders.
+package graph
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+//go:embed schema.graphql
+var sdl string
+
+// Request is the body of a GraphQL request.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Schema runs the queries of the statistics schema within its Limits.
+type Schema struct {
+	schema *graphql.Schema
+	limits Limits
+}
+
+// New parses the schema. It only fails if the SDL and the resolvers
+// disagree.
+func New(limits Limits) (*Schema, error) {
+	schema, err := graphql.ParseSchema(sdl, &rootResolver{},
+		graphql.UseStringDescriptions(),
+		graphql.MaxParallelism(maxBatch),
+		graphql.MaxDepth(limits.MaxDepth),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the graphql schema: %w", err)
+	}
+	return &Schema{schema: schema, limits: limits}, nil
+}
+
+// Exec runs req against db. Invalid queries and queries over the limits
+// are answered with errors and no data, without reaching the database.
+func (s *Schema) Exec(ctx context.Context, db *sql.DB, req Request) *graphql.Response {
+	if errs := s.check(req); len(errs) > 0 {
+		return &graphql.Response{Errors: errs}
+	}
+	ctx = withLoaders(ctx, newLoaders(ctx, db))
+	return s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
+
+// check validates req, depth included, and measures its complexity.
+func (s *Schema) check(req Request) []*errors.QueryError {
+	if errs := s.schema.ValidateWithVariables(req.Query, req.Variables); len(errs) > 0 {
+		for _, err := range errs {
+			if err.Rule == "MaxDepthExceeded" {
+				err.Extensions = map[string]interface{}{"code": err.Rule}
+			}
+		}
+		return errs
+	}
+
+	q, errs := s.parse(req)
+	if len(errs) > 0 {
+		return errs
+	}
+	return s.limits.check(q)
+}
+
+// parse reads the operation req runs.
+func (s *Schema) parse(req Request) (query, []*errors.QueryError) {
+	doc, err := parseQuery(req.Query)
+	if err != nil {
+		return query{}, []*errors.QueryError{errors.Errorf("%s", err)}
+	}
+
+	op := doc.Operations.Get(req.OperationName)
+	if op == nil && req.OperationName == "" {
+		if len(doc.Operations) != 1 {
+			return query{}, []*errors.QueryError{errors.Errorf("operationName is required when the query has several operations")}
+		}
+		op = doc.Operations[0]
+	}
+	if op == nil {
+		return query{}, []*errors.QueryError{errors.Errorf("no operation named %q", req.OperationName)}
+	}
+	return query{schema: s.schema.ASTSchema(), doc: doc, op: op, variables: req.Variables}, nil
+}
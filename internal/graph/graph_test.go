@@ -0,0 +1,223 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSchema(t *testing.T, limits Limits) *Schema {
+	schema, err := New(limits)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestExec_BatchesTheStudentsOfAPage(t *testing.T) {
+	getOthers, getStats, getGrades := database.GetOtherStudentsCourseAverages, database.GetStudentCourseStatsBatch, database.GetGradesBatch
+	t.Cleanup(func() {
+		database.GetOtherStudentsCourseAverages, database.GetStudentCourseStatsBatch, database.GetGradesBatch = getOthers, getStats, getGrades
+	})
+
+	database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts database.ListOptions) (*database.Page, error) {
+		assert.Equal(t, "", studentID)
+		assert.Equal(t, 2, opts.Limit)
+		assert.Equal(t, database.SortStudentID, opts.Sort)
+		return &database.Page{
+			Items: []database.StudentAverage{
+				{StudentID: "s1", AverageGrade: 8.0, TaskCount: 2},
+				{StudentID: "s2", AverageGrade: 6.0, TaskCount: 1},
+			},
+			Total:      3,
+			NextCursor: "next",
+		}, nil
+	}
+
+	var mu sync.Mutex
+	var statsCalls, gradesCalls [][]database.StudentCourse
+	database.GetStudentCourseStatsBatch = func(ctx context.Context, DB *sql.DB, keys []database.StudentCourse) (map[database.StudentCourse]database.StudentCourseStats, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		statsCalls = append(statsCalls, keys)
+		return map[database.StudentCourse]database.StudentCourseStats{
+			{StudentID: "s1", CourseID: "c1"}: {GradeSum: 9, GradeCount: 1, TaskGradeSum: 16, TaskCount: 2, TaskOnTimeCount: 1},
+		}, nil
+	}
+	database.GetGradesBatch = func(ctx context.Context, DB *sql.DB, keys []database.StudentCourse) (map[database.StudentCourse][]model.GradeTask, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		gradesCalls = append(gradesCalls, keys)
+		return map[database.StudentCourse][]model.GradeTask{
+			{StudentID: "s2", CourseID: "c1"}: {{StudentID: "s2", CourseID: "c1", TaskID: "t1", Grade: 6, CreatedAt: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)}},
+		}, nil
+	}
+
+	resp := newTestSchema(t, Limits{}).Exec(context.Background(), nil, Request{
+		Query: `query($first: Int!) {
+			course(id: "c1") {
+				students(first: $first, sort: STUDENT_ID) {
+					total
+					nextCursor
+					items {
+						average
+						student { id }
+						enrollment { gradeAverage taskAverage onTimePercentage grades { grade task { id } } }
+					}
+				}
+			}
+		}`,
+		Variables: map[string]interface{}{"first": 2},
+	})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"course": {"students": {"total": 3, "nextCursor": "next", "items": [
+		{"average": 8, "student": {"id": "s1"}, "enrollment": {"gradeAverage": 9, "taskAverage": 8, "onTimePercentage": 50, "grades": []}},
+		{"average": 6, "student": {"id": "s2"}, "enrollment": {"gradeAverage": null, "taskAverage": null, "onTimePercentage": null, "grades": [{"grade": 6, "task": {"id": "t1"}}]}}
+	]}}}`, string(resp.Data))
+
+	// One query per loader for the whole page
+	want := []database.StudentCourse{{StudentID: "s1", CourseID: "c1"}, {StudentID: "s2", CourseID: "c1"}}
+	for _, calls := range [][][]database.StudentCourse{statsCalls, gradesCalls} {
+		require.Len(t, calls, 1)
+		sort.Slice(calls[0], func(i, j int) bool { return calls[0][i].StudentID < calls[0][j].StudentID })
+		assert.Equal(t, want, calls[0])
+	}
+}
+
+func TestExec_ReportsResolverErrors(t *testing.T) {
+	resp := newTestSchema(t, Limits{}).Exec(context.Background(), nil, Request{
+		Query:     `query($size: Float) { course(id: "c1") { distribution(bucketSize: $size) { count } } }`,
+		Variables: map[string]interface{}{"size": 0},
+	})
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "invalid bucket size")
+}
+
+func TestExec_RejectsInvalidQueries(t *testing.T) {
+	resp := newTestSchema(t, Limits{}).Exec(context.Background(), nil, Request{Query: `{ course(id: "c1") { nope } }`})
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "nope")
+	assert.Nil(t, resp.Data)
+}
+
+func TestExec_Limits(t *testing.T) {
+	schema := newTestSchema(t, Limits{MaxDepth: 6, MaxComplexity: 100})
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"within the limits", `{ course(id: "c1") { id task(id: "t1") { id course { id } } } }`, ""},
+		{"too deep", `{ course(id: "c1") { task(id: "t1") { course { task(id: "t2") { course { task(id: "t3") { id } } } } } } }`, "MaxDepthExceeded"},
+		{"too deep through a fragment", `{ course(id: "c1") { ...nested } } fragment nested on Course { task(id: "t1") { course { task(id: "t2") { course { task(id: "t3") { id } } } } } }`, "MaxDepthExceeded"},
+		// 2 + 50 * (items, average, student, id)
+		{"too complex", `{ course(id: "c1") { students { items { average student { id } } } } }`, "MaxComplexityExceeded"},
+		// 2 + 20 * 4
+		{"paged within the limit", `{ course(id: "c1") { students(first: 20) { items { average student { id } } } } }`, ""},
+		// 3 + 10 * (course, students, total)
+		{"nested lists", `{ student(id: "s1") { course(id: "c1") { grades { course { students(first: 1) { total } } } } } }`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := schema.check(Request{Query: tt.query})
+			if tt.code == "" {
+				assert.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, 1)
+			assert.Equal(t, tt.code, errs[0].Extensions["code"])
+		})
+	}
+}
+
+func TestExec_LimitsReadVariables(t *testing.T) {
+	schema := newTestSchema(t, Limits{MaxDepth: 6, MaxComplexity: 100})
+	query := `
+		# 2 + first * 4
+		query Students($first: Int = 50) {
+			course(id: "c1") { students(first: $first) { items { average, student { id } } } }
+		}`
+
+	assert.Empty(t, schema.check(Request{Query: query, Variables: map[string]interface{}{"first": float64(20)}}))
+	errs := schema.check(Request{Query: query})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "MaxComplexityExceeded", errs[0].Extensions["code"])
+}
+
+func TestExec_IntrospectionIsNotCounted(t *testing.T) {
+	resp := newTestSchema(t, Limits{MaxDepth: 8, MaxComplexity: 5}).Exec(context.Background(), nil, Request{
+		Query: `{ __schema { types { name fields { name type { name ofType { name ofType { name ofType { name } } } } } } } }`,
+	})
+	require.Empty(t, resp.Errors)
+
+	var data struct {
+		Schema struct {
+			Types []struct{ Name string } `json:"types"`
+		} `json:"__schema"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	assert.NotEmpty(t, data.Schema.Types)
+}
+
+func TestLoader_BatchesAndCaches(t *testing.T) {
+	var mu sync.Mutex
+	var calls [][]string
+	l := newLoader(context.Background(), func(ctx context.Context, keys []string) (map[string]int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, keys)
+		values := map[string]int{}
+		for _, key := range keys {
+			values[key] = len(key)
+		}
+		return values, nil
+	})
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "bb", "a", "ccc"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			value, err := l.Load(context.Background(), key)
+			assert.NoError(t, err)
+			assert.Equal(t, len(key), value)
+		}(key)
+	}
+	wg.Wait()
+
+	value, err := l.Load(context.Background(), "bb")
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+
+	require.Len(t, calls, 1)
+	assert.ElementsMatch(t, []string{"a", "bb", "ccc"}, calls[0])
+}
+
+func TestLoader_FetchesWithTheRequestContext(t *testing.T) {
+	l := newLoader(context.Background(), func(ctx context.Context, keys []string) (map[string]int, error) {
+		time.Sleep(10 * time.Millisecond)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return map[string]int{"a": 1, "b": 2}, nil
+	})
+
+	// The caller that starts the batch gives up before it is fetched
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := l.Load(ctx, "a")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	value, err := l.Load(context.Background(), "b")
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+}
@@ -0,0 +1,183 @@
+package graph
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"text/scanner"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// defaultListSize is how many items the complexity counts for a list
+// without a first argument, such as a series or the grades of a student.
+const defaultListSize = 10
+
+// Limits bound the queries a client may send. Zero disables a limit.
+type Limits struct {
+	// MaxDepth bounds how many fields deep a selection may nest. It is
+	// checked by graphql-go when validating, introspection fields included.
+	MaxDepth int
+	// MaxComplexity bounds the estimated number of fields a query
+	// resolves: every field counts one, times the size of the lists it is
+	// in, which is their first argument or defaultListSize. Introspection
+	// fields are not counted: they never reach the database.
+	MaxComplexity int
+}
+
+// query is an operation to measure, with what it needs to resolve its
+// fragments, fields and variables.
+type query struct {
+	schema    *types.Schema
+	doc       *types.ExecutableDefinition
+	op        *types.OperationDefinition
+	variables map[string]interface{}
+}
+
+func (l Limits) check(q query) []*errors.QueryError {
+	root := q.schema.EntryPoints[string(q.op.Type)]
+	if complexity := q.complexity(q.op.Selections, root, 1, false); l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return []*errors.QueryError{limitError("MaxComplexityExceeded", "query complexity %d exceeds the limit of %d", complexity, l.MaxComplexity)}
+	}
+	return nil
+}
+
+func limitError(code string, format string, args ...interface{}) *errors.QueryError {
+	err := errors.Errorf(format, args...)
+	err.Extensions = map[string]interface{}{"code": code}
+	return err
+}
+
+// fields calls visit with every field of set, selected on parent, and its
+// definition, looking into fragments.
+func (q query) fields(set types.SelectionSet, parent types.NamedType, visit func(*types.Field, *types.FieldDefinition)) {
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *types.Field:
+			if strings.HasPrefix(selection.Name.Name, "__") {
+				continue
+			}
+			if definition := fieldsOf(parent).Get(selection.Name.Name); definition != nil {
+				visit(selection, definition)
+			}
+		case *types.InlineFragment:
+			on := parent
+			if selection.On.Name != "" {
+				on = q.schema.Types[selection.On.Name]
+			}
+			q.fields(selection.Selections, on, visit)
+		case *types.FragmentSpread:
+			if fragment := q.doc.Fragments.Get(selection.Name.Name); fragment != nil {
+				q.fields(fragment.Selections, q.schema.Types[fragment.On.Name], visit)
+			}
+		}
+	}
+}
+
+// complexity adds up the fields of set, each resolved multiplier times.
+// paged is set within a field with a first argument, whose lists are
+// already counted by it.
+func (q query) complexity(set types.SelectionSet, parent types.NamedType, multiplier int, paged bool) int {
+	total := 0
+	q.fields(set, parent, func(field *types.Field, definition *types.FieldDefinition) {
+		total += multiplier
+
+		inner, innerPaged := multiplier, false
+		if first, ok := q.firstArgument(field, definition); ok {
+			inner, innerPaged = multiplier*first, true
+		} else if isList(definition.Type) && !paged {
+			inner = multiplier * defaultListSize
+		}
+		total += q.complexity(field.SelectionSet, namedType(definition.Type), inner, innerPaged)
+	})
+	return total
+}
+
+// firstArgument returns the first argument of field, as sent or
+// defaulted, or the largest page when it can't be read.
+func (q query) firstArgument(field *types.Field, definition *types.FieldDefinition) (int, bool) {
+	argument := definition.Arguments.Get("first")
+	if argument == nil {
+		return 0, false
+	}
+
+	values := []types.Value{argument.Default}
+	if value, ok := field.Arguments.Get("first"); ok {
+		values = append([]types.Value{value}, values...)
+	}
+	for _, value := range values {
+		if first, ok := q.intValue(value); ok {
+			// Out of range values are rejected by the resolver anyway
+			return max(first, 1), true
+		}
+	}
+	return database.MaxListLimit, true
+}
+
+func (q query) intValue(value types.Value) (int, bool) {
+	switch value := value.(type) {
+	case *types.PrimitiveValue:
+		if value.Type != scanner.Int {
+			return 0, false
+		}
+		n, err := strconv.Atoi(value.Text)
+		return n, err == nil
+	case *types.Variable:
+		raw, ok := q.variables[value.Name]
+		if !ok {
+			if variable := q.op.Vars.Get(value.Name); variable != nil {
+				return q.intValue(variable.Default)
+			}
+			return 0, false
+		}
+		switch raw := raw.(type) {
+		case int:
+			return raw, true
+		case int32:
+			return int(raw), true
+		case int64:
+			return int(raw), true
+		case float64:
+			return int(raw), true
+		case json.Number:
+			n, err := raw.Int64()
+			return int(n), err == nil
+		}
+	}
+	return 0, false
+}
+
+func fieldsOf(t types.NamedType) types.FieldsDefinition {
+	switch t := t.(type) {
+	case *types.ObjectTypeDefinition:
+		return t.Fields
+	case *types.InterfaceTypeDefinition:
+		return t.Fields
+	}
+	return nil
+}
+
+func isList(t types.Type) bool {
+	if nonNull, ok := t.(*types.NonNull); ok {
+		t = nonNull.OfType
+	}
+	_, ok := t.(*types.List)
+	return ok
+}
+
+func namedType(t types.Type) types.NamedType {
+	for {
+		switch wrapper := t.(type) {
+		case *types.NonNull:
+			t = wrapper.OfType
+		case *types.List:
+			t = wrapper.OfType
+		default:
+			named, _ := t.(types.NamedType)
+			return named
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+)
+
+const (
+	// batchWait is how long a loader waits for more keys before fetching.
+	// The resolvers of a list run concurrently, so they all ask within it.
+	batchWait = 2 * time.Millisecond
+	// maxBatch is the most keys fetched at once, and how many resolvers
+	// the schema runs in parallel.
+	maxBatch = 100
+)
+
+// loader is a DataLoader: it fetches the keys asked for within batchWait
+// of each other with a single call and keeps the results for the rest of
+// the request, so a list of N items costs one query instead of N.
+type loader[K comparable, V any] struct {
+	// ctx is the context of the request, which the batches are fetched
+	// with: a batch serves several callers, so it can't be bound to the
+	// one that happened to start it.
+	ctx   context.Context
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	results map[K]*result[V]
+	batch   *batch[K]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable] struct {
+	keys       []K
+	dispatched bool
+}
+
+func newLoader[K comparable, V any](ctx context.Context, fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{ctx: ctx, fetch: fetch, results: map[K]*result[V]{}}
+}
+
+// Load returns the value of key, the zero value when the fetch left it
+// out. ctx only bounds the wait of this caller.
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	r, ok := l.results[key]
+	var full *batch[K]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.results[key] = r
+		if l.batch == nil {
+			b := &batch[K]{}
+			l.batch = b
+			time.AfterFunc(batchWait, func() { l.dispatch(b) })
+		}
+		l.batch.keys = append(l.batch.keys, key)
+		if len(l.batch.keys) >= maxBatch {
+			full = l.batch
+		}
+	}
+	l.mu.Unlock()
+
+	if full != nil {
+		l.dispatch(full)
+	}
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// dispatch fetches the keys of b, once.
+func (l *loader[K, V]) dispatch(b *batch[K]) {
+	l.mu.Lock()
+	if b.dispatched {
+		l.mu.Unlock()
+		return
+	}
+	b.dispatched = true
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	values, err := l.fetch(l.ctx, b.keys)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range b.keys {
+		r := l.results[key]
+		r.value, r.err = values[key], err
+		close(r.done)
+	}
+}
+
+// loaders are the loaders of one request, all reading from the same
+// connection with the context of the request.
+type loaders struct {
+	db          *sql.DB
+	courses     *loader[string, database.CourseStats]
+	enrollments *loader[database.StudentCourse, database.StudentCourseStats]
+	tasks       *loader[database.CourseTask, database.TaskStats]
+	grades      *loader[database.StudentCourse, []model.GradeTask]
+}
+
+func newLoaders(ctx context.Context, db *sql.DB) *loaders {
+	return &loaders{
+		db: db,
+		courses: newLoader(ctx, func(ctx context.Context, ids []string) (map[string]database.CourseStats, error) {
+			return database.GetCourseStatsBatch(ctx, db, ids)
+		}),
+		enrollments: newLoader(ctx, func(ctx context.Context, keys []database.StudentCourse) (map[database.StudentCourse]database.StudentCourseStats, error) {
+			return database.GetStudentCourseStatsBatch(ctx, db, keys)
+		}),
+		tasks: newLoader(ctx, func(ctx context.Context, keys []database.CourseTask) (map[database.CourseTask]database.TaskStats, error) {
+			return database.GetTaskStatsBatch(ctx, db, keys)
+		}),
+		grades: newLoader(ctx, func(ctx context.Context, keys []database.StudentCourse) (map[database.StudentCourse][]model.GradeTask, error) {
+			return database.GetGradesBatch(ctx, db, keys)
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+// loadersFrom returns the loaders Exec put in ctx.
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
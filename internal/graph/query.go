@@ -0,0 +1,264 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+	"text/scanner"
+
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// parseQuery reads a query into the AST of graphql-go, which keeps its
+// query parser internal and only exposes the AST. The query must have
+// passed validation already: the parser tokenizes like graphql-go, but
+// does not say where a malformed query goes wrong.
+func parseQuery(query string) (doc *types.ExecutableDefinition, err error) {
+	p := &parser{}
+	p.sc.Init(strings.NewReader(query))
+	p.sc.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats | scanner.ScanStrings
+	p.sc.Error = func(*scanner.Scanner, string) {}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if r, ok := r.(syntaxError); ok {
+				doc, err = nil, r
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	p.advance()
+	return p.document(), nil
+}
+
+type syntaxError string
+
+func (e syntaxError) Error() string { return "syntax error: " + string(e) }
+
+// parser reads one token ahead: next and text are the token the parser is
+// looking at.
+type parser struct {
+	sc   scanner.Scanner
+	next rune
+	text string
+}
+
+// advance moves to the next token, skipping commas and comments, which
+// GraphQL ignores.
+func (p *parser) advance() {
+	for {
+		p.next = p.sc.Scan()
+		switch p.next {
+		case ',':
+			continue
+		case '#':
+			for next := p.sc.Peek(); next != '\n' && next != scanner.EOF; next = p.sc.Peek() {
+				p.sc.Next()
+			}
+			continue
+		}
+		p.text = p.sc.TokenText()
+		return
+	}
+}
+
+func (p *parser) expect(token rune) string {
+	if p.next != token {
+		panic(syntaxError(fmt.Sprintf("unexpected %q, expecting %s", p.text, scanner.TokenString(token))))
+	}
+	text := p.text
+	p.advance()
+	return text
+}
+
+func (p *parser) keyword(keyword string) {
+	if p.next != scanner.Ident || p.text != keyword {
+		panic(syntaxError(fmt.Sprintf("unexpected %q, expecting %q", p.text, keyword)))
+	}
+	p.advance()
+}
+
+func (p *parser) name() types.Ident {
+	return types.Ident{Name: p.expect(scanner.Ident)}
+}
+
+func (p *parser) document() *types.ExecutableDefinition {
+	doc := &types.ExecutableDefinition{}
+	for p.next != scanner.EOF {
+		if p.next == scanner.Ident && p.text == "fragment" {
+			p.advance()
+			fragment := &types.FragmentDefinition{Name: p.name()}
+			p.keyword("on")
+			fragment.On = types.TypeName{Ident: p.name()}
+			p.directives()
+			fragment.Selections = p.selectionSet()
+			doc.Fragments = append(doc.Fragments, fragment)
+			continue
+		}
+
+		op := &types.OperationDefinition{Type: "query"}
+		if p.next == scanner.Ident {
+			op.Type = types.OperationType(p.name().Name)
+			if p.next == scanner.Ident {
+				op.Name = p.name()
+			}
+			if p.next == '(' {
+				op.Vars = p.variables()
+			}
+			p.directives()
+		}
+		op.Selections = p.selectionSet()
+		doc.Operations = append(doc.Operations, op)
+	}
+	return doc
+}
+
+// variables reads the variable definitions of an operation, keeping their
+// names and defaults.
+func (p *parser) variables() types.ArgumentsDefinition {
+	var vars types.ArgumentsDefinition
+	p.expect('(')
+	for p.next != ')' {
+		p.expect('$')
+		variable := &types.InputValueDefinition{Name: p.name()}
+		p.expect(':')
+		p.skipType()
+		if p.next == '=' {
+			p.advance()
+			variable.Default = p.value()
+		}
+		p.directives()
+		vars = append(vars, variable)
+	}
+	p.expect(')')
+	return vars
+}
+
+func (p *parser) skipType() {
+	if p.next == '[' {
+		p.advance()
+		p.skipType()
+		p.expect(']')
+	} else {
+		p.name()
+	}
+	if p.next == '!' {
+		p.advance()
+	}
+}
+
+// directives skips the directives of a selection: they can only drop
+// fields, so counting them anyway errs on the safe side.
+func (p *parser) directives() {
+	for p.next == '@' {
+		p.advance()
+		p.name()
+		if p.next == '(' {
+			p.arguments()
+		}
+	}
+}
+
+func (p *parser) selectionSet() types.SelectionSet {
+	var set types.SelectionSet
+	p.expect('{')
+	for p.next != '}' {
+		set = append(set, p.selection())
+	}
+	p.expect('}')
+	return set
+}
+
+func (p *parser) selection() types.Selection {
+	if p.next == '.' {
+		p.expect('.')
+		p.expect('.')
+		p.expect('.')
+		if p.next == scanner.Ident && p.text != "on" {
+			spread := &types.FragmentSpread{Name: p.name()}
+			p.directives()
+			return spread
+		}
+		fragment := &types.InlineFragment{}
+		if p.next == scanner.Ident {
+			p.keyword("on")
+			fragment.On = types.TypeName{Ident: p.name()}
+		}
+		p.directives()
+		fragment.Selections = p.selectionSet()
+		return fragment
+	}
+
+	field := &types.Field{Alias: p.name()}
+	field.Name = field.Alias
+	if p.next == ':' {
+		p.advance()
+		field.Name = p.name()
+	}
+	if p.next == '(' {
+		field.Arguments = p.arguments()
+	}
+	p.directives()
+	if p.next == '{' {
+		field.SelectionSet = p.selectionSet()
+	}
+	return field
+}
+
+func (p *parser) arguments() types.ArgumentList {
+	var arguments types.ArgumentList
+	p.expect('(')
+	for p.next != ')' {
+		argument := &types.Argument{Name: p.name()}
+		p.expect(':')
+		argument.Value = p.value()
+		arguments = append(arguments, argument)
+	}
+	p.expect(')')
+	return arguments
+}
+
+func (p *parser) value() types.Value {
+	switch p.next {
+	case '$':
+		p.advance()
+		return &types.Variable{Name: p.name().Name}
+	case '-':
+		p.advance()
+		number := p.next
+		if number != scanner.Int && number != scanner.Float {
+			panic(syntaxError(fmt.Sprintf("unexpected %q, expecting a number", p.text)))
+		}
+		return &types.PrimitiveValue{Type: number, Text: "-" + p.expect(number)}
+	case scanner.Int, scanner.Float, scanner.String:
+		token := p.next
+		return &types.PrimitiveValue{Type: token, Text: p.expect(token)}
+	case scanner.Ident:
+		if p.text == "null" {
+			p.advance()
+			return &types.NullValue{}
+		}
+		return &types.PrimitiveValue{Type: scanner.Ident, Text: p.expect(scanner.Ident)}
+	case '[':
+		list := &types.ListValue{}
+		p.advance()
+		for p.next != ']' {
+			list.Values = append(list.Values, p.value())
+		}
+		p.expect(']')
+		return list
+	case '{':
+		object := &types.ObjectValue{}
+		p.advance()
+		for p.next != '}' {
+			field := &types.ObjectField{Name: p.name()}
+			p.expect(':')
+			field.Value = p.value()
+			object.Fields = append(object.Fields, field)
+		}
+		p.expect('}')
+		return object
+	}
+	panic(syntaxError(fmt.Sprintf("unexpected %q, expecting a value", p.text)))
+}
@@ -0,0 +1,526 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// The resolvers follow schema.graphql: each type there has one here, and
+// graphql-go matches fields to methods by name.
+
+// rootResolver resolves Query.
+type rootResolver struct{}
+
+func (*rootResolver) Course(args struct{ ID graphql.ID }) *courseResolver {
+	return &courseResolver{id: string(args.ID)}
+}
+
+func (*rootResolver) Student(args struct{ ID graphql.ID }) *studentResolver {
+	return &studentResolver{id: string(args.ID)}
+}
+
+func average(sum float64, count int) *float64 {
+	if count == 0 {
+		return nil
+	}
+	avg := sum / float64(count)
+	return &avg
+}
+
+func percentage(part, count int) *float64 {
+	if count == 0 {
+		return nil
+	}
+	p := float64(part) * 100 / float64(count)
+	return &p
+}
+
+type timeRangeInput struct {
+	StartDate   *string
+	EndDate     *string
+	GroupBy     *string
+	Tz          *string
+	WeekStart   *string
+	Institution *string
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// parse reads the range like the REST handlers read their query
+// parameters.
+func (in *timeRangeInput) parse() (database.TimeGrouping, time.Time, time.Time, error) {
+	if in == nil {
+		in = &timeRangeInput{}
+	}
+	grouping, err := database.NewTimeGrouping(value(in.GroupBy), value(in.Tz), value(in.WeekStart), value(in.Institution))
+	if err != nil {
+		return grouping, time.Time{}, time.Time{}, err
+	}
+	start, end, err := database.ParseDateRange(value(in.StartDate), value(in.EndDate), grouping.Location)
+	if err != nil {
+		return grouping, start, end, errors.New("invalid date format. Use YYYY-MM-DD")
+	}
+	return grouping, start, end, nil
+}
+
+type seriesArgs struct {
+	Range      *timeRangeInput
+	Fill       *string
+	Rolling    int32
+	Cumulative bool
+}
+
+func (args seriesArgs) parse() (database.TimeGrouping, time.Time, time.Time, database.SeriesOptions, error) {
+	grouping, start, end, err := args.Range.parse()
+	series := database.SeriesOptions{Fill: value(args.Fill), Rolling: int(args.Rolling), Cumulative: args.Cumulative}
+	return grouping, start, end, series, err
+}
+
+type listArgs struct {
+	First       int32
+	After       *string
+	Sort        *string
+	Order       *string
+	MinAverage  *float64
+	MaxAverage  *float64
+	OnTime      bool
+	GradedAfter *string
+}
+
+func (args listArgs) options() (database.ListOptions, error) {
+	opts := database.ListOptions{
+		Cursor:     value(args.After),
+		Sort:       strings.ToLower(value(args.Sort)),
+		Order:      strings.ToLower(value(args.Order)),
+		MinAverage: args.MinAverage,
+		MaxAverage: args.MaxAverage,
+		Limit:      int(args.First),
+		OnTimeOnly: args.OnTime,
+	}
+	if args.GradedAfter != nil {
+		gradedAfter, err := time.Parse("2006-01-02", *args.GradedAfter)
+		if err != nil {
+			return opts, errors.New("invalid gradedAfter format. Use YYYY-MM-DD")
+		}
+		opts.GradedAfter = gradedAfter
+	}
+	return opts, opts.Validate()
+}
+
+type courseResolver struct {
+	id string
+}
+
+func (r *courseResolver) stats(ctx context.Context) (database.CourseStats, error) {
+	return loadersFrom(ctx).courses.Load(ctx, r.id)
+}
+
+func (r *courseResolver) ID() graphql.ID {
+	return graphql.ID(r.id)
+}
+
+func (r *courseResolver) StudentCount(ctx context.Context) (int32, error) {
+	s, err := r.stats(ctx)
+	return int32(s.Students), err
+}
+
+func (r *courseResolver) GradeAverage(ctx context.Context) (*float64, error) {
+	s, err := r.stats(ctx)
+	return average(s.GradeSum, s.GradeCount), err
+}
+
+func (r *courseResolver) GradeCount(ctx context.Context) (int32, error) {
+	s, err := r.stats(ctx)
+	return int32(s.GradeCount), err
+}
+
+func (r *courseResolver) TaskAverage(ctx context.Context) (*float64, error) {
+	s, err := r.stats(ctx)
+	return average(s.TaskGradeSum, s.TaskCount), err
+}
+
+func (r *courseResolver) TaskCount(ctx context.Context) (int32, error) {
+	s, err := r.stats(ctx)
+	return int32(s.TaskCount), err
+}
+
+func (r *courseResolver) OnTimePercentage(ctx context.Context) (*float64, error) {
+	s, err := r.stats(ctx)
+	return percentage(s.TaskOnTimeCount, s.TaskCount), err
+}
+
+func (r *courseResolver) AveragesOverTime(ctx context.Context, args seriesArgs) ([]*averagePeriodResolver, error) {
+	grouping, start, end, series, err := args.parse()
+	if err != nil {
+		return nil, err
+	}
+	periods, err := database.GetCourseAveragesOverTime(ctx, loadersFrom(ctx).db, r.id, start, end, grouping, series)
+	return averagePeriods(periods), err
+}
+
+func (r *courseResolver) OnTimeOverTime(ctx context.Context, args struct{ Range *timeRangeInput }) ([]*onTimePeriodResolver, error) {
+	grouping, start, end, err := args.Range.parse()
+	if err != nil {
+		return nil, err
+	}
+	periods, err := database.GetOnTimeSubmissionPercentageForCourse(ctx, loadersFrom(ctx).db, r.id, start, end, grouping)
+	return onTimePeriods(periods), err
+}
+
+func (r *courseResolver) Distribution(ctx context.Context, args struct{ BucketSize float64 }) ([]*bucketResolver, error) {
+	return distribution(ctx, r.id, "", args.BucketSize)
+}
+
+func (r *courseResolver) Task(args struct{ ID graphql.ID }) *taskResolver {
+	return &taskResolver{courseID: r.id, id: string(args.ID)}
+}
+
+func (r *courseResolver) Student(args struct{ ID graphql.ID }) *enrollmentResolver {
+	return &enrollmentResolver{studentID: string(args.ID), courseID: r.id}
+}
+
+func (r *courseResolver) Students(ctx context.Context, args listArgs) (*studentAveragePageResolver, error) {
+	opts, err := args.options()
+	if err != nil {
+		return nil, err
+	}
+	// No student is left out with an empty id
+	page, err := database.GetOtherStudentsCourseAverages(ctx, loadersFrom(ctx).db, "", r.id, opts)
+	return studentAveragePage(r.id, page, err)
+}
+
+type studentResolver struct {
+	id string
+}
+
+func (r *studentResolver) ID() graphql.ID {
+	return graphql.ID(r.id)
+}
+
+func (r *studentResolver) Course(args struct{ ID graphql.ID }) *enrollmentResolver {
+	return &enrollmentResolver{studentID: r.id, courseID: string(args.ID)}
+}
+
+func (r *studentResolver) AveragesOverTime(ctx context.Context, args seriesArgs) ([]*averagePeriodResolver, error) {
+	grouping, start, end, series, err := args.parse()
+	if err != nil {
+		return nil, err
+	}
+	periods, err := database.GetStudentAveragesOverTime(ctx, loadersFrom(ctx).db, r.id, start, end, grouping, series)
+	return averagePeriods(periods), err
+}
+
+type enrollmentResolver struct {
+	studentID string
+	courseID  string
+}
+
+func (r *enrollmentResolver) key() database.StudentCourse {
+	return database.StudentCourse{StudentID: r.studentID, CourseID: r.courseID}
+}
+
+func (r *enrollmentResolver) stats(ctx context.Context) (database.StudentCourseStats, error) {
+	return loadersFrom(ctx).enrollments.Load(ctx, r.key())
+}
+
+func (r *enrollmentResolver) Student() *studentResolver {
+	return &studentResolver{id: r.studentID}
+}
+
+func (r *enrollmentResolver) Course() *courseResolver {
+	return &courseResolver{id: r.courseID}
+}
+
+func (r *enrollmentResolver) GradeAverage(ctx context.Context) (*float64, error) {
+	s, err := r.stats(ctx)
+	return average(s.GradeSum, s.GradeCount), err
+}
+
+func (r *enrollmentResolver) GradeCount(ctx context.Context) (int32, error) {
+	s, err := r.stats(ctx)
+	return int32(s.GradeCount), err
+}
+
+func (r *enrollmentResolver) TaskAverage(ctx context.Context) (*float64, error) {
+	s, err := r.stats(ctx)
+	return average(s.TaskGradeSum, s.TaskCount), err
+}
+
+func (r *enrollmentResolver) TaskCount(ctx context.Context) (int32, error) {
+	s, err := r.stats(ctx)
+	return int32(s.TaskCount), err
+}
+
+func (r *enrollmentResolver) OnTimePercentage(ctx context.Context) (*float64, error) {
+	s, err := r.stats(ctx)
+	return percentage(s.TaskOnTimeCount, s.TaskCount), err
+}
+
+func (r *enrollmentResolver) OnTimeOverTime(ctx context.Context, args struct{ Range *timeRangeInput }) ([]*onTimePeriodResolver, error) {
+	grouping, start, end, err := args.Range.parse()
+	if err != nil {
+		return nil, err
+	}
+	periods, err := database.GetOnTimeSubmissionPercentageForStudent(ctx, loadersFrom(ctx).db, r.courseID, r.studentID, start, end, grouping)
+	return onTimePeriods(periods), err
+}
+
+func (r *enrollmentResolver) Grades(ctx context.Context) ([]*gradeResolver, error) {
+	grades, err := loadersFrom(ctx).grades.Load(ctx, r.key())
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*gradeResolver, len(grades))
+	for i, grade := range grades {
+		resolvers[i] = &gradeResolver{grade: grade}
+	}
+	return resolvers, nil
+}
+
+func (r *enrollmentResolver) OtherStudents(ctx context.Context, args listArgs) (*studentAveragePageResolver, error) {
+	opts, err := args.options()
+	if err != nil {
+		return nil, err
+	}
+	page, err := database.GetOtherStudentsCourseAverages(ctx, loadersFrom(ctx).db, r.studentID, r.courseID, opts)
+	return studentAveragePage(r.courseID, page, err)
+}
+
+type taskResolver struct {
+	courseID string
+	id       string
+}
+
+func (r *taskResolver) stats(ctx context.Context) (database.TaskStats, error) {
+	return loadersFrom(ctx).tasks.Load(ctx, database.CourseTask{CourseID: r.courseID, TaskID: r.id})
+}
+
+func (r *taskResolver) ID() graphql.ID {
+	return graphql.ID(r.id)
+}
+
+func (r *taskResolver) Course() *courseResolver {
+	return &courseResolver{id: r.courseID}
+}
+
+func (r *taskResolver) Average(ctx context.Context) (*float64, error) {
+	s, err := r.stats(ctx)
+	return average(s.GradeSum, s.GradeCount), err
+}
+
+func (r *taskResolver) GradeCount(ctx context.Context) (int32, error) {
+	s, err := r.stats(ctx)
+	return int32(s.GradeCount), err
+}
+
+func (r *taskResolver) OnTimeCount(ctx context.Context) (int32, error) {
+	s, err := r.stats(ctx)
+	return int32(s.OnTimeCount), err
+}
+
+func (r *taskResolver) OnTimePercentage(ctx context.Context) (*float64, error) {
+	s, err := r.stats(ctx)
+	return percentage(s.OnTimeCount, s.GradeCount), err
+}
+
+func (r *taskResolver) Distribution(ctx context.Context, args struct{ BucketSize float64 }) ([]*bucketResolver, error) {
+	return distribution(ctx, r.courseID, r.id, args.BucketSize)
+}
+
+func (r *taskResolver) Students(ctx context.Context, args listArgs) (*studentAveragePageResolver, error) {
+	opts, err := args.options()
+	if err != nil {
+		return nil, err
+	}
+	page, err := database.GetAveragesForTask(ctx, loadersFrom(ctx).db, r.courseID, r.id, opts)
+	return studentAveragePage(r.courseID, page, err)
+}
+
+type gradeResolver struct {
+	grade model.GradeTask
+}
+
+func (r *gradeResolver) Student() *studentResolver {
+	return &studentResolver{id: r.grade.StudentID}
+}
+
+func (r *gradeResolver) Course() *courseResolver {
+	return &courseResolver{id: r.grade.CourseID}
+}
+
+func (r *gradeResolver) Task() *taskResolver {
+	if r.grade.TaskID == "" {
+		return nil
+	}
+	return &taskResolver{courseID: r.grade.CourseID, id: r.grade.TaskID}
+}
+
+func (r *gradeResolver) Grade() float64 {
+	return r.grade.Grade
+}
+
+func (r *gradeResolver) OnTime() bool {
+	return r.grade.OnTime
+}
+
+func (r *gradeResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.grade.CreatedAt}
+}
+
+type studentAverageResolver struct {
+	courseID  string
+	studentID string
+	average   float64
+	count     int
+}
+
+func (r *studentAverageResolver) Student() *studentResolver {
+	return &studentResolver{id: r.studentID}
+}
+
+func (r *studentAverageResolver) Enrollment() *enrollmentResolver {
+	return &enrollmentResolver{studentID: r.studentID, courseID: r.courseID}
+}
+
+func (r *studentAverageResolver) Average() float64 {
+	return r.average
+}
+
+func (r *studentAverageResolver) Count() int32 {
+	return int32(r.count)
+}
+
+type studentAveragePageResolver struct {
+	items      []*studentAverageResolver
+	total      int
+	nextCursor string
+}
+
+// studentAveragePage wraps a page of listStudents.
+func studentAveragePage(courseID string, page *database.Page, err error) (*studentAveragePageResolver, error) {
+	if err != nil {
+		return nil, err
+	}
+	resolver := &studentAveragePageResolver{total: page.Total, nextCursor: page.NextCursor}
+	for _, item := range page.Items {
+		resolver.items = append(resolver.items, &studentAverageResolver{courseID: courseID, studentID: item.StudentID, average: item.AverageGrade, count: item.Count()})
+	}
+	return resolver, nil
+}
+
+func (r *studentAveragePageResolver) Items() []*studentAverageResolver {
+	return r.items
+}
+
+func (r *studentAveragePageResolver) Total() int32 {
+	return int32(r.total)
+}
+
+func (r *studentAveragePageResolver) NextCursor() *string {
+	if r.nextCursor == "" {
+		return nil
+	}
+	return &r.nextCursor
+}
+
+// averagePeriodResolver and onTimePeriodResolver read the rows of the
+// over-time queries.
+type averagePeriodResolver struct {
+	row database.PeriodAverage
+}
+
+func averagePeriods(rows []database.PeriodAverage) []*averagePeriodResolver {
+	resolvers := make([]*averagePeriodResolver, len(rows))
+	for i, row := range rows {
+		resolvers[i] = &averagePeriodResolver{row: row}
+	}
+	return resolvers
+}
+
+func (r *averagePeriodResolver) Period() string {
+	return r.row.Period
+}
+
+func (r *averagePeriodResolver) Average() *float64 {
+	return r.row.AverageGrade
+}
+
+func (r *averagePeriodResolver) GradeCount() int32 {
+	return int32(r.row.GradeCount)
+}
+
+func (r *averagePeriodResolver) RollingAverage() *float64 {
+	return r.row.RollingAverage
+}
+
+func (r *averagePeriodResolver) CumulativeAverage() *float64 {
+	return r.row.CumulativeAverage
+}
+
+type onTimePeriodResolver struct {
+	row database.OnTimeStat
+}
+
+func onTimePeriods(rows []database.OnTimeStat) []*onTimePeriodResolver {
+	resolvers := make([]*onTimePeriodResolver, len(rows))
+	for i, row := range rows {
+		resolvers[i] = &onTimePeriodResolver{row: row}
+	}
+	return resolvers
+}
+
+func (r *onTimePeriodResolver) Period() string {
+	return r.row.Period
+}
+
+func (r *onTimePeriodResolver) OnTimeCount() int32 {
+	return int32(r.row.OnTimeCount)
+}
+
+func (r *onTimePeriodResolver) TotalCount() int32 {
+	return int32(r.row.TotalCount)
+}
+
+func (r *onTimePeriodResolver) Percentage() float64 {
+	return r.row.Percentage
+}
+
+type bucketResolver struct {
+	bucket database.GradeBucket
+}
+
+func distribution(ctx context.Context, courseID, taskID string, bucketSize float64) ([]*bucketResolver, error) {
+	buckets, err := database.GetGradeDistribution(ctx, loadersFrom(ctx).db, courseID, taskID, bucketSize)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*bucketResolver, len(buckets))
+	for i, bucket := range buckets {
+		resolvers[i] = &bucketResolver{bucket: bucket}
+	}
+	return resolvers, nil
+}
+
+func (r *bucketResolver) From() float64 {
+	return r.bucket.From
+}
+
+func (r *bucketResolver) To() float64 {
+	return r.bucket.To
+}
+
+func (r *bucketResolver) Count() int32 {
+	return int32(r.bucket.Count)
+}
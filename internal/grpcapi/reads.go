@@ -0,0 +1,304 @@
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	statsv1 "github.com/1c2025-IngSoftware2-g7/service_stats/proto/stats/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// checkID checks an ID of a request the way the REST endpoint checks its
+// path.
+func checkID(name, id string) error {
+	if !service.ValidID(id) {
+		return invalidArgument("invalid " + name)
+	}
+	return nil
+}
+
+// parseRange reads the grouping and dates of r, which may be nil for the
+// whole history in a single period.
+func parseRange(r *statsv1.TimeRange) (database.TimeGrouping, time.Time, time.Time, error) {
+	grouping, err := database.NewTimeGrouping(r.GetGroupBy(), r.GetTz(), r.GetWeekStart(), r.GetInstitution())
+	if err != nil {
+		return grouping, time.Time{}, time.Time{}, invalidArgument(err.Error())
+	}
+	start, end, err := database.ParseDateRange(r.GetStartDate(), r.GetEndDate(), grouping.Location)
+	if err != nil {
+		return grouping, time.Time{}, time.Time{}, invalidArgument("invalid date format, use YYYY-MM-DD")
+	}
+	return grouping, start, end, nil
+}
+
+func parseSeries(o *statsv1.SeriesOptions, grouping database.TimeGrouping, start, end time.Time) (database.SeriesOptions, error) {
+	series := database.SeriesOptions{
+		Fill:       o.GetFill(),
+		Rolling:    int(o.GetRolling()),
+		Cumulative: o.GetCumulative(),
+	}
+	if err := series.Validate(grouping, start, end); err != nil {
+		return series, invalidArgument(err.Error())
+	}
+	return series, nil
+}
+
+func parseList(o *statsv1.ListOptions) (database.ListOptions, error) {
+	opts := database.ListOptions{
+		Limit:      int(o.GetLimit()),
+		Cursor:     o.GetCursor(),
+		Sort:       o.GetSort(),
+		Order:      o.GetOrder(),
+		MinAverage: o.MinAverage,
+		MaxAverage: o.MaxAverage,
+		OnTimeOnly: o.GetOnTime(),
+	}
+	if o.GetGradedAfter() != "" {
+		gradedAfter, err := time.Parse("2006-01-02", o.GetGradedAfter())
+		if err != nil {
+			return opts, invalidArgument("invalid graded_after format, use YYYY-MM-DD")
+		}
+		opts.GradedAfter = gradedAfter
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, invalidArgument(err.Error())
+	}
+	return opts, nil
+}
+
+func queriedRange(start, end time.Time, grouping database.TimeGrouping) *statsv1.QueriedRange {
+	r := &statsv1.QueriedRange{Tz: "UTC"}
+	if grouping.Location != nil {
+		r.Tz = grouping.Location.String()
+	}
+	if !start.IsZero() {
+		r.Start = timestamppb.New(start)
+	}
+	if !end.IsZero() {
+		r.End = timestamppb.New(end)
+	}
+	return r
+}
+
+func pagination(page *database.Page, opts database.ListOptions) *statsv1.Pagination {
+	limit := opts.Limit
+	if limit == 0 {
+		limit = database.DefaultListLimit
+	}
+	return &statsv1.Pagination{Limit: int32(limit), Total: int32(page.Total), NextCursor: page.NextCursor}
+}
+
+func averagePeriods(rows []database.PeriodAverage) []*statsv1.AveragePeriod {
+	periods := make([]*statsv1.AveragePeriod, len(rows))
+	for i, row := range rows {
+		periods[i] = &statsv1.AveragePeriod{
+			Period:            row.Period,
+			Average:           row.AverageGrade,
+			GradeCount:        int32(row.GradeCount),
+			RollingAverage:    row.RollingAverage,
+			CumulativeAverage: row.CumulativeAverage,
+		}
+	}
+	return periods
+}
+
+func onTimePeriods(rows []database.OnTimeStat) []*statsv1.OnTimePeriod {
+	periods := make([]*statsv1.OnTimePeriod, len(rows))
+	for i, row := range rows {
+		periods[i] = &statsv1.OnTimePeriod{
+			Period:      row.Period,
+			OnTimeCount: int32(row.OnTimeCount),
+			TotalCount:  int32(row.TotalCount),
+			Percentage:  row.Percentage,
+		}
+	}
+	return periods
+}
+
+func studentAverages(page *database.Page) []*statsv1.StudentAverage {
+	items := make([]*statsv1.StudentAverage, len(page.Items))
+	for i, item := range page.Items {
+		items[i] = &statsv1.StudentAverage{StudentId: item.StudentID, Average: item.AverageGrade, Count: int32(item.Count())}
+	}
+	return items
+}
+
+func (s *Server) GetStudentCourseAverage(ctx context.Context, req *statsv1.GetStudentCourseAverageRequest) (*statsv1.AverageResponse, error) {
+	if req.GetStudentId() == "" {
+		return nil, invalidArgument("missing student_id")
+	}
+	if err := checkID("course_id", req.GetCourseId()); err != nil {
+		return nil, err
+	}
+
+	average, code, err := database.GetAvgGradeForStudent(ctx, s.deps.readDB(), req.GetStudentId(), req.GetCourseId())
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	if code == http.StatusNotFound {
+		return nil, status.Error(codes.NotFound, "no grades found for the student in the course")
+	}
+	return &statsv1.AverageResponse{Average: average}, nil
+}
+
+func (s *Server) GetStudentTaskAverage(ctx context.Context, req *statsv1.GetStudentTaskAverageRequest) (*statsv1.AverageResponse, error) {
+	if err := checkID("student_id", req.GetStudentId()); err != nil {
+		return nil, err
+	}
+	if err := checkID("course_id", req.GetCourseId()); err != nil {
+		return nil, err
+	}
+	if err := checkID("task_id", req.GetTaskId()); err != nil {
+		return nil, err
+	}
+
+	average, code, err := database.GetAvgGradeTaskForStudent(ctx, s.deps.readDB(), req.GetStudentId(), req.GetCourseId(), req.GetTaskId())
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	if code == http.StatusNotFound {
+		return nil, status.Error(codes.NotFound, "no grades found for the student in this task")
+	}
+	return &statsv1.AverageResponse{Average: average}, nil
+}
+
+func (s *Server) GetStudentAveragesOverTime(ctx context.Context, req *statsv1.GetStudentAveragesOverTimeRequest) (*statsv1.AveragesOverTimeResponse, error) {
+	grouping, start, end, err := parseRange(req.GetRange())
+	if err != nil {
+		return nil, err
+	}
+	series, err := parseSeries(req.GetSeries(), grouping, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.GetStudentAveragesOverTime(ctx, s.deps.readDB(), req.GetStudentId(), start, end, grouping, series)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return &statsv1.AveragesOverTimeResponse{
+		Averages: averagePeriods(rows),
+		Range:    queriedRange(start, end, grouping),
+		GroupBy:  grouping.String(),
+	}, nil
+}
+
+func (s *Server) GetCourseAveragesOverTime(ctx context.Context, req *statsv1.GetCourseAveragesOverTimeRequest) (*statsv1.AveragesOverTimeResponse, error) {
+	grouping, start, end, err := parseRange(req.GetRange())
+	if err != nil {
+		return nil, err
+	}
+	series, err := parseSeries(req.GetSeries(), grouping, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.GetCourseAveragesOverTime(ctx, s.deps.readDB(), req.GetCourseId(), start, end, grouping, series)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return &statsv1.AveragesOverTimeResponse{
+		Averages: averagePeriods(rows),
+		Range:    queriedRange(start, end, grouping),
+		GroupBy:  grouping.String(),
+	}, nil
+}
+
+func (s *Server) GetStudentCourseTasksAverage(ctx context.Context, req *statsv1.GetStudentCourseTasksAverageRequest) (*statsv1.GetStudentCourseTasksAverageResponse, error) {
+	if err := checkID("student_id", req.GetStudentId()); err != nil {
+		return nil, err
+	}
+	if err := checkID("course_id", req.GetCourseId()); err != nil {
+		return nil, err
+	}
+	opts, err := parseList(req.GetList())
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.deps.readDB()
+	average, code, err := database.GetStudentCourseTasksAverage(ctx, db, req.GetStudentId(), req.GetCourseId())
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	others, err := database.GetOtherStudentsCourseAverages(ctx, db, req.GetStudentId(), req.GetCourseId(), opts)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	resp := &statsv1.GetStudentCourseTasksAverageResponse{
+		OtherStudents: studentAverages(others),
+		Pagination:    pagination(others, opts),
+	}
+	if code != http.StatusNotFound {
+		resp.StudentAverage = proto.Float64(average)
+	}
+	return resp, nil
+}
+
+func (s *Server) GetTaskAverages(ctx context.Context, req *statsv1.GetTaskAveragesRequest) (*statsv1.GetTaskAveragesResponse, error) {
+	if req.GetCourseId() == "" || req.GetTaskId() == "" {
+		return nil, invalidArgument("invalid course_id or task_id")
+	}
+	opts, err := parseList(req.GetList())
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.deps.readDB()
+	averages, err := database.GetAveragesForTask(ctx, db, req.GetCourseId(), req.GetTaskId(), opts)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	groupAverage, _, _, err := database.GetTaskSummary(ctx, db, req.GetCourseId(), req.GetTaskId())
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	return &statsv1.GetTaskAveragesResponse{
+		GroupAverage: groupAverage,
+		Students:     studentAverages(averages),
+		Pagination:   pagination(averages, opts),
+	}, nil
+}
+
+func (s *Server) GetCourseOnTimePercentage(ctx context.Context, req *statsv1.GetCourseOnTimePercentageRequest) (*statsv1.OnTimePercentageResponse, error) {
+	grouping, start, end, err := parseRange(req.GetRange())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.GetOnTimeSubmissionPercentageForCourse(ctx, s.deps.readDB(), req.GetCourseId(), start, end, grouping)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return &statsv1.OnTimePercentageResponse{
+		Periods: onTimePeriods(rows),
+		Range:   queriedRange(start, end, grouping),
+		GroupBy: grouping.String(),
+	}, nil
+}
+
+func (s *Server) GetStudentOnTimePercentage(ctx context.Context, req *statsv1.GetStudentOnTimePercentageRequest) (*statsv1.OnTimePercentageResponse, error) {
+	grouping, start, end, err := parseRange(req.GetRange())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.GetOnTimeSubmissionPercentageForStudent(ctx, s.deps.readDB(), req.GetCourseId(), req.GetStudentId(), start, end, grouping)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	return &statsv1.OnTimePercentageResponse{
+		Periods: onTimePeriods(rows),
+		Range:   queriedRange(start, end, grouping),
+		GroupBy: grouping.String(),
+	}, nil
+}
@@ -0,0 +1,140 @@
+// Package grpcapi serves the statistics over gRPC for internal consumers.
+// Each RPC of proto/stats/v1 mirrors a REST endpoint and runs the same
+// service and repository functions, with the same validation.
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/handlers"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/logging"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	statsv1 "github.com/1c2025-IngSoftware2-g7/service_stats/proto/stats/v1"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Dependencies groups what the RPCs need, like routes.Dependencies does for
+// the REST endpoints.
+type Dependencies struct {
+	DB       *sql.DB
+	Enqueuer handlers.Enqueuer
+	// Reads routes the read RPCs to the read replica when one is
+	// configured and healthy. Nil sends every read to DB.
+	Reads *database.ReadRouter
+	// SyncWrites makes AddGrade and AddGradeTask store grades before
+	// answering instead of enqueueing them (WRITE_MODE=sync).
+	SyncWrites bool
+	// GradeListener is told about the grades stored in sync mode. May be
+	// nil.
+	GradeListener service.Listener
+}
+
+// readDB returns the connection for a read-only call, looked up per call
+// so a replica falling behind is skipped right away.
+func (d Dependencies) readDB() *sql.DB {
+	if d.Reads == nil {
+		return d.DB
+	}
+	return d.Reads.Reader()
+}
+
+// Server implements statsv1.StatsServiceServer.
+type Server struct {
+	statsv1.UnimplementedStatsServiceServer
+	deps Dependencies
+}
+
+func NewServer(deps Dependencies) *Server {
+	return &Server{deps: deps}
+}
+
+// New returns a gRPC server with the statistics service, the standard
+// health service and reflection, so grpcurl can list the methods. Calls are
+// traced, logged and counted like the HTTP requests.
+func New(deps Dependencies, healthServer *health.Server) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			logging.GRPCRequestIDInterceptor(),
+			logging.GRPCLogger(),
+			metrics.GRPCUnaryInterceptor(),
+			recoverInterceptor,
+		),
+	)
+	statsv1.RegisterStatsServiceServer(server, NewServer(deps))
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	return server
+}
+
+// GracefulStop waits for the calls in flight like http.Server.Shutdown, and
+// cuts them off when ctx is done.
+func GracefulStop(ctx context.Context, server *grpc.Server) error {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		return ctx.Err()
+	}
+}
+
+// recoverInterceptor turns a panic into an INTERNAL error, as gin.Recovery
+// does with a 500, instead of taking the whole API down.
+func recoverInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "panic in grpc handler", "method", info.FullMethod, "panic", r, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// queryError maps a failed repository call to a status, like
+// handlers.queryErrorStatus does: CANCELED when the client went away,
+// DEADLINE_EXCEEDED when the query ran out of time, INVALID_ARGUMENT when
+// the grouping turned out to be invalid, INTERNAL otherwise.
+func queryError(ctx context.Context, err error) error {
+	switch {
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	case database.IsQueryCanceled(err):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, database.ErrInvalidGrouping):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// writeError maps the errors of the service layer. Grades the database
+// rejects are the client's fault, as in the REST API.
+func writeError(ctx context.Context, err error) error {
+	if errors.Is(err, service.ErrInvalidGrade) || database.IsPermanentError(err) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return queryError(ctx, err)
+}
+
+func invalidArgument(msg string) error {
+	return status.Error(codes.InvalidArgument, msg)
+}
@@ -0,0 +1,244 @@
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+	statsv1 "github.com/1c2025-IngSoftware2-g7/service_stats/proto/stats/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+type fakeEnqueuer struct {
+	taskType string
+	payload  interface{}
+	err      error
+}
+
+func (f *fakeEnqueuer) Enqueue(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
+	f.taskType, f.payload = taskType, payload
+	return 2 * time.Minute, f.err
+}
+
+// dial serves deps over an in-memory connection and returns a client.
+func dial(t *testing.T, deps Dependencies) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := New(deps, health.NewServer())
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestAddGrade_Queued(t *testing.T) {
+	enqueuer := &fakeEnqueuer{}
+	client := statsv1.NewStatsServiceClient(dial(t, Dependencies{Enqueuer: enqueuer}))
+
+	resp, err := client.AddGrade(context.Background(), &statsv1.AddGradeRequest{Grade: &statsv1.Grade{StudentId: "s1", CourseId: "c1", Grade: 8, OnTime: true}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2*time.Minute, resp.GetQueued().GetExpectedDelay().AsDuration())
+	assert.Equal(t, types.TaskAddStudentGrade, enqueuer.taskType)
+	assert.Equal(t, model.Grade{StudentID: "s1", CourseID: "c1", Grade: 8, OnTime: true}, enqueuer.payload)
+}
+
+func TestAddGrade_Invalid(t *testing.T) {
+	enqueuer := &fakeEnqueuer{}
+	client := statsv1.NewStatsServiceClient(dial(t, Dependencies{Enqueuer: enqueuer}))
+
+	_, err := client.AddGrade(context.Background(), &statsv1.AddGradeRequest{Grade: &statsv1.Grade{StudentId: "s1", CourseId: "c1", Grade: -1}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "negative grade")
+	assert.Nil(t, enqueuer.payload)
+
+	enqueuer.err = errors.New("redis down")
+	_, err = client.AddGradeTask(context.Background(), &statsv1.AddGradeTaskRequest{Grade: &statsv1.GradeTask{StudentId: "s1", CourseId: "c1", TaskId: "t1", Grade: 7}})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestAddGrade_Sync(t *testing.T) {
+	insertGrade := service.InsertGrade
+	defer func() { service.InsertGrade = insertGrade }()
+
+	createdAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, grade model.Grade) (model.Grade, error) {
+		grade.CreatedAt = createdAt
+		return grade, nil
+	}
+
+	var stored []service.Change
+	listener := listenerFunc(func(ctx context.Context, change service.Change) { stored = append(stored, change) })
+	client := statsv1.NewStatsServiceClient(dial(t, Dependencies{SyncWrites: true, GradeListener: listener}))
+
+	resp, err := client.AddGrade(context.Background(), &statsv1.AddGradeRequest{Grade: &statsv1.Grade{StudentId: "s1", CourseId: "c1", Grade: 8}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "s1", resp.GetStored().GetStudentId())
+	assert.Equal(t, createdAt, resp.GetStored().GetCreatedAt().AsTime())
+	require.Len(t, stored, 1)
+	assert.Equal(t, "c1", stored[0].CourseID)
+}
+
+type listenerFunc func(ctx context.Context, change service.Change)
+
+func (f listenerFunc) GradeStored(ctx context.Context, change service.Change) { f(ctx, change) }
+
+func TestGetStudentCourseAverage(t *testing.T) {
+	getAvg := database.GetAvgGradeForStudent
+	defer func() { database.GetAvgGradeForStudent = getAvg }()
+
+	database.GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
+		if studentID == "s2" {
+			return 0, http.StatusNotFound, nil
+		}
+		return 7.5, http.StatusOK, nil
+	}
+	client := statsv1.NewStatsServiceClient(dial(t, Dependencies{}))
+
+	resp, err := client.GetStudentCourseAverage(context.Background(), &statsv1.GetStudentCourseAverageRequest{StudentId: "s1", CourseId: "c1"})
+	require.NoError(t, err)
+	assert.Equal(t, 7.5, resp.GetAverage())
+
+	_, err = client.GetStudentCourseAverage(context.Background(), &statsv1.GetStudentCourseAverageRequest{StudentId: "s2", CourseId: "c1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.GetStudentCourseAverage(context.Background(), &statsv1.GetStudentCourseAverageRequest{StudentId: "s1", CourseId: "c_1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetCourseAveragesOverTime(t *testing.T) {
+	getAverages := database.GetCourseAveragesOverTime
+	defer func() { database.GetCourseAveragesOverTime = getAverages }()
+
+	database.GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
+		assert.Equal(t, "c1", courseID)
+		assert.Equal(t, "week", grouping.String())
+		assert.Equal(t, 2, series.Rolling)
+		return []database.PeriodAverage{
+			{Period: "2026-03-02", AverageGrade: proto.Float64(8), GradeCount: 2, RollingAverage: proto.Float64(8)},
+			{Period: "2026-03-09"},
+		}, nil
+	}
+	client := statsv1.NewStatsServiceClient(dial(t, Dependencies{}))
+
+	resp, err := client.GetCourseAveragesOverTime(context.Background(), &statsv1.GetCourseAveragesOverTimeRequest{
+		CourseId: "c1",
+		Range:    &statsv1.TimeRange{StartDate: "2026-03-01", EndDate: "2026-03-15", GroupBy: "week"},
+		Series:   &statsv1.SeriesOptions{Fill: "null", Rolling: 2},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "week", resp.GetGroupBy())
+	assert.Equal(t, "UTC", resp.GetRange().GetTz())
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), resp.GetRange().GetStart().AsTime())
+	require.Len(t, resp.GetAverages(), 2)
+	assert.True(t, proto.Equal(&statsv1.AveragePeriod{Period: "2026-03-02", Average: proto.Float64(8), GradeCount: 2, RollingAverage: proto.Float64(8)}, resp.GetAverages()[0]))
+	assert.True(t, proto.Equal(&statsv1.AveragePeriod{Period: "2026-03-09"}, resp.GetAverages()[1]))
+}
+
+func TestGetCourseAveragesOverTime_InvalidRange(t *testing.T) {
+	client := statsv1.NewStatsServiceClient(dial(t, Dependencies{}))
+
+	for _, r := range []*statsv1.TimeRange{{GroupBy: "fortnight"}, {StartDate: "01/03/2026"}} {
+		_, err := client.GetCourseAveragesOverTime(context.Background(), &statsv1.GetCourseAveragesOverTimeRequest{CourseId: "c1", Range: r})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), r.String())
+	}
+}
+
+func TestGetTaskAverages(t *testing.T) {
+	getAverages, getSummary := database.GetAveragesForTask, database.GetTaskSummary
+	defer func() { database.GetAveragesForTask, database.GetTaskSummary = getAverages, getSummary }()
+
+	database.GetAveragesForTask = func(ctx context.Context, DB *sql.DB, courseID, taskID string, opts database.ListOptions) (*database.Page, error) {
+		assert.Equal(t, 1, opts.Limit)
+		assert.Equal(t, 6.0, *opts.MinAverage)
+		return &database.Page{
+			Items:      []database.StudentAverage{{StudentID: "s1", AverageGrade: 9, GradeCount: 1}},
+			Total:      2,
+			NextCursor: "next",
+		}, nil
+	}
+	database.GetTaskSummary = func(ctx context.Context, DB *sql.DB, courseID, taskID string) (float64, int, int, error) {
+		return 8, 2, 1, nil
+	}
+	client := statsv1.NewStatsServiceClient(dial(t, Dependencies{}))
+
+	resp, err := client.GetTaskAverages(context.Background(), &statsv1.GetTaskAveragesRequest{
+		CourseId: "c1",
+		TaskId:   "t1",
+		List:     &statsv1.ListOptions{Limit: 1, MinAverage: proto.Float64(6)},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, proto.Equal(&statsv1.GetTaskAveragesResponse{
+		GroupAverage: 8,
+		Students:     []*statsv1.StudentAverage{{StudentId: "s1", Average: 9, Count: 1}},
+		Pagination:   &statsv1.Pagination{Limit: 1, Total: 2, NextCursor: "next"},
+	}, resp), resp.String())
+
+	_, err = client.GetTaskAverages(context.Background(), &statsv1.GetTaskAveragesRequest{CourseId: "c1", TaskId: "t1", List: &statsv1.ListOptions{Sort: "name"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestQueryErrors(t *testing.T) {
+	getOnTime := database.GetOnTimeSubmissionPercentageForCourse
+	defer func() { database.GetOnTimeSubmissionPercentageForCourse = getOnTime }()
+
+	var err error
+	database.GetOnTimeSubmissionPercentageForCourse = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping) ([]database.OnTimeStat, error) {
+		return nil, err
+	}
+	client := statsv1.NewStatsServiceClient(dial(t, Dependencies{}))
+
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{database.ErrInvalidGrouping, codes.InvalidArgument},
+		{errors.New("connection refused"), codes.Internal},
+	}
+	for _, tt := range tests {
+		err = tt.err
+		_, got := client.GetCourseOnTimePercentage(context.Background(), &statsv1.GetCourseOnTimePercentageRequest{CourseId: "c1"})
+		assert.Equal(t, tt.code, status.Code(got), tt.err.Error())
+	}
+}
+
+func TestHealth(t *testing.T) {
+	conn := dial(t, Dependencies{})
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
+
+func TestRecoverInterceptor(t *testing.T) {
+	_, err := recoverInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { panic("boom") })
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
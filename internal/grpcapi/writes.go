@@ -0,0 +1,107 @@
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+	statsv1 "github.com/1c2025-IngSoftware2-g7/service_stats/proto/stats/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AddGrade stores the grade when WRITE_MODE is sync and enqueues it
+// otherwise. Unlike the REST endpoint, invalid grades are rejected before
+// they reach the queue.
+func (s *Server) AddGrade(ctx context.Context, req *statsv1.AddGradeRequest) (*statsv1.AddGradeResponse, error) {
+	g := req.GetGrade()
+	grade := model.Grade{
+		StudentID: g.GetStudentId(),
+		CourseID:  g.GetCourseId(),
+		Grade:     g.GetGrade(),
+		OnTime:    g.GetOnTime(),
+		CreatedAt: optionalTime(g.GetCreatedAt()),
+	}
+	if err := service.ValidateGrade(grade); err != nil {
+		return nil, invalidArgument(err.Error())
+	}
+
+	if s.deps.SyncWrites {
+		stored, err := service.AddGrade(ctx, s.deps.DB, s.deps.GradeListener, grade)
+		if err != nil {
+			return nil, writeError(ctx, err)
+		}
+		return &statsv1.AddGradeResponse{Result: &statsv1.AddGradeResponse_Stored{Stored: &statsv1.Grade{
+			StudentId: stored.StudentID,
+			CourseId:  stored.CourseID,
+			Grade:     stored.Grade,
+			OnTime:    stored.OnTime,
+			CreatedAt: timestamppb.New(stored.CreatedAt),
+		}}}, nil
+	}
+
+	queued, err := s.enqueue(ctx, types.TaskAddStudentGrade, grade)
+	if err != nil {
+		return nil, err
+	}
+	return &statsv1.AddGradeResponse{Result: &statsv1.AddGradeResponse_Queued{Queued: queued}}, nil
+}
+
+// AddGradeTask is AddGrade for the grades of a task.
+func (s *Server) AddGradeTask(ctx context.Context, req *statsv1.AddGradeTaskRequest) (*statsv1.AddGradeTaskResponse, error) {
+	g := req.GetGrade()
+	grade := model.GradeTask{
+		StudentID: g.GetStudentId(),
+		CourseID:  g.GetCourseId(),
+		TaskID:    g.GetTaskId(),
+		Grade:     g.GetGrade(),
+		OnTime:    g.GetOnTime(),
+		CreatedAt: optionalTime(g.GetCreatedAt()),
+	}
+	if err := service.ValidateGradeTask(grade); err != nil {
+		return nil, invalidArgument(err.Error())
+	}
+
+	if s.deps.SyncWrites {
+		stored, err := service.SaveGradeTask(ctx, s.deps.DB, s.deps.GradeListener, grade)
+		if err != nil {
+			return nil, writeError(ctx, err)
+		}
+		return &statsv1.AddGradeTaskResponse{Result: &statsv1.AddGradeTaskResponse_Stored{Stored: &statsv1.GradeTask{
+			StudentId: stored.StudentID,
+			CourseId:  stored.CourseID,
+			TaskId:    stored.TaskID,
+			Grade:     stored.Grade,
+			OnTime:    stored.OnTime,
+			CreatedAt: timestamppb.New(stored.CreatedAt),
+		}}}, nil
+	}
+
+	queued, err := s.enqueue(ctx, types.TaskAddStudentGradeTask, grade)
+	if err != nil {
+		return nil, err
+	}
+	return &statsv1.AddGradeTaskResponse{Result: &statsv1.AddGradeTaskResponse_Queued{Queued: queued}}, nil
+}
+
+func (s *Server) enqueue(ctx context.Context, taskType string, payload interface{}) (*statsv1.Queued, error) {
+	delay, err := s.deps.Enqueuer.Enqueue(ctx, taskType, payload)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to enqueue task: %v", err)
+	}
+	return &statsv1.Queued{ExpectedDelay: durationpb.New(delay)}, nil
+}
+
+// optionalTime converts a timestamp that may be unset. Grades without
+// created_at get the zero time, as when the REST body leaves it out.
+func optionalTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
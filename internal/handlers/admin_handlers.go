@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdminToken only lets through the requests that carry token as a
+// bearer token.
+func RequireAdminToken(token string, next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sent, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing admin token"})
+			return
+		}
+		next(c)
+	}
+}
+
+// deadLetterResponse renders a dead letter. Payloads are JSON except for
+// the ones that failed for not being JSON, which are returned as a string.
+func deadLetterResponse(d database.DeadLetter) gin.H {
+	var payload interface{} = string(d.Payload)
+	if json.Valid(d.Payload) {
+		payload = json.RawMessage(d.Payload)
+	}
+	return gin.H{
+		"id":         d.ID,
+		"task_id":    d.TaskID,
+		"queue":      d.Queue,
+		"task_type":  d.TaskType,
+		"payload":    payload,
+		"error":      d.Error,
+		"retried":    d.Retried,
+		"permanent":  d.Permanent,
+		"status":     d.Status,
+		"failed_at":  d.FailedAt.Format(time.RFC3339),
+		"updated_at": d.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// deadLetterErrorStatus maps the errors of the dead letter repository
+// functions to a status code.
+func deadLetterErrorStatus(c *gin.Context, err error) int {
+	switch {
+	case errors.Is(err, database.ErrDeadLetterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, database.ErrDeadLetterResolved):
+		return http.StatusConflict
+	}
+	return queryErrorStatus(c, err, http.StatusInternalServerError)
+}
+
+func deadLetterID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dead letter id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// Handler para listar las tareas que el worker dejó de reintentar
+func APIHandlerListDeadLetters(db *sql.DB, c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", database.DeadLetterPending, database.DeadLetterReplayed, database.DeadLetterDiscarded:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, replayed or discarded"})
+		return
+	}
+
+	limit := database.DefaultListLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > database.MaxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		limit = parsed
+	}
+
+	var cursor int64
+	if value := c.Query("cursor"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		cursor = parsed
+	}
+
+	deadLetters, err := database.ListDeadLetters(requestContext(c), db, status, limit, cursor)
+	if err != nil {
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]gin.H, 0, len(deadLetters))
+	for _, d := range deadLetters {
+		items = append(items, deadLetterResponse(d))
+	}
+	var next interface{}
+	if len(deadLetters) == limit {
+		next = strconv.FormatInt(deadLetters[len(deadLetters)-1].ID, 10)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": items,
+		"pagination":   gin.H{"limit": limit, "next_cursor": next},
+	})
+}
+
+// Handler para inspeccionar una tarea fallida
+func APIHandlerGetDeadLetter(db *sql.DB, c *gin.Context) {
+	id, ok := deadLetterID(c)
+	if !ok {
+		return
+	}
+
+	d, err := database.GetDeadLetter(requestContext(c), db, id)
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(c, err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetterResponse(*d))
+}
+
+// DeadLetterUpdateRequest reemplaza el payload con el que se va a
+// reencolar la tarea
+type DeadLetterUpdateRequest struct {
+	Payload json.RawMessage `json:"payload" binding:"required"`
+}
+
+// Handler para corregir el payload de una tarea fallida antes de reencolarla
+func APIHandlerUpdateDeadLetter(db *sql.DB, c *gin.Context) {
+	id, ok := deadLetterID(c)
+	if !ok {
+		return
+	}
+
+	var req DeadLetterUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(req.Payload, &object); err != nil || object == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payload must be a JSON object"})
+		return
+	}
+
+	d, err := database.UpdateDeadLetterPayload(requestContext(c), db, id, req.Payload)
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(c, err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetterResponse(*d))
+}
+
+// Handler para reencolar una tarea fallida con su payload actual
+func APIHandlerReplayDeadLetter(db *sql.DB, replayer Replayer, c *gin.Context) {
+	id, ok := deadLetterID(c)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+
+	// Claiming the dead letter first keeps two replays from both enqueueing it
+	d, err := database.ResolveDeadLetter(ctx, db, id, database.DeadLetterReplayed)
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(c, err), gin.H{"error": err.Error()})
+		return
+	}
+
+	reopen := func() {
+		if err := database.ReopenDeadLetter(ctx, db, id); err != nil {
+			slog.ErrorContext(ctx, "failed to reopen dead letter", "id", id, "error", err)
+		}
+	}
+
+	// The stored payload is a whole envelope, possibly of an older version;
+	// it is enqueued again as a current one with fresh metadata
+	env, err := queue.DecodeEnvelope(d.TaskType, d.Payload)
+	if err != nil {
+		reopen()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The payload can't be decoded, fix it with PUT before replaying: " + err.Error()})
+		return
+	}
+
+	// The task already went through its delay once, so it runs right away
+	if err := replayer.EnqueueNow(ctx, d.TaskType, env.Payload); err != nil {
+		reopen()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to enqueue task"})
+		return
+	}
+
+	slog.InfoContext(ctx, "dead letter replayed", "id", id, "task_type", d.TaskType)
+	c.JSON(http.StatusOK, deadLetterResponse(*d))
+}
+
+// Handler para descartar una tarea fallida
+func APIHandlerDiscardDeadLetter(db *sql.DB, c *gin.Context) {
+	id, ok := deadLetterID(c)
+	if !ok {
+		return
+	}
+
+	d, err := database.ResolveDeadLetter(requestContext(c), db, id, database.DeadLetterDiscarded)
+	if err != nil {
+		c.JSON(deadLetterErrorStatus(c, err), gin.H{"error": err.Error()})
+		return
+	}
+
+	slog.InfoContext(requestContext(c), "dead letter discarded", "id", id, "task_type", d.TaskType)
+	c.JSON(http.StatusOK, deadLetterResponse(*d))
+}
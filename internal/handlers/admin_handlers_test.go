@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deadLetter(id int64, status string, payload string) *database.DeadLetter {
+	failedAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	return &database.DeadLetter{
+		ID: id, TaskID: "t1", Queue: "default", TaskType: "task:add_student_grade",
+		Payload: []byte(payload), Error: "db error", Retried: 15,
+		Status: status, FailedAt: failedAt, UpdatedAt: failedAt,
+	}
+}
+
+func resetDeadLetterMocks() {
+	database.ListDeadLetters = func(ctx context.Context, DB *sql.DB, status string, limit int, beforeID int64) ([]database.DeadLetter, error) {
+		return nil, errors.New("ListDeadLetters not mocked")
+	}
+	database.ResolveDeadLetter = func(ctx context.Context, DB *sql.DB, id int64, status string) (*database.DeadLetter, error) {
+		return nil, errors.New("ResolveDeadLetter not mocked")
+	}
+	database.ReopenDeadLetter = func(ctx context.Context, DB *sql.DB, id int64) error {
+		return errors.New("ReopenDeadLetter not mocked")
+	}
+}
+
+func TestRequireAdminToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", RequireAdminToken("s3cret", func(c *gin.Context) { c.Status(http.StatusNoContent) }))
+
+	for header, want := range map[string]int{
+		"":               http.StatusUnauthorized,
+		"Bearer wrong":   http.StatusUnauthorized,
+		"s3cret":         http.StatusUnauthorized,
+		"Bearer s3cret":  http.StatusNoContent,
+		"Bearer s3cret ": http.StatusUnauthorized,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", header)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code, header)
+	}
+}
+
+func TestAPIHandlerListDeadLetters(t *testing.T) {
+	resetDeadLetterMocks()
+	var gotStatus string
+	var gotLimit int
+	var gotCursor int64
+	database.ListDeadLetters = func(ctx context.Context, DB *sql.DB, status string, limit int, beforeID int64) ([]database.DeadLetter, error) {
+		gotStatus, gotLimit, gotCursor = status, limit, beforeID
+		return []database.DeadLetter{*deadLetter(9, "pending", `{"student_id":"s1"}`), *deadLetter(7, "pending", "not json")}, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/admin/dead_letters?status=pending&limit=2&cursor=10", nil)
+
+	APIHandlerListDeadLetters(mock_database(), c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "pending", gotStatus)
+	assert.Equal(t, 2, gotLimit)
+	assert.Equal(t, int64(10), gotCursor)
+
+	var body struct {
+		DeadLetters []map[string]interface{} `json:"dead_letters"`
+		Pagination  map[string]interface{}   `json:"pagination"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.DeadLetters, 2)
+	assert.Equal(t, map[string]interface{}{"student_id": "s1"}, body.DeadLetters[0]["payload"])
+	assert.Equal(t, "not json", body.DeadLetters[1]["payload"])
+	assert.Equal(t, "7", body.Pagination["next_cursor"])
+}
+
+func TestAPIHandlerListDeadLetters_InvalidParams(t *testing.T) {
+	for _, query := range []string{"status=lost", "limit=0", "limit=501", "cursor=abc"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/admin/dead_letters?"+query, nil)
+
+		APIHandlerListDeadLetters(mock_database(), c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestAPIHandlerGetDeadLetter_NotFound(t *testing.T) {
+	database.GetDeadLetter = func(ctx context.Context, DB *sql.DB, id int64) (*database.DeadLetter, error) {
+		return nil, database.ErrDeadLetterNotFound
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/admin/dead_letters/3", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "3"}}
+
+	APIHandlerGetDeadLetter(mock_database(), c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAPIHandlerUpdateDeadLetter(t *testing.T) {
+	var received []byte
+	database.UpdateDeadLetterPayload = func(ctx context.Context, DB *sql.DB, id int64, payload []byte) (*database.DeadLetter, error) {
+		received = payload
+		return deadLetter(id, "pending", string(payload)), nil
+	}
+
+	for body, want := range map[string]int{
+		`{"payload":{"student_id":"s1","course_id":"c1","grade":7}}`: http.StatusOK,
+		`{"payload":"not an object"}`:                                http.StatusBadRequest,
+		`{}`:                                                         http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("PUT", "/admin/dead_letters/3", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = []gin.Param{{Key: "id", Value: "3"}}
+
+		APIHandlerUpdateDeadLetter(mock_database(), c)
+
+		assert.Equal(t, want, w.Code, body)
+	}
+	assert.JSONEq(t, `{"student_id":"s1","course_id":"c1","grade":7}`, string(received))
+}
+
+func TestAPIHandlerReplayDeadLetter(t *testing.T) {
+	resetDeadLetterMocks()
+	database.ResolveDeadLetter = func(ctx context.Context, DB *sql.DB, id int64, status string) (*database.DeadLetter, error) {
+		assert.Equal(t, database.DeadLetterReplayed, status)
+		return deadLetter(id, status, `{"student_id":"s1"}`), nil
+	}
+
+	var enqueued interface{}
+	enqueuer := &MockEnqueuer{EnqueueNowFunc: func(ctx context.Context, taskType string, payload interface{}) error {
+		assert.Equal(t, "task:add_student_grade", taskType)
+		enqueued = payload
+		return nil
+	}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/admin/dead_letters/3/replay", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "3"}}
+
+	APIHandlerReplayDeadLetter(mock_database(), enqueuer, c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, json.RawMessage(`{"student_id":"s1"}`), enqueued)
+	assert.NotContains(t, w.Body.String(), "expected_delay_seconds", "replays run right away")
+}
+
+func TestAPIHandlerReplayDeadLetter_ReopensOnFailure(t *testing.T) {
+	for name, payload := range map[string]string{
+		"enqueue fails": `{"student_id":"s1"}`,
+		"invalid json":  "not json",
+	} {
+		resetDeadLetterMocks()
+		database.ResolveDeadLetter = func(ctx context.Context, DB *sql.DB, id int64, status string) (*database.DeadLetter, error) {
+			return deadLetter(id, status, payload), nil
+		}
+		reopened := false
+		database.ReopenDeadLetter = func(ctx context.Context, DB *sql.DB, id int64) error {
+			reopened = true
+			return nil
+		}
+		enqueuer := &MockEnqueuer{EnqueueNowFunc: func(ctx context.Context, taskType string, payload interface{}) error {
+			return errors.New("redis down")
+		}}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/admin/dead_letters/3/replay", nil)
+		c.Params = []gin.Param{{Key: "id", Value: "3"}}
+
+		APIHandlerReplayDeadLetter(mock_database(), enqueuer, c)
+
+		assert.NotEqual(t, http.StatusOK, w.Code, name)
+		assert.True(t, reopened, name)
+	}
+}
+
+func TestAPIHandlerDiscardDeadLetter_AlreadyResolved(t *testing.T) {
+	resetDeadLetterMocks()
+	database.ResolveDeadLetter = func(ctx context.Context, DB *sql.DB, id int64, status string) (*database.DeadLetter, error) {
+		return nil, database.ErrDeadLetterResolved
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/admin/dead_letters/3/discard", nil)
+	c.Params = []gin.Param{{Key: "id", Value: "3"}}
+
+	APIHandlerDiscardDeadLetter(mock_database(), c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
@@ -1,28 +1,54 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
-	"service_stats/internal/database"
 	"time"
-	"unicode"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/cache"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
-func isValidObjectID(id string) bool {
-	// Validación más flexible para course_id y task_id
-	if len(id) < 1 || len(id) > 50 {
-		return false
-	}
-	// Verifica que solo contenga caracteres alfanuméricos
-	for _, c := range id {
-		if !unicode.IsLetter(c) && !unicode.IsNumber(c) && c != '-'{
-			return false
-		}
+// requestContext returns the context of the incoming request, so database
+// calls inherit its trace and cancellation.
+func requestContext(c *gin.Context) context.Context {
+	if c.Request == nil {
+		return context.Background()
 	}
-	return true
+	return c.Request.Context()
+}
+
+// StatusClientClosedRequest follows the nginx convention for a request the
+// client abandoned before the response was ready.
+const StatusClientClosedRequest = 499
+
+// queryErrorStatus picks the status for a failed repository call: 499 when
+// the client disconnected (which cancels the request context and with it
+// the query), 504 when the query ran out of time, 400 when the grouping
+// turned out to be invalid (an institution without terms), fallback
+// otherwise.
+func queryErrorStatus(c *gin.Context, err error, fallback int) int {
+	if requestContext(c).Err() != nil {
+		return StatusClientClosedRequest
+	}
+	if database.IsQueryCanceled(err) {
+		return http.StatusGatewayTimeout
+	}
+	if errors.Is(err, database.ErrInvalidGrouping) {
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
+// isValidObjectID checks the IDs of the path like the gRPC API does.
+func isValidObjectID(id string) bool {
+	return service.ValidID(id)
 }
 
 /*func APIHandlerInsertGrade( c *gin.Context, StudentGrade model.Grade) {
@@ -71,9 +97,9 @@ func APIHandlerGetStatsForStudent(db *sql.DB, c *gin.Context) {
 		return
 	}
 
-	avgGrade, code, err := database.GetAvgGradeForStudent(db, studentID, courseID)
+	avgGrade, code, err := database.GetAvgGradeForStudent(requestContext(c), db, studentID, courseID)
 	if err != nil {
-		c.JSON(code, gin.H{"result": "Failed to get average grade", "status": http.StatusInternalServerError})
+		c.JSON(queryErrorStatus(c, err, code), gin.H{"result": "Failed to get average grade", "status": http.StatusInternalServerError})
 		return
 	}
 
@@ -93,38 +119,117 @@ func APIHandlerGetStatsForStudent(db *sql.DB, c *gin.Context) {
 
 // Estructuras para las requests
 type TimeRangeRequest struct {
-	StartDate string `form:"start_date"`
-	EndDate   string `form:"end_date"`
-	GroupBy   string `form:"group_by"` // "day", "week", "month", "quarter", "year"
+	StartDate   string `form:"start_date"`
+	EndDate     string `form:"end_date"`
+	GroupBy     string `form:"group_by"`    // unidad ("day", "week", ...), intervalo ("3 days") o "term"
+	Timezone    string `form:"tz"`          // nombre IANA, por ejemplo "America/Argentina/Buenos_Aires"
+	WeekStart   string `form:"week_start"`  // "iso" (lunes) o "sunday"
+	Institution string `form:"institution"` // calendario académico para group_by=term
 }
 
-// Helper function para parsear fechas
-func parseTimeRange(start, end string) (time.Time, time.Time, error) {
-	layout := "2006-01-02" // Formato YYYY-MM-DD
-	var startTime, endTime time.Time
-	var err error
+// SeriesRequest son los parámetros que completan las series de promedios
+type SeriesRequest struct {
+	Fill       string `form:"fill"`    // "null", "zero", "previous"
+	Rolling    int    `form:"rolling"` // períodos del promedio móvil
+	Cumulative bool   `form:"cumulative"`
+}
 
-	if start != "" {
-		startTime, err = time.Parse(layout, start)
-		if err != nil {
-			return time.Time{}, time.Time{}, err
-		}
-	} else {
-		startTime = time.Time{} // Cero value para indicar "sin límite"
+// parseSeriesOptions reads and validates the SeriesRequest of c for the
+// given range. Errors are meant for a 400 response.
+func parseSeriesOptions(c *gin.Context, grouping database.TimeGrouping, startTime, endTime time.Time) (database.SeriesOptions, error) {
+	var req SeriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return database.SeriesOptions{}, errors.New("Invalid query parameters")
+	}
+
+	series := database.SeriesOptions{
+		Fill:       req.Fill,
+		Rolling:    req.Rolling,
+		Cumulative: req.Cumulative,
+	}
+	if err := series.Validate(grouping, startTime, endTime); err != nil {
+		return series, err
 	}
+	return series, nil
+}
+
+// ListRequest son los parámetros de paginación, orden y filtros de los
+// endpoints que devuelven listas de estudiantes
+type ListRequest struct {
+	Limit       int      `form:"limit"`
+	Cursor      string   `form:"cursor"`
+	Sort        string   `form:"sort"`  // "average", "count", "student_id"
+	Order       string   `form:"order"` // "asc", "desc"
+	MinAverage  *float64 `form:"min_average"`
+	MaxAverage  *float64 `form:"max_average"`
+	OnTime      bool     `form:"on_time"`
+	GradedAfter string   `form:"graded_after"` // YYYY-MM-DD
+}
 
-	if end != "" {
-		endTime, err = time.Parse(layout, end)
+// parseListOptions reads and validates the ListRequest of c. Errors are
+// meant for a 400 response.
+func parseListOptions(c *gin.Context) (database.ListOptions, error) {
+	var req ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return database.ListOptions{}, errors.New("Invalid query parameters")
+	}
+
+	opts := database.ListOptions{
+		Limit:      req.Limit,
+		Cursor:     req.Cursor,
+		Sort:       req.Sort,
+		Order:      req.Order,
+		MinAverage: req.MinAverage,
+		MaxAverage: req.MaxAverage,
+		OnTimeOnly: req.OnTime,
+	}
+	if req.GradedAfter != "" {
+		gradedAfter, err := time.Parse("2006-01-02", req.GradedAfter)
 		if err != nil {
-			return time.Time{}, time.Time{}, err
+			return opts, errors.New("Invalid graded_after format. Use YYYY-MM-DD")
 		}
-		// Ajustamos para incluir todo el día final
-		endTime = endTime.Add(24*time.Hour - time.Nanosecond)
-	} else {
-		endTime = time.Now()
+		opts.GradedAfter = gradedAfter
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// pagination describes a page in the response body
+func pagination(page *database.Page, opts database.ListOptions) gin.H {
+	limit := opts.Limit
+	if limit == 0 {
+		limit = database.DefaultListLimit
 	}
+	var next interface{}
+	if page.NextCursor != "" {
+		next = page.NextCursor
+	}
+	return gin.H{
+		"limit":       limit,
+		"total":       page.Total,
+		"next_cursor": next,
+	}
+}
+
+// parseTimeGrouping reads the grouping of req. Errors are meant for a 400
+// response.
+func parseTimeGrouping(req TimeRangeRequest) (database.TimeGrouping, error) {
+	return database.NewTimeGrouping(req.GroupBy, req.Timezone, req.WeekStart, req.Institution)
+}
 
-	return startTime, endTime, nil
+// timeRange describes the queried range in the response body
+func timeRange(startTime, endTime time.Time, grouping database.TimeGrouping) gin.H {
+	tz := "UTC"
+	if grouping.Location != nil {
+		tz = grouping.Location.String()
+	}
+	return gin.H{
+		"start": startTime.Format(time.RFC3339),
+		"end":   endTime.Format(time.RFC3339),
+		"tz":    tz,
+	}
 }
 
 // Handler para promedio de estudiante
@@ -137,28 +242,37 @@ func APIHandlerGetStudentAverageOverTime(db *sql.DB, c *gin.Context) {
 		return
 	}
 
-	startTime, endTime, err := parseTimeRange(req.StartDate, req.EndDate)
+	grouping, err := parseTimeGrouping(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startTime, endTime, err := database.ParseDateRange(req.StartDate, req.EndDate, grouping.Location)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 		return
 	}
 
-	log.Println("Fetching averages for student:", studentID, "from", startTime, "to", endTime, "grouped by", req.GroupBy)
+	slog.DebugContext(requestContext(c), "fetching student averages", "student_id", studentID, "start", startTime, "end", endTime, "group_by", grouping.String())
+
+	series, err := parseSeriesOptions(c, grouping, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	averages, err := database.GetStudentAveragesOverTime(db, studentID, startTime, endTime, req.GroupBy)
+	averages, err := database.GetStudentAveragesOverTime(requestContext(c), db, studentID, startTime, endTime, grouping, series)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"student_id": studentID,
 		"averages":   averages,
-		"time_range": gin.H{
-			"start": startTime.Format(time.RFC3339),
-			"end":   endTime.Format(time.RFC3339),
-		},
-		"group_by": req.GroupBy,
+		"time_range": timeRange(startTime, endTime, grouping),
+		"group_by":   grouping.String(),
 	})
 }
 
@@ -172,26 +286,35 @@ func APIHandlerGetCourseAverageOverTime(db *sql.DB, c *gin.Context) {
 		return
 	}
 
-	startTime, endTime, err := parseTimeRange(req.StartDate, req.EndDate)
+	grouping, err := parseTimeGrouping(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startTime, endTime, err := database.ParseDateRange(req.StartDate, req.EndDate, grouping.Location)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 		return
 	}
 
-	averages, err := database.GetCourseAveragesOverTime(db, courseID, startTime, endTime, req.GroupBy)
+	series, err := parseSeriesOptions(c, grouping, startTime, endTime)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	averages, err := database.GetCourseAveragesOverTime(requestContext(c), db, courseID, startTime, endTime, grouping, series)
+	if err != nil {
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"course_id": courseID,
-		"averages":  averages,
-		"time_range": gin.H{
-			"start": startTime.Format(time.RFC3339),
-			"end":   endTime.Format(time.RFC3339),
-		},
-		"group_by": req.GroupBy,
+		"course_id":  courseID,
+		"averages":   averages,
+		"time_range": timeRange(startTime, endTime, grouping),
+		"group_by":   grouping.String(),
 	})
 }
 
@@ -210,9 +333,9 @@ func APIHandlerGetStatsForStudentTask(db *sql.DB, c *gin.Context) {
 		return
 	}
 
-	avgGrade, code, err := database.GetAvgGradeTaskForStudent(db, studentID, courseID, taskID)
+	avgGrade, code, err := database.GetAvgGradeTaskForStudent(requestContext(c), db, studentID, courseID, taskID)
 	if err != nil {
-		c.JSON(code, gin.H{"result": "Failed to get average grade", "status": http.StatusInternalServerError})
+		c.JSON(queryErrorStatus(c, err, code), gin.H{"result": "Failed to get average grade", "status": http.StatusInternalServerError})
 		return
 	}
 
@@ -245,17 +368,23 @@ func APIHandlerGetStudentCourseTasksAverage(db *sql.DB, c *gin.Context) {
 		return
 	}
 
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Obtener promedio del estudiante solicitado
-	studentAvg, code, err := database.GetStudentCourseTasksAverage(db, studentID, courseID)
+	studentAvg, code, err := database.GetStudentCourseTasksAverage(requestContext(c), db, studentID, courseID)
 	if err != nil {
-		c.JSON(code, gin.H{"error": err.Error()})
+		c.JSON(queryErrorStatus(c, err, code), gin.H{"error": err.Error()})
 		return
 	}
 
 	// Obtener promedios de otros estudiantes
-	otherStudents, err := database.GetOtherStudentsCourseAverages(db, studentID, courseID)
+	otherStudents, err := database.GetOtherStudentsCourseAverages(requestContext(c), db, studentID, courseID, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -264,7 +393,8 @@ func APIHandlerGetStudentCourseTasksAverage(db *sql.DB, c *gin.Context) {
 		"student_id":      studentID,
 		"course_id":       courseID,
 		"student_average": studentAvg,
-		"other_students":  otherStudents,
+		"other_students":  otherStudents.Items,
+		"pagination":      pagination(otherStudents, opts),
 	}
 
 	if code == http.StatusNotFound {
@@ -284,30 +414,31 @@ func APIHandlerGetTaskAverages(db *sql.DB, c *gin.Context) {
 		return
 	}
 
-	averages, err := database.GetAveragesForTask(db, courseID, taskID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Calcular promedio general del grupo
-	var totalSum float64
-	var totalCount int
-	for _, student := range averages {
-		totalSum += student["average_grade"].(float64) * float64(student["grade_count"].(int))
-		totalCount += student["grade_count"].(int)
+	averages, err := database.GetAveragesForTask(requestContext(c), db, courseID, taskID, opts)
+	if err != nil {
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
 	}
 
-	groupAverage := 0.0
-	if totalCount > 0 {
-		groupAverage = totalSum / float64(totalCount)
+	// El promedio general del grupo sale de course_task_stats
+	groupAverage, _, _, err := database.GetTaskSummary(requestContext(c), db, courseID, taskID)
+	if err != nil {
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"course_id":     courseID,
 		"task_id":       taskID,
 		"group_average": groupAverage,
-		"students":      averages,
+		"students":      averages.Items,
+		"pagination":    pagination(averages, opts),
 	})
 }
 
@@ -321,26 +452,29 @@ func APIHandlerGetCourseOnTimePercentage(db_ref *sql.DB, c *gin.Context) {
 		return
 	}
 
-	startTime, endTime, err := parseTimeRange(req.StartDate, req.EndDate)
+	grouping, err := parseTimeGrouping(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startTime, endTime, err := database.ParseDateRange(req.StartDate, req.EndDate, grouping.Location)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 		return
 	}
 
-	results, err := database.GetOnTimeSubmissionPercentageForCourse(db_ref, courseID, startTime, endTime, req.GroupBy)
+	results, err := database.GetOnTimeSubmissionPercentageForCourse(requestContext(c), db_ref, courseID, startTime, endTime, grouping)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"course_id": courseID,
-		"data":      results,
-		"time_range": gin.H{
-			"start": startTime.Format(time.RFC3339),
-			"end":   endTime.Format(time.RFC3339),
-		},
-		"group_by": req.GroupBy,
+		"course_id":  courseID,
+		"data":       results,
+		"time_range": timeRange(startTime, endTime, grouping),
+		"group_by":   grouping.String(),
 	})
 }
 
@@ -355,15 +489,21 @@ func APIHandlerGetStudentOnTimePercentage(db_ref *sql.DB, c *gin.Context) {
 		return
 	}
 
-	startTime, endTime, err := parseTimeRange(req.StartDate, req.EndDate)
+	grouping, err := parseTimeGrouping(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startTime, endTime, err := database.ParseDateRange(req.StartDate, req.EndDate, grouping.Location)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 		return
 	}
 
-	results, err := database.GetOnTimeSubmissionPercentageForStudent(db_ref, courseID, studentID, startTime, endTime, req.GroupBy)
+	results, err := database.GetOnTimeSubmissionPercentageForStudent(requestContext(c), db_ref, courseID, studentID, startTime, endTime, grouping)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -371,10 +511,97 @@ func APIHandlerGetStudentOnTimePercentage(db_ref *sql.DB, c *gin.Context) {
 		"course_id":  courseID,
 		"student_id": studentID,
 		"data":       results,
-		"time_range": gin.H{
-			"start": startTime.Format(time.RFC3339),
-			"end":   endTime.Format(time.RFC3339),
-		},
-		"group_by": req.GroupBy,
+		"time_range": timeRange(startTime, endTime, grouping),
+		"group_by":   grouping.String(),
 	})
 }
+
+// AcademicTermRequest es un período del calendario académico, con fechas
+// en formato YYYY-MM-DD
+type AcademicTermRequest struct {
+	Name     string `json:"name" binding:"required"`
+	StartsOn string `json:"starts_on" binding:"required"`
+	EndsOn   string `json:"ends_on" binding:"required"`
+}
+
+type AcademicCalendarRequest struct {
+	Terms []AcademicTermRequest `json:"terms" binding:"required,dive"`
+}
+
+func academicTermsResponse(institutionID string, terms []database.AcademicTerm) gin.H {
+	body := make([]gin.H, 0, len(terms))
+	for _, term := range terms {
+		body = append(body, gin.H{
+			"name":      term.Name,
+			"starts_on": term.StartsOn.Format("2006-01-02"),
+			"ends_on":   term.EndsOn.Format("2006-01-02"),
+		})
+	}
+	return gin.H{"institution_id": institutionID, "terms": body}
+}
+
+// Handler para consultar el calendario académico de una institución
+func APIHandlerGetAcademicTerms(db *sql.DB, c *gin.Context) {
+	institutionID := c.Param("institution_id")
+	if !isValidObjectID(institutionID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid institution_id format"})
+		return
+	}
+
+	terms, err := database.GetAcademicTerms(requestContext(c), db, institutionID)
+	if err != nil {
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, academicTermsResponse(institutionID, terms))
+}
+
+// Handler para reemplazar el calendario académico de una institución
+func APIHandlerReplaceAcademicTerms(db *sql.DB, responses cache.Invalidator, c *gin.Context) {
+	institutionID := c.Param("institution_id")
+	if !isValidObjectID(institutionID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid institution_id format"})
+		return
+	}
+
+	var req AcademicCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	terms := make([]database.AcademicTerm, 0, len(req.Terms))
+	for _, term := range req.Terms {
+		startsOn, err := time.Parse("2006-01-02", term.StartsOn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		endsOn, err := time.Parse("2006-01-02", term.EndsOn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		terms = append(terms, database.AcademicTerm{Name: term.Name, StartsOn: startsOn, EndsOn: endsOn})
+	}
+
+	err := database.ReplaceAcademicTerms(requestContext(c), db, institutionID, terms)
+	if errors.Is(err, database.ErrInvalidAcademicTerms) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	// The periods of the cached group_by=term responses may have moved
+	if responses != nil {
+		if err := responses.Invalidate(requestContext(c), cache.InstitutionTag(institutionID)); err != nil {
+			slog.WarnContext(requestContext(c), "error invalidating cached responses", "institution_id", institutionID, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, academicTermsResponse(institutionID, terms))
+}
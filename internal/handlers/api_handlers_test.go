@@ -1,18 +1,22 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"service_stats/internal/database"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsValidObjectID(t *testing.T) {
@@ -116,11 +120,15 @@ func mock_database() *sql.DB {
 	return db
 }
 
+func float(value float64) *float64 {
+	return &value
+}
+
 func TestMissingParams(t *testing.T) {
 
 	db := mock_database()
 
-	database.GetAvgGradeForStudent = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 0, http.StatusBadRequest, errors.New("Missing student_id or course_id")
 	}
 
@@ -172,7 +180,7 @@ func TestAPIHandlerGetStatsForStudent(t *testing.T) {
 			studentID: "abc",
 			courseID:  "507f1f77bcf86cd799439011",
 			mockFunc: func() {
-				database.GetAvgGradeForStudent = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+				database.GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 					return 0, http.StatusInternalServerError, errors.New("DB error")
 				}
 			},
@@ -184,7 +192,7 @@ func TestAPIHandlerGetStatsForStudent(t *testing.T) {
 			studentID: "abc",
 			courseID:  "507f1f77bcf86cd799439011",
 			mockFunc: func() {
-				database.GetAvgGradeForStudent = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+				database.GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 					return 0, http.StatusNotFound, nil
 				}
 			},
@@ -196,7 +204,7 @@ func TestAPIHandlerGetStatsForStudent(t *testing.T) {
 			studentID: "abc",
 			courseID:  "507f1f77bcf86cd799439011",
 			mockFunc: func() {
-				database.GetAvgGradeForStudent = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+				database.GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 					return 7.5, http.StatusOK, nil
 				}
 			},
@@ -235,7 +243,7 @@ func TestInvalidCourseID(t *testing.T) {
 
 	db := mock_database()
 
-	database.GetAvgGradeForStudent = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 0, http.StatusBadRequest, errors.New("Missing student_id or course_id")
 	}
 
@@ -257,7 +265,7 @@ func TestDBError(t *testing.T) {
 
 	db := mock_database()
 
-	database.GetAvgGradeForStudent = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 0, http.StatusInternalServerError, errors.New("Failed to get average grade")
 	}
 
@@ -279,7 +287,7 @@ func TestNoGradesFound(t *testing.T) {
 
 	db := mock_database()
 
-	database.GetAvgGradeForStudent = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 0, http.StatusNotFound, errors.New("No grades found for this student in the course")
 	}
 
@@ -301,7 +309,7 @@ func TestSuccessCase(t *testing.T) {
 
 	db := mock_database()
 
-	database.GetAvgGradeForStudent = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetAvgGradeForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 92.5, http.StatusOK, nil
 	}
 
@@ -325,10 +333,11 @@ func TestSuccessCase(t *testing.T) {
 func TestAPIHandlerGetStudentAverageOverTime_HappyPath(t *testing.T) {
 	db := mock_database()
 
-	database.GetStudentAveragesOverTime = func(DB *sql.DB, studentID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
+	database.GetStudentAveragesOverTime = func(ctx context.Context, DB *sql.DB, studentID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
 		// Mocked data for testing
-		return []map[string]interface{}{
-			{"student_id": "123", "averages": []float64{90.5, 85}, "group_by": groupBy},
+		return []database.PeriodAverage{
+			{Period: "2023-01-02T00:00:00Z", AverageGrade: float(90.5), GradeCount: 2},
+			{Period: "2023-01-09T00:00:00Z", AverageGrade: float(85), GradeCount: 1},
 		}, nil
 	}
 
@@ -343,14 +352,14 @@ func TestAPIHandlerGetStudentAverageOverTime_HappyPath(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), `"student_id":"123"`)
-	assert.Contains(t, w.Body.String(), `"averages":[90.5,85]`)
+	assert.Contains(t, w.Body.String(), `"averages":[{"period":"2023-01-02T00:00:00Z","average_grade":90.5,"grade_count":2},{"period":"2023-01-09T00:00:00Z","average_grade":85,"grade_count":1}]`)
 	assert.Contains(t, w.Body.String(), `"group_by":"week"`)
 }
 
 func TestAPIHandlerGetStudentAverageOverTime_InvalidQueryParams(t *testing.T) {
 	db := mock_database()
 
-	database.GetStudentAveragesOverTime = func(DB *sql.DB, studentID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
+	database.GetStudentAveragesOverTime = func(ctx context.Context, DB *sql.DB, studentID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
 		return nil, errors.New("Invalid query parameters")
 	}
 
@@ -370,7 +379,7 @@ func TestAPIHandlerGetStudentAverageOverTime_InvalidQueryParams(t *testing.T) {
 func TestAPIHandlerGetStudentAverageOverTime_InvalidDateFormat(t *testing.T) {
 	db := mock_database()
 
-	database.GetStudentAveragesOverTime = func(DB *sql.DB, studentID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
+	database.GetStudentAveragesOverTime = func(ctx context.Context, DB *sql.DB, studentID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
 		return nil, errors.New("Invalid date format")
 	}
 
@@ -390,7 +399,7 @@ func TestAPIHandlerGetStudentAverageOverTime_InvalidDateFormat(t *testing.T) {
 func TestAPIHandlerGetStudentAverageOverTime_DatabaseError(t *testing.T) {
 	db := mock_database()
 
-	database.GetStudentAveragesOverTime = func(DB *sql.DB, studentID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
+	database.GetStudentAveragesOverTime = func(ctx context.Context, DB *sql.DB, studentID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
 		return nil, errors.New("db error")
 	}
 
@@ -407,14 +416,110 @@ func TestAPIHandlerGetStudentAverageOverTime_DatabaseError(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "db error")
 }
 
+func TestAPIHandlerGetStudentAverageOverTime_Timezone(t *testing.T) {
+	db := mock_database()
+
+	var got database.TimeGrouping
+	var gotStart, gotEnd time.Time
+	database.GetStudentAveragesOverTime = func(ctx context.Context, DB *sql.DB, studentID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
+		got, gotStart, gotEnd = grouping, startTime, endTime
+		return []database.PeriodAverage{}, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest("GET", "/student/123/averages?start_date=2026-03-01&end_date=2026-03-07&group_by=week&tz=America/Argentina/Buenos_Aires&week_start=sunday", nil)
+	c.Request = req
+	c.Params = []gin.Param{{Key: "student_id", Value: "123"}}
+
+	APIHandlerGetStudentAverageOverTime(db, c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "week", got.Unit)
+	assert.Equal(t, database.WeekStartSunday, got.WeekStart)
+	require.NotNil(t, got.Location)
+	assert.Equal(t, "America/Argentina/Buenos_Aires", got.Location.String())
+
+	// The days of the range are the ones of the requested zone
+	assert.Equal(t, time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC), gotStart.UTC())
+	assert.Equal(t, time.Date(2026, 3, 8, 3, 0, 0, 0, time.UTC), gotEnd.Add(time.Nanosecond).UTC())
+	assert.Contains(t, w.Body.String(), `"start":"2026-03-01T00:00:00-03:00"`)
+	assert.Contains(t, w.Body.String(), `"tz":"America/Argentina/Buenos_Aires"`)
+}
+
+func TestAPIHandlerGetStudentAverageOverTime_InvalidGrouping(t *testing.T) {
+	db := mock_database()
+
+	for _, query := range []string{
+		"group_by=week&tz=Mars/Olympus",
+		"group_by=week&week_start=saturday",
+		"group_by=fortnight",
+		"group_by=3+months",
+		"group_by=term",
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		req, _ := http.NewRequest("GET", "/student/123/averages?"+query, nil)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "student_id", Value: "123"}}
+
+		APIHandlerGetStudentAverageOverTime(db, c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Contains(t, w.Body.String(), "invalid grouping", query)
+	}
+}
+
+func TestAPIHandlerGetCourseAverageOverTime_Series(t *testing.T) {
+	db := mock_database()
+
+	var got database.SeriesOptions
+	database.GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
+		got = series
+		return []database.PeriodAverage{}, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequest("GET", "/course/abc123/averages?start_date=2026-03-01&end_date=2026-03-31&group_by=week&fill=zero&rolling=4&cumulative=true", nil)
+	c.Request = req
+	c.Params = []gin.Param{{Key: "course_id", Value: "abc123"}}
+
+	APIHandlerGetCourseAverageOverTime(db, c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, database.SeriesOptions{Fill: database.FillZero, Rolling: 4, Cumulative: true}, got)
+}
+
+func TestAPIHandlerGetCourseAverageOverTime_InvalidSeries(t *testing.T) {
+	db := mock_database()
+
+	for _, query := range []string{"group_by=week&fill=linear", "fill=zero", "group_by=day&rolling=abc"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		req, _ := http.NewRequest("GET", "/course/abc123/averages?"+query, nil)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "course_id", Value: "abc123"}}
+
+		APIHandlerGetCourseAverageOverTime(db, c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
 // Tests now for APIHandlerGetCourseAverageOverTime
 
 func TestAPIHandlerGetCourseAverageOverTime_Success(t *testing.T) {
 	db := mock_database()
 
-	database.GetCourseAveragesOverTime = func(DB *sql.DB, courseID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
-		return []map[string]interface{}{
-			{"course_id": "abc123", "averages": []float64{75.5, 80, 82.3}, "group_by": groupBy},
+	database.GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
+		return []database.PeriodAverage{
+			{Period: "2023-01-02T00:00:00Z", AverageGrade: float(75.5), GradeCount: 4, RollingAverage: float(75.5)},
+			{Period: "2023-01-09T00:00:00Z", AverageGrade: nil, GradeCount: 0},
 		}, nil
 	}
 
@@ -429,14 +534,14 @@ func TestAPIHandlerGetCourseAverageOverTime_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), `"course_id":"abc123"`)
-	assert.Contains(t, w.Body.String(), `"averages":[75.5,80,82.3]`)
+	assert.Contains(t, w.Body.String(), `"averages":[{"period":"2023-01-02T00:00:00Z","average_grade":75.5,"grade_count":4,"rolling_average":75.5},{"period":"2023-01-09T00:00:00Z","average_grade":null,"grade_count":0}]`)
 	assert.Contains(t, w.Body.String(), `"group_by":"week"`)
 }
 
 func TestAPIHandlerGetCourseAverageOverTime_InvalidQueryParams(t *testing.T) {
 	db := mock_database()
 
-	database.GetCourseAveragesOverTime = func(DB *sql.DB, courseID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
+	database.GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
 		return nil, errors.New("Invalid query parameters")
 	}
 	w := httptest.NewRecorder()
@@ -456,7 +561,7 @@ func TestAPIHandlerGetCourseAverageOverTime_InvalidQueryParams(t *testing.T) {
 func TestAPIHandlerGetCourseAverageOverTime_InvalidDateFormat(t *testing.T) {
 	db := mock_database()
 
-	database.GetCourseAveragesOverTime = func(DB *sql.DB, courseID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
+	database.GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
 		return nil, errors.New("Invalid date format")
 	}
 	w := httptest.NewRecorder()
@@ -475,7 +580,7 @@ func TestAPIHandlerGetCourseAverageOverTime_InvalidDateFormat(t *testing.T) {
 func TestAPIHandlerGetCourseAverageOverTime_DatabaseError(t *testing.T) {
 	db := mock_database()
 
-	database.GetCourseAveragesOverTime = func(DB *sql.DB, courseID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
+	database.GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
 		return nil, errors.New("database failure")
 	}
 
@@ -497,7 +602,7 @@ func TestAPIHandlerGetCourseAverageOverTime_DatabaseError(t *testing.T) {
 func TestAPIHandlerGetStatsForStudentTask_Success(t *testing.T) {
 	db := mock_database()
 
-	database.GetAvgGradeTaskForStudent = func(DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
+	database.GetAvgGradeTaskForStudent = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
 		return 88.5, http.StatusOK, nil
 	}
 
@@ -523,7 +628,7 @@ func TestAPIHandlerGetStatsForStudentTask_Success(t *testing.T) {
 func TestAPIHandlerGetStatsForStudentTask_InvalidStudentID(t *testing.T) {
 	db := mock_database()
 
-	database.GetAvgGradeTaskForStudent = func(DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
+	database.GetAvgGradeTaskForStudent = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
 		return 0, http.StatusBadRequest, errors.New("Invalid student_id format")
 	}
 	w := httptest.NewRecorder()
@@ -546,7 +651,7 @@ func TestAPIHandlerGetStatsForStudentTask_InvalidStudentID(t *testing.T) {
 func TestAPIHandlerGetStatsForStudentTask_InvalidCourseOrTaskID(t *testing.T) {
 	db := mock_database()
 
-	database.GetAvgGradeTaskForStudent = func(DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
+	database.GetAvgGradeTaskForStudent = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
 		return 0, http.StatusBadRequest, errors.New("Invalid course_id or task_id format")
 	}
 	w := httptest.NewRecorder()
@@ -569,7 +674,7 @@ func TestAPIHandlerGetStatsForStudentTask_InvalidCourseOrTaskID(t *testing.T) {
 func TestAPIHandlerGetStatsForStudentTask_NoGradesFound(t *testing.T) {
 	db := mock_database()
 
-	database.GetAvgGradeTaskForStudent = func(DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
+	database.GetAvgGradeTaskForStudent = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
 		return 0, http.StatusNotFound, errors.New("No grades found for the student in this task")
 	}
 
@@ -593,7 +698,7 @@ func TestAPIHandlerGetStatsForStudentTask_NoGradesFound(t *testing.T) {
 func TestAPIHandlerGetStatsForStudentTask_DatabaseError(t *testing.T) {
 	db := mock_database()
 
-	database.GetAvgGradeTaskForStudent = func(DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
+	database.GetAvgGradeTaskForStudent = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
 		return 0, http.StatusInternalServerError, errors.New("database failure")
 	}
 	w := httptest.NewRecorder()
@@ -616,7 +721,7 @@ func TestAPIHandlerGetStatsForStudentTask_DatabaseError(t *testing.T) {
 func TestAPIHandlerGetStatsForStudentTask_UserNotFound(t *testing.T) {
 	db := mock_database()
 
-	database.GetAvgGradeTaskForStudent = func(DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
+	database.GetAvgGradeTaskForStudent = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, taskID string) (float64, int, error) {
 		return 0, http.StatusNotFound, errors.New("No grades found for the student in this task")
 	}
 
@@ -644,14 +749,14 @@ func TestAPIHandlerGetStatsForStudentTask_UserNotFound(t *testing.T) {
 func TestAPIHandlerGetStudentCourseTasksAverage_Success(t *testing.T) {
 	db := mock_database()
 
-	database.GetStudentCourseTasksAverage = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 91.5, http.StatusOK, nil
 	}
-	database.GetOtherStudentsCourseAverages = func(DB *sql.DB, studentID string, courseID string) ([]map[string]interface{}, error) {
-		return []map[string]interface{}{
-			{"student_id": "507f1f77bcf86cd799439013", "average_grade": 88.0, "task_count": 2},
-			{"student_id": "507f1f77bcf86cd799439014", "average_grade": 92.0, "task_count": 3},
-		}, nil
+	database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts database.ListOptions) (*database.Page, error) {
+		return &database.Page{Items: []database.StudentAverage{
+			{StudentID: "507f1f77bcf86cd799439013", AverageGrade: 88.0, TaskCount: 2},
+			{StudentID: "507f1f77bcf86cd799439014", AverageGrade: 92.0, TaskCount: 3},
+		}, Total: 2}, nil
 	}
 
 	w := httptest.NewRecorder()
@@ -667,18 +772,18 @@ func TestAPIHandlerGetStudentCourseTasksAverage_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), `"student_average":91.5`)
-	assert.Contains(t, w.Body.String(), `{"course_id":"507f1f77bcf86cd799439012","other_students":[{"average_grade":88,"student_id":"507f1f77bcf86cd799439013","task_count":2},{"average_grade":92,"student_id":"507f1f77bcf86cd799439014","task_count":3}],"student_average":91.5,"student_id":"507f1f77bcf86cd799439011"}`)
+	assert.Contains(t, w.Body.String(), `{"course_id":"507f1f77bcf86cd799439012","other_students":[{"student_id":"507f1f77bcf86cd799439013","average_grade":88,"task_count":2},{"student_id":"507f1f77bcf86cd799439014","average_grade":92,"task_count":3}],"pagination":{"limit":50,"next_cursor":null,"total":2},"student_average":91.5,"student_id":"507f1f77bcf86cd799439011"}`)
 }
 
 func TestAPIHandlerGetStudentCourseTasksAverage_InvalidStudentID(t *testing.T) {
 	db := mock_database()
 
-	database.GetStudentCourseTasksAverage = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 0, http.StatusBadRequest, errors.New("Invalid student_id format")
 	}
 
-	database.GetOtherStudentsCourseAverages = func(DB *sql.DB, studentID string, courseID string) ([]map[string]interface{}, error) {
-		return nil, nil // Not really called on this case: Lucas fix
+	database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts database.ListOptions) (*database.Page, error) {
+		return &database.Page{}, nil // Not really called on this case: Lucas fix
 	}
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -698,12 +803,12 @@ func TestAPIHandlerGetStudentCourseTasksAverage_InvalidCourseID(t *testing.T) {
 
 	db := mock_database()
 
-	database.GetStudentCourseTasksAverage = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 0, http.StatusBadRequest, errors.New("Invalid course_id format")
 	}
 
-	database.GetOtherStudentsCourseAverages = func(DB *sql.DB, studentID string, courseID string) ([]map[string]interface{}, error) {
-		return nil, nil // Not really called on this case: Lucas fix
+	database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts database.ListOptions) (*database.Page, error) {
+		return &database.Page{}, nil // Not really called on this case: Lucas fix
 	}
 
 	w := httptest.NewRecorder()
@@ -723,15 +828,15 @@ func TestAPIHandlerGetStudentCourseTasksAverage_InvalidCourseID(t *testing.T) {
 func TestAPIHandlerGetStudentCourseTasksAverage_DBErrorOnStudentAvg(t *testing.T) {
 	db := mock_database()
 
-	database.GetStudentCourseTasksAverage = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 0, http.StatusInternalServerError, errors.New("db error")
 	}
 
-	database.GetOtherStudentsCourseAverages = func(DB *sql.DB, studentID string, courseID string) ([]map[string]interface{}, error) {
-		return []map[string]interface{}{
-			{"student_id": "507f1f77bcf86cd799439013", "average_grade": 88.0, "task_count": 2},
-			{"student_id": "507f1f77bcf86cd799439014", "average_grade": 92.0, "task_count": 3},
-		}, nil
+	database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts database.ListOptions) (*database.Page, error) {
+		return &database.Page{Items: []database.StudentAverage{
+			{StudentID: "507f1f77bcf86cd799439013", AverageGrade: 88.0, TaskCount: 2},
+			{StudentID: "507f1f77bcf86cd799439014", AverageGrade: 92.0, TaskCount: 3},
+		}, Total: 2}, nil
 	}
 
 	w := httptest.NewRecorder()
@@ -752,11 +857,11 @@ func TestAPIHandlerGetStudentCourseTasksAverage_DBErrorOnOthers(t *testing.T) {
 
 	db := mock_database()
 
-	database.GetStudentCourseTasksAverage = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 85, http.StatusOK, nil
 	}
 
-	database.GetOtherStudentsCourseAverages = func(DB *sql.DB, studentID string, courseID string) ([]map[string]interface{}, error) {
+	database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts database.ListOptions) (*database.Page, error) {
 		return nil, errors.New("db error")
 	}
 
@@ -777,12 +882,12 @@ func TestAPIHandlerGetStudentCourseTasksAverage_DBErrorOnOthers(t *testing.T) {
 func TestAPIHandlerGetStudentCourseTasksAverage_StudentNotFound(t *testing.T) {
 
 	db := mock_database()
-	database.GetStudentCourseTasksAverage = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+	database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 		return 0, http.StatusNotFound, errors.New("No grades found for the requested student")
 	}
 
-	database.GetOtherStudentsCourseAverages = func(DB *sql.DB, studentID string, courseID string) ([]map[string]interface{}, error) {
-		return nil, nil // Not really called on this case: Lucas fix
+	database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts database.ListOptions) (*database.Page, error) {
+		return &database.Page{}, nil // Not really called on this case: Lucas fix
 	}
 
 	w := httptest.NewRecorder()
@@ -837,7 +942,7 @@ func TestAPIHandlerGetStudentCourseTasksAverage(t *testing.T) {
 	})
 
 	t.Run("GetStudentCourseTasksAverage returns error", func(t *testing.T) {
-		database.GetStudentCourseTasksAverage = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+		database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 			return 0, http.StatusInternalServerError, errors.New("some DB error")
 		}
 
@@ -858,11 +963,11 @@ func TestAPIHandlerGetStudentCourseTasksAverage(t *testing.T) {
 	})
 
 	t.Run("GetOtherStudentsCourseAverages returns error", func(t *testing.T) {
-		database.GetStudentCourseTasksAverage = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+		database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 			return 7.5, http.StatusOK, nil
 		}
 
-		database.GetOtherStudentsCourseAverages = func(DB *sql.DB, studentID string, courseID string) ([]map[string]interface{}, error) {
+		database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts database.ListOptions) (*database.Page, error) {
 			return nil, errors.New("other students DB error")
 		}
 
@@ -883,15 +988,15 @@ func TestAPIHandlerGetStudentCourseTasksAverage(t *testing.T) {
 	})
 
 	t.Run("No grades found for student (NotFound)", func(t *testing.T) {
-		database.GetStudentCourseTasksAverage = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+		database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 			return 0, http.StatusNotFound, nil
 		}
 
-		database.GetOtherStudentsCourseAverages = func(DB *sql.DB, studentID string, courseID string) ([]map[string]interface{}, error) {
-			return []map[string]interface{}{
-				{"student_id": "otherstudent1", "average_grade": 6.0, "task_count": 2},
-				{"student_id": "otherstudent2", "average_grade": 7.5, "task_count": 3},
-			}, nil
+		database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts database.ListOptions) (*database.Page, error) {
+			return &database.Page{Items: []database.StudentAverage{
+				{StudentID: "otherstudent1", AverageGrade: 6.0, TaskCount: 2},
+				{StudentID: "otherstudent2", AverageGrade: 7.5, TaskCount: 3},
+			}, Total: 2}, nil
 		}
 
 		w := httptest.NewRecorder()
@@ -912,15 +1017,15 @@ func TestAPIHandlerGetStudentCourseTasksAverage(t *testing.T) {
 	})
 
 	t.Run("Happy path", func(t *testing.T) {
-		database.GetStudentCourseTasksAverage = func(db *sql.DB, studentID, courseID string) (float64, int, error) {
+		database.GetStudentCourseTasksAverage = func(ctx context.Context, db *sql.DB, studentID, courseID string) (float64, int, error) {
 			return 8.0, http.StatusOK, nil
 		}
 
-		database.GetOtherStudentsCourseAverages = func(DB *sql.DB, studentID string, courseID string) ([]map[string]interface{}, error) {
-			return []map[string]interface{}{
-				{"student_id": "otherstudent1", "average_grade": 7.0, "task_count": 2},
-				{"student_id": "otherstudent2", "average_grade": 9.0, "task_count": 3},
-			}, nil
+		database.GetOtherStudentsCourseAverages = func(ctx context.Context, DB *sql.DB, studentID string, courseID string, opts database.ListOptions) (*database.Page, error) {
+			return &database.Page{Items: []database.StudentAverage{
+				{StudentID: "otherstudent1", AverageGrade: 7.0, TaskCount: 2},
+				{StudentID: "otherstudent2", AverageGrade: 9.0, TaskCount: 3},
+			}, Total: 2}, nil
 		}
 
 		w := httptest.NewRecorder()
@@ -946,11 +1051,14 @@ func TestAPIHandlerGetStudentCourseTasksAverage(t *testing.T) {
 
 func TestAPIHandlerGetTaskAverages_Success(t *testing.T) {
 	db := mock_database()
-	database.GetAveragesForTask = func(db *sql.DB, courseID, taskID string) ([]map[string]interface{}, error) {
-		return []map[string]interface{}{
-			{"average_grade": 85.0, "grade_count": 2},
-			{"average_grade": 95.0, "grade_count": 3},
-		}, nil
+	database.GetAveragesForTask = func(ctx context.Context, db *sql.DB, courseID, taskID string, opts database.ListOptions) (*database.Page, error) {
+		return &database.Page{Items: []database.StudentAverage{
+			{AverageGrade: 85.0, GradeCount: 2},
+			{AverageGrade: 95.0, GradeCount: 3},
+		}, Total: 2}, nil
+	}
+	database.GetTaskSummary = func(ctx context.Context, db *sql.DB, courseID, taskID string) (float64, int, int, error) {
+		return 91.0, 5, 4, nil
 	}
 
 	w := httptest.NewRecorder()
@@ -971,7 +1079,7 @@ func TestAPIHandlerGetTaskAverages_Success(t *testing.T) {
 
 func TestAPIHandlerGetTaskAverages_DBError(t *testing.T) {
 	db := mock_database()
-	database.GetAveragesForTask = func(db *sql.DB, courseID, taskID string) ([]map[string]interface{}, error) {
+	database.GetAveragesForTask = func(ctx context.Context, db *sql.DB, courseID, taskID string, opts database.ListOptions) (*database.Page, error) {
 		return nil, errors.New("mock DB error")
 	}
 
@@ -1030,7 +1138,7 @@ func TestAPIHandlerGetCourseOnTimePercentage(t *testing.T) {
 			name:           "valid request returns 200",
 			query:          "start_date=2023-01-01&end_date=2023-01-31&group_by=week",
 			courseID:       "course123",
-			mockReturn:     map[string]interface{}{"percentage": 80},
+			mockReturn:     80.0,
 			mockError:      nil,
 			expectedStatus: http.StatusOK,
 			expectedBody:   `"course_id":"course123"`,
@@ -1057,12 +1165,12 @@ func TestAPIHandlerGetCourseOnTimePercentage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			database.GetOnTimeSubmissionPercentageForCourse = func(DB *sql.DB, courseID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
+			database.GetOnTimeSubmissionPercentageForCourse = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping) ([]database.OnTimeStat, error) {
 				if tt.mockError != nil {
 					return nil, tt.mockError
 				}
-				return []map[string]interface{}{
-					{"course_id": tt.courseID, "percentage": tt.mockReturn.(map[string]interface{})["percentage"]},
+				return []database.OnTimeStat{
+					{Period: "2023-01-02T00:00:00Z", OnTimeCount: 4, TotalCount: 5, Percentage: tt.mockReturn.(float64)},
 				}, nil
 			}
 
@@ -1101,7 +1209,7 @@ func TestAPIHandlerGetStudentOnTimePercentage(t *testing.T) {
 			query:          "start_date=2023-01-01&end_date=2023-01-31&group_by=day",
 			courseID:       "course123",
 			studentID:      "student456",
-			mockReturn:     map[string]interface{}{"percentage": 90},
+			mockReturn:     90.0,
 			mockError:      nil,
 			expectedStatus: http.StatusOK,
 			expectedBody:   `"student_id":"student456"`,
@@ -1130,12 +1238,12 @@ func TestAPIHandlerGetStudentOnTimePercentage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			database.GetOnTimeSubmissionPercentageForStudent = func(DB *sql.DB, courseID, studentID string, startTime, endTime time.Time, groupBy string) ([]map[string]interface{}, error) {
+			database.GetOnTimeSubmissionPercentageForStudent = func(ctx context.Context, DB *sql.DB, courseID, studentID string, startTime, endTime time.Time, grouping database.TimeGrouping) ([]database.OnTimeStat, error) {
 				if tt.mockError != nil {
 					return nil, tt.mockError
 				}
-				return []map[string]interface{}{
-					{"course_id": tt.courseID, "student_id": tt.studentID, "percentage": tt.mockReturn.(map[string]interface{})["percentage"]},
+				return []database.OnTimeStat{
+					{Period: "2023-01-02T00:00:00Z", OnTimeCount: 9, TotalCount: 10, Percentage: tt.mockReturn.(float64)},
 				}, nil
 			}
 
@@ -1154,3 +1262,234 @@ func TestAPIHandlerGetStudentOnTimePercentage(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIHandlerGetStudentAverageOverTime_QueryTimeout(t *testing.T) {
+	db := mock_database()
+
+	database.GetStudentAveragesOverTime = func(ctx context.Context, DB *sql.DB, studentID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
+		return nil, context.DeadlineExceeded
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request, _ = http.NewRequest("GET", "/student/123/averages", nil)
+	c.Params = []gin.Param{{Key: "student_id", Value: "123"}}
+
+	APIHandlerGetStudentAverageOverTime(db, c)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestAPIHandlerGetStudentAverageOverTime_ClientDisconnected(t *testing.T) {
+	db := mock_database()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	database.GetStudentAveragesOverTime = func(ctx context.Context, DB *sql.DB, studentID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
+		// The client goes away while the query runs
+		cancel()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request, _ = http.NewRequestWithContext(ctx, "GET", "/student/123/averages", nil)
+	c.Params = []gin.Param{{Key: "student_id", Value: "123"}}
+
+	APIHandlerGetStudentAverageOverTime(db, c)
+
+	assert.Equal(t, StatusClientClosedRequest, w.Code)
+}
+
+func TestAPIHandlerGetTaskAverages_ListOptions(t *testing.T) {
+	db := mock_database()
+	var received database.ListOptions
+	database.GetAveragesForTask = func(ctx context.Context, db *sql.DB, courseID, taskID string, opts database.ListOptions) (*database.Page, error) {
+		received = opts
+		return &database.Page{
+			Items:      []database.StudentAverage{{StudentID: "s1", AverageGrade: 7.0, GradeCount: 1}},
+			Total:      30,
+			NextCursor: "abc",
+		}, nil
+	}
+	database.GetTaskSummary = func(ctx context.Context, db *sql.DB, courseID, taskID string) (float64, int, int, error) {
+		return 7.0, 30, 20, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/course/c1/task/t1/averages?limit=10&sort=count&order=asc&min_average=4&on_time=true&graded_after=2026-03-01", nil)
+	c.Params = []gin.Param{{Key: "course_id", Value: "c1"}, {Key: "task_id", Value: "t1"}}
+
+	APIHandlerGetTaskAverages(db, c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"pagination":{"limit":10,"next_cursor":"abc","total":30}`)
+	assert.Equal(t, 10, received.Limit)
+	assert.Equal(t, database.SortCount, received.Sort)
+	assert.Equal(t, "asc", received.Order)
+	require.NotNil(t, received.MinAverage)
+	assert.Equal(t, 4.0, *received.MinAverage)
+	assert.Nil(t, received.MaxAverage)
+	assert.True(t, received.OnTimeOnly)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), received.GradedAfter)
+}
+
+func TestAPIHandlerGetTaskAverages_InvalidListOptions(t *testing.T) {
+	db := mock_database()
+
+	for _, query := range []string{
+		"limit=1000",
+		"limit=ten",
+		"sort=name",
+		"order=sideways",
+		"min_average=8&max_average=2",
+		"on_time=maybe",
+		"graded_after=03-01-2026",
+		"cursor=garbage",
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/course/c1/task/t1/averages?"+query, nil)
+		c.Params = []gin.Param{{Key: "course_id", Value: "c1"}, {Key: "task_id", Value: "t1"}}
+
+		APIHandlerGetTaskAverages(db, c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestAPIHandlerGetCourseAverageOverTime_ByTerm(t *testing.T) {
+	db := mock_database()
+	var received database.TimeGrouping
+	database.GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
+		received = grouping
+		return []database.PeriodAverage{{Period: "2026-1C", AverageGrade: float(7), GradeCount: 10}}, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/course/c1/averages?group_by=term&institution=fiuba", nil)
+	c.Params = []gin.Param{{Key: "course_id", Value: "c1"}}
+
+	APIHandlerGetCourseAverageOverTime(db, c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, database.UnitTerm, received.Unit)
+	assert.Equal(t, "fiuba", received.Institution)
+	assert.Contains(t, w.Body.String(), `"group_by":"term"`)
+}
+
+func TestAPIHandlerGetCourseAverageOverTime_NormalizesInterval(t *testing.T) {
+	db := mock_database()
+	database.GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
+		return nil, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/course/c1/averages?group_by=3day", nil)
+	c.Params = []gin.Param{{Key: "course_id", Value: "c1"}}
+
+	APIHandlerGetCourseAverageOverTime(db, c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"group_by":"3 days"`)
+}
+
+func TestAPIHandlerGetCourseAverageOverTime_InstitutionWithoutTerms(t *testing.T) {
+	db := mock_database()
+	database.GetCourseAveragesOverTime = func(ctx context.Context, DB *sql.DB, courseID string, startTime, endTime time.Time, grouping database.TimeGrouping, series database.SeriesOptions) ([]database.PeriodAverage, error) {
+		return nil, fmt.Errorf("%w: institution %q has no academic terms", database.ErrInvalidGrouping, grouping.Institution)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/course/c1/averages?group_by=term&institution=unknown", nil)
+	c.Params = []gin.Param{{Key: "course_id", Value: "c1"}}
+
+	APIHandlerGetCourseAverageOverTime(db, c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAPIHandlerGetAcademicTerms(t *testing.T) {
+	db := mock_database()
+	database.GetAcademicTerms = func(ctx context.Context, DB *sql.DB, institutionID string) ([]database.AcademicTerm, error) {
+		return []database.AcademicTerm{{
+			Name:     "2026-1C",
+			StartsOn: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+			EndsOn:   time.Date(2026, 7, 11, 0, 0, 0, 0, time.UTC),
+		}}, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/institution/fiuba/terms", nil)
+	c.Params = []gin.Param{{Key: "institution_id", Value: "fiuba"}}
+
+	APIHandlerGetAcademicTerms(db, c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"institution_id":"fiuba","terms":[{"name":"2026-1C","starts_on":"2026-03-09","ends_on":"2026-07-11"}]}`, w.Body.String())
+}
+
+type recordingInvalidator struct {
+	tags []string
+}
+
+func (r *recordingInvalidator) Invalidate(ctx context.Context, tags ...string) error {
+	r.tags = append(r.tags, tags...)
+	return nil
+}
+
+func TestAPIHandlerReplaceAcademicTerms(t *testing.T) {
+	db := mock_database()
+	var received []database.AcademicTerm
+	database.ReplaceAcademicTerms = func(ctx context.Context, DB *sql.DB, institutionID string, terms []database.AcademicTerm) error {
+		received = terms
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := `{"terms":[{"name":"2026-1C","starts_on":"2026-03-09","ends_on":"2026-07-11"}]}`
+	c.Request, _ = http.NewRequest("PUT", "/institution/fiuba/terms", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = []gin.Param{{Key: "institution_id", Value: "fiuba"}}
+
+	responses := &recordingInvalidator{}
+	APIHandlerReplaceAcademicTerms(db, responses, c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, received, 1)
+	assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), received[0].StartsOn)
+	assert.Equal(t, []string{"institution:fiuba"}, responses.tags)
+}
+
+func TestAPIHandlerReplaceAcademicTerms_Invalid(t *testing.T) {
+	db := mock_database()
+	database.ReplaceAcademicTerms = func(ctx context.Context, DB *sql.DB, institutionID string, terms []database.AcademicTerm) error {
+		return database.ValidateAcademicTerms(terms)
+	}
+
+	for _, body := range []string{
+		`{"terms":[{"name":"2026-1C","starts_on":"09/03/2026","ends_on":"2026-07-11"}]}`,
+		`{"terms":[{"starts_on":"2026-03-09","ends_on":"2026-07-11"}]}`,
+		`{"terms":[{"name":"a","starts_on":"2026-03-09","ends_on":"2026-07-11"},{"name":"b","starts_on":"2026-07-01","ends_on":"2026-12-01"}]}`,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("PUT", "/institution/fiuba/terms", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = []gin.Param{{Key: "institution_id", Value: "fiuba"}}
+
+		responses := &recordingInvalidator{}
+		APIHandlerReplaceAcademicTerms(db, responses, c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Empty(t, responses.tags, body)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxEventSize bounds the body of a webhook delivery.
+const maxEventSize = 64 << 10
+
+// Handler para recibir eventos de otros servicios por webhook. La firma
+// HMAC se verifica antes de mirar el cuerpo
+func APIHandlerReceiveEvent(consumer *events.Consumer, secret string, tolerance time.Duration, c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxEventSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the event"})
+		return
+	}
+	if len(body) > maxEventSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Event too large"})
+		return
+	}
+
+	err = events.VerifySignature(secret, c.GetHeader(events.TimestampHeader), c.GetHeader(events.SignatureHeader), body, tolerance, time.Now())
+	if err != nil {
+		slog.WarnContext(requestContext(c), "rejected event webhook", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing signature"})
+		return
+	}
+
+	err = consumer.HandleJSON(requestContext(c), events.SourceWebhook, body)
+	if errors.Is(err, events.ErrInvalidEvent) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		// The sender retries, and delivering the event again is harmless
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to enqueue the event"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "accepted"})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/events"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const gradedEvent = `{"id":"evt-1","type":"assignment.graded","data":{"student_id":"s1","course_id":"c1","assignment_id":"a1","grade":9}}`
+
+func receiveEvent(enqueuer Enqueuer, body string, sign func(timestamp string) string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/stats/events", strings.NewReader(body))
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	c.Request.Header.Set(events.TimestampHeader, timestamp)
+	c.Request.Header.Set(events.SignatureHeader, sign(timestamp))
+
+	APIHandlerReceiveEvent(events.NewConsumer(enqueuer), "s3cret", time.Minute, c)
+	return w
+}
+
+func TestAPIHandlerReceiveEvent(t *testing.T) {
+	var taskTypes []string
+	enqueuer := &MockEnqueuer{EnqueueFunc: func(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
+		taskTypes = append(taskTypes, taskType)
+		return time.Minute, nil
+	}}
+
+	w := receiveEvent(enqueuer, gradedEvent, func(timestamp string) string {
+		return events.Sign("s3cret", timestamp, []byte(gradedEvent))
+	})
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, []string{types.TaskAddStudentGradeTask}, taskTypes)
+}
+
+func TestAPIHandlerReceiveEvent_InvalidSignature(t *testing.T) {
+	enqueuer := &MockEnqueuer{EnqueueFunc: func(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
+		t.Fatal("an unsigned event must not be enqueued")
+		return 0, nil
+	}}
+
+	w := receiveEvent(enqueuer, gradedEvent, func(timestamp string) string {
+		return events.Sign("other", timestamp, []byte(gradedEvent))
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAPIHandlerReceiveEvent_Errors(t *testing.T) {
+	failing := &MockEnqueuer{EnqueueFunc: func(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
+		return 0, errors.New("redis down")
+	}}
+
+	for body, expected := range map[string]int{
+		`{"id":"evt-1","type":"assignment.graded","data":{}}`: http.StatusBadRequest,
+		gradedEvent: http.StatusServiceUnavailable,
+	} {
+		w := receiveEvent(failing, body, func(timestamp string) string {
+			return events.Sign("s3cret", timestamp, []byte(body))
+		})
+		assert.Equal(t, expected, w.Code, body)
+	}
+}
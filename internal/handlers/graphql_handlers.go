@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/graph"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxGraphQLRequestSize bounds the body of a GraphQL request.
+const maxGraphQLRequestSize = 64 << 10
+
+// Handler para consultas GraphQL sobre las estadísticas. Los errores de la
+// consulta vuelven con 200 en el campo errors, como pide GraphQL
+func APIHandlerGraphQL(schema *graph.Schema, db *sql.DB, c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxGraphQLRequestSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the request"})
+		return
+	}
+	if len(body) > maxGraphQLRequestSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request too large"})
+		return
+	}
+
+	var req graph.Request
+	if err := json.Unmarshal(body, &req); err != nil || req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schema.Exec(requestContext(c), db, req))
+}
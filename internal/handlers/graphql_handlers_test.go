@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/graph"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postGraphQL(t *testing.T, body string) *httptest.ResponseRecorder {
+	schema, err := graph.New(graph.Limits{MaxDepth: 5, MaxComplexity: 100})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/stats/graphql", strings.NewReader(body))
+
+	APIHandlerGraphQL(schema, mock_database(), c)
+	return w
+}
+
+func TestAPIHandlerGraphQL(t *testing.T) {
+	getStats := database.GetCourseStatsBatch
+	defer func() { database.GetCourseStatsBatch = getStats }()
+	database.GetCourseStatsBatch = func(ctx context.Context, DB *sql.DB, courseIDs []string) (map[string]database.CourseStats, error) {
+		return map[string]database.CourseStats{"c1": {Students: 2, GradeSum: 15, GradeCount: 2}}, nil
+	}
+
+	w := postGraphQL(t, `{"query": "query($id: ID!) { course(id: $id) { studentCount gradeAverage taskAverage } }", "variables": {"id": "c1"}}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data": {"course": {"studentCount": 2, "gradeAverage": 7.5, "taskAverage": null}}}`, w.Body.String())
+}
+
+func TestAPIHandlerGraphQL_QueryErrors(t *testing.T) {
+	w := postGraphQL(t, `{"query": "{ course(id: \"c1\") { students(first: 100) { items { average } } } }"}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "MaxComplexityExceeded")
+	assert.NotContains(t, w.Body.String(), `"data"`)
+}
+
+func TestAPIHandlerGraphQL_InvalidBody(t *testing.T) {
+	for _, body := range []string{`not json`, `{"variables": {}}`} {
+		w := postGraphQL(t, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	w := postGraphQL(t, `{"query": "`+strings.Repeat(" ", maxGraphQLRequestSize)+`{ course(id: \"c1\") { id } }"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
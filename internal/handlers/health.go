@@ -3,6 +3,8 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/health"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -10,3 +12,17 @@ import (
 func HealthCheckHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "OK"})
 }
+
+// LivenessHandler reports that the process is alive. It doesn't look at any
+// dependency, so an outage elsewhere never gets the pod restarted.
+func LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// ReadinessHandler reports whether the service can serve traffic, with the
+// status and latency of every dependency. It answers 503 when any check
+// fails.
+func ReadinessHandler(checker *health.Checker, c *gin.Context) {
+	report := checker.Run(requestContext(c))
+	c.JSON(report.HTTPStatus(), report)
+}
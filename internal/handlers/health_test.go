@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/health"
 
 	"github.com/gin-gonic/gin"
 )
@@ -28,3 +33,52 @@ func TestHealthCheckHandler(t *testing.T) {
 		t.Fatalf("expected body %s but got %s", expectedBody, w.Body.String())
 	}
 }
+
+func TestLivenessHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/health/live", nil)
+
+	LivenessHandler(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 but got %d", w.Code)
+	}
+	if strings.TrimSpace(w.Body.String()) != `{"status":"up"}` {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+}
+
+func TestReadinessHandler_Down(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/health/ready", nil)
+
+	checker := health.NewChecker(time.Second, health.Check{
+		Name: "database",
+		Run: func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, errors.New("connection refused")
+		},
+	})
+
+	ReadinessHandler(checker, c)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503 but got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"database":{"status":"down"`) {
+		t.Fatalf("expected database to be reported down, got %s", w.Body.String())
+	}
+}
+
+func TestReadinessHandler_Up(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/health/ready", nil)
+
+	ReadinessHandler(health.NewChecker(time.Second), c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 but got %d", w.Code)
+	}
+}
@@ -1,23 +1,31 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-	"service_stats/internal/model"
-	"service_stats/internal/types"
 	"time"
 
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+
 	"github.com/gin-gonic/gin"
 )
 
 type Enqueuer interface {
-	Enqueue(taskType string, payload interface{}) (time.Duration, error)
+	Enqueue(ctx context.Context, taskType string, payload interface{}) (time.Duration, error)
+}
+
+// Replayer enqueues tasks to run right away, without the processing delay
+// of the grades.
+type Replayer interface {
+	EnqueueNow(ctx context.Context, taskType string, payload interface{}) error
 }
 
 func EnqueueAddStadisticForStudent(c *gin.Context, enqueuer Enqueuer, payload model.Grade) {
 	taskType := types.TaskAddStudentGrade
 
-	expected_delay_in_sec, err := enqueuer.Enqueue(taskType, payload)
+	expected_delay_in_sec, err := enqueuer.Enqueue(requestContext(c), taskType, payload)
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"result": "Failed to enqueue task", "status": http.StatusBadRequest})
@@ -30,7 +38,7 @@ func EnqueueAddStadisticForStudent(c *gin.Context, enqueuer Enqueuer, payload mo
 func EnqueueAddGradeTask(c *gin.Context, enqueuer Enqueuer, payload model.GradeTask) {
 	taskType := types.TaskAddStudentGradeTask
 
-	expected_delay_in_sec, err := enqueuer.Enqueue(taskType, payload)
+	expected_delay_in_sec, err := enqueuer.Enqueue(requestContext(c), taskType, payload)
 
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"result": "Failed to enqueue task", "status": http.StatusBadRequest})
@@ -1,29 +1,36 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
-	"service_stats/internal/model"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+
 	"github.com/gin-gonic/gin"
 )
 
 type MockEnqueuer struct {
-	EnqueueFunc func(taskType string, payload interface{}) (time.Duration, error)
+	EnqueueFunc    func(ctx context.Context, taskType string, payload interface{}) (time.Duration, error)
+	EnqueueNowFunc func(ctx context.Context, taskType string, payload interface{}) error
+}
+
+func (m *MockEnqueuer) Enqueue(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
+	return m.EnqueueFunc(ctx, taskType, payload)
 }
 
-func (m *MockEnqueuer) Enqueue(taskType string, payload interface{}) (time.Duration, error) {
-	return m.EnqueueFunc(taskType, payload)
+func (m *MockEnqueuer) EnqueueNow(ctx context.Context, taskType string, payload interface{}) error {
+	return m.EnqueueNowFunc(ctx, taskType, payload)
 }
 
 func TestEnqueueAddStadisticForStudent_Success(t *testing.T) {
 	// Mock enqueuer returns 5 seconds delay, no error
 	mock := &MockEnqueuer{
-		EnqueueFunc: func(taskType string, payload interface{}) (time.Duration, error) {
+		EnqueueFunc: func(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
 			return 5 * time.Second, nil
 		},
 	}
@@ -56,7 +63,7 @@ func TestEnqueueAddStadisticForStudent_Success(t *testing.T) {
 func TestEnqueueAddStadisticForStudent_Error(t *testing.T) {
 	// Mock enqueuer returns error
 	mock := &MockEnqueuer{
-		EnqueueFunc: func(taskType string, payload interface{}) (time.Duration, error) {
+		EnqueueFunc: func(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
 			return 0, errors.New("enqueue failed")
 		},
 	}
@@ -84,7 +91,7 @@ func TestEnqueueAddStadisticForStudent_Error(t *testing.T) {
 func TestEnqueueAddGradeTask_Sucess(t *testing.T) {
 	// Mock enqueuer returns 10 seconds delay, no error
 	mock := &MockEnqueuer{
-		EnqueueFunc: func(taskType string, payload interface{}) (time.Duration, error) {
+		EnqueueFunc: func(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
 			return 10 * time.Second, nil
 		},
 	}
@@ -117,7 +124,7 @@ func TestEnqueueAddGradeTask_Sucess(t *testing.T) {
 func TestEnqueueAddGradeTask_Failure(t *testing.T) {
 	// Mock enqueuer returns error
 	mock := &MockEnqueuer{
-		EnqueueFunc: func(taskType string, payload interface{}) (time.Duration, error) {
+		EnqueueFunc: func(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
 			return 0, errors.New("enqueue failed")
 		},
 	}
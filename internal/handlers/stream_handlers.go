@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamRetry is how long an SSE client waits before reconnecting, sent in
+// the retry field.
+const streamRetry = 3 * time.Second
+
+// Handler para recibir por SSE los agregados de un curso cada vez que se
+// guarda una nota
+func APIHandlerStreamCourse(hub *realtime.Hub, heartbeat time.Duration, c *gin.Context) {
+	streamUpdates(hub, realtime.CourseTopic(c.Param("course_id")), heartbeat, c)
+}
+
+// Handler para recibir por SSE los agregados de un estudiante, en todos
+// sus cursos, cada vez que se guarda una de sus notas
+func APIHandlerStreamStudent(hub *realtime.Hub, heartbeat time.Duration, c *gin.Context) {
+	streamUpdates(hub, realtime.StudentTopic(c.Param("student_id")), heartbeat, c)
+}
+
+// streamUpdates sends the updates of topic as "stats" events until the
+// client leaves or the server shuts down. A comment every heartbeat keeps
+// proxies from closing an idle connection.
+func streamUpdates(hub *realtime.Hub, topic string, heartbeat time.Duration, c *gin.Context) {
+	updates, unsubscribe := hub.Subscribe(topic)
+	defer unsubscribe()
+	if updates == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "The server is shutting down"})
+		return
+	}
+
+	// The connection outlives the WriteTimeout of the server
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(requestContext(c), "could not clear the write deadline of an SSE stream", "error", err)
+	}
+
+	metrics.ObserveSSEConnection(1)
+	defer metrics.ObserveSSEConnection(-1)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Tells nginx not to buffer the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	io.WriteString(c.Writer, "retry: "+strconv.FormatInt(streamRetry.Milliseconds(), 10)+"\n\n")
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			c.SSEvent("stats", update)
+			c.Writer.Flush()
+		case <-ticker.C:
+			io.WriteString(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHub() *realtime.Hub {
+	// The hub is never run, so it needs no Redis client
+	return realtime.NewHub(nil, "updates")
+}
+
+func TestAPIHandlerStreamCourse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hub := newTestHub()
+	router := gin.New()
+	router.GET("/course/:course_id/stream", func(c *gin.Context) {
+		APIHandlerStreamCourse(hub, time.Hour, c)
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/course/c1/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// The client is subscribed once the headers are sent
+	hub.Broadcast(realtime.Update{CourseID: "c2", StudentID: "s2", Grade: 4})
+	hub.Broadcast(realtime.Update{CourseID: "c1", StudentID: "s1", Grade: 9})
+	hub.Disconnect()
+
+	body := new(strings.Builder)
+	_, err = io.Copy(body, resp.Body)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(body.String(), "retry: 3000\n\n"), body.String())
+	assert.Contains(t, body.String(), "event:stats\ndata:{\"course_id\":\"c1\",\"student_id\":\"s1\"")
+	assert.NotContains(t, body.String(), "c2")
+}
+
+func TestAPIHandlerStreamStudent_HubClosed(t *testing.T) {
+	hub := newTestHub()
+	hub.Disconnect()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "student_id", Value: "s1"}}
+	c.Request, _ = http.NewRequest("GET", "/student/s1/stream", nil)
+
+	APIHandlerStreamStudent(hub, time.Hour, c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookSubscriptionRequest registra una URL para recibir eventos. Si no
+// se envía secret se genera uno
+type WebhookSubscriptionRequest struct {
+	URL       string   `json:"url" binding:"required"`
+	Events    []string `json:"events" binding:"required"`
+	CourseID  string   `json:"course_id"`
+	Threshold *float64 `json:"threshold"`
+	Secret    string   `json:"secret"`
+}
+
+// webhookSubscriptionResponse renders a subscription. The secret is only
+// shown when the subscription is created.
+func webhookSubscriptionResponse(s database.WebhookSubscription) gin.H {
+	return gin.H{
+		"id":         s.ID,
+		"url":        s.URL,
+		"events":     s.Events,
+		"course_id":  s.CourseID,
+		"threshold":  s.Threshold,
+		"created_at": s.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func webhookDeliveryResponse(d database.WebhookDelivery) gin.H {
+	return gin.H{
+		"id":              d.ID,
+		"subscription_id": d.SubscriptionID,
+		"event_id":        d.EventID,
+		"event_type":      d.EventType,
+		"payload":         json.RawMessage(d.Payload),
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"response_status": d.ResponseStatus,
+		"last_error":      d.LastError,
+		"created_at":      d.CreatedAt.Format(time.RFC3339),
+		"updated_at":      d.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func webhookID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// Handler para listar las suscripciones a webhooks, sin sus secretos
+func APIHandlerListWebhooks(db *sql.DB, c *gin.Context) {
+	subscriptions, err := database.ListWebhookSubscriptions(requestContext(c), db)
+	if err != nil {
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]gin.H, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		items = append(items, webhookSubscriptionResponse(s))
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": items})
+}
+
+// Handler para registrar una suscripción. El secreto solo se devuelve en
+// esta respuesta
+func APIHandlerCreateWebhook(db *sql.DB, c *gin.Context) {
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	subscription := database.WebhookSubscription{
+		URL:       req.URL,
+		Events:    req.Events,
+		CourseID:  req.CourseID,
+		Threshold: req.Threshold,
+		Secret:    req.Secret,
+	}
+	if err := webhooks.ValidateSubscription(subscription); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if subscription.Secret == "" {
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate a secret"})
+			return
+		}
+		subscription.Secret = secret
+	}
+
+	created, err := database.InsertWebhookSubscription(requestContext(c), db, subscription)
+	if err != nil {
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	slog.InfoContext(requestContext(c), "webhook subscription created", "id", created.ID, "events", created.Events)
+	response := webhookSubscriptionResponse(*created)
+	response["secret"] = created.Secret
+	c.JSON(http.StatusCreated, response)
+}
+
+// Handler para eliminar una suscripción junto con su historial de envíos
+func APIHandlerDeleteWebhook(db *sql.DB, c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	err := database.DeleteWebhookSubscription(requestContext(c), db, id)
+	if errors.Is(err, database.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	slog.InfoContext(requestContext(c), "webhook subscription deleted", "id", id)
+	c.Status(http.StatusNoContent)
+}
+
+// Handler para consultar el historial de envíos de una suscripción,
+// paginado como las tareas fallidas
+func APIHandlerListWebhookDeliveries(db *sql.DB, c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", database.WebhookDeliveryPending, database.WebhookDeliveryDelivered, database.WebhookDeliveryFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, delivered or failed"})
+		return
+	}
+
+	limit := database.DefaultListLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > database.MaxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		limit = parsed
+	}
+
+	var cursor int64
+	if value := c.Query("cursor"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		cursor = parsed
+	}
+
+	ctx := requestContext(c)
+	if _, err := database.GetWebhookSubscription(ctx, db, id); err != nil {
+		if errors.Is(err, database.ErrWebhookNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := database.ListWebhookDeliveries(ctx, db, id, status, limit, cursor)
+	if err != nil {
+		c.JSON(queryErrorStatus(c, err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]gin.H, 0, len(deliveries))
+	for _, d := range deliveries {
+		items = append(items, webhookDeliveryResponse(d))
+	}
+	var next interface{}
+	if len(deliveries) == limit {
+		next = strconv.FormatInt(deliveries[len(deliveries)-1].ID, 10)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": items,
+		"pagination": gin.H{"limit": limit, "next_cursor": next},
+	})
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIHandlerCreateWebhook(t *testing.T) {
+	var stored database.WebhookSubscription
+	insert := database.InsertWebhookSubscription
+	defer func() { database.InsertWebhookSubscription = insert }()
+	database.InsertWebhookSubscription = func(ctx context.Context, DB *sql.DB, s database.WebhookSubscription) (*database.WebhookSubscription, error) {
+		stored = s
+		s.ID = 4
+		s.CreatedAt = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+		return &s, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/admin/webhooks", strings.NewReader(
+		`{"url":"https://example.com/hook","events":["student.average_below_threshold"],"threshold":6}`))
+
+	APIHandlerCreateWebhook(mock_database(), c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Len(t, stored.Secret, 64, "a secret is generated when none is sent")
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 4.0, response["id"])
+	assert.Equal(t, stored.Secret, response["secret"])
+	assert.Equal(t, 6.0, response["threshold"])
+}
+
+func TestAPIHandlerCreateWebhook_Invalid(t *testing.T) {
+	for _, body := range []string{
+		`{"url":"https://example.com/hook","events":["student.average_below_threshold"]}`,
+		`{"url":"example.com","events":["grade.updated"]}`,
+		`{"url":"https://example.com/hook","events":["grade.deleted"]}`,
+		`{"url":"https://example.com/hook"}`,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/admin/webhooks", strings.NewReader(body))
+
+		APIHandlerCreateWebhook(mock_database(), c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestAPIHandlerListWebhooks_HidesSecrets(t *testing.T) {
+	list := database.ListWebhookSubscriptions
+	defer func() { database.ListWebhookSubscriptions = list }()
+	database.ListWebhookSubscriptions = func(ctx context.Context, DB *sql.DB) ([]database.WebhookSubscription, error) {
+		return []database.WebhookSubscription{{ID: 1, URL: "https://example.com/hook", Events: []string{"grade.updated"}, Secret: "s3cret"}}, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/admin/webhooks", nil)
+
+	APIHandlerListWebhooks(mock_database(), c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "https://example.com/hook")
+	assert.NotContains(t, w.Body.String(), "s3cret")
+}
+
+func TestAPIHandlerDeleteWebhook_NotFound(t *testing.T) {
+	remove := database.DeleteWebhookSubscription
+	defer func() { database.DeleteWebhookSubscription = remove }()
+	database.DeleteWebhookSubscription = func(ctx context.Context, DB *sql.DB, id int64) error {
+		return database.ErrWebhookNotFound
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "9"}}
+	c.Request, _ = http.NewRequest("DELETE", "/admin/webhooks/9", nil)
+
+	APIHandlerDeleteWebhook(mock_database(), c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAPIHandlerListWebhookDeliveries(t *testing.T) {
+	getSubscription, listDeliveries := database.GetWebhookSubscription, database.ListWebhookDeliveries
+	defer func() {
+		database.GetWebhookSubscription = getSubscription
+		database.ListWebhookDeliveries = listDeliveries
+	}()
+	database.GetWebhookSubscription = func(ctx context.Context, DB *sql.DB, id int64) (*database.WebhookSubscription, error) {
+		return &database.WebhookSubscription{ID: id}, nil
+	}
+	var gotStatus string
+	var gotLimit int
+	var gotCursor int64
+	database.ListWebhookDeliveries = func(ctx context.Context, DB *sql.DB, subscriptionID int64, status string, limit int, beforeID int64) ([]database.WebhookDelivery, error) {
+		gotStatus, gotLimit, gotCursor = status, limit, beforeID
+		return []database.WebhookDelivery{
+			{ID: 12, SubscriptionID: subscriptionID, EventType: "grade.updated", Payload: []byte(`{"id":"e12"}`), Status: "failed", Attempts: 13, ResponseStatus: 500},
+		}, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Request, _ = http.NewRequest("GET", "/admin/webhooks/1/deliveries?status=failed&limit=1&cursor=20", nil)
+
+	APIHandlerListWebhookDeliveries(mock_database(), c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "failed", gotStatus)
+	assert.Equal(t, 1, gotLimit)
+	assert.Equal(t, int64(20), gotCursor)
+
+	var response struct {
+		Deliveries []map[string]interface{} `json:"deliveries"`
+		Pagination map[string]interface{}   `json:"pagination"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Deliveries, 1)
+	assert.Equal(t, map[string]interface{}{"id": "e12"}, response.Deliveries[0]["payload"])
+	assert.Equal(t, "12", response.Pagination["next_cursor"])
+}
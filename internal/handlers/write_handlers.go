@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeErrorStatus maps the errors of the service layer to a status code.
+// Grades the database rejects, like the worker's permanent errors, are the
+// client's fault.
+func writeErrorStatus(c *gin.Context, err error) int {
+	if errors.Is(err, service.ErrInvalidGrade) || database.IsPermanentError(err) {
+		return http.StatusBadRequest
+	}
+	return queryErrorStatus(c, err, http.StatusInternalServerError)
+}
+
+// SaveGrade stores a grade before answering, running the same service
+// function as the worker. It is used instead of EnqueueAddStadisticForStudent
+// when WRITE_MODE is sync.
+func SaveGrade(c *gin.Context, db *sql.DB, listener service.Listener, payload model.Grade) {
+	stored, err := service.AddGrade(requestContext(c), db, listener, payload)
+	if err != nil {
+		c.JSON(writeErrorStatus(c, err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, stored)
+}
+
+// SaveGradeTask is the synchronous counterpart of EnqueueAddGradeTask.
+func SaveGradeTask(c *gin.Context, db *sql.DB, listener service.Listener, payload model.GradeTask) {
+	stored, err := service.SaveGradeTask(requestContext(c), db, listener, payload)
+	if err != nil {
+		c.JSON(writeErrorStatus(c, err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, stored)
+}
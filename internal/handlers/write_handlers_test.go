@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveGrade(t *testing.T) {
+	createdAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		g.CreatedAt = createdAt
+		return g, nil
+	}
+	defer func() { service.InsertGrade = database.InsertGrade }()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/stats/student/grade", nil)
+
+	SaveGrade(c, mock_database(), nil, model.Grade{StudentID: "s1", CourseID: "c1", Grade: 8, OnTime: true})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var stored model.Grade
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+	assert.Equal(t, model.Grade{StudentID: "s1", CourseID: "c1", Grade: 8, OnTime: true, CreatedAt: createdAt}, stored)
+}
+
+func TestSaveGradeTask(t *testing.T) {
+	service.CheckGradeTaskExists = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (bool, error) {
+		return true, nil
+	}
+	service.UpdateGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		return gt, nil
+	}
+	defer func() {
+		service.CheckGradeTaskExists = database.CheckGradeTaskExists
+		service.UpdateGradeTask = database.UpdateGradeTask
+	}()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/stats/student/task/grade", nil)
+
+	SaveGradeTask(c, mock_database(), nil, model.GradeTask{StudentID: "s1", CourseID: "c1", TaskID: "t1", Grade: 8})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"task_id":"t1"`)
+}
+
+func TestSaveGrade_Errors(t *testing.T) {
+	defer func() { service.InsertGrade = database.InsertGrade }()
+
+	for name, tc := range map[string]struct {
+		grade  model.Grade
+		err    error
+		status int
+	}{
+		"invalid":    {grade: model.Grade{StudentID: "s1", CourseID: "c1", Grade: -1}, status: http.StatusBadRequest},
+		"constraint": {grade: model.Grade{StudentID: "s1", CourseID: "c1"}, err: &pq.Error{Code: "23514"}, status: http.StatusBadRequest},
+		"db down":    {grade: model.Grade{StudentID: "s1", CourseID: "c1"}, err: errors.New("connection refused"), status: http.StatusInternalServerError},
+	} {
+		service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+			return model.Grade{}, tc.err
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/stats/student/grade", nil)
+
+		SaveGrade(c, mock_database(), nil, tc.grade)
+		assert.Equal(t, tc.status, w.Code, name)
+	}
+}
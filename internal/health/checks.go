@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+
+	"github.com/hibiken/asynq"
+)
+
+// Pinger is anything that can check its connection, like *asynq.Client.
+type Pinger interface {
+	Ping() error
+}
+
+// QueueInspector is the part of *asynq.Inspector used by QueueBacklogCheck.
+type QueueInspector interface {
+	Queues() ([]string, error)
+	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
+}
+
+func DatabaseCheck(db *sql.DB) Check {
+	return Check{
+		Name: "database",
+		Run: func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, db.PingContext(ctx)
+		},
+	}
+}
+
+// MigrationsCheck fails while the schema is behind the migrations this
+// binary was built with.
+func MigrationsCheck(db *sql.DB) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) (map[string]interface{}, error) {
+			pending, err := database.PendingMigrations(ctx, db)
+			if err != nil {
+				return nil, err
+			}
+
+			details := map[string]interface{}{"pending": len(pending)}
+			if len(pending) > 0 {
+				return details, fmt.Errorf("%d pending migrations, first is %d (%s)", len(pending), pending[0].Version, pending[0].Name)
+			}
+			return details, nil
+		},
+	}
+}
+
+func RedisCheck(pinger Pinger) Check {
+	return Check{
+		Name: "redis",
+		Run: func(ctx context.Context) (map[string]interface{}, error) {
+			result := make(chan error, 1)
+			go func() { result <- pinger.Ping() }()
+
+			select {
+			case err := <-result:
+				return nil, err
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}
+}
+
+// QueueBacklogCheck fails when the queue has more than maxPending tasks
+// waiting, or its oldest pending task has waited longer than maxLatency. A
+// zero threshold disables that limit.
+func QueueBacklogCheck(inspector QueueInspector, queue string, maxPending int, maxLatency time.Duration) Check {
+	return Check{
+		Name: "queue_backlog",
+		Run: func(ctx context.Context) (map[string]interface{}, error) {
+			queues, err := inspector.Queues()
+			if err != nil {
+				return nil, err
+			}
+
+			// asynq only creates a queue when the first task is enqueued
+			if !slices.Contains(queues, queue) {
+				return map[string]interface{}{"queue": queue, "pending": 0}, nil
+			}
+
+			info, err := inspector.GetQueueInfo(queue)
+			if err != nil {
+				return nil, err
+			}
+
+			details := map[string]interface{}{
+				"queue":      queue,
+				"pending":    info.Pending,
+				"scheduled":  info.Scheduled,
+				"retry":      info.Retry,
+				"archived":   info.Archived,
+				"latency_ms": info.Latency.Milliseconds(),
+			}
+
+			if maxPending > 0 && info.Pending > maxPending {
+				return details, fmt.Errorf("%d pending tasks exceed the limit of %d", info.Pending, maxPending)
+			}
+			if maxLatency > 0 && info.Latency > maxLatency {
+				return details, fmt.Errorf("oldest pending task waited %s, limit is %s", info.Latency, maxLatency)
+			}
+			return details, nil
+		},
+	}
+}
+
+// OutboxCheck reports how many tasks wait in the outbox table, how many of
+// them failed to enqueue at least once and the age of the oldest one. It
+// only fails when the table can't be read: a backlog means Redis is down,
+// which RedisCheck reports, and the API keeps accepting grades meanwhile.
+func OutboxCheck(db *sql.DB) Check {
+	return Check{
+		Name: "outbox",
+		Run: func(ctx context.Context) (map[string]interface{}, error) {
+			stats, err := database.GetOutboxStats(ctx, db)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"pending":       stats.Pending,
+				"retrying":      stats.Retrying,
+				"oldest_age_ms": stats.OldestAge.Milliseconds(),
+			}, nil
+		},
+	}
+}
+
+// ReplicaCheck reports where reads are routed and the replica lag from the
+// last check of router. It never fails: when the replica is behind or down
+// reads fall back to the primary, which DatabaseCheck already covers.
+func ReplicaCheck(router *database.ReadRouter) Check {
+	return Check{
+		Name: "read_replica",
+		Run: func(ctx context.Context) (map[string]interface{}, error) {
+			status := router.Status()
+
+			details := map[string]interface{}{"reads": "primary"}
+			if !status.Configured {
+				return details, nil
+			}
+			if status.Healthy {
+				details["reads"] = "replica"
+			}
+			details["lag_ms"] = status.Lag.Milliseconds()
+			if status.Error != nil {
+				details["error"] = status.Error.Error()
+			}
+			return details, nil
+		},
+	}
+}
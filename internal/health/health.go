@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckFunc probes a dependency. It may return details (queue sizes,
+// pending migrations, ...) to include in the report, even on failure.
+type CheckFunc func(ctx context.Context) (map[string]interface{}, error)
+
+type Check struct {
+	Name string
+	Run  CheckFunc
+	// Optional checks are reported but don't make the report down.
+	Optional bool
+}
+
+// Optional returns check as an optional check.
+func Optional(check Check) Check {
+	check.Optional = true
+	return check
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status    string                 `json:"status"`
+	LatencyMS float64                `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report is the readiness response: overall status plus one entry per
+// dependency.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checker runs a fixed set of checks concurrently, each bounded by Timeout.
+type Checker struct {
+	Checks  []Check
+	Timeout time.Duration
+
+	draining atomic.Bool
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{Checks: checks, Timeout: timeout}
+}
+
+// Drain makes every later Run report down, so the load balancer stops
+// sending new requests while the in-flight ones finish.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Run executes every check and reports "up" only if all of them pass. A nil
+// Checker has no dependencies and is always up.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Checks: map[string]Result{}}
+	if c == nil {
+		return report
+	}
+
+	if c.draining.Load() {
+		report.Status = StatusDown
+		report.Checks["shutdown"] = Result{Status: StatusDown, Error: "shutting down"}
+		return report
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range c.Checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := c.runOne(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusUp && !check.Optional {
+				report.Status = StatusDown
+			}
+		}(check)
+	}
+
+	wg.Wait()
+	return report
+}
+
+func (c *Checker) runOne(ctx context.Context, check Check) Result {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	details, err := check.Run(ctx)
+	result := Result{
+		Status:    StatusUp,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// HTTPStatus maps a report to the status code probes expect.
+func (r Report) HTTPStatus() int {
+	if r.Status == StatusUp {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
+
+// LiveHandler answers liveness probes: if the process can serve this, it is
+// alive. It never checks dependencies, so a database outage doesn't make
+// Kubernetes restart healthy pods.
+func LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusUp})
+	})
+}
+
+// ReadyHandler answers readiness probes with the report of checker.
+func ReadyHandler(checker *Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Run(r.Context())
+		writeJSON(w, report.HTTPStatus(), report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,204 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePinger struct {
+	err   error
+	delay time.Duration
+}
+
+func (p fakePinger) Ping() error {
+	time.Sleep(p.delay)
+	return p.err
+}
+
+type fakeInspector struct {
+	queues []string
+	info   *asynq.QueueInfo
+	err    error
+}
+
+func (i fakeInspector) Queues() ([]string, error) {
+	return i.queues, i.err
+}
+
+func (i fakeInspector) GetQueueInfo(queue string) (*asynq.QueueInfo, error) {
+	return i.info, i.err
+}
+
+func passing(name string) Check {
+	return Check{Name: name, Run: func(ctx context.Context) (map[string]interface{}, error) { return nil, nil }}
+}
+
+func failing(name string) Check {
+	return Check{Name: name, Run: func(ctx context.Context) (map[string]interface{}, error) {
+		return map[string]interface{}{"extra": 1}, errors.New(name + " is down")
+	}}
+}
+
+func TestChecker_AllUp(t *testing.T) {
+	report := NewChecker(time.Second, passing("a"), passing("b")).Run(context.Background())
+
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, http.StatusOK, report.HTTPStatus())
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, StatusUp, report.Checks["a"].Status)
+}
+
+func TestChecker_OneDown(t *testing.T) {
+	report := NewChecker(time.Second, passing("a"), failing("b")).Run(context.Background())
+
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, http.StatusServiceUnavailable, report.HTTPStatus())
+	assert.Equal(t, StatusUp, report.Checks["a"].Status)
+	assert.Equal(t, StatusDown, report.Checks["b"].Status)
+	assert.Equal(t, "b is down", report.Checks["b"].Error)
+	assert.Equal(t, 1, report.Checks["b"].Details["extra"])
+}
+
+func TestChecker_OptionalDown(t *testing.T) {
+	report := NewChecker(time.Second, passing("a"), Optional(failing("b"))).Run(context.Background())
+
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, StatusDown, report.Checks["b"].Status)
+	assert.Equal(t, "b is down", report.Checks["b"].Error)
+}
+
+func TestChecker_Nil(t *testing.T) {
+	var checker *Checker
+	report := checker.Run(context.Background())
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Empty(t, report.Checks)
+}
+
+func TestRedisCheck_Timeout(t *testing.T) {
+	checker := NewChecker(10*time.Millisecond, RedisCheck(fakePinger{delay: 200 * time.Millisecond}))
+	report := checker.Run(context.Background())
+
+	assert.Equal(t, StatusDown, report.Checks["redis"].Status)
+	assert.Contains(t, report.Checks["redis"].Error, "deadline exceeded")
+}
+
+func TestRedisCheck_Error(t *testing.T) {
+	report := NewChecker(time.Second, RedisCheck(fakePinger{err: errors.New("connection refused")})).Run(context.Background())
+	assert.Equal(t, "connection refused", report.Checks["redis"].Error)
+}
+
+func TestDatabaseCheck(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectPing().WillReturnError(errors.New("db down"))
+
+	report := NewChecker(time.Second, DatabaseCheck(db)).Run(context.Background())
+	assert.Equal(t, StatusDown, report.Checks["database"].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrationsCheck_Pending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT version FROM schema_migrations`).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+
+	report := NewChecker(time.Second, MigrationsCheck(db)).Run(context.Background())
+	assert.Equal(t, StatusDown, report.Checks["migrations"].Status)
+	assert.Contains(t, report.Checks["migrations"].Error, "pending migrations")
+}
+
+func TestOutboxCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`FROM task_outbox`).WillReturnRows(sqlmock.NewRows([]string{"count", "retrying", "oldest"}).AddRow(12, 3, 95000.0))
+
+	report := NewChecker(time.Second, OutboxCheck(db)).Run(context.Background())
+	assert.Equal(t, StatusUp, report.Checks["outbox"].Status)
+	assert.Equal(t, map[string]interface{}{"pending": 12, "retrying": 3, "oldest_age_ms": int64(95000)}, report.Checks["outbox"].Details)
+}
+
+func TestQueueBacklogCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		inspector  fakeInspector
+		wantStatus string
+	}{
+		{"queue not created yet", fakeInspector{queues: []string{}}, StatusUp},
+		{"within limits", fakeInspector{queues: []string{"default"}, info: &asynq.QueueInfo{Pending: 5, Latency: time.Second}}, StatusUp},
+		{"too many pending", fakeInspector{queues: []string{"default"}, info: &asynq.QueueInfo{Pending: 50}}, StatusDown},
+		{"too old", fakeInspector{queues: []string{"default"}, info: &asynq.QueueInfo{Pending: 1, Latency: time.Hour}}, StatusDown},
+		{"redis error", fakeInspector{err: errors.New("redis down")}, StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := QueueBacklogCheck(tt.inspector, "default", 10, time.Minute)
+			report := NewChecker(time.Second, check).Run(context.Background())
+			assert.Equal(t, tt.wantStatus, report.Checks["queue_backlog"].Status)
+		})
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	w := httptest.NewRecorder()
+	LiveHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	ReadyHandler(NewChecker(time.Second, failing("redis"))).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusDown, report.Checks["redis"].Status)
+}
+
+func TestChecker_Drain(t *testing.T) {
+	checker := NewChecker(time.Second, passing("database"))
+	assert.Equal(t, StatusUp, checker.Run(context.Background()).Status)
+
+	checker.Drain()
+
+	report := checker.Run(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, "shutting down", report.Checks["shutdown"].Error)
+	assert.NotContains(t, report.Checks, "database")
+}
+
+func TestReplicaCheck_NeverFails(t *testing.T) {
+	primary, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	router := database.NewReadRouter(primary, replica, time.Second)
+	mock.ExpectQuery("pg_last_wal_receive_lsn").WillReturnError(errors.New("connection refused"))
+	router.Check(context.Background())
+
+	report := NewChecker(time.Second, ReplicaCheck(router)).Run(context.Background())
+
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, "primary", report.Checks["read_replica"].Details["reads"])
+	assert.Contains(t, report.Checks["read_replica"].Details["error"], "connection refused")
+}
@@ -0,0 +1,51 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Hook is one step of a shutdown sequence, such as draining the HTTP server
+// or closing the database.
+type Hook struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Close adapts a plain Close method (sql.DB, asynq.Client, ...) to a Hook.
+func Close(name string, closer interface{ Close() error }) Hook {
+	return Hook{Name: name, Run: func(context.Context) error { return closer.Close() }}
+}
+
+// SignalContext returns a context cancelled on SIGINT or SIGTERM, which is
+// how Kubernetes asks a pod to stop.
+func SignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(parent, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+}
+
+// Shutdown runs hooks in order under a single deadline. Every hook runs even
+// if an earlier one failed, so a stuck HTTP drain still lets the database
+// connections be closed. The returned error joins every failure.
+func Shutdown(timeout time.Duration, hooks ...Hook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	for _, hook := range hooks {
+		start := time.Now()
+		if err := hook.Run(ctx); err != nil {
+			slog.Error("shutdown step failed", "step", hook.Name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", hook.Name, err))
+			continue
+		}
+		slog.Info("shutdown step done", "step", hook.Name, "duration_ms", time.Since(start).Milliseconds())
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,79 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCloser struct {
+	closed bool
+	err    error
+}
+
+func (f *fakeCloser) Close() error {
+	f.closed = true
+	return f.err
+}
+
+func TestShutdown_RunsHooksInOrder(t *testing.T) {
+	var order []string
+	hook := func(name string) Hook {
+		return Hook{Name: name, Run: func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		}}
+	}
+
+	err := Shutdown(time.Second, hook("http"), hook("queue"), hook("db"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http", "queue", "db"}, order)
+}
+
+func TestShutdown_ContinuesAfterFailure(t *testing.T) {
+	db := &fakeCloser{}
+
+	err := Shutdown(time.Second,
+		Hook{Name: "http", Run: func(ctx context.Context) error { return errors.New("drain failed") }},
+		Close("db", db),
+	)
+
+	assert.ErrorContains(t, err, "http: drain failed")
+	assert.True(t, db.closed)
+}
+
+func TestShutdown_SharesDeadline(t *testing.T) {
+	var deadline time.Time
+	err := Shutdown(50*time.Millisecond, Hook{Name: "slow", Run: func(ctx context.Context) error {
+		deadline, _ = ctx.Deadline()
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, deadline.IsZero())
+}
+
+func TestClose_PropagatesError(t *testing.T) {
+	closer := &fakeCloser{err: errors.New("already closed")}
+	err := Close("redis", closer).Run(context.Background())
+	assert.EqualError(t, err, "already closed")
+}
+
+func TestSignalContext_CancelledOnSIGTERM(t *testing.T) {
+	ctx, stop := SignalContext(context.Background())
+	defer stop()
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context was not cancelled by SIGTERM")
+	}
+}
@@ -0,0 +1,21 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// AsynqLogger routes the asynq server's own logs through slog. It satisfies
+// asynq.Logger.
+type AsynqLogger struct{}
+
+func (AsynqLogger) Debug(args ...interface{}) { slog.Debug(fmt.Sprint(args...), "component", "asynq") }
+func (AsynqLogger) Info(args ...interface{})  { slog.Info(fmt.Sprint(args...), "component", "asynq") }
+func (AsynqLogger) Warn(args ...interface{})  { slog.Warn(fmt.Sprint(args...), "component", "asynq") }
+func (AsynqLogger) Error(args ...interface{}) { slog.Error(fmt.Sprint(args...), "component", "asynq") }
+
+func (AsynqLogger) Fatal(args ...interface{}) {
+	slog.Error(fmt.Sprint(args...), "component", "asynq")
+	os.Exit(1)
+}
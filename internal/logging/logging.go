@@ -0,0 +1,179 @@
+package logging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LevelOff disables redaction when used as the redaction level.
+const LevelOff = slog.Level(100)
+
+// Config selects how logs are written.
+type Config struct {
+	// Level is the minimum level logged: debug, info, warn or error.
+	Level string
+	// Format is json (default) or text.
+	Format string
+	// RedactLevel is the level from which student identifiers are replaced
+	// by a short hash: debug, info, warn, error or off. Defaults to info,
+	// so raw identifiers only show up in debug logs.
+	RedactLevel string
+}
+
+// redactedKeys are the attribute keys holding student identifiers.
+var redactedKeys = map[string]bool{
+	"student_id": true,
+}
+
+// ParseLevel converts a level name into a slog.Level. "off" is accepted so
+// it can also be used for the redaction threshold.
+func ParseLevel(name string, fallback slog.Level) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		return fallback, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "off", "none":
+		return LevelOff, nil
+	default:
+		return fallback, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// New builds a logger writing to w according to cfg.
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level, slog.LevelInfo)
+	if err != nil {
+		return nil, err
+	}
+	redactLevel, err := ParseLevel(cfg.RedactLevel, slog.LevelInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+
+	var inner slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		inner = slog.NewJSONHandler(w, options)
+	case "text":
+		inner = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (expected json or text)", cfg.Format)
+	}
+
+	return slog.New(newHandler(inner, redactLevel)), nil
+}
+
+// Init builds a logger with New and installs it as the slog default, which
+// also routes the standard library log package through it.
+func Init(w io.Writer, cfg Config) error {
+	logger, err := New(w, cfg)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// Redact replaces a student identifier with a stable short hash, so log
+// lines about the same student can still be correlated.
+func Redact(value string) string {
+	if value == "" {
+		return value
+	}
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// handler adds request and trace IDs from the context to every record, and
+// redacts student identifiers on records at or above redactLevel. It keeps
+// a redacted and a plain copy of the inner handler so attributes added with
+// With are redacted depending on the level of each record.
+type handler struct {
+	plain       slog.Handler
+	redacted    slog.Handler
+	redactLevel slog.Level
+}
+
+func newHandler(inner slog.Handler, redactLevel slog.Level) *handler {
+	return &handler{plain: inner, redacted: inner, redactLevel: redactLevel}
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.plain.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	redact := r.Level >= h.redactLevel
+
+	record := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if redact {
+			a = redactAttr(a)
+		}
+		record.AddAttrs(a)
+		return true
+	})
+
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+
+	if redact {
+		return h.redacted.Handle(ctx, record)
+	}
+	return h.plain.Handle(ctx, record)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &handler{
+		plain:       h.plain.WithAttrs(attrs),
+		redacted:    h.redacted.WithAttrs(redacted),
+		redactLevel: h.redactLevel,
+	}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{
+		plain:       h.plain.WithGroup(name),
+		redacted:    h.redacted.WithGroup(name),
+		redactLevel: h.redactLevel,
+	}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = redactAttr(member)
+		}
+		return slog.Group(a.Key, redacted...)
+	}
+	if redactedKeys[a.Key] {
+		return slog.String(a.Key, Redact(a.Value.String()))
+	}
+	return a
+}
@@ -0,0 +1,217 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	buf.Reset()
+	return entry
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected slog.Level
+		wantErr  bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"off", LevelOff, false},
+		{"verbose", slog.LevelInfo, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			level, err := ParseLevel(tt.input, slog.LevelInfo)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.expected, level)
+		})
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, Config{Format: "xml"})
+	assert.Error(t, err)
+
+	_, err = New(&bytes.Buffer{}, Config{Level: "loud"})
+	assert.Error(t, err)
+
+	_, err = New(&bytes.Buffer{}, Config{RedactLevel: "sometimes"})
+	assert.Error(t, err)
+}
+
+func TestLevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{Level: "warn"})
+	require.NoError(t, err)
+
+	logger.Info("hidden")
+	assert.Empty(t, buf.String())
+
+	logger.Warn("shown")
+	assert.Equal(t, "shown", decode(t, &buf)["msg"])
+}
+
+func TestRedaction_DependsOnLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{Level: "debug", RedactLevel: "info"})
+	require.NoError(t, err)
+
+	logger.Debug("debug line", "student_id", "student1")
+	assert.Equal(t, "student1", decode(t, &buf)["student_id"])
+
+	logger.Info("info line", "student_id", "student1", "course_id", "course1")
+	entry := decode(t, &buf)
+	assert.Equal(t, Redact("student1"), entry["student_id"])
+	assert.Equal(t, "course1", entry["course_id"])
+}
+
+func TestRedaction_WithAttrsAndGroups(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{Level: "debug", RedactLevel: "info"})
+	require.NoError(t, err)
+
+	scoped := logger.With("student_id", "student1")
+
+	scoped.Debug("debug line")
+	assert.Equal(t, "student1", decode(t, &buf)["student_id"])
+
+	scoped.Info("info line", slog.Group("grade", "student_id", "student2"))
+	entry := decode(t, &buf)
+	assert.Equal(t, Redact("student1"), entry["student_id"])
+	assert.Equal(t, Redact("student2"), entry["grade"].(map[string]interface{})["student_id"])
+}
+
+func TestRedaction_Off(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{RedactLevel: "off"})
+	require.NoError(t, err)
+
+	logger.Error("error line", "student_id", "student1")
+	assert.Equal(t, "student1", decode(t, &buf)["student_id"])
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "", Redact(""))
+	assert.Equal(t, Redact("student1"), Redact("student1"))
+	assert.NotEqual(t, Redact("student1"), Redact("student2"))
+	assert.NotContains(t, Redact("student1"), "student1")
+}
+
+func TestRequestIDAddedFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{})
+	require.NoError(t, err)
+
+	logger.InfoContext(WithRequestID(context.Background(), "req-1"), "with id")
+	assert.Equal(t, "req-1", decode(t, &buf)["request_id"])
+
+	logger.InfoContext(context.Background(), "without id")
+	assert.NotContains(t, decode(t, &buf), "request_id")
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+
+	var seen string
+	router.GET("/", func(c *gin.Context) {
+		seen = RequestID(c.Request.Context())
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "from-client")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "from-client", seen)
+	assert.Equal(t, "from-client", w.Header().Get(RequestIDHeader))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.NotEmpty(t, seen)
+	assert.NotEqual(t, "from-client", seen)
+	assert.Equal(t, seen, w.Header().Get(RequestIDHeader))
+}
+
+func TestGinLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{})
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestIDMiddleware(), GinLogger())
+	router.GET("/student/:student_id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/student/student1", nil))
+
+	entry := decode(t, &buf)
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "/student/:student_id", entry["route"])
+	assert.EqualValues(t, 404, entry["status"])
+	assert.NotEmpty(t, entry["request_id"])
+	assert.NotContains(t, entry, "path")
+}
+
+func TestGRPCInterceptors(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{})
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/stats.v1.StatsService/GetTaskAverages"}
+	var seen string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		seen = RequestID(ctx)
+		return nil, status.Error(codes.NotFound, "no grades")
+	}
+	logged := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return GRPCLogger()(ctx, req, info, handler)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, "from-client"))
+	_, err = GRPCRequestIDInterceptor()(ctx, nil, info, logged)
+
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "from-client", seen)
+	entry := decode(t, &buf)
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "/stats.v1.StatsService/GetTaskAverages", entry["method"])
+	assert.Equal(t, "NotFound", entry["code"])
+	assert.Equal(t, "from-client", entry["request_id"])
+
+	_, _ = GRPCRequestIDInterceptor()(context.Background(), nil, info, logged)
+	assert.NotEmpty(t, seen)
+	assert.NotEqual(t, "from-client", seen)
+}
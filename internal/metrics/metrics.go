@@ -0,0 +1,296 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "service_stats"
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Outcomes of a consumed event besides OutcomeFailure.
+const (
+	EventEnqueued = "enqueued"
+	EventIgnored  = "ignored"
+	EventInvalid  = "invalid"
+)
+
+// Outcomes of a webhook delivery attempt.
+const (
+	WebhookDelivered = "delivered"
+	WebhookRetrying  = "retrying"
+	WebhookFailed    = "failed"
+)
+
+var (
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	GRPCRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC calls handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	GRPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC call latency, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of repository functions in the database package.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"function"})
+
+	DBReplicaLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_replica_lag_seconds",
+		Help:      "Replication lag of the read replica at the last check.",
+	})
+
+	DBReplicaHealthy = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_replica_healthy",
+		Help:      "1 while reads are routed to the replica, 0 while they fall back to the primary.",
+	})
+
+	CacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Requests to cached endpoints, by route and result (hit, miss or not_modified).",
+	}, []string{"route", "result"})
+
+	CacheErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_errors_total",
+		Help:      "Response cache calls to Redis that failed and fell back to the local cache, by operation.",
+	}, []string{"operation"})
+
+	EnqueueTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "queue_enqueue_total",
+		Help:      "Tasks sent to the queue, by task type and outcome.",
+	}, []string{"task_type", "outcome"})
+
+	TasksProcessedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "worker_tasks_processed_total",
+		Help:      "Tasks processed by the worker, by task type and outcome.",
+	}, []string{"task_type", "outcome"})
+
+	TaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "worker_task_duration_seconds",
+		Help:      "Time spent processing a task, by task type and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"task_type", "outcome"})
+
+	DeadLettersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "worker_dead_letters_total",
+		Help:      "Tasks archived by the worker, by task type and reason (permanent or exhausted).",
+	}, []string{"task_type", "reason"})
+
+	OutboxWritesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_writes_total",
+		Help:      "Tasks written to the outbox table, by task type and outcome.",
+	}, []string{"task_type", "outcome"})
+
+	OutboxPendingTasks = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "outbox_pending_tasks",
+		Help:      "Tasks in the outbox table waiting to be enqueued, as of the last relay round.",
+	})
+
+	OutboxRetryingTasks = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "outbox_retrying_tasks",
+		Help:      "Tasks in the outbox table that failed to enqueue at least once, as of the last relay round.",
+	})
+
+	OutboxOldestTaskAge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "outbox_oldest_task_age_seconds",
+		Help:      "How long the oldest task in the outbox table has waited, as of the last relay round.",
+	})
+
+	EventsConsumedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_consumed_total",
+		Help:      "Events received from other services, by source, event type and outcome.",
+	}, []string{"source", "event_type", "outcome"})
+
+	WebhookDeliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Attempts to deliver an event to a webhook subscription, by event type and outcome (delivered, retrying or failed).",
+	}, []string{"event_type", "outcome"})
+
+	RealtimePublishedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "realtime_updates_published_total",
+		Help:      "Aggregate updates published to the API replicas, by outcome.",
+	}, []string{"outcome"})
+
+	RealtimeDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "realtime_updates_dropped_total",
+		Help:      "Aggregate updates not sent to an SSE client because it was falling behind.",
+	})
+
+	SSEConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sse_connections",
+		Help:      "Open Server-Sent Events connections.",
+	})
+)
+
+func outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+// Handler exposes every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// GinMiddleware records request count and latency labelled with the route
+// template (c.FullPath()), so path parameters don't explode cardinality.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		HTTPRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+		HTTPRequestsTotal.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	}
+}
+
+// GRPCUnaryInterceptor is GinMiddleware for the gRPC API. Methods are
+// labelled with their full name, like /stats.v1.StatsService/AddGrade.
+func GRPCUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		GRPCRequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		GRPCRequestsTotal.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+		return resp, err
+	}
+}
+
+// ObserveDBQuery records how long a repository function took. It is meant to
+// be deferred at the top of the function:
+//
+//	defer metrics.ObserveDBQuery("InsertGrade", time.Now())
+func ObserveDBQuery(function string, start time.Time) {
+	DBQueryDuration.WithLabelValues(function).Observe(time.Since(start).Seconds())
+}
+
+// ObserveEnqueue counts an attempt to enqueue a task of the given type.
+func ObserveEnqueue(taskType string, err error) {
+	EnqueueTotal.WithLabelValues(taskType, outcome(err)).Inc()
+}
+
+// ObserveOutboxWrite counts an attempt to write a task of the given type to
+// the outbox table.
+func ObserveOutboxWrite(taskType string, err error) {
+	OutboxWritesTotal.WithLabelValues(taskType, outcome(err)).Inc()
+}
+
+// ObserveOutbox records the size of the outbox table.
+func ObserveOutbox(pending, retrying int, oldestAge time.Duration) {
+	OutboxPendingTasks.Set(float64(pending))
+	OutboxRetryingTasks.Set(float64(retrying))
+	OutboxOldestTaskAge.Set(oldestAge.Seconds())
+}
+
+// ObserveEvent counts an event received from source. outcome is one of the
+// Event* constants or OutcomeFailure.
+func ObserveEvent(source, eventType, outcome string) {
+	EventsConsumedTotal.WithLabelValues(source, eventType, outcome).Inc()
+}
+
+// ObserveWebhookDelivery counts an attempt to deliver an event of the given
+// type to a webhook subscription. outcome is one of the Webhook* constants.
+func ObserveWebhookDelivery(eventType, outcome string) {
+	WebhookDeliveriesTotal.WithLabelValues(eventType, outcome).Inc()
+}
+
+// ObserveRealtimePublish counts an aggregate update published for the SSE
+// clients.
+func ObserveRealtimePublish(err error) {
+	RealtimePublishedTotal.WithLabelValues(outcome(err)).Inc()
+}
+
+// ObserveRealtimeDropped counts an update a slow SSE client missed.
+func ObserveRealtimeDropped() {
+	RealtimeDroppedTotal.Inc()
+}
+
+// ObserveSSEConnection tracks an SSE connection opening (1) or closing
+// (-1).
+func ObserveSSEConnection(delta float64) {
+	SSEConnections.Add(delta)
+}
+
+// ObserveDeadLetter counts a task the worker gave up on, either because its
+// error was permanent or because it ran out of retries.
+func ObserveDeadLetter(taskType string, permanent bool) {
+	reason := "exhausted"
+	if permanent {
+		reason = "permanent"
+	}
+	DeadLettersTotal.WithLabelValues(taskType, reason).Inc()
+}
+
+// AsynqMiddleware records processing duration and outcome for every task the
+// worker handles.
+func AsynqMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		start := time.Now()
+		err := next.ProcessTask(ctx, t)
+
+		result := outcome(err)
+		TaskDuration.WithLabelValues(t.Type(), result).Observe(time.Since(start).Seconds())
+		TasksProcessedTotal.WithLabelValues(t.Type(), result).Inc()
+		return err
+	})
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGinMiddleware_UsesRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinMiddleware())
+	router.GET("/course/:course_id", func(c *gin.Context) {
+		c.Status(http.StatusTeapot)
+	})
+
+	before := testutil.ToFloat64(HTTPRequestsTotal.WithLabelValues("GET", "/course/:course_id", "418"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/course/abc", nil))
+
+	after := testutil.ToFloat64(HTTPRequestsTotal.WithLabelValues("GET", "/course/:course_id", "418"))
+	assert.Equal(t, before+1, after)
+}
+
+func TestGinMiddleware_UnmatchedRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinMiddleware())
+
+	before := testutil.ToFloat64(HTTPRequestsTotal.WithLabelValues("GET", "unmatched", "404"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	after := testutil.ToFloat64(HTTPRequestsTotal.WithLabelValues("GET", "unmatched", "404"))
+	assert.Equal(t, before+1, after)
+}
+
+func TestGRPCUnaryInterceptor(t *testing.T) {
+	method := "/stats.v1.StatsService/AddGrade"
+	before := testutil.ToFloat64(GRPCRequestsTotal.WithLabelValues(method, "InvalidArgument"))
+
+	_, err := GRPCUnaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.InvalidArgument, "negative grade")
+		})
+
+	assert.Error(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(GRPCRequestsTotal.WithLabelValues(method, "InvalidArgument")))
+}
+
+func TestObserveEnqueue(t *testing.T) {
+	okBefore := testutil.ToFloat64(EnqueueTotal.WithLabelValues("task:test", OutcomeSuccess))
+	failBefore := testutil.ToFloat64(EnqueueTotal.WithLabelValues("task:test", OutcomeFailure))
+
+	ObserveEnqueue("task:test", nil)
+	ObserveEnqueue("task:test", errors.New("redis down"))
+
+	assert.Equal(t, okBefore+1, testutil.ToFloat64(EnqueueTotal.WithLabelValues("task:test", OutcomeSuccess)))
+	assert.Equal(t, failBefore+1, testutil.ToFloat64(EnqueueTotal.WithLabelValues("task:test", OutcomeFailure)))
+}
+
+func TestObserveDeadLetter(t *testing.T) {
+	permanentBefore := testutil.ToFloat64(DeadLettersTotal.WithLabelValues("task:test", "permanent"))
+	exhaustedBefore := testutil.ToFloat64(DeadLettersTotal.WithLabelValues("task:test", "exhausted"))
+
+	ObserveDeadLetter("task:test", true)
+	ObserveDeadLetter("task:test", false)
+
+	assert.Equal(t, permanentBefore+1, testutil.ToFloat64(DeadLettersTotal.WithLabelValues("task:test", "permanent")))
+	assert.Equal(t, exhaustedBefore+1, testutil.ToFloat64(DeadLettersTotal.WithLabelValues("task:test", "exhausted")))
+}
+
+func TestObserveDBQuery(t *testing.T) {
+	ObserveDBQuery("TestFunction", time.Now())
+	assert.Equal(t, 1, testutil.CollectAndCount(DBQueryDuration, "service_stats_db_query_duration_seconds"))
+}
+
+func TestAsynqMiddleware(t *testing.T) {
+	handler := AsynqMiddleware(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		if string(task.Payload()) == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	}))
+
+	okBefore := testutil.ToFloat64(TasksProcessedTotal.WithLabelValues("task:mw", OutcomeSuccess))
+	failBefore := testutil.ToFloat64(TasksProcessedTotal.WithLabelValues("task:mw", OutcomeFailure))
+
+	assert.NoError(t, handler.ProcessTask(context.Background(), asynq.NewTask("task:mw", []byte("ok"))))
+	assert.Error(t, handler.ProcessTask(context.Background(), asynq.NewTask("task:mw", []byte("fail"))))
+
+	assert.Equal(t, okBefore+1, testutil.ToFloat64(TasksProcessedTotal.WithLabelValues("task:mw", OutcomeSuccess)))
+	assert.Equal(t, failBefore+1, testutil.ToFloat64(TasksProcessedTotal.WithLabelValues("task:mw", OutcomeFailure)))
+}
+
+func TestHandler(t *testing.T) {
+	ObserveEnqueue("task:exposed", nil)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `service_stats_queue_enqueue_total{outcome="success",task_type="task:exposed"}`)
+}
+
+func TestObserveOutbox(t *testing.T) {
+	before := testutil.ToFloat64(OutboxWritesTotal.WithLabelValues("task:outbox", OutcomeFailure))
+	ObserveOutboxWrite("task:outbox", errors.New("db down"))
+	assert.Equal(t, before+1, testutil.ToFloat64(OutboxWritesTotal.WithLabelValues("task:outbox", OutcomeFailure)))
+
+	ObserveOutbox(7, 2, 90*time.Second)
+	assert.Equal(t, 7.0, testutil.ToFloat64(OutboxPendingTasks))
+	assert.Equal(t, 2.0, testutil.ToFloat64(OutboxRetryingTasks))
+	assert.Equal(t, 90.0, testutil.ToFloat64(OutboxOldestTaskAge))
+}
@@ -0,0 +1,161 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Schema is a raw OpenAPI schema object. It is kept as a map so specs can
+// be written inline next to the routes without a full schema model.
+type Schema map[string]interface{}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type Parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
+	Schema      Schema `json:"schema,omitempty"`
+}
+
+type MediaType struct {
+	Schema Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Operation struct {
+	Tags        []string            `json:"tags,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Components struct {
+	Schemas map[string]Schema `json:"schemas,omitempty"`
+}
+
+// Document is the root of an OpenAPI 3 document.
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components,omitempty"`
+}
+
+func NewDocument(info Info) *Document {
+	return &Document{
+		OpenAPI:    "3.0.0",
+		Info:       info,
+		Paths:      map[string]map[string]*Operation{},
+		Components: Components{Schemas: map[string]Schema{}},
+	}
+}
+
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// PathFromGin converts a Gin route path ("/course/:course_id") into its
+// OpenAPI form ("/course/{course_id}").
+func PathFromGin(path string) string {
+	return ginParam.ReplaceAllString(path, "{$1}")
+}
+
+// AddOperation registers op under the given method and Gin path. Path
+// parameters present in the route but not declared in op are added, so the
+// spec can never miss one.
+func (d *Document) AddOperation(method string, ginPath string, op Operation) {
+	declared := map[string]bool{}
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			declared[p.Name] = true
+		}
+	}
+
+	var pathParams []Parameter
+	for _, match := range ginParam.FindAllStringSubmatch(ginPath, -1) {
+		if !declared[match[1]] {
+			pathParams = append(pathParams, PathParam(match[1], ""))
+		}
+	}
+	op.Parameters = append(pathParams, op.Parameters...)
+
+	if op.Responses == nil {
+		op.Responses = map[string]Response{"200": {Description: "OK"}}
+	}
+
+	path := PathFromGin(ginPath)
+	if d.Paths[path] == nil {
+		d.Paths[path] = map[string]*Operation{}
+	}
+	d.Paths[path][strings.ToLower(method)] = &op
+}
+
+// HasOperation reports whether the document describes the given method and
+// Gin path.
+func (d *Document) HasOperation(method string, ginPath string) bool {
+	item, ok := d.Paths[PathFromGin(ginPath)]
+	if !ok {
+		return false
+	}
+	_, ok = item[strings.ToLower(method)]
+	return ok
+}
+
+func PathParam(name string, description string) Parameter {
+	return Parameter{Name: name, In: "path", Required: true, Description: description, Schema: Schema{"type": "string"}}
+}
+
+func QueryParam(name string, description string, schema Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func HeaderParam(name string, description string, schema Schema) Parameter {
+	return Parameter{Name: name, In: "header", Required: true, Description: description, Schema: schema}
+}
+
+func Ref(name string) Schema {
+	return Schema{"$ref": "#/components/schemas/" + name}
+}
+
+func JSONBody(schema Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+func JSONResponse(description string, schema Schema) Response {
+	return Response{Description: description, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+// Handler serves the document as JSON.
+func Handler(doc *Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	}
+}
@@ -0,0 +1,60 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathFromGin(t *testing.T) {
+	assert.Equal(t, "/course/{course_id}/task/{task_id}", PathFromGin("/course/:course_id/task/:task_id"))
+	assert.Equal(t, "/health", PathFromGin("/health"))
+	assert.Equal(t, "/files/{path}", PathFromGin("/files/*path"))
+}
+
+func TestAddOperation_AddsMissingPathParams(t *testing.T) {
+	doc := NewDocument(Info{Title: "test", Version: "1"})
+
+	doc.AddOperation(http.MethodGet, "/course/:course_id/student/:student_id", Operation{
+		Parameters: []Parameter{PathParam("student_id", "ID del estudiante")},
+	})
+
+	op := doc.Paths["/course/{course_id}/student/{student_id}"]["get"]
+	require.NotNil(t, op)
+	require.Len(t, op.Parameters, 2)
+	assert.Equal(t, "course_id", op.Parameters[0].Name)
+	assert.Equal(t, "student_id", op.Parameters[1].Name)
+	assert.Equal(t, "ID del estudiante", op.Parameters[1].Description)
+	assert.Contains(t, op.Responses, "200")
+}
+
+func TestHasOperation(t *testing.T) {
+	doc := NewDocument(Info{Title: "test", Version: "1"})
+	doc.AddOperation(http.MethodPost, "/student/grade", Operation{})
+
+	assert.True(t, doc.HasOperation(http.MethodPost, "/student/grade"))
+	assert.False(t, doc.HasOperation(http.MethodGet, "/student/grade"))
+	assert.False(t, doc.HasOperation(http.MethodPost, "/student/other"))
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc := NewDocument(Info{Title: "test", Version: "1"})
+	doc.AddOperation(http.MethodGet, "/health", Operation{})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	Handler(doc)(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "3.0.0", body["openapi"])
+	assert.Contains(t, body["paths"], "/health")
+}
@@ -0,0 +1,36 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const swaggerUIVersion = "5.17.14"
+
+const swaggerUITemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>%[1]s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@%[2]s/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@%[2]s/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: '%[3]s', dom_id: '#swagger-ui' });
+    };
+  </script>
+</body>
+</html>`
+
+// SwaggerUIHandler serves a Swagger UI page that loads the spec from specURL.
+func SwaggerUIHandler(title string, specURL string) gin.HandlerFunc {
+	page := fmt.Sprintf(swaggerUITemplate, title, swaggerUIVersion, specURL)
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+	}
+}
@@ -0,0 +1,59 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+
+	"github.com/hibiken/asynq"
+)
+
+var RecordDeadLetter = database.RecordDeadLetter
+
+// NewDeadLetterHandler returns the asynq.ErrorHandler of the worker. asynq
+// calls it on every failure; when the failure makes asynq archive the task
+// (a permanent error or the last retry), the task is recorded in the
+// dead_letter_tasks table so it can be inspected and replayed from the API.
+func NewDeadLetterHandler(DB *sql.DB) asynq.ErrorHandler {
+	return asynq.ErrorHandlerFunc(func(ctx context.Context, t *asynq.Task, err error) {
+		retried, _ := asynq.GetRetryCount(ctx)
+		maxRetry, _ := asynq.GetMaxRetry(ctx)
+		if !archived(err, retried, maxRetry) {
+			return
+		}
+		isPermanent := errors.Is(err, asynq.SkipRetry)
+
+		taskID, _ := asynq.GetTaskID(ctx)
+		queueName, _ := asynq.GetQueueName(ctx)
+		metrics.ObserveDeadLetter(t.Type(), isPermanent)
+
+		// The task's deadline may be about to expire, and losing the record
+		// would lose the grade
+		ctx = context.WithoutCancel(ctx)
+		recordErr := RecordDeadLetter(ctx, DB, database.DeadLetter{
+			TaskID:    taskID,
+			Queue:     queueName,
+			TaskType:  t.Type(),
+			Payload:   t.Payload(),
+			Error:     err.Error(),
+			Retried:   retried,
+			Permanent: isPermanent,
+		})
+		if recordErr != nil {
+			slog.ErrorContext(ctx, "failed to record dead letter, the task is only in the asynq archive",
+				"task_id", taskID, "task_type", t.Type(), "error", recordErr)
+			return
+		}
+		slog.WarnContext(ctx, "task archived", "task_id", taskID, "task_type", t.Type(), "retried", retried, "permanent", isPermanent, "error", err)
+	})
+}
+
+// archived reports whether asynq archives a task that failed with err after
+// being retried the given number of times, mirroring its own rule.
+func archived(err error, retried, maxRetry int) bool {
+	return errors.Is(err, asynq.SkipRetry) || retried >= maxRetry
+}
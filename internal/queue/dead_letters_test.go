@@ -0,0 +1,39 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchived(t *testing.T) {
+	assert.True(t, archived(permanent(errors.New("bad json")), 0, 15))
+	assert.True(t, archived(errors.New("db down"), 15, 15))
+	assert.False(t, archived(errors.New("db down"), 3, 15))
+}
+
+func TestDeadLetterHandler_RecordsArchivedTasks(t *testing.T) {
+	var recorded *database.DeadLetter
+	RecordDeadLetter = func(ctx context.Context, DB *sql.DB, d database.DeadLetter) error {
+		recorded = &d
+		return nil
+	}
+	defer func() { RecordDeadLetter = database.RecordDeadLetter }()
+
+	task := asynq.NewTask(types.TaskAddStudentGrade, []byte("not json"))
+	NewDeadLetterHandler(nil).HandleError(context.Background(), task, permanent(errors.New("bad json")))
+
+	if assert.NotNil(t, recorded) {
+		assert.Equal(t, types.TaskAddStudentGrade, recorded.TaskType)
+		assert.Equal(t, []byte("not json"), recorded.Payload)
+		assert.True(t, recorded.Permanent)
+		assert.Contains(t, recorded.Error, "bad json")
+	}
+}
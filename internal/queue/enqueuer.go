@@ -1,12 +1,20 @@
 package queue
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"math/rand"
 	"time"
 
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/logging"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/tracing"
+
 	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 /*type Enqueuer struct {
@@ -52,31 +60,109 @@ type AsynqClient interface {
 
 type Enqueuer struct {
 	Client AsynqClient
+	// Queue is the asynq queue tasks go to; asynq's "default" when empty.
+	Queue string
 }
 
-func NewEnqueuer(redisAddr string) *Enqueuer {
-	client := asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr})
-	return &Enqueuer{Client: client}
+func NewEnqueuer(redisOpt asynq.RedisConnOpt, queueName string) *Enqueuer {
+	client := asynq.NewClient(redisOpt)
+	return &Enqueuer{Client: client, Queue: queueName}
 }
 
-func (e *Enqueuer) Enqueue(taskType string, payload interface{}) (time.Duration, error) {
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return 0, err
+// Ping checks the connection to Redis when the underlying client supports
+// it, which *asynq.Client does.
+func (e *Enqueuer) Ping() error {
+	if pinger, ok := e.Client.(interface{ Ping() error }); ok {
+		return pinger.Ping()
 	}
+	return nil
+}
 
-	task := asynq.NewTask(taskType, data)
+// Close releases the Redis connections of the underlying client.
+func (e *Enqueuer) Close() error {
+	if closer, ok := e.Client.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
 
-	minSeconds := 30
-	maxSeconds := 180
-	delay := time.Duration(rand.Intn(maxSeconds-minSeconds+1)+minSeconds) * time.Second
+func (e *Enqueuer) Enqueue(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
+	ctx, span := startEnqueueSpan(ctx, taskType)
+	defer span.End()
 
-	_, err = e.Client.Enqueue(task, asynq.ProcessIn(delay))
+	data, err := encodeEnvelope(taskType, payload, taskMetadata(ctx))
 	if err != nil {
-		log.Printf("Failed to enqueue task: %v", err)
 		return 0, err
 	}
 
-	log.Printf("Task enqueued: %s", taskType)
+	delay := processingDelay()
+	if err := e.enqueue(ctx, taskType, data, asynq.ProcessIn(delay)); err != nil {
+		return 0, err
+	}
+
+	slog.InfoContext(ctx, "task enqueued", "task_type", taskType, "delay", delay.String())
 	return delay, nil
 }
+
+// EnqueueNow enqueues a task to be processed right away, for the tasks that
+// don't go through the processing delay of the grades.
+func (e *Enqueuer) EnqueueNow(ctx context.Context, taskType string, payload interface{}) error {
+	ctx, span := startEnqueueSpan(ctx, taskType)
+	defer span.End()
+
+	data, err := encodeEnvelope(taskType, payload, taskMetadata(ctx))
+	if err != nil {
+		return err
+	}
+	return e.enqueue(ctx, taskType, data)
+}
+
+// enqueue sends an encoded envelope to the queue, recording the outcome in
+// the span of ctx.
+func (e *Enqueuer) enqueue(ctx context.Context, taskType string, data []byte, opts ...asynq.Option) error {
+	opts = append(opts, asynq.MaxRetry(RetryPolicyFor(taskType).MaxRetry))
+	if e.Queue != "" {
+		opts = append(opts, asynq.Queue(e.Queue))
+	}
+	_, err := e.Client.Enqueue(asynq.NewTask(taskType, data), opts...)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		// Only the outbox relay sets task IDs: the task was enqueued by an
+		// earlier attempt whose outcome was lost
+		slog.DebugContext(ctx, "task already enqueued", "task_type", taskType)
+		return nil
+	}
+	metrics.ObserveEnqueue(taskType, err)
+	if err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "failed to enqueue task", "task_type", taskType, "error", err)
+	}
+	return err
+}
+
+func startEnqueueSpan(ctx context.Context, taskType string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "enqueue "+taskType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("messaging.system", "asynq"), attribute.String("task.type", taskType)),
+	)
+}
+
+// taskMetadata returns the metadata of a task enqueued from ctx: its trace
+// context and request ID.
+func taskMetadata(ctx context.Context) map[string]string {
+	meta := map[string]string{}
+	tracing.Inject(ctx, meta)
+	if id := logging.RequestID(ctx); id != "" {
+		meta[requestIDField] = id
+	}
+	return meta
+}
+
+// processingDelay is how long a new task waits before being processed,
+// between 30 seconds and 3 minutes.
+func processingDelay() time.Duration {
+	minSeconds := 30
+	maxSeconds := 180
+	return time.Duration(rand.Intn(maxSeconds-minSeconds+1)+minSeconds) * time.Second
+}
@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -32,7 +33,7 @@ func TestEnqueue_Success(t *testing.T) {
 		Name string
 	}{Name: "test"}
 
-	delay, err := enqueuer.Enqueue("test_task", payload)
+	delay, err := enqueuer.Enqueue(context.Background(), "test_task", payload)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -42,6 +43,29 @@ func TestEnqueue_Success(t *testing.T) {
 	}
 }
 
+func TestEnqueue_Queue(t *testing.T) {
+	var queue string
+	mockClient := &MockAsynqClient{
+		EnqueueFunc: func(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+			for _, opt := range opts {
+				if opt.Type() == asynq.QueueOpt {
+					queue = opt.Value().(string)
+				}
+			}
+			return &asynq.TaskInfo{ID: "123"}, nil
+		},
+	}
+
+	enqueuer := &Enqueuer{Client: mockClient, Queue: "critical"}
+	if _, err := enqueuer.Enqueue(context.Background(), "test_task", struct{}{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if queue != "critical" {
+		t.Errorf("expected the task in queue critical, got %q", queue)
+	}
+}
+
 func TestEnqueue_Failure(t *testing.T) {
 	mockClient := &MockAsynqClient{
 		EnqueueFunc: func(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
@@ -55,7 +79,7 @@ func TestEnqueue_Failure(t *testing.T) {
 		Name string
 	}{Name: "test"}
 
-	_, err := enqueuer.Enqueue("test_task", payload)
+	_, err := enqueuer.Enqueue(context.Background(), "test_task", payload)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
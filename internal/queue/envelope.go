@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// EnvelopeVersion is the version of the envelopes the Enqueuer writes.
+//
+// Tasks wait in Redis for minutes, so during a rolling deploy a worker may
+// receive tasks written by an older API. When the envelope or a payload
+// changes shape, bump EnvelopeVersion, add an upcaster from the previous
+// version to upcasters and add fixtures for the new version under
+// testdata/envelopes. Upcasters are never removed while a task of that
+// version may still be queued or in the dead letters.
+const EnvelopeVersion = 2
+
+var (
+	// ErrInvalidEnvelope is returned for task payloads that can't be decoded.
+	ErrInvalidEnvelope = errors.New("invalid task envelope")
+	// ErrUnsupportedVersion is returned for envelopes of a version this
+	// build doesn't know, such as one written by a newer API.
+	ErrUnsupportedVersion = errors.New("unsupported task envelope version")
+)
+
+// Envelope is the payload of every task.
+type Envelope struct {
+	Version int    `json:"version"`
+	Type    string `json:"type"`
+	// Payload is the task itself, such as a model.Grade.
+	Payload json.RawMessage `json:"payload"`
+	// Metadata carries the trace context and the request ID of the API
+	// request that enqueued the task.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// upcasters[v] turns an envelope of version v into one of version v+1.
+var upcasters = map[int]func(Envelope) (Envelope, error){
+	// Version 1 had no envelope: the payload was the model itself, with the
+	// metadata as one more field
+	1: func(env Envelope) (Envelope, error) {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(env.Payload, &object); err != nil || object == nil {
+			return env, fmt.Errorf("%w: version 1 payload is not a JSON object", ErrInvalidEnvelope)
+		}
+		delete(object, metadataField)
+		payload, err := json.Marshal(object)
+		if err != nil {
+			return env, err
+		}
+		env.Payload = payload
+		env.Version = 2
+		return env, nil
+	},
+}
+
+// encodeEnvelope wraps payload in an envelope of the current version.
+func encodeEnvelope(taskType string, payload interface{}, meta map[string]string) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(meta) == 0 {
+		meta = nil
+	}
+	return json.Marshal(Envelope{Version: EnvelopeVersion, Type: taskType, Payload: data, Metadata: meta})
+}
+
+// DecodeEnvelope reads the payload of a task of type taskType and upcasts
+// it to EnvelopeVersion. Errors wrap ErrInvalidEnvelope or
+// ErrUnsupportedVersion; retrying never fixes them.
+func DecodeEnvelope(taskType string, data []byte) (Envelope, error) {
+	var probe struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+
+	var env Envelope
+	if probe.Version == nil {
+		env = Envelope{Version: 1, Type: taskType, Payload: data, Metadata: metadataFrom(data)}
+	} else if err := json.Unmarshal(data, &env); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+
+	if env.Version < 1 || env.Version > EnvelopeVersion {
+		return Envelope{}, fmt.Errorf("%w: %d (this build reads 1 to %d)", ErrUnsupportedVersion, env.Version, EnvelopeVersion)
+	}
+	if env.Type != taskType {
+		return Envelope{}, fmt.Errorf("%w: envelope of type %q in a %q task", ErrInvalidEnvelope, env.Type, taskType)
+	}
+
+	for env.Version < EnvelopeVersion {
+		upcast, ok := upcasters[env.Version]
+		if !ok {
+			return Envelope{}, fmt.Errorf("%w: no upcaster from version %d", ErrUnsupportedVersion, env.Version)
+		}
+		var err error
+		if env, err = upcast(env); err != nil {
+			return Envelope{}, err
+		}
+	}
+	return env, nil
+}
+
+// decodeTask decodes a task payload into v, marking every error as
+// permanent.
+func decodeTask(taskType string, data []byte, v interface{}) error {
+	env, err := DecodeEnvelope(taskType, data)
+	if err != nil {
+		return permanent(err)
+	}
+	if err := json.Unmarshal(env.Payload, v); err != nil {
+		return permanent(fmt.Errorf("%w: %v", errInvalidPayload, err))
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/webhooks"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureModels holds what the fixture of each task type decodes to, in
+// every version.
+var fixtureModels = map[string]interface{}{
+	types.TaskAddStudentGrade:     model.Grade{StudentID: "student1", CourseID: "course1", Grade: 8.5, OnTime: true},
+	types.TaskAddStudentGradeTask: model.GradeTask{StudentID: "student1", CourseID: "course1", TaskID: "task1", Grade: 8.5, OnTime: true},
+	types.TaskDeliverWebhook:      webhooks.DeliveryTask{DeliveryID: 42},
+}
+
+// fixtureSince holds the first version of the task types added after
+// version 1; no older task of those types can be queued.
+var fixtureSince = map[string]int{
+	types.TaskDeliverWebhook: 2,
+}
+
+func fixture(t *testing.T, version int, taskType string) []byte {
+	name := strings.ReplaceAll(taskType, ":", "_") + ".json"
+	data, err := os.ReadFile(filepath.Join("testdata", "envelopes", fmt.Sprintf("v%d", version), name))
+	require.NoError(t, err, "every version needs a fixture for every task type")
+	return data
+}
+
+// TestEnvelopeFixtures keeps payloads written by every past version of the
+// API decodable by the current worker.
+func TestEnvelopeFixtures(t *testing.T) {
+	for version := 1; version <= EnvelopeVersion; version++ {
+		for taskType, want := range fixtureModels {
+			if version < fixtureSince[taskType] {
+				continue
+			}
+			t.Run(fmt.Sprintf("v%d/%s", version, taskType), func(t *testing.T) {
+				env, err := DecodeEnvelope(taskType, fixture(t, version, taskType))
+				require.NoError(t, err)
+				assert.Equal(t, EnvelopeVersion, env.Version)
+				assert.Equal(t, taskType, env.Type)
+				assert.Equal(t, "req-1", env.Metadata[requestIDField])
+
+				var payload map[string]json.RawMessage
+				require.NoError(t, json.Unmarshal(env.Payload, &payload))
+				assert.NotContains(t, payload, metadataField)
+
+				got := newModel(taskType)
+				require.NoError(t, json.Unmarshal(env.Payload, got))
+				assert.Equal(t, want, deref(got))
+			})
+		}
+	}
+}
+
+func newModel(taskType string) interface{} {
+	switch taskType {
+	case types.TaskAddStudentGradeTask:
+		return &model.GradeTask{}
+	case types.TaskDeliverWebhook:
+		return &webhooks.DeliveryTask{}
+	}
+	return &model.Grade{}
+}
+
+func deref(v interface{}) interface{} {
+	switch m := v.(type) {
+	case *model.Grade:
+		return *m
+	case *model.GradeTask:
+		return *m
+	case *webhooks.DeliveryTask:
+		return *m
+	}
+	return v
+}
+
+// TestEnvelopeFixtures_Handlers runs the fixtures of every version through
+// the task handlers.
+func TestEnvelopeFixtures_Handlers(t *testing.T) {
+	var grades []model.Grade
+	var gradeTasks []model.GradeTask
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		grades = append(grades, g)
+		return g, nil
+	}
+	service.InsertGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		gradeTasks = append(gradeTasks, gt)
+		return gt, nil
+	}
+	service.CheckGradeTaskExists = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (bool, error) {
+		return false, nil
+	}
+	defer func() {
+		service.InsertGrade = database.InsertGrade
+		service.InsertGradeTask = database.InsertGradeTask
+		service.CheckGradeTaskExists = database.CheckGradeTaskExists
+	}()
+
+	for version := 1; version <= EnvelopeVersion; version++ {
+		require.NoError(t, HandleAddStadisticForStudent(context.Background(), asynq.NewTask(types.TaskAddStudentGrade, fixture(t, version, types.TaskAddStudentGrade))))
+		require.NoError(t, HandleAddGradeTask(context.Background(), asynq.NewTask(types.TaskAddStudentGradeTask, fixture(t, version, types.TaskAddStudentGradeTask))))
+	}
+	assert.Len(t, grades, EnvelopeVersion)
+	assert.Len(t, gradeTasks, EnvelopeVersion)
+}
+
+func TestEncodeEnvelope_RoundTrip(t *testing.T) {
+	grade := model.Grade{StudentID: "s1", CourseID: "c1", Grade: 7}
+	data, err := encodeEnvelope(types.TaskAddStudentGrade, grade, map[string]string{requestIDField: "req-9"})
+	require.NoError(t, err)
+
+	env, err := DecodeEnvelope(types.TaskAddStudentGrade, data)
+	require.NoError(t, err)
+	assert.Equal(t, EnvelopeVersion, env.Version)
+	assert.Equal(t, "req-9", env.Metadata[requestIDField])
+
+	var decoded model.Grade
+	require.NoError(t, json.Unmarshal(env.Payload, &decoded))
+	assert.Equal(t, grade, decoded)
+}
+
+func TestDecodeEnvelope_LegacyWithoutMetadata(t *testing.T) {
+	env, err := DecodeEnvelope(types.TaskAddStudentGrade, []byte(`{"student_id":"s1","course_id":"c1","grade":7}`))
+	require.NoError(t, err)
+	assert.Empty(t, env.Metadata)
+	assert.JSONEq(t, `{"student_id":"s1","course_id":"c1","grade":7}`, string(env.Payload))
+}
+
+func TestDecodeEnvelope_Rejects(t *testing.T) {
+	for data, want := range map[string]error{
+		fmt.Sprintf(`{"version":%d,"type":"task:add_student_grade","payload":{}}`, EnvelopeVersion+1): ErrUnsupportedVersion,
+		`{"version":0,"type":"task:add_student_grade","payload":{}}`:                                  ErrUnsupportedVersion,
+		`{"version":2,"type":"task:add_student_grade_task","payload":{}}`:                             ErrInvalidEnvelope,
+		`{"version":"2","type":"task:add_student_grade","payload":{}}`:                                ErrInvalidEnvelope,
+		`["student_id"]`: ErrInvalidEnvelope,
+		`not json`:       ErrInvalidEnvelope,
+	} {
+		_, err := DecodeEnvelope(types.TaskAddStudentGrade, []byte(data))
+		assert.ErrorIs(t, err, want, data)
+	}
+
+	// Handlers send unknown versions to the dead letters right away
+	err := HandleAddStadisticForStudent(context.Background(), asynq.NewTask(types.TaskAddStudentGrade,
+		[]byte(`{"version":99,"type":"task:add_student_grade","payload":{}}`)))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	assert.ErrorIs(t, err, asynq.SkipRetry)
+}
@@ -3,12 +3,14 @@ package queue
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 
-	"service_stats/internal/database"
-	"service_stats/internal/model"
-	"service_stats/internal/types"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/webhooks"
 
 	// Add this line to import the internal package
 	"github.com/hibiken/asynq"
@@ -16,70 +18,83 @@ import (
 
 var db *sql.DB
 
-func NewMux(database_ref *sql.DB) *asynq.ServeMux {
+// gradeListener is told about every committed grade, to invalidate the
+// cached API responses and notify webhooks. May be nil.
+var gradeListener service.Listener
+
+// webhookDeliverer posts the deliveries of the webhook subscriptions. When
+// nil, webhook tasks are left for a worker that has it.
+var webhookDeliverer *webhooks.Deliverer
+
+func NewMux(database_ref *sql.DB, listener service.Listener, deliverer *webhooks.Deliverer) *asynq.ServeMux {
 	mux := asynq.NewServeMux()
+	mux.Use(metrics.AsynqMiddleware, TracingMiddleware, RequestIDMiddleware)
 	mux.HandleFunc(types.TaskAddStudentGrade, HandleAddStadisticForStudent)
 	mux.HandleFunc(types.TaskAddStudentGradeTask, HandleAddGradeTask)
+	if deliverer != nil {
+		mux.HandleFunc(types.TaskDeliverWebhook, HandleDeliverWebhook)
+	}
 	db = database_ref
+	gradeListener = listener
+	webhookDeliverer = deliverer
 	return mux
 }
 
-var (
-	InsertGradeFunc      = database.InsertGrade
-	InsertGradeTask      = database.InsertGradeTask
-	UpdateGradeTask      = database.UpdateGradeTask
-	CheckGradeTaskExists = database.CheckGradeTaskExists
-)
+// errInvalidPayload is returned, marked as permanent, for tasks whose
+// payload can never be decoded.
+var errInvalidPayload = errors.New("invalid payload")
 
+// HandleAddStadisticForStudent stores a grade. Invalid payloads and
+// database errors that would repeat are returned as permanent, so the task
+// goes to the dead letters without waiting for its retries.
 func HandleAddStadisticForStudent(ctx context.Context, t *asynq.Task) error {
 	var p model.Grade
-	if err := json.Unmarshal(t.Payload(), &p); err != nil {
-		return err
-	}
-
-	log.Printf("Processing task: %s with payload: %+v", t.Type(), p)
-
-	err := InsertGradeFunc(db, p)
-	if err != nil {
-		log.Printf("Failed to insert grade for %v: %v", p, err)
+	if err := decodeTask(t.Type(), t.Payload(), &p); err != nil {
+		slog.ErrorContext(ctx, "failed to decode task payload", "task_type", t.Type(), "error", err)
 		return err
 	}
 
-	return nil
+	_, err := service.AddGrade(ctx, db, gradeListener, p)
+	return taskError(err)
 }
 
+// HandleAddGradeTask stores or updates the grade of a task, with the same
+// error handling as HandleAddStadisticForStudent.
 func HandleAddGradeTask(ctx context.Context, t *asynq.Task) error {
 	var p model.GradeTask
-	if err := json.Unmarshal(t.Payload(), &p); err != nil {
-		log.Printf("[ERROR] Failed to unmarshal task payload: %v", err)
+	if err := decodeTask(t.Type(), t.Payload(), &p); err != nil {
+		slog.ErrorContext(ctx, "failed to decode task payload", "task_type", t.Type(), "error", err)
 		return err
 	}
 
-	log.Printf("Processing grade task: %s with payload: %+v", t.Type(), p)
+	_, err := service.SaveGradeTask(ctx, db, gradeListener, p)
+	return taskError(err)
+}
 
-	exists, err := CheckGradeTaskExists(db, p.StudentID, p.CourseID, p.TaskID)
-	if err != nil {
-		log.Printf("[ERROR] Checking grade task existence: %v", err)
+// HandleDeliverWebhook makes one attempt of a webhook delivery. Failed
+// attempts are retried with the backoff of the task type; the last one
+// marks the delivery as failed instead of sending the task to the dead
+// letters, since the delivery history already records it.
+func HandleDeliverWebhook(ctx context.Context, t *asynq.Task) error {
+	var p webhooks.DeliveryTask
+	if err := decodeTask(t.Type(), t.Payload(), &p); err != nil {
+		slog.ErrorContext(ctx, "failed to decode task payload", "task_type", t.Type(), "error", err)
 		return err
 	}
 
-	if exists {
-		err = UpdateGradeTask(db, p)
-		if err != nil {
-			log.Printf("[ERROR] Updating grade task: %v", err)
-			return err
-		}
-		log.Printf("Grade task UPDATED - Student: %s, Course: %s, Task: %s, Grade: %.2f, OnTime: %t",
-			p.StudentID, p.CourseID, p.TaskID, p.Grade, p.OnTime)
-	} else {
-		err = InsertGradeTask(db, p)
-		if err != nil {
-			log.Printf("[ERROR] Inserting grade task: %v", err)
-			return err
-		}
-		log.Printf("Grade task INSERTED - Student: %s, Course: %s, Task: %s, Grade: %.2f, OnTime: %t",
-			p.StudentID, p.CourseID, p.TaskID, p.Grade, p.OnTime)
-	}
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	return webhookDeliverer.Deliver(ctx, p.DeliveryID, retried >= maxRetry)
+}
 
+// taskError marks as permanent the errors of the service layer that
+// retrying can't fix.
+func taskError(err error) error {
+	if errors.Is(err, service.ErrInvalidGrade) {
+		return permanent(err)
+	}
+	if err != nil {
+		return classify(err)
+	}
 	return nil
 }
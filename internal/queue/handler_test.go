@@ -5,10 +5,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"service_stats/internal/database"
-	"service_stats/internal/model"
-	"service_stats/internal/types"
 	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/webhooks"
 
 	"github.com/hibiken/asynq"
 	"github.com/stretchr/testify/assert"
@@ -17,17 +21,17 @@ import (
 func TestHandleAddStadisticForStudent(t *testing.T) {
 	// Setup mock
 	mockCalled := false
-	InsertGradeFunc = func(db *sql.DB, g model.Grade) error {
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
 		mockCalled = true
 		assert.Equal(t, "student1", g.StudentID)
-		return nil
+		return g, nil
 	}
 	defer func() {
 		// reset after test
-		InsertGradeFunc = database.InsertGrade
+		service.InsertGrade = database.InsertGrade
 	}()
 
-	payload, _ := json.Marshal(model.Grade{StudentID: "student1", Grade: 90})
+	payload, _ := json.Marshal(model.Grade{StudentID: "student1", CourseID: "course1", Grade: 90})
 
 	task := asynq.NewTask(types.TaskAddStudentGrade, payload)
 
@@ -43,14 +47,14 @@ func TestHandleAddStadisticForStudent_BadJSON(t *testing.T) {
 }
 
 func TestHandleAddStadisticForStudent_DBError(t *testing.T) {
-	InsertGradeFunc = func(db *sql.DB, g model.Grade) error {
-		return errors.New("db error")
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		return model.Grade{}, errors.New("db error")
 	}
 	defer func() {
-		InsertGradeFunc = database.InsertGrade
+		service.InsertGrade = database.InsertGrade
 	}()
 
-	payload, _ := json.Marshal(model.Grade{StudentID: "student1", Grade: 90})
+	payload, _ := json.Marshal(model.Grade{StudentID: "student1", CourseID: "course1", Grade: 90})
 	task := asynq.NewTask(types.TaskAddStudentGrade, payload)
 
 	err := HandleAddStadisticForStudent(context.Background(), task)
@@ -59,32 +63,32 @@ func TestHandleAddStadisticForStudent_DBError(t *testing.T) {
 
 func TestHandleAddGradeTask(t *testing.T) {
 	mockCalled := false
-	InsertGradeTask = func(db *sql.DB, gt model.GradeTask) error {
+	service.InsertGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
 		mockCalled = true
 		assert.Equal(t, "task1", gt.TaskID)
-		return nil
+		return gt, nil
 	}
 
-	UpdateGradeTask = func(db *sql.DB, gt model.GradeTask) error {
+	service.UpdateGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
 		mockCalled = true
 		assert.Equal(t, "", gt.TaskID)
-		return nil
+		return gt, nil
 	}
 
-	CheckGradeTaskExists = func(db *sql.DB, studentID, courseID, taskID string) (bool, error) {
-		assert.Equal(t, "", studentID)
-		assert.Equal(t, "", courseID)
+	service.CheckGradeTaskExists = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (bool, error) {
+		assert.Equal(t, "student1", studentID)
+		assert.Equal(t, "course1", courseID)
 		assert.Equal(t, "task1", taskID)
 		return false, nil
 	}
 
 	defer func() {
-		InsertGradeTask = database.InsertGradeTask
-		UpdateGradeTask = database.UpdateGradeTask
-		CheckGradeTaskExists = database.CheckGradeTaskExists
+		service.InsertGradeTask = database.InsertGradeTask
+		service.UpdateGradeTask = database.UpdateGradeTask
+		service.CheckGradeTaskExists = database.CheckGradeTaskExists
 	}()
 
-	payload, _ := json.Marshal(model.GradeTask{TaskID: "task1", Grade: 85})
+	payload, _ := json.Marshal(model.GradeTask{StudentID: "student1", CourseID: "course1", TaskID: "task1", Grade: 85})
 
 	task := asynq.NewTask(types.TaskAddStudentGradeTask, payload)
 
@@ -100,25 +104,25 @@ func TestHandleAddGradeTask_BadJSON(t *testing.T) {
 }
 
 func TestHandleAddGradeTask_DBError(t *testing.T) {
-	InsertGradeTask = func(db *sql.DB, gt model.GradeTask) error {
-		return errors.New("db error")
+	service.InsertGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		return model.GradeTask{}, errors.New("db error")
 	}
 
-	UpdateGradeTask = func(db *sql.DB, gt model.GradeTask) error {
-		return errors.New("db error")
+	service.UpdateGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		return model.GradeTask{}, errors.New("db error")
 	}
 
-	CheckGradeTaskExists = func(db *sql.DB, studentID, courseID, taskID string) (bool, error) {
+	service.CheckGradeTaskExists = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (bool, error) {
 		return false, nil
 	}
 
 	defer func() {
-		InsertGradeTask = database.InsertGradeTask
-		UpdateGradeTask = database.UpdateGradeTask
-		CheckGradeTaskExists = database.CheckGradeTaskExists
+		service.InsertGradeTask = database.InsertGradeTask
+		service.UpdateGradeTask = database.UpdateGradeTask
+		service.CheckGradeTaskExists = database.CheckGradeTaskExists
 	}()
 
-	payload, _ := json.Marshal(model.GradeTask{TaskID: "task1", Grade: 85})
+	payload, _ := json.Marshal(model.GradeTask{StudentID: "student1", CourseID: "course1", TaskID: "task1", Grade: 85})
 	task := asynq.NewTask(types.TaskAddStudentGradeTask, payload)
 
 	err := HandleAddGradeTask(context.Background(), task)
@@ -126,22 +130,22 @@ func TestHandleAddGradeTask_DBError(t *testing.T) {
 }
 
 func TestHandleAddGradeTask_CaseWhenGradeTaskIsErr(t *testing.T) {
-	InsertGradeTask = func(db *sql.DB, gt model.GradeTask) error {
-		return nil
+	service.InsertGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		return gt, nil
 	}
 
-	UpdateGradeTask = func(db *sql.DB, gt model.GradeTask) error {
-		return nil
+	service.UpdateGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		return gt, nil
 	}
 
-	CheckGradeTaskExists = func(db *sql.DB, studentID, courseID, taskID string) (bool, error) {
+	service.CheckGradeTaskExists = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (bool, error) {
 		return true, nil
 	}
 
 	defer func() {
-		InsertGradeTask = database.InsertGradeTask
-		UpdateGradeTask = database.UpdateGradeTask
-		CheckGradeTaskExists = database.CheckGradeTaskExists
+		service.InsertGradeTask = database.InsertGradeTask
+		service.UpdateGradeTask = database.UpdateGradeTask
+		service.CheckGradeTaskExists = database.CheckGradeTaskExists
 	}()
 
 	payload, _ := json.Marshal(model.GradeTask{StudentID: "student1", CourseID: "course1", TaskID: "task1", Grade: 85})
@@ -154,20 +158,20 @@ func TestHandleAddGradeTask_CaseWhenGradeTaskIsErr(t *testing.T) {
 func TestHandleAddGradeTask_CaseWhenExists(t *testing.T) {
 	// Here we test if either is null and if it works
 
-	InsertGradeTask = func(db *sql.DB, gt model.GradeTask) error {
-		return nil
+	service.InsertGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		return gt, nil
 	}
 
-	UpdateGradeTask = func(db *sql.DB, gt model.GradeTask) error {
-		return nil
+	service.UpdateGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		return gt, nil
 	}
-	CheckGradeTaskExists = func(db *sql.DB, studentID, courseID, taskID string) (bool, error) {
+	service.CheckGradeTaskExists = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (bool, error) {
 		return true, nil
 	}
 	defer func() {
-		InsertGradeTask = database.InsertGradeTask
-		UpdateGradeTask = database.UpdateGradeTask
-		CheckGradeTaskExists = database.CheckGradeTaskExists
+		service.InsertGradeTask = database.InsertGradeTask
+		service.UpdateGradeTask = database.UpdateGradeTask
+		service.CheckGradeTaskExists = database.CheckGradeTaskExists
 	}()
 
 	payload, _ := json.Marshal(model.GradeTask{StudentID: "student1", CourseID: "course1", TaskID: "task1", Grade: 85})
@@ -176,3 +180,81 @@ func TestHandleAddGradeTask_CaseWhenExists(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, true) // Just to ensure the test runs without error
 }
+
+// recordingInvalidator keeps the tags it was asked to invalidate.
+type recordingInvalidator struct {
+	tags []string
+	err  error
+}
+
+func (r *recordingInvalidator) Invalidate(ctx context.Context, tags ...string) error {
+	r.tags = append(r.tags, tags...)
+	return r.err
+}
+
+func TestHandlers_InvalidateCachedResponsesAfterCommit(t *testing.T) {
+	invalidator := &recordingInvalidator{}
+	gradeListener = service.InvalidateResponses{Responses: invalidator}
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		return g, nil
+	}
+	service.InsertGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		return gt, nil
+	}
+	service.CheckGradeTaskExists = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (bool, error) {
+		return false, nil
+	}
+	defer func() {
+		gradeListener = nil
+		service.InsertGrade = database.InsertGrade
+		service.InsertGradeTask = database.InsertGradeTask
+		service.CheckGradeTaskExists = database.CheckGradeTaskExists
+	}()
+
+	payload, _ := json.Marshal(model.Grade{StudentID: "student1", CourseID: "course1", Grade: 90})
+	assert.NoError(t, HandleAddStadisticForStudent(context.Background(), asynq.NewTask(types.TaskAddStudentGrade, payload)))
+	assert.Equal(t, []string{"course:course1", "student:student1"}, invalidator.tags)
+
+	invalidator.tags = nil
+	payload, _ = json.Marshal(model.GradeTask{StudentID: "student2", CourseID: "course2", TaskID: "task1", Grade: 85})
+	assert.NoError(t, HandleAddGradeTask(context.Background(), asynq.NewTask(types.TaskAddStudentGradeTask, payload)))
+	assert.Equal(t, []string{"course:course2", "student:student2"}, invalidator.tags)
+
+	// A failed invalidation doesn't fail the task: the grade is already stored
+	invalidator.err = errors.New("redis down")
+	assert.NoError(t, HandleAddGradeTask(context.Background(), asynq.NewTask(types.TaskAddStudentGradeTask, payload)))
+}
+
+func TestHandlers_DoNotInvalidateOnFailure(t *testing.T) {
+	invalidator := &recordingInvalidator{}
+	gradeListener = service.InvalidateResponses{Responses: invalidator}
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		return model.Grade{}, errors.New("db error")
+	}
+	defer func() {
+		gradeListener = nil
+		service.InsertGrade = database.InsertGrade
+	}()
+
+	payload, _ := json.Marshal(model.Grade{StudentID: "student1", CourseID: "course1", Grade: 90})
+	assert.Error(t, HandleAddStadisticForStudent(context.Background(), asynq.NewTask(types.TaskAddStudentGrade, payload)))
+	assert.Empty(t, invalidator.tags)
+}
+
+func TestHandleDeliverWebhook(t *testing.T) {
+	var delivered []int64
+	webhooks.GetWebhookDelivery = func(ctx context.Context, DB *sql.DB, id int64) (*database.WebhookDelivery, error) {
+		delivered = append(delivered, id)
+		return &database.WebhookDelivery{ID: id, Status: database.WebhookDeliveryDelivered}, nil
+	}
+	defer func() { webhooks.GetWebhookDelivery = database.GetWebhookDelivery }()
+	webhookDeliverer = webhooks.NewDeliverer(nil, time.Second)
+	defer func() { webhookDeliverer = nil }()
+
+	payload, _ := encodeEnvelope(types.TaskDeliverWebhook, webhooks.DeliveryTask{DeliveryID: 3}, nil)
+	assert.NoError(t, HandleDeliverWebhook(context.Background(), asynq.NewTask(types.TaskDeliverWebhook, payload)))
+	assert.Equal(t, []int64{3}, delivered)
+
+	err := HandleDeliverWebhook(context.Background(), asynq.NewTask(types.TaskDeliverWebhook, []byte("invalid json")))
+	assert.ErrorIs(t, err, asynq.SkipRetry)
+}
@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/logging"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/tracing"
+
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// metadataField is the key under which request metadata (trace context and
+// similar) travels in a task payload. asynq tasks have no headers, so it is
+// a field of the Envelope; version 1 payloads carried it next to the model
+// fields, under the same key.
+const metadataField = "metadata"
+
+// requestIDField is the metadata key holding the ID of the API request that
+// enqueued the task.
+const requestIDField = "request_id"
+
+// metadataFrom returns the metadata carried in a task payload of any
+// version, or an empty map if there is none.
+func metadataFrom(payload []byte) map[string]string {
+	var envelope struct {
+		Metadata map[string]string `json:"metadata"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil || envelope.Metadata == nil {
+		return map[string]string{}
+	}
+	return envelope.Metadata
+}
+
+// TracingMiddleware continues the trace started by the API request that
+// enqueued the task, so HTTP request, task and DB writes share one trace.
+func TracingMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		ctx = tracing.Extract(ctx, metadataFrom(t.Payload()))
+
+		ctx, span := tracing.Tracer().Start(ctx, "process "+t.Type(),
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(attribute.String("messaging.system", "asynq"), attribute.String("task.type", t.Type())),
+		)
+		defer span.End()
+
+		err := next.ProcessTask(ctx, t)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	})
+}
+
+// RequestIDMiddleware restores the ID of the request that enqueued the task,
+// so worker logs can be matched with the API logs of that request.
+func RequestIDMiddleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		ctx = logging.WithRequestID(ctx, metadataFrom(t.Payload())[requestIDField])
+		return next.ProcessTask(ctx, t)
+	})
+}
@@ -0,0 +1,91 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/logging"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/tracing"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMetadataFrom_AnyVersion(t *testing.T) {
+	legacy := []byte(`{"student_id":"s1","metadata":{"traceparent":"abc"}}`)
+	envelope := []byte(`{"version":2,"type":"t","payload":{},"metadata":{"traceparent":"abc"}}`)
+
+	assert.Equal(t, "abc", metadataFrom(legacy)["traceparent"])
+	assert.Equal(t, "abc", metadataFrom(envelope)["traceparent"])
+}
+
+func TestMetadataFrom_Missing(t *testing.T) {
+	assert.Empty(t, metadataFrom([]byte(`{"student_id":"s1"}`)))
+	assert.Empty(t, metadataFrom([]byte(`not json`)))
+}
+
+func TestTraceContinuesFromEnqueueToHandler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	_, err := tracing.Init(context.Background(), "test", tracing.ExporterNone)
+	require.NoError(t, err)
+
+	var enqueued *asynq.Task
+	enqueuer := &Enqueuer{Client: &MockAsynqClient{
+		EnqueueFunc: func(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+			enqueued = task
+			return &asynq.TaskInfo{ID: "1"}, nil
+		},
+	}}
+
+	ctx, root := tracing.Tracer().Start(context.Background(), "POST /stats/student/grade")
+	_, err = enqueuer.Enqueue(ctx, types.TaskAddStudentGrade, model.Grade{StudentID: "student1"})
+	require.NoError(t, err)
+	root.End()
+
+	var handlerSpan trace.SpanContext
+	handler := TracingMiddleware(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return nil
+	}))
+	require.NoError(t, handler.ProcessTask(context.Background(), enqueued))
+
+	assert.Equal(t, root.SpanContext().TraceID(), handlerSpan.TraceID())
+
+	names := []string{}
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	assert.Contains(t, names, "enqueue "+types.TaskAddStudentGrade)
+	assert.Contains(t, names, "process "+types.TaskAddStudentGrade)
+}
+
+func TestRequestIDTravelsWithTask(t *testing.T) {
+	var enqueued *asynq.Task
+	enqueuer := &Enqueuer{Client: &MockAsynqClient{
+		EnqueueFunc: func(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+			enqueued = task
+			return &asynq.TaskInfo{ID: "1"}, nil
+		},
+	}}
+
+	ctx := logging.WithRequestID(context.Background(), "req-42")
+	_, err := enqueuer.Enqueue(ctx, types.TaskAddStudentGradeTask, model.GradeTask{StudentID: "student1"})
+	require.NoError(t, err)
+
+	var requestID string
+	handler := RequestIDMiddleware(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		requestID = logging.RequestID(ctx)
+		return nil
+	}))
+	require.NoError(t, handler.ProcessTask(context.Background(), enqueued))
+
+	assert.Equal(t, "req-42", requestID)
+}
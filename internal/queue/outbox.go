@@ -0,0 +1,167 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/tracing"
+
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var (
+	InsertOutboxTask     = database.InsertOutboxTask
+	ClaimOutboxTasks     = database.ClaimOutboxTasks
+	DeleteOutboxTask     = database.DeleteOutboxTask
+	RescheduleOutboxTask = database.RescheduleOutboxTask
+	GetOutboxStats       = database.GetOutboxStats
+)
+
+// OutboxBackoff spaces the attempts of the relay to enqueue a task. The
+// relay never gives up on a task, so MaxRetry is not used.
+var OutboxBackoff = RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}
+
+// Outbox writes tasks to the task_outbox table instead of Redis, so a task
+// accepted by the API survives a Redis outage. A Relay moves them to the
+// queue.
+type Outbox struct {
+	DB *sql.DB
+}
+
+func NewOutbox(DB *sql.DB) *Outbox {
+	return &Outbox{DB: DB}
+}
+
+// Enqueue stores the task in the outbox. The returned delay is counted from
+// now, as if the task had been enqueued directly.
+func (o *Outbox) Enqueue(ctx context.Context, taskType string, payload interface{}) (time.Duration, error) {
+	ctx, span := startEnqueueSpan(ctx, taskType)
+	defer span.End()
+
+	data, err := encodeEnvelope(taskType, payload, taskMetadata(ctx))
+	if err != nil {
+		return 0, err
+	}
+
+	delay := processingDelay()
+	err = InsertOutboxTask(ctx, o.DB, taskType, data, time.Now().Add(delay))
+	metrics.ObserveOutboxWrite(taskType, err)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "failed to write task to the outbox", "task_type", taskType, "error", err)
+		return 0, err
+	}
+
+	slog.InfoContext(ctx, "task written to the outbox", "task_type", taskType, "delay", delay.String())
+	return delay, nil
+}
+
+// Relay moves the tasks of the outbox to the queue. Several relays can run
+// at once, each claiming different tasks.
+type Relay struct {
+	DB       *sql.DB
+	Enqueuer *Enqueuer
+	// BatchSize is how many tasks are claimed at a time.
+	BatchSize int
+	// Lease is how long claimed tasks stay hidden from other relays. It must
+	// be longer than enqueueing a whole batch takes.
+	Lease time.Duration
+}
+
+func NewRelay(DB *sql.DB, enqueuer *Enqueuer, batchSize int) *Relay {
+	return &Relay{DB: DB, Enqueuer: enqueuer, BatchSize: batchSize, Lease: 30 * time.Second}
+}
+
+// Flush claims a batch of due tasks and enqueues them. A task that fails to
+// enqueue is rescheduled with OutboxBackoff; it only leaves the outbox once
+// it is in the queue. It returns how many tasks were enqueued.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	tasks, err := ClaimOutboxTasks(ctx, r.DB, r.BatchSize, r.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, task := range tasks {
+		if err := r.send(ctx, task); err != nil {
+			delay := OutboxBackoff.Delay(task.Attempts)
+			if err := RescheduleOutboxTask(ctx, r.DB, task.ID, err.Error(), delay); err != nil {
+				// The lease expires and the task is claimed again
+				slog.ErrorContext(ctx, "failed to reschedule outbox task", "id", task.ID, "error", err)
+			}
+			continue
+		}
+		sent++
+
+		// Deleting may fail after the task was enqueued; the task ID keeps
+		// the next attempt from enqueueing it twice
+		if err := DeleteOutboxTask(ctx, r.DB, task.ID); err != nil {
+			slog.ErrorContext(ctx, "failed to delete relayed outbox task", "id", task.ID, "error", err)
+		}
+	}
+	return sent, nil
+}
+
+func (r *Relay) send(ctx context.Context, task database.OutboxTask) error {
+	// The span joins the trace of the request that accepted the task
+	if env, err := DecodeEnvelope(task.TaskType, task.Payload); err == nil {
+		ctx = tracing.Extract(ctx, env.Metadata)
+	}
+	ctx, span := startEnqueueSpan(ctx, task.TaskType)
+	defer span.End()
+
+	return r.Enqueuer.enqueue(ctx, task.TaskType, task.Payload,
+		asynq.TaskID(fmt.Sprintf("outbox:%d", task.ID)),
+		asynq.ProcessAt(task.ProcessAt),
+	)
+}
+
+// Run flushes the outbox every interval until ctx is done, and records its
+// size in the metrics. A round in progress when ctx is done is finished, so
+// shutdown doesn't leave tasks enqueued but still in the outbox.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		roundCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.Lease)
+		r.round(roundCtx)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// round flushes until the due tasks run out, so a backlog left by a Redis
+// outage drains without waiting an interval per batch. It stops at the first
+// batch with a failure, which while Redis is down is every batch.
+func (r *Relay) round(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, err := r.Flush(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to flush the outbox", "error", err)
+			break
+		}
+		if sent < r.BatchSize {
+			break
+		}
+	}
+
+	stats, err := GetOutboxStats(ctx, r.DB)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to read outbox stats", "error", err)
+		return
+	}
+	metrics.ObserveOutbox(stats.Pending, stats.Retrying, stats.OldestAge)
+}
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/logging"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutbox replaces the outbox repository functions with an in-memory
+// table.
+type fakeOutbox struct {
+	tasks       map[int64]database.OutboxTask
+	nextID      int64
+	rescheduled map[int64]string
+}
+
+func useFakeOutbox(t *testing.T) *fakeOutbox {
+	f := &fakeOutbox{tasks: map[int64]database.OutboxTask{}, rescheduled: map[int64]string{}}
+	InsertOutboxTask = func(ctx context.Context, DB *sql.DB, taskType string, payload []byte, processAt time.Time) error {
+		f.nextID++
+		f.tasks[f.nextID] = database.OutboxTask{ID: f.nextID, TaskType: taskType, Payload: payload, ProcessAt: processAt}
+		return nil
+	}
+	ClaimOutboxTasks = func(ctx context.Context, DB *sql.DB, limit int, lease time.Duration) ([]database.OutboxTask, error) {
+		var claimed []database.OutboxTask
+		for id := int64(1); id <= f.nextID && len(claimed) < limit; id++ {
+			if task, ok := f.tasks[id]; ok {
+				if _, waiting := f.rescheduled[id]; !waiting {
+					claimed = append(claimed, task)
+				}
+			}
+		}
+		return claimed, nil
+	}
+	DeleteOutboxTask = func(ctx context.Context, DB *sql.DB, id int64) error {
+		delete(f.tasks, id)
+		return nil
+	}
+	RescheduleOutboxTask = func(ctx context.Context, DB *sql.DB, id int64, lastError string, delay time.Duration) error {
+		f.rescheduled[id] = lastError
+		return nil
+	}
+	t.Cleanup(func() {
+		InsertOutboxTask = database.InsertOutboxTask
+		ClaimOutboxTasks = database.ClaimOutboxTasks
+		DeleteOutboxTask = database.DeleteOutboxTask
+		RescheduleOutboxTask = database.RescheduleOutboxTask
+	})
+	return f
+}
+
+func TestOutbox_Enqueue(t *testing.T) {
+	f := useFakeOutbox(t)
+
+	ctx := logging.WithRequestID(context.Background(), "req-7")
+	delay, err := NewOutbox(nil).Enqueue(ctx, types.TaskAddStudentGrade, model.Grade{StudentID: "s1", CourseID: "c1", Grade: 7})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, delay, 30*time.Second)
+
+	require.Len(t, f.tasks, 1)
+	task := f.tasks[1]
+	assert.WithinDuration(t, time.Now().Add(delay), task.ProcessAt, time.Second)
+
+	env, err := DecodeEnvelope(types.TaskAddStudentGrade, task.Payload)
+	require.NoError(t, err)
+	assert.Equal(t, "req-7", env.Metadata[requestIDField])
+}
+
+func TestOutbox_EnqueueFailure(t *testing.T) {
+	InsertOutboxTask = func(ctx context.Context, DB *sql.DB, taskType string, payload []byte, processAt time.Time) error {
+		return errors.New("db down")
+	}
+	defer func() { InsertOutboxTask = database.InsertOutboxTask }()
+
+	_, err := NewOutbox(nil).Enqueue(context.Background(), types.TaskAddStudentGrade, model.Grade{})
+	assert.EqualError(t, err, "db down")
+}
+
+func TestRelay_Flush(t *testing.T) {
+	f := useFakeOutbox(t)
+	outbox := NewOutbox(nil)
+	for i := 0; i < 3; i++ {
+		_, err := outbox.Enqueue(context.Background(), types.TaskAddStudentGrade, model.Grade{StudentID: "s1", CourseID: "c1"})
+		require.NoError(t, err)
+	}
+	processAt := f.tasks[1].ProcessAt
+
+	var sent []*asynq.Task
+	var options [][]asynq.Option
+	calls := 0
+	client := &MockAsynqClient{EnqueueFunc: func(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+		calls++
+		if calls == 2 {
+			return nil, errors.New("connection refused")
+		}
+		sent = append(sent, task)
+		options = append(options, opts)
+		return &asynq.TaskInfo{}, nil
+	}}
+	relay := NewRelay(nil, &Enqueuer{Client: client}, 10)
+
+	n, err := relay.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// The failed task stays in the outbox until a later attempt
+	assert.Len(t, f.tasks, 1)
+	assert.Equal(t, "connection refused", f.rescheduled[2])
+
+	require.Len(t, sent, 2)
+	assert.Contains(t, options[0], asynq.TaskID("outbox:1"))
+	assert.Contains(t, options[0], asynq.ProcessAt(processAt))
+	env, err := DecodeEnvelope(types.TaskAddStudentGrade, sent[0].Payload())
+	require.NoError(t, err)
+	assert.Equal(t, EnvelopeVersion, env.Version)
+}
+
+func TestRelay_AlreadyEnqueued(t *testing.T) {
+	f := useFakeOutbox(t)
+	_, err := NewOutbox(nil).Enqueue(context.Background(), types.TaskAddStudentGrade, model.Grade{StudentID: "s1", CourseID: "c1"})
+	require.NoError(t, err)
+
+	// An earlier relay enqueued the task but failed to delete it
+	client := &MockAsynqClient{EnqueueFunc: func(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+		return nil, asynq.ErrTaskIDConflict
+	}}
+
+	n, err := NewRelay(nil, &Enqueuer{Client: client}, 10).Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, f.tasks)
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+
+	"github.com/hibiken/asynq"
+)
+
+// RetryPolicy says how many times and how far apart a task type is retried
+// before asynq archives it.
+type RetryPolicy struct {
+	MaxRetry int
+	// BaseDelay is the wait before the first retry; it doubles with each
+	// retry up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy applies to the task types missing from RetryPolicies.
+var DefaultRetryPolicy = RetryPolicy{MaxRetry: 10, BaseDelay: 10 * time.Second, MaxDelay: 30 * time.Minute}
+
+// RetryPolicies holds the retry policy of each task type. Grades are
+// retried for about a day, which outlasts a database failover or a
+// maintenance window. Webhook deliveries give up after about 10 hours.
+var RetryPolicies = map[string]RetryPolicy{
+	types.TaskAddStudentGrade:     {MaxRetry: 20, BaseDelay: 5 * time.Second, MaxDelay: 2 * time.Hour},
+	types.TaskAddStudentGradeTask: {MaxRetry: 20, BaseDelay: 5 * time.Second, MaxDelay: 2 * time.Hour},
+	types.TaskDeliverWebhook:      {MaxRetry: 12, BaseDelay: 30 * time.Second, MaxDelay: 2 * time.Hour},
+}
+
+// RetryPolicyFor returns the retry policy of taskType.
+func RetryPolicyFor(taskType string) RetryPolicy {
+	if policy, ok := RetryPolicies[taskType]; ok {
+		return policy
+	}
+	return DefaultRetryPolicy
+}
+
+// Delay returns the wait before retry n, counting from zero.
+func (p RetryPolicy) Delay(n int) time.Duration {
+	delay := p.MaxDelay
+	if n < 32 {
+		if exponential := p.BaseDelay << n; exponential > 0 && exponential < p.MaxDelay {
+			delay = exponential
+		}
+	}
+	// Up to 20% of jitter, so tasks that failed together don't all come
+	// back at the same time
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// RetryDelay is the asynq.RetryDelayFunc of the worker: it applies the
+// policy of the task's type.
+func RetryDelay(n int, err error, t *asynq.Task) time.Duration {
+	return RetryPolicyFor(t.Type()).Delay(n)
+}
+
+// permanent marks err as one retrying can't fix, so asynq archives the task
+// right away instead of using up its retries.
+func permanent(err error) error {
+	return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
+}
+
+// classify marks as permanent the database errors that would happen again
+// on every retry, such as constraint violations.
+func classify(err error) error {
+	if database.IsPermanentError(err) {
+		return permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+
+	"github.com/hibiken/asynq"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{MaxRetry: 5, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+
+	for n, want := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute} {
+		delay := policy.Delay(n)
+		assert.GreaterOrEqual(t, delay, want, "retry %d", n)
+		assert.LessOrEqual(t, delay, want+want/5, "retry %d", n)
+	}
+	assert.LessOrEqual(t, policy.Delay(100), time.Minute+time.Minute/5, "no overflow")
+}
+
+func TestRetryPolicyFor(t *testing.T) {
+	assert.Equal(t, RetryPolicies[types.TaskAddStudentGrade], RetryPolicyFor(types.TaskAddStudentGrade))
+	assert.Equal(t, DefaultRetryPolicy, RetryPolicyFor("task:unknown"))
+}
+
+func TestEnqueue_SetsMaxRetry(t *testing.T) {
+	var options []asynq.Option
+	enqueuer := &Enqueuer{Client: &MockAsynqClient{
+		EnqueueFunc: func(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+			options = opts
+			return &asynq.TaskInfo{}, nil
+		},
+	}}
+
+	_, err := enqueuer.Enqueue(context.Background(), types.TaskAddStudentGrade, model.Grade{StudentID: "s1"})
+	assert.NoError(t, err)
+	assert.Contains(t, options, asynq.MaxRetry(RetryPolicies[types.TaskAddStudentGrade].MaxRetry))
+}
+
+func TestHandlers_PermanentErrors(t *testing.T) {
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		return model.Grade{}, &pq.Error{Code: "23502"}
+	}
+	defer func() { service.InsertGrade = database.InsertGrade }()
+
+	valid, _ := json.Marshal(model.Grade{StudentID: "s1", CourseID: "c1", Grade: 7})
+	missingCourse, _ := json.Marshal(model.Grade{StudentID: "s1", Grade: 7})
+	missingTask, _ := json.Marshal(model.GradeTask{StudentID: "s1", CourseID: "c1", Grade: 7})
+
+	for name, task := range map[string]*asynq.Task{
+		"bad json":             asynq.NewTask(types.TaskAddStudentGrade, []byte("not json")),
+		"missing course":       asynq.NewTask(types.TaskAddStudentGrade, missingCourse),
+		"constraint violation": asynq.NewTask(types.TaskAddStudentGrade, valid),
+	} {
+		err := HandleAddStadisticForStudent(context.Background(), task)
+		assert.ErrorIs(t, err, asynq.SkipRetry, name)
+	}
+
+	err := HandleAddGradeTask(context.Background(), asynq.NewTask(types.TaskAddStudentGradeTask, missingTask))
+	assert.ErrorIs(t, err, asynq.SkipRetry)
+	assert.ErrorIs(t, err, service.ErrInvalidGrade)
+}
+
+func TestHandlers_TransientErrorsAreRetried(t *testing.T) {
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		return model.Grade{}, errors.New("connection refused")
+	}
+	defer func() { service.InsertGrade = database.InsertGrade }()
+
+	payload, _ := json.Marshal(model.Grade{StudentID: "s1", CourseID: "c1", Grade: 7})
+	err := HandleAddStadisticForStudent(context.Background(), asynq.NewTask(types.TaskAddStudentGrade, payload))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, asynq.SkipRetry)
+}
@@ -0,0 +1,126 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// bufferSize is how many updates a client may fall behind before it
+// misses some. Every update carries the whole aggregates, so a client that
+// misses one is fixed by the next.
+const bufferSize = 16
+
+// Hub receives the updates published to the channel and hands each one to
+// the local clients following its topics.
+type Hub struct {
+	client  redis.UniversalClient
+	Channel string
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan Update]struct{}
+	closed      bool
+}
+
+// NewHub subscribes to channel through client. The client is shared, so
+// closing it is left to the caller.
+func NewHub(client redis.UniversalClient, channel string) *Hub {
+	return &Hub{client: client, Channel: channel, subscribers: map[string]map[chan Update]struct{}{}}
+}
+
+// Run dispatches the published updates until ctx is done. The Redis client
+// resubscribes on its own after a lost connection; the updates published
+// meanwhile are lost, like with any pub/sub.
+func (h *Hub) Run(ctx context.Context) {
+	pubsub := h.client.Subscribe(ctx, h.Channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			h.dispatch([]byte(message.Payload))
+		}
+	}
+}
+
+// dispatch hands a published update to the local clients.
+func (h *Hub) dispatch(payload []byte) {
+	var update Update
+	if err := json.Unmarshal(payload, &update); err != nil {
+		slog.Error("invalid realtime update", "channel", h.Channel, "error", err)
+		return
+	}
+	h.Broadcast(update)
+}
+
+// Broadcast hands update to the local clients following its topics,
+// skipping the ones whose buffer is full.
+func (h *Hub) Broadcast(update Update) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, topic := range update.topics() {
+		for updates := range h.subscribers[topic] {
+			select {
+			case updates <- update:
+			default:
+				metrics.ObserveRealtimeDropped()
+			}
+		}
+	}
+}
+
+// Subscribe returns the updates of topic and the function that stops them.
+// The channel is closed by that function or when the hub disconnects its
+// clients; it is nil when the hub is already closed.
+func (h *Hub) Subscribe(topic string) (<-chan Update, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, func() {}
+	}
+
+	updates := make(chan Update, bufferSize)
+	if h.subscribers[topic] == nil {
+		h.subscribers[topic] = map[chan Update]struct{}{}
+	}
+	h.subscribers[topic][updates] = struct{}{}
+
+	return updates, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[topic][updates]; !ok {
+			return
+		}
+		delete(h.subscribers[topic], updates)
+		if len(h.subscribers[topic]) == 0 {
+			delete(h.subscribers, topic)
+		}
+		close(updates)
+	}
+}
+
+// Disconnect closes the channel of every client, so the SSE handlers
+// return and the HTTP server can shut down. Later subscriptions get a nil
+// channel.
+func (h *Hub) Disconnect() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for topic, subscribers := range h.subscribers {
+		for updates := range subscribers {
+			close(updates)
+		}
+		delete(h.subscribers, topic)
+	}
+}
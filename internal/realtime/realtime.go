@@ -0,0 +1,168 @@
+// Package realtime pushes the aggregates of a course and a student to the
+// SSE clients as soon as a grade is committed. The worker (or the API, in
+// sync mode) publishes an Update to a Redis pub/sub channel, and every API
+// replica fans it out to its own clients through a Hub.
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	GetStudentCourseStats = database.GetStudentCourseStats
+	GetCourseStats        = database.GetCourseStats
+	GetTaskSummary        = database.GetTaskSummary
+)
+
+// Update is what the SSE clients receive for each committed grade: the
+// grade and the aggregates it changed, read right after the commit.
+type Update struct {
+	CourseID  string `json:"course_id"`
+	StudentID string `json:"student_id"`
+	// TaskID is empty for course grades.
+	TaskID    string    `json:"task_id,omitempty"`
+	Grade     float64   `json:"grade"`
+	OnTime    bool      `json:"on_time"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Student StudentAggregates `json:"student"`
+	Course  CourseAggregates  `json:"course"`
+	// Task is only set for task grades.
+	Task *TaskAggregates `json:"task,omitempty"`
+}
+
+// StudentAggregates are the averages of the student in the course. An
+// average is null while there is nothing to average.
+type StudentAggregates struct {
+	GradeAverage *float64 `json:"grade_average"`
+	GradeCount   int      `json:"grade_count"`
+	TaskAverage  *float64 `json:"task_average"`
+	TaskCount    int      `json:"task_count"`
+}
+
+// CourseAggregates are the averages of every student in the course.
+type CourseAggregates struct {
+	Students     int      `json:"students"`
+	GradeAverage *float64 `json:"grade_average"`
+	GradeCount   int      `json:"grade_count"`
+	TaskAverage  *float64 `json:"task_average"`
+	TaskCount    int      `json:"task_count"`
+	// OnTimePercentage is the share of task grades submitted on time.
+	OnTimePercentage *float64 `json:"on_time_percentage"`
+}
+
+// TaskAggregates are the averages of the task across the course.
+type TaskAggregates struct {
+	Average     float64 `json:"average"`
+	GradeCount  int     `json:"grade_count"`
+	OnTimeCount int     `json:"on_time_count"`
+}
+
+// CourseTopic and StudentTopic name what an SSE client follows. Each
+// update goes to the topic of its course and to the one of its student.
+func CourseTopic(courseID string) string   { return "course:" + courseID }
+func StudentTopic(studentID string) string { return "student:" + studentID }
+
+func (u Update) topics() []string {
+	return []string{CourseTopic(u.CourseID), StudentTopic(u.StudentID)}
+}
+
+func average(sum float64, count int) *float64 {
+	if count == 0 {
+		return nil
+	}
+	avg := sum / float64(count)
+	return &avg
+}
+
+// publisher is the part of the Redis client the Publisher uses.
+type publisher interface {
+	Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd
+}
+
+// Publisher is the service.Listener that publishes an Update for each
+// committed grade.
+type Publisher struct {
+	DB      *sql.DB
+	Channel string
+	client  publisher
+}
+
+// NewPublisher publishes to channel through client. The client is shared,
+// so closing it is left to the caller.
+func NewPublisher(DB *sql.DB, client redis.UniversalClient, channel string) *Publisher {
+	return &Publisher{DB: DB, Channel: channel, client: client}
+}
+
+// GradeStored publishes the aggregates change left. Failures are logged:
+// the clients get the next update, or the data on their next reload.
+func (p *Publisher) GradeStored(ctx context.Context, change service.Change) {
+	update, err := p.update(ctx, change)
+	if err == nil {
+		var payload []byte
+		if payload, err = json.Marshal(update); err == nil {
+			err = p.client.Publish(ctx, p.Channel, payload).Err()
+		}
+	}
+	metrics.ObserveRealtimePublish(err)
+	if err != nil {
+		slog.ErrorContext(ctx, "error publishing realtime update", "course_id", change.CourseID, "student_id", change.StudentID, "error", err)
+	}
+}
+
+func (p *Publisher) update(ctx context.Context, change service.Change) (Update, error) {
+	update := Update{
+		CourseID:  change.CourseID,
+		StudentID: change.StudentID,
+		TaskID:    change.TaskID,
+		Grade:     change.Grade,
+		OnTime:    change.OnTime,
+		CreatedAt: change.CreatedAt,
+	}
+
+	student, err := GetStudentCourseStats(ctx, p.DB, change.StudentID, change.CourseID)
+	if err != nil {
+		return Update{}, err
+	}
+	update.Student = StudentAggregates{
+		GradeAverage: average(student.GradeSum, student.GradeCount),
+		GradeCount:   student.GradeCount,
+		TaskAverage:  average(student.TaskGradeSum, student.TaskCount),
+		TaskCount:    student.TaskCount,
+	}
+
+	course, err := GetCourseStats(ctx, p.DB, change.CourseID)
+	if err != nil {
+		return Update{}, err
+	}
+	update.Course = CourseAggregates{
+		Students:     course.Students,
+		GradeAverage: average(course.GradeSum, course.GradeCount),
+		GradeCount:   course.GradeCount,
+		TaskAverage:  average(course.TaskGradeSum, course.TaskCount),
+		TaskCount:    course.TaskCount,
+	}
+	if onTime := average(float64(course.TaskOnTimeCount), course.TaskCount); onTime != nil {
+		percentage := *onTime * 100
+		update.Course.OnTimePercentage = &percentage
+	}
+
+	if change.TaskID != "" {
+		avg, count, onTime, err := GetTaskSummary(ctx, p.DB, change.CourseID, change.TaskID)
+		if err != nil {
+			return Update{}, err
+		}
+		update.Task = &TaskAggregates{Average: avg, GradeCount: count, OnTimeCount: onTime}
+	}
+	return update, nil
+}
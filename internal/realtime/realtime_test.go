@@ -0,0 +1,116 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePublisher struct {
+	channel  string
+	messages [][]byte
+}
+
+func (f *fakePublisher) Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd {
+	f.channel = channel
+	f.messages = append(f.messages, message.([]byte))
+	return redis.NewIntResult(1, nil)
+}
+
+func mockStats(t *testing.T) {
+	GetStudentCourseStats = func(ctx context.Context, DB *sql.DB, studentID, courseID string) (database.StudentCourseStats, error) {
+		return database.StudentCourseStats{TaskGradeSum: 15, TaskCount: 2}, nil
+	}
+	GetCourseStats = func(ctx context.Context, DB *sql.DB, courseID string) (database.CourseStats, error) {
+		return database.CourseStats{Students: 3, GradeSum: 14, GradeCount: 2, TaskGradeSum: 40, TaskCount: 5, TaskOnTimeCount: 4}, nil
+	}
+	GetTaskSummary = func(ctx context.Context, DB *sql.DB, courseID, taskID string) (float64, int, int, error) {
+		return 8, 3, 2, nil
+	}
+	t.Cleanup(func() {
+		GetStudentCourseStats = database.GetStudentCourseStats
+		GetCourseStats = database.GetCourseStats
+		GetTaskSummary = database.GetTaskSummary
+	})
+}
+
+func TestPublisher_PublishesTheAggregates(t *testing.T) {
+	mockStats(t)
+	client := &fakePublisher{}
+	publisher := &Publisher{Channel: "updates", client: client}
+
+	publisher.GradeStored(context.Background(), service.Change{StudentID: "s1", CourseID: "c1", TaskID: "t1", Grade: 9, OnTime: true})
+
+	require.Len(t, client.messages, 1)
+	assert.Equal(t, "updates", client.channel)
+	assert.JSONEq(t, `{
+		"course_id": "c1", "student_id": "s1", "task_id": "t1", "grade": 9, "on_time": true, "created_at": "0001-01-01T00:00:00Z",
+		"student": {"grade_average": null, "grade_count": 0, "task_average": 7.5, "task_count": 2},
+		"course": {"students": 3, "grade_average": 7, "grade_count": 2, "task_average": 8, "task_count": 5, "on_time_percentage": 80},
+		"task": {"average": 8, "grade_count": 3, "on_time_count": 2}
+	}`, string(client.messages[0]))
+}
+
+func TestPublisher_SkipsOnError(t *testing.T) {
+	mockStats(t)
+	GetCourseStats = func(ctx context.Context, DB *sql.DB, courseID string) (database.CourseStats, error) {
+		return database.CourseStats{}, errors.New("db down")
+	}
+	client := &fakePublisher{}
+
+	(&Publisher{Channel: "updates", client: client}).GradeStored(context.Background(), service.Change{StudentID: "s1", CourseID: "c1"})
+
+	assert.Empty(t, client.messages)
+}
+
+func TestHub_DispatchesToTopics(t *testing.T) {
+	hub := NewHub(nil, "updates")
+	course, stopCourse := hub.Subscribe(CourseTopic("c1"))
+	student, stopStudent := hub.Subscribe(StudentTopic("s1"))
+	other, stopOther := hub.Subscribe(CourseTopic("c2"))
+	defer stopCourse()
+	defer stopStudent()
+	defer stopOther()
+
+	payload, err := json.Marshal(Update{CourseID: "c1", StudentID: "s1", Grade: 7})
+	require.NoError(t, err)
+	hub.dispatch(payload)
+	hub.dispatch([]byte("not json"))
+
+	assert.Equal(t, 7.0, (<-course).Grade)
+	assert.Equal(t, 7.0, (<-student).Grade)
+	assert.Empty(t, other)
+}
+
+func TestHub_DropsForSlowClients(t *testing.T) {
+	hub := NewHub(nil, "updates")
+	updates, stop := hub.Subscribe(CourseTopic("c1"))
+	defer stop()
+
+	for i := 0; i < bufferSize+5; i++ {
+		hub.Broadcast(Update{CourseID: "c1", Grade: float64(i)})
+	}
+	assert.Len(t, updates, bufferSize)
+}
+
+func TestHub_Disconnect(t *testing.T) {
+	hub := NewHub(nil, "updates")
+	updates, stop := hub.Subscribe(CourseTopic("c1"))
+
+	hub.Disconnect()
+	_, open := <-updates
+	assert.False(t, open)
+	stop()
+
+	late, _ := hub.Subscribe(CourseTopic("c1"))
+	assert.Nil(t, late)
+}
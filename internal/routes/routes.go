@@ -0,0 +1,822 @@
+package routes
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/cache"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/config"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/events"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/graph"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/handlers"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/health"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/openapi"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/realtime"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	BasePath    = "/stats"
+	SpecPath    = "/openapi.json"
+	SwaggerPath = "/docs"
+	MetricsPath = "/metrics"
+)
+
+// Dependencies groups everything the route handlers need to serve requests.
+type Dependencies struct {
+	DB       *sql.DB
+	Enqueuer handlers.Enqueuer
+	// Replayer enqueues the replayed dead letters straight to the queue,
+	// even in outbox mode.
+	Replayer  handlers.Replayer
+	Readiness *health.Checker
+	// Reads routes the GET endpoints to the read replica when one is
+	// configured and healthy. Nil sends every read to DB.
+	Reads *database.ReadRouter
+	// Features switches the optional endpoints (docs and metrics) on.
+	Features config.Features
+	// Cache stores the responses of the statistics endpoints. Nil disables
+	// caching.
+	Cache        cache.Store
+	CacheOptions cache.Options
+	// AdminToken guards the admin endpoints, which are only registered
+	// while it is set.
+	AdminToken string
+	// SyncWrites makes the POST endpoints store grades before answering
+	// instead of enqueueing them (WRITE_MODE=sync).
+	SyncWrites bool
+	// GradeListener is told about the grades stored in sync mode, besides
+	// the cache invalidation. May be nil.
+	GradeListener service.Listener
+	// Events receives the events other services send by webhook. The
+	// webhook is only registered while it and EventsSecret are set.
+	Events *events.Consumer
+	// EventsSecret signs the webhook deliveries, which must be sent within
+	// EventsTolerance of their timestamp.
+	EventsSecret    string
+	EventsTolerance time.Duration
+
+	// Realtime serves the SSE streams when set, with a comment every
+	// RealtimeHeartbeat.
+	Realtime          *realtime.Hub
+	RealtimeHeartbeat time.Duration
+
+	// GraphQL serves the GraphQL endpoint when set.
+	GraphQL *graph.Schema
+}
+
+// admin wraps an admin handler with the token check.
+func (d Dependencies) admin(handler gin.HandlerFunc) gin.HandlerFunc {
+	return handlers.RequireAdminToken(d.AdminToken, handler)
+}
+
+// cached wraps a statistics handler with the response cache, if any.
+func (d Dependencies) cached(handler gin.HandlerFunc) gin.HandlerFunc {
+	if d.Cache == nil {
+		return handler
+	}
+	return cache.Handler(d.Cache, d.CacheOptions, handler)
+}
+
+// gradeListener is told about the grades stored in sync mode: it drops the
+// cached responses they made stale and tells GradeListener.
+func (d Dependencies) gradeListener() service.Listener {
+	listeners := service.Listeners{}
+	if d.Cache != nil {
+		listeners = append(listeners, service.InvalidateResponses{Responses: d.Cache})
+	}
+	return append(listeners, d.GradeListener)
+}
+
+// readDB returns the connection for a read-only request, looked up per
+// request so a replica falling behind is skipped right away.
+func (d Dependencies) readDB() *sql.DB {
+	if d.Reads == nil {
+		return d.DB
+	}
+	return d.Reads.Reader()
+}
+
+// cachedReadDB is readDB for the routes behind the response cache. The
+// cache versions were read before the handler ran; a replica that hasn't
+// replayed the writes behind them would answer without a grade that
+// already invalidated the cache, so its answer is served but not stored.
+func (d Dependencies) cachedReadDB(c *gin.Context) *sql.DB {
+	db := d.readDB()
+	if d.Cache == nil || db == d.DB {
+		return db
+	}
+
+	caughtUp, err := d.Reads.CaughtUp(c.Request.Context())
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "error comparing the replica with the primary", "error", err)
+	}
+	if !caughtUp {
+		cache.DontStore(c)
+	}
+	return db
+}
+
+// Route is a single endpoint of the API together with its OpenAPI
+// description. The spec served at /stats/openapi.json is built from these,
+// so a route cannot be registered without being documented.
+type Route struct {
+	Method  string
+	Path    string
+	Handler gin.HandlerFunc
+	Doc     openapi.Operation
+}
+
+func Routes(deps Dependencies) []Route {
+	enqueuer := deps.Enqueuer
+
+	return []Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/health",
+			Handler: handlers.HealthCheckHandler,
+			Doc: openapi.Operation{
+				Tags:    []string{"Health"},
+				Summary: "Health check",
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("OK", openapi.Schema{
+						"type":       "object",
+						"properties": map[string]openapi.Schema{"status": {"type": "string", "example": "OK"}},
+					}),
+				},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/health/live",
+			Handler: handlers.LivenessHandler,
+			Doc: openapi.Operation{
+				Tags:    []string{"Health"},
+				Summary: "Liveness probe: el proceso está vivo",
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Proceso vivo", openapi.Schema{
+						"type":       "object",
+						"properties": map[string]openapi.Schema{"status": {"type": "string", "example": "up"}},
+					}),
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/health/ready",
+			Handler: func(c *gin.Context) {
+				handlers.ReadinessHandler(deps.Readiness, c)
+			},
+			Doc: openapi.Operation{
+				Tags:    []string{"Health"},
+				Summary: "Readiness probe: estado y latencia de cada dependencia",
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Listo para recibir tráfico", openapi.Ref("HealthReport")),
+					"503": openapi.JSONResponse("Alguna dependencia no está disponible", openapi.Ref("HealthReport")),
+				},
+			},
+		},
+		{
+			// For each POST, we will enqueue a task to process the student grade
+			Method: http.MethodPost,
+			Path:   "/student/grade",
+			Handler: func(c *gin.Context) {
+				var grade model.Grade
+				if err := c.ShouldBindJSON(&grade); err != nil {
+					c.JSON(400, gin.H{"error": "Invalid input"})
+					return
+				}
+				slog.DebugContext(c.Request.Context(), "received grade", "student_id", grade.StudentID, "course_id", grade.CourseID)
+				if deps.SyncWrites {
+					handlers.SaveGrade(c, deps.DB, deps.gradeListener(), grade)
+					return
+				}
+				handlers.EnqueueAddStadisticForStudent(c, enqueuer, grade)
+			},
+			Doc: openapi.Operation{
+				Tags:        []string{"User Stats"},
+				Summary:     "Registrar una nueva calificación (encolada, o guardada en el momento con WRITE_MODE=sync)",
+				RequestBody: openapi.JSONBody(openapi.Ref("Grade")),
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Tarea encolada exitosamente", openapi.Ref("QueuedResponse")),
+					"201": openapi.JSONResponse("Calificación guardada (WRITE_MODE=sync)", openapi.Ref("Grade")),
+					"400": {Description: "Entrada inválida"},
+				},
+			},
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/student/task/grade",
+			Handler: func(c *gin.Context) {
+				var gradeTask model.GradeTask
+				if err := c.ShouldBindJSON(&gradeTask); err != nil {
+					c.JSON(400, gin.H{"error": "Invalid input"})
+					return
+				}
+				slog.DebugContext(c.Request.Context(), "received grade task", "student_id", gradeTask.StudentID, "course_id", gradeTask.CourseID, "task_id", gradeTask.TaskID)
+				if deps.SyncWrites {
+					handlers.SaveGradeTask(c, deps.DB, deps.gradeListener(), gradeTask)
+					return
+				}
+				handlers.EnqueueAddGradeTask(c, enqueuer, gradeTask)
+			},
+			Doc: openapi.Operation{
+				Tags:        []string{"User Stats"},
+				Summary:     "Registrar calificación de tarea (encolada, o guardada en el momento con WRITE_MODE=sync)",
+				RequestBody: openapi.JSONBody(openapi.Ref("GradeTask")),
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Tarea encolada exitosamente", openapi.Ref("QueuedResponse")),
+					"201": openapi.JSONResponse("Calificación guardada (WRITE_MODE=sync)", openapi.Ref("GradeTask")),
+					"400": {Description: "Entrada inválida"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/student/:student_id/course/:course_id",
+			Handler: deps.cached(func(c *gin.Context) {
+				handlers.APIHandlerGetStatsForStudent(deps.cachedReadDB(c), c)
+			}),
+			Doc: openapi.Operation{
+				Tags:    []string{"Course Stats", "User Stats"},
+				Summary: "Obtener estadísticas de un estudiante en un curso",
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Estadísticas del estudiante", openapi.Ref("StudentCourseStats")),
+					"304": {Description: "Sin cambios respecto del ETag enviado en If-None-Match"},
+					"400": {Description: "Parámetros inválidos"},
+					"404": {Description: "No se encontraron datos"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/student/:student_id/average",
+			Handler: deps.cached(func(c *gin.Context) {
+				handlers.APIHandlerGetStudentAverageOverTime(deps.cachedReadDB(c), c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"User Stats"},
+				Summary:    "Obtener promedio de calificaciones de un estudiante a lo largo del tiempo",
+				Parameters: seriesParams,
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Promedios del estudiante por período", openapi.Ref("StudentAverageOverTime")),
+					"304": {Description: "Sin cambios respecto del ETag enviado en If-None-Match"},
+					"400": {Description: "Parámetros inválidos"},
+					"500": {Description: "Error interno del servidor"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/course/:course_id/average",
+			Handler: deps.cached(func(c *gin.Context) {
+				handlers.APIHandlerGetCourseAverageOverTime(deps.cachedReadDB(c), c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"Course Stats"},
+				Summary:    "Obtener promedio de calificaciones de un curso a lo largo del tiempo",
+				Parameters: seriesParams,
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Promedios del curso por período", openapi.Ref("CourseAverageOverTime")),
+					"304": {Description: "Sin cambios respecto del ETag enviado en If-None-Match"},
+					"400": {Description: "Parámetros inválidos"},
+					"500": {Description: "Error interno del servidor"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/student/:student_id/course/:course_id/task/average",
+			Handler: deps.cached(func(c *gin.Context) {
+				handlers.APIHandlerGetStudentCourseTasksAverage(deps.cachedReadDB(c), c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"Course Stats", "User Stats"},
+				Summary:    "Obtener promedio de tareas de un estudiante en un curso junto al de sus compañeros",
+				Parameters: listParams,
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Promedio del estudiante y de sus compañeros", openapi.Ref("StudentCourseTasksAverage")),
+					"304": {Description: "Sin cambios respecto del ETag enviado en If-None-Match"},
+					"400": {Description: "Parámetros inválidos"},
+					"500": {Description: "Error interno del servidor"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/student/:student_id/course/:course_id/task/:task_id",
+			Handler: deps.cached(func(c *gin.Context) {
+				handlers.APIHandlerGetStatsForStudentTask(deps.cachedReadDB(c), c)
+			}),
+			Doc: openapi.Operation{
+				Tags:    []string{"Course Stats", "User Stats"},
+				Summary: "Obtener promedio de un estudiante en una tarea específica",
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Promedio del estudiante en la tarea", openapi.Ref("StudentTaskStats")),
+					"304": {Description: "Sin cambios respecto del ETag enviado en If-None-Match"},
+					"400": {Description: "Parámetros inválidos"},
+					"404": {Description: "No se encontraron datos"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/course/:course_id/task/:task_id/averages",
+			Handler: deps.cached(func(c *gin.Context) {
+				handlers.APIHandlerGetTaskAverages(deps.cachedReadDB(c), c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"Course Stats"},
+				Summary:    "Obtener promedios de una tarea específica",
+				Parameters: listParams,
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Promedios de la tarea", openapi.Ref("TaskAverages")),
+					"304": {Description: "Sin cambios respecto del ETag enviado en If-None-Match"},
+					"400": {Description: "Parámetros inválidos"},
+					"500": {Description: "Error interno del servidor"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/course/:course_id/on_time_percentage",
+			Handler: deps.cached(func(c *gin.Context) {
+				handlers.APIHandlerGetCourseOnTimePercentage(deps.cachedReadDB(c), c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"Course Stats"},
+				Summary:    "Obtener porcentaje de entregas a tiempo en un curso",
+				Parameters: timeRangeParams,
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Estadísticas de entregas a tiempo", openapi.Ref("OnTimePercentageResponse")),
+					"304": {Description: "Sin cambios respecto del ETag enviado en If-None-Match"},
+					"400": {Description: "Parámetros inválidos"},
+					"500": {Description: "Error interno del servidor"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/course/:course_id/student/:student_id/on_time_percentage",
+			Handler: deps.cached(func(c *gin.Context) {
+				handlers.APIHandlerGetStudentOnTimePercentage(deps.cachedReadDB(c), c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"Course Stats", "User Stats"},
+				Summary:    "Obtener porcentaje de entregas a tiempo de un estudiante",
+				Parameters: timeRangeParams,
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Estadísticas de entregas a tiempo", openapi.Ref("OnTimePercentageResponse")),
+					"304": {Description: "Sin cambios respecto del ETag enviado en If-None-Match"},
+					"400": {Description: "Parámetros inválidos"},
+					"500": {Description: "Error interno del servidor"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/institution/:institution_id/terms",
+			Handler: func(c *gin.Context) {
+				handlers.APIHandlerGetAcademicTerms(deps.readDB(), c)
+			},
+			Doc: openapi.Operation{
+				Tags:    []string{"Institution"},
+				Summary: "Obtener el calendario académico de una institución",
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Cuatrimestres de la institución", openapi.Ref("AcademicCalendar")),
+					"400": {Description: "Parámetros inválidos"},
+					"500": {Description: "Error interno del servidor"},
+				},
+			},
+		},
+	}
+}
+
+// NewDocument builds the OpenAPI document for the given routes, all of them
+// mounted under basePath.
+func NewDocument(basePath string, routes []Route) *openapi.Document {
+	doc := openapi.NewDocument(openapi.Info{
+		Title:       "Statistics API",
+		Version:     "1.0.0",
+		Description: "Microservicio para gestión de estadísticas educativas en ClassConnect",
+	})
+	doc.Servers = servers
+	doc.Tags = tags
+	doc.Components.Schemas = schemas
+
+	AddToDocument(doc, basePath, routes)
+
+	return doc
+}
+
+// AddToDocument documents routes mounted under basePath in doc.
+func AddToDocument(doc *openapi.Document, basePath string, routes []Route) {
+	for _, route := range routes {
+		doc.AddOperation(route.Method, basePath+route.Path, route.Doc)
+	}
+}
+
+func handle(group gin.IRoutes, routes []Route) {
+	for _, route := range routes {
+		group.Handle(route.Method, route.Path, route.Handler)
+	}
+}
+
+// termRoutes replace the academic calendar of an institution, behind the
+// admin token like adminRoutes. Saving a calendar invalidates the cached
+// responses grouped by its terms.
+func termRoutes(deps Dependencies) []Route {
+	return []Route{
+		{
+			Method: http.MethodPut,
+			Path:   "/institution/:institution_id/terms",
+			Handler: deps.admin(func(c *gin.Context) {
+				handlers.APIHandlerReplaceAcademicTerms(deps.DB, deps.Cache, c)
+			}),
+			Doc: openapi.Operation{
+				Tags:        []string{"Institution"},
+				Summary:     "Reemplazar el calendario académico de una institución",
+				RequestBody: openapi.JSONBody(openapi.Ref("AcademicCalendar")),
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Calendario guardado", openapi.Ref("AcademicCalendar")),
+					"400": {Description: "Entrada inválida o cuatrimestres superpuestos"},
+					"401": {Description: "Token de administración inválido o ausente"},
+					"500": {Description: "Error interno del servidor"},
+				},
+			},
+		},
+	}
+}
+
+// adminRoutes manage the tasks the worker gave up on. They always write to
+// the primary, since a replica may not have the latest status yet.
+func adminRoutes(deps Dependencies) []Route {
+	idParam := []openapi.Parameter{openapi.PathParam("id", "ID de la tarea fallida")}
+	deadLetterResponses := func(description string) map[string]openapi.Response {
+		return map[string]openapi.Response{
+			"200": openapi.JSONResponse(description, openapi.Ref("DeadLetter")),
+			"400": {Description: "Parámetros inválidos"},
+			"401": {Description: "Token de administración inválido o ausente"},
+			"404": {Description: "No existe la tarea fallida"},
+			"409": {Description: "La tarea ya fue reencolada o descartada"},
+		}
+	}
+
+	return []Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/admin/dead_letters",
+			Handler: deps.admin(func(c *gin.Context) {
+				handlers.APIHandlerListDeadLetters(deps.DB, c)
+			}),
+			Doc: openapi.Operation{
+				Tags:    []string{"Admin"},
+				Summary: "Listar las tareas que el worker dejó de reintentar",
+				Parameters: []openapi.Parameter{
+					openapi.QueryParam("status", "Filtrar por estado", openapi.Schema{"type": "string", "enum": []string{"pending", "replayed", "discarded"}}),
+					openapi.QueryParam("limit", "Cantidad de tareas por página (1-500, por defecto 50)", openapi.Schema{"type": "integer", "minimum": 1, "maximum": 500}),
+					openapi.QueryParam("cursor", "Valor de next_cursor de la página anterior", openapi.Schema{"type": "string"}),
+				},
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Tareas fallidas, las más recientes primero", openapi.Ref("DeadLetterList")),
+					"400": {Description: "Parámetros inválidos"},
+					"401": {Description: "Token de administración inválido o ausente"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/admin/dead_letters/:id",
+			Handler: deps.admin(func(c *gin.Context) {
+				handlers.APIHandlerGetDeadLetter(deps.DB, c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"Admin"},
+				Summary:    "Inspeccionar una tarea fallida",
+				Parameters: idParam,
+				Responses:  deadLetterResponses("Tarea fallida"),
+			},
+		},
+		{
+			Method: http.MethodPut,
+			Path:   "/admin/dead_letters/:id",
+			Handler: deps.admin(func(c *gin.Context) {
+				handlers.APIHandlerUpdateDeadLetter(deps.DB, c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"Admin"},
+				Summary:    "Corregir el payload de una tarea fallida pendiente",
+				Parameters: idParam,
+				RequestBody: openapi.JSONBody(openapi.Schema{
+					"type":       "object",
+					"required":   []string{"payload"},
+					"properties": map[string]openapi.Schema{"payload": {"type": "object"}},
+				}),
+				Responses: deadLetterResponses("Tarea fallida actualizada"),
+			},
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/admin/dead_letters/:id/replay",
+			Handler: deps.admin(func(c *gin.Context) {
+				handlers.APIHandlerReplayDeadLetter(deps.DB, deps.Replayer, c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"Admin"},
+				Summary:    "Reencolar una tarea fallida pendiente",
+				Parameters: idParam,
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Tarea reencolada para procesarse en el momento", openapi.Ref("DeadLetter")),
+					"400": {Description: "El payload no es JSON válido"},
+					"401": {Description: "Token de administración inválido o ausente"},
+					"404": {Description: "No existe la tarea fallida"},
+					"409": {Description: "La tarea ya fue reencolada o descartada"},
+					"503": {Description: "No se pudo encolar la tarea"},
+				},
+			},
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/admin/dead_letters/:id/discard",
+			Handler: deps.admin(func(c *gin.Context) {
+				handlers.APIHandlerDiscardDeadLetter(deps.DB, c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"Admin"},
+				Summary:    "Descartar una tarea fallida pendiente",
+				Parameters: idParam,
+				Responses:  deadLetterResponses("Tarea descartada"),
+			},
+		},
+	}
+}
+
+// webhookRoutes manage the webhook subscriptions, behind the admin token
+// like adminRoutes.
+func webhookRoutes(deps Dependencies) []Route {
+	idParam := []openapi.Parameter{openapi.PathParam("id", "ID de la suscripción")}
+
+	return []Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/admin/webhooks",
+			Handler: deps.admin(func(c *gin.Context) {
+				handlers.APIHandlerListWebhooks(deps.DB, c)
+			}),
+			Doc: openapi.Operation{
+				Tags:    []string{"Webhooks"},
+				Summary: "Listar las suscripciones a webhooks",
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Suscripciones, sin sus secretos", openapi.Schema{
+						"type":       "object",
+						"properties": map[string]openapi.Schema{"webhooks": {"type": "array", "items": openapi.Ref("WebhookSubscription")}},
+					}),
+					"401": {Description: "Token de administración inválido o ausente"},
+				},
+			},
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/admin/webhooks",
+			Handler: deps.admin(func(c *gin.Context) {
+				handlers.APIHandlerCreateWebhook(deps.DB, c)
+			}),
+			Doc: openapi.Operation{
+				Tags:        []string{"Webhooks"},
+				Summary:     "Registrar una URL para recibir eventos firmados con HMAC-SHA256",
+				RequestBody: openapi.JSONBody(openapi.Ref("WebhookSubscription")),
+				Responses: map[string]openapi.Response{
+					"201": openapi.JSONResponse("Suscripción creada, con su secreto", openapi.Ref("WebhookSubscription")),
+					"400": {Description: "URL, eventos o umbral inválidos"},
+					"401": {Description: "Token de administración inválido o ausente"},
+				},
+			},
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/admin/webhooks/:id",
+			Handler: deps.admin(func(c *gin.Context) {
+				handlers.APIHandlerDeleteWebhook(deps.DB, c)
+			}),
+			Doc: openapi.Operation{
+				Tags:       []string{"Webhooks"},
+				Summary:    "Eliminar una suscripción y su historial de envíos",
+				Parameters: idParam,
+				Responses: map[string]openapi.Response{
+					"204": {Description: "Suscripción eliminada"},
+					"400": {Description: "ID inválido"},
+					"401": {Description: "Token de administración inválido o ausente"},
+					"404": {Description: "No existe la suscripción"},
+				},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/admin/webhooks/:id/deliveries",
+			Handler: deps.admin(func(c *gin.Context) {
+				handlers.APIHandlerListWebhookDeliveries(deps.DB, c)
+			}),
+			Doc: openapi.Operation{
+				Tags:    []string{"Webhooks"},
+				Summary: "Historial de envíos de una suscripción",
+				Parameters: append(idParam,
+					openapi.QueryParam("status", "Filtrar por estado", openapi.Schema{"type": "string", "enum": []string{"pending", "delivered", "failed"}}),
+					openapi.QueryParam("limit", "Cantidad de envíos por página (1-500, por defecto 50)", openapi.Schema{"type": "integer", "minimum": 1, "maximum": 500}),
+					openapi.QueryParam("cursor", "Valor de next_cursor de la página anterior", openapi.Schema{"type": "string"}),
+				),
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Envíos, los más recientes primero", openapi.Ref("WebhookDeliveryList")),
+					"400": {Description: "Parámetros inválidos"},
+					"401": {Description: "Token de administración inválido o ausente"},
+					"404": {Description: "No existe la suscripción"},
+				},
+			},
+		},
+	}
+}
+
+// eventRoutes receive the events of other services, as an alternative to
+// the POST endpoints.
+func eventRoutes(deps Dependencies) []Route {
+	return []Route{
+		{
+			Method: http.MethodPost,
+			Path:   "/events",
+			Handler: func(c *gin.Context) {
+				handlers.APIHandlerReceiveEvent(deps.Events, deps.EventsSecret, deps.EventsTolerance, c)
+			},
+			Doc: openapi.Operation{
+				Tags:    []string{"Events"},
+				Summary: "Recibir un evento firmado con HMAC-SHA256 en X-Event-Signature y X-Event-Timestamp",
+				Parameters: []openapi.Parameter{
+					openapi.HeaderParam(events.TimestampHeader, "Momento del envío en segundos Unix", openapi.Schema{"type": "integer"}),
+					openapi.HeaderParam(events.SignatureHeader, "sha256= seguido del HMAC en hexadecimal de \"<timestamp>.<cuerpo>\"", openapi.Schema{"type": "string"}),
+				},
+				RequestBody: openapi.JSONBody(openapi.Ref("Event")),
+				Responses: map[string]openapi.Response{
+					"202": openapi.JSONResponse("Evento aceptado; su tarea fue encolada o el tipo no requiere ninguna", openapi.Schema{
+						"type":       "object",
+						"properties": map[string]openapi.Schema{"status": {"type": "string", "example": "accepted"}},
+					}),
+					"400": {Description: "Evento inválido; no debe reenviarse"},
+					"401": {Description: "Firma inválida, ausente o vencida"},
+					"413": {Description: "Evento demasiado grande"},
+					"503": {Description: "No se pudo encolar la tarea; el evento puede reenviarse"},
+				},
+			},
+		},
+	}
+}
+
+// streamRoutes push the aggregates over Server-Sent Events as grades are
+// committed.
+func streamRoutes(deps Dependencies) []Route {
+	streamResponses := map[string]openapi.Response{
+		"200": {
+			Description: "Stream de eventos `stats`, uno por nota guardada, con comentarios `: ping` periódicos",
+			Content:     map[string]openapi.MediaType{"text/event-stream": {Schema: openapi.Ref("StatsUpdate")}},
+		},
+		"503": {Description: "El servidor se está apagando; el cliente debe reconectarse"},
+	}
+
+	return []Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/course/:course_id/stream",
+			Handler: func(c *gin.Context) {
+				handlers.APIHandlerStreamCourse(deps.Realtime, deps.RealtimeHeartbeat, c)
+			},
+			Doc: openapi.Operation{
+				Tags:       []string{"Course Stats"},
+				Summary:    "Recibir por SSE los agregados del curso cada vez que se guarda una nota",
+				Parameters: []openapi.Parameter{openapi.PathParam("course_id", "ID del curso")},
+				Responses:  streamResponses,
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/student/:student_id/stream",
+			Handler: func(c *gin.Context) {
+				handlers.APIHandlerStreamStudent(deps.Realtime, deps.RealtimeHeartbeat, c)
+			},
+			Doc: openapi.Operation{
+				Tags:       []string{"User Stats"},
+				Summary:    "Recibir por SSE los agregados del estudiante cada vez que se guarda una de sus notas",
+				Parameters: []openapi.Parameter{openapi.PathParam("student_id", "ID del estudiante")},
+				Responses:  streamResponses,
+			},
+		},
+	}
+}
+
+// graphqlRoutes serve the statistics over GraphQL. Queries only read, so
+// they go to the replica like the GET endpoints.
+func graphqlRoutes(deps Dependencies) []Route {
+	return []Route{
+		{
+			Method: http.MethodPost,
+			Path:   "/graphql",
+			Handler: func(c *gin.Context) {
+				handlers.APIHandlerGraphQL(deps.GraphQL, deps.readDB(), c)
+			},
+			Doc: openapi.Operation{
+				Tags:        []string{"GraphQL"},
+				Summary:     "Consultar estudiantes, cursos, tareas y notas con GraphQL; el esquema se obtiene por introspección",
+				RequestBody: openapi.JSONBody(openapi.Ref("GraphQLRequest")),
+				Responses: map[string]openapi.Response{
+					"200": openapi.JSONResponse("Resultado de la consulta; los errores de la consulta vienen en errors", openapi.Ref("GraphQLResponse")),
+					"400": {Description: "Cuerpo inválido o sin query"},
+					"413": {Description: "Consulta demasiado grande"},
+				},
+			},
+		},
+	}
+}
+
+// systemRoutes are operational endpoints mounted at the root of the server
+// rather than under BasePath.
+func systemRoutes() []Route {
+	return []Route{
+		{
+			Method:  http.MethodGet,
+			Path:    MetricsPath,
+			Handler: gin.WrapH(metrics.Handler()),
+			Doc: openapi.Operation{
+				Tags:      []string{"Health"},
+				Summary:   "Métricas en formato Prometheus",
+				Responses: map[string]openapi.Response{"200": {Description: "Métricas en formato de texto de Prometheus"}},
+			},
+		},
+	}
+}
+
+// docRoutes serve the OpenAPI document and a Swagger UI page for it.
+func docRoutes(doc *openapi.Document) []Route {
+	return []Route{
+		{
+			Method:  http.MethodGet,
+			Path:    SpecPath,
+			Handler: openapi.Handler(doc),
+			Doc: openapi.Operation{
+				Tags:      []string{"Docs"},
+				Summary:   "Especificación OpenAPI del servicio",
+				Responses: map[string]openapi.Response{"200": openapi.JSONResponse("Documento OpenAPI 3", openapi.Schema{"type": "object"})},
+			},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    SwaggerPath,
+			Handler: openapi.SwaggerUIHandler(doc.Info.Title, BasePath+SpecPath),
+			Doc: openapi.Operation{
+				Tags:      []string{"Docs"},
+				Summary:   "Swagger UI",
+				Responses: map[string]openapi.Response{"200": {Description: "Página HTML de Swagger UI"}},
+			},
+		},
+	}
+}
+
+// Register mounts every route under BasePath and the system routes at the
+// root, and returns the OpenAPI document describing all of them.
+func Register(router *gin.Engine, deps Dependencies) *openapi.Document {
+	doc := NewDocument(BasePath, nil)
+
+	api := Routes(deps)
+	if deps.AdminToken != "" {
+		api = append(api, adminRoutes(deps)...)
+		api = append(api, webhookRoutes(deps)...)
+		api = append(api, termRoutes(deps)...)
+	}
+	if deps.Events != nil && deps.EventsSecret != "" {
+		api = append(api, eventRoutes(deps)...)
+	}
+	if deps.Realtime != nil {
+		api = append(api, streamRoutes(deps)...)
+	}
+	if deps.GraphQL != nil {
+		api = append(api, graphqlRoutes(deps)...)
+	}
+	if deps.Features.Docs {
+		api = append(api, docRoutes(doc)...)
+	}
+
+	var system []Route
+	if deps.Features.Metrics {
+		system = systemRoutes()
+	}
+
+	AddToDocument(doc, BasePath, api)
+	AddToDocument(doc, "", system)
+
+	handle(router.Group(BasePath), api)
+	handle(router, system)
+
+	return doc
+}
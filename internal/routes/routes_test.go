@@ -0,0 +1,271 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/cache"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/config"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/events"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/graph"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/realtime"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	hub := realtime.NewHub(nil, "updates")
+
+	schema, err := graph.New(graph.Limits{})
+	require.NoError(t, err)
+
+	router := gin.New()
+	Register(router, Dependencies{
+		DB:              db,
+		Features:        config.Features{Docs: true, Metrics: true},
+		AdminToken:      "s3cret",
+		Events:          events.NewConsumer(nil),
+		EventsSecret:    "s3cret",
+		EventsTolerance: time.Minute,
+		Realtime:        hub,
+		GraphQL:         schema,
+	})
+	return router
+}
+
+func TestEveryRegisteredRouteIsInSpec(t *testing.T) {
+	router := setupRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, BasePath+SpecPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+
+	registered := router.Routes()
+	require.NotEmpty(t, registered)
+
+	for _, route := range registered {
+		path := route.Path
+		for _, segment := range strings.Split(path, "/") {
+			if strings.HasPrefix(segment, ":") {
+				path = strings.Replace(path, segment, "{"+segment[1:]+"}", 1)
+			}
+		}
+
+		item, ok := spec.Paths[path]
+		if !assert.Truef(t, ok, "route %s %s is not documented in the OpenAPI spec", route.Method, route.Path) {
+			continue
+		}
+		_, ok = item[strings.ToLower(route.Method)]
+		assert.Truef(t, ok, "route %s %s is not documented in the OpenAPI spec", route.Method, route.Path)
+	}
+}
+
+func TestSpecHasNoUnregisteredOperations(t *testing.T) {
+	router := setupRouter(t)
+	doc := NewDocument(BasePath, Routes(Dependencies{}))
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	for _, route := range Routes(Dependencies{}) {
+		assert.Truef(t, registered[route.Method+" "+BasePath+route.Path], "documented route %s %s is not registered", route.Method, route.Path)
+		assert.True(t, doc.HasOperation(route.Method, BasePath+route.Path))
+	}
+}
+
+func TestStudentTaskRouteIsRegistered(t *testing.T) {
+	router := setupRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats/student/s1/course/c1/task/invalid_id", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid course_id or task_id format")
+}
+
+func TestSwaggerUIPage(t *testing.T) {
+	router := setupRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, BasePath+SwaggerPath, nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), BasePath+SpecPath)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	router := setupRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
+
+func TestDisabledFeaturesAreNotRegistered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	router := gin.New()
+	Register(router, Dependencies{DB: db})
+
+	for _, path := range []string{BasePath + SpecPath, BasePath + SwaggerPath, MetricsPath, BasePath + "/admin/dead_letters"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equalf(t, http.StatusNotFound, w.Code, "%s should not be served", path)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, BasePath+"/events", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code, "the event webhook needs a secret")
+}
+
+func TestEventWebhookRequiresSignature(t *testing.T) {
+	router := setupRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, BasePath+"/events", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAdminRoutesRequireToken(t *testing.T) {
+	router := setupRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, BasePath+"/admin/dead_letters/1", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, BasePath+"/institution/fiuba/terms", strings.NewReader(`{"terms":[]}`)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "replacing a calendar needs the admin token")
+}
+
+func TestReadDB(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	assert.Same(t, primary, Dependencies{DB: primary}.readDB())
+
+	reads := database.NewReadRouter(primary, replica, time.Minute)
+	deps := Dependencies{DB: primary, Reads: reads}
+	assert.Same(t, primary, deps.readDB(), "unchecked replica is not used")
+
+	mock.ExpectQuery("pg_last_wal_receive_lsn").WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(0))
+	reads.Check(context.Background())
+	assert.Same(t, replica, deps.readDB())
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Same(t, replica, deps.cachedReadDB(c))
+
+	// With the cache on, misses still read from the replica, but are only
+	// stored once it has replayed what the primary committed
+	deps.Cache = cache.NewLRU(10)
+	primaryMock.ExpectQuery("pg_current_wal_lsn").WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000148"))
+	mock.ExpectQuery("pg_last_wal_replay_lsn").WillReturnRows(sqlmock.NewRows([]string{"replayed"}).AddRow(true))
+	assert.Same(t, replica, deps.cachedReadDB(c))
+	assert.False(t, c.GetBool("cache.uncacheable"))
+
+	primaryMock.ExpectQuery("pg_current_wal_lsn").WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000200"))
+	mock.ExpectQuery("pg_last_wal_replay_lsn").WillReturnRows(sqlmock.NewRows([]string{"replayed"}).AddRow(false))
+	assert.Same(t, replica, deps.cachedReadDB(c))
+	assert.True(t, c.GetBool("cache.uncacheable"), "a lagging replica's answer is not cached")
+}
+
+func TestStatsRoutesAreCached(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	calls := 0
+	original := database.GetAveragesForTask
+	originalSummary := database.GetTaskSummary
+	database.GetAveragesForTask = func(ctx context.Context, db *sql.DB, courseID, taskID string, opts database.ListOptions) (*database.Page, error) {
+		calls++
+		return &database.Page{}, nil
+	}
+	database.GetTaskSummary = func(ctx context.Context, db *sql.DB, courseID, taskID string) (float64, int, int, error) {
+		return 0, 0, 0, nil
+	}
+	defer func() {
+		database.GetAveragesForTask = original
+		database.GetTaskSummary = originalSummary
+	}()
+
+	router := gin.New()
+	Register(router, Dependencies{DB: db, Cache: cache.NewLRU(10), CacheOptions: cache.Options{TTL: time.Minute}})
+
+	path := BasePath + "/course/c1/task/t1/averages"
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, path, nil))
+	require.Equal(t, http.StatusOK, first.Code)
+	require.NotEmpty(t, first.Header().Get("ETag"))
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("If-None-Match", first.Header().Get("ETag"))
+	second := httptest.NewRecorder()
+	router.ServeHTTP(second, req)
+	assert.Equal(t, http.StatusNotModified, second.Code)
+	assert.Equal(t, 1, calls)
+
+	// Health endpoints are never cached
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, BasePath+"/health/live", nil))
+	assert.Empty(t, w.Header().Get("ETag"))
+}
+
+func TestSyncWritesStoreGrades(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service.InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		return g, nil
+	}
+	defer func() { service.InsertGrade = database.InsertGrade }()
+
+	// No enqueuer: sync writes never touch the queue
+	router := gin.New()
+	Register(router, Dependencies{DB: db, SyncWrites: true})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, BasePath+"/student/grade",
+		strings.NewReader(`{"student_id":"s1","course_id":"c1","grade":7}`)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"student_id":"s1"`)
+}
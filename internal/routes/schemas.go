@@ -0,0 +1,395 @@
+package routes
+
+import "github.com/1c2025-IngSoftware2-g7/service_stats/internal/openapi"
+
+var tags = []openapi.Tag{
+	{Name: "Health", Description: "Health Checkpoints for the service"},
+	{Name: "User Stats", Description: "Operaciones relacionadas a las estadisticas de usuario"},
+	{Name: "Course Stats", Description: "Operaciones relacionadas a las estadisticas de un curso"},
+	{Name: "Institution", Description: "Calendario académico de las instituciones"},
+	{Name: "Admin", Description: "Administración de las tareas fallidas; requiere ADMIN_TOKEN"},
+	{Name: "Events", Description: "Eventos publicados por otros servicios de ClassConnect"},
+	{Name: "Webhooks", Description: "Suscripciones a los eventos de las estadísticas; requiere ADMIN_TOKEN"},
+	{Name: "GraphQL", Description: "Estudiantes, cursos, tareas y notas en una sola consulta"},
+	{Name: "Docs", Description: "Documentación de la API"},
+}
+
+var servers = []openapi.Server{
+	{URL: "http://localhost:8080"},
+	{URL: "https://service-api-stats.onrender.com"},
+	{URL: "https://34.61.96.62"},
+}
+
+var timeRangeParams = []openapi.Parameter{
+	openapi.QueryParam("start_date", "Fecha de inicio (YYYY-MM-DD)", openapi.Schema{"type": "string", "format": "date"}),
+	openapi.QueryParam("end_date", "Fecha de fin (YYYY-MM-DD)", openapi.Schema{"type": "string", "format": "date"}),
+	openapi.QueryParam("group_by", "Agrupamiento temporal: minute, hour, day, week, month, quarter, year, un intervalo como \"3 days\" (minutos, horas, días o semanas, hasta 1000) o term. Vacío devuelve un único período all_time", openapi.Schema{"type": "string", "example": "week"}),
+	openapi.QueryParam("tz", "Zona horaria IANA de las fechas y los períodos (por defecto UTC)", openapi.Schema{"type": "string", "example": "America/Argentina/Buenos_Aires"}),
+	openapi.QueryParam("week_start", "Primer día de la semana con group_by=week o intervalos de semanas", openapi.Schema{"type": "string", "enum": []string{"iso", "sunday"}}),
+	openapi.QueryParam("institution", "Institución cuyo calendario académico se usa con group_by=term", openapi.Schema{"type": "string"}),
+}
+
+var seriesParams = append(append([]openapi.Parameter{}, timeRangeParams...),
+	openapi.QueryParam("fill", "Devolver todos los períodos del rango, completando los vacíos (requiere start_date)", openapi.Schema{"type": "string", "enum": []string{"null", "zero", "previous"}}),
+	openapi.QueryParam("rolling", "Cantidad de períodos del promedio móvil, contados desde start_date; 0 lo omite (requiere start_date)", openapi.Schema{"type": "integer", "minimum": 0, "maximum": 1000}),
+	openapi.QueryParam("cumulative", "Agregar el promedio acumulado desde start_date, sin las notas anteriores (requiere start_date)", openapi.Schema{"type": "boolean"}),
+)
+
+var listParams = []openapi.Parameter{
+	openapi.QueryParam("limit", "Cantidad de estudiantes por página (1-500, por defecto 50)", openapi.Schema{"type": "integer", "minimum": 1, "maximum": 500}),
+	openapi.QueryParam("cursor", "Valor de next_cursor de la página anterior", openapi.Schema{"type": "string"}),
+	openapi.QueryParam("sort", "Campo de orden", openapi.Schema{"type": "string", "enum": []string{"average", "count", "student_id"}}),
+	openapi.QueryParam("order", "Sentido del orden (por defecto desc, asc para student_id)", openapi.Schema{"type": "string", "enum": []string{"asc", "desc"}}),
+	openapi.QueryParam("min_average", "Promedio mínimo", openapi.Schema{"type": "number"}),
+	openapi.QueryParam("max_average", "Promedio máximo", openapi.Schema{"type": "number"}),
+	openapi.QueryParam("on_time", "Considerar solo entregas a tiempo", openapi.Schema{"type": "boolean"}),
+	openapi.QueryParam("graded_after", "Considerar solo notas desde esta fecha (YYYY-MM-DD)", openapi.Schema{"type": "string", "format": "date"}),
+}
+
+var schemas = map[string]openapi.Schema{
+	"Grade": {
+		"type":     "object",
+		"required": []string{"student_id", "course_id", "grade"},
+		"properties": map[string]openapi.Schema{
+			"student_id": {"type": "string"},
+			"course_id":  {"type": "string"},
+			"grade":      {"type": "number", "format": "float"},
+			"on_time":    {"type": "boolean"},
+			"created_at": {"type": "string", "format": "date-time", "readOnly": true},
+		},
+	},
+	"Event": {
+		"type":     "object",
+		"required": []string{"id", "type"},
+		"properties": map[string]openapi.Schema{
+			"id":          {"type": "string"},
+			"type":        {"type": "string", "example": "assignment.graded"},
+			"source":      {"type": "string", "example": "assignments"},
+			"occurred_at": {"type": "string", "format": "date-time"},
+			"data": {
+				"type":        "object",
+				"description": "Con assignment.graded: student_id, course_id, assignment_id, grade y on_time",
+			},
+		},
+	},
+	"GradeTask": {
+		"type":     "object",
+		"required": []string{"student_id", "course_id", "task_id", "grade"},
+		"properties": map[string]openapi.Schema{
+			"student_id": {"type": "string"},
+			"course_id":  {"type": "string"},
+			"task_id":    {"type": "string"},
+			"grade":      {"type": "number", "format": "float"},
+			"on_time":    {"type": "boolean"},
+			"created_at": {"type": "string", "format": "date-time", "readOnly": true},
+		},
+	},
+	"QueuedResponse": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"result": {"type": "string"},
+			"status": {"type": "integer", "example": 200},
+		},
+	},
+	"ErrorResponse": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"error": {"type": "string"},
+		},
+	},
+	"HealthReport": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"status": {"type": "string", "enum": []string{"up", "down"}},
+			"checks": {
+				"type": "object",
+				"additionalProperties": openapi.Schema{
+					"type": "object",
+					"properties": map[string]openapi.Schema{
+						"status":     {"type": "string", "enum": []string{"up", "down"}},
+						"latency_ms": {"type": "number"},
+						"error":      {"type": "string"},
+						"details":    {"type": "object"},
+					},
+				},
+			},
+		},
+	},
+	"TimeRange": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"start": {"type": "string", "format": "date-time"},
+			"end":   {"type": "string", "format": "date-time"},
+			"tz":    {"type": "string"},
+		},
+	},
+	"PeriodAverage": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"period":             {"type": "string", "description": "Inicio del período (RFC 3339), nombre del cuatrimestre con group_by=term o all_time"},
+			"average_grade":      {"type": "number", "format": "float", "nullable": true, "description": "null en períodos sin notas con fill=null"},
+			"grade_count":        {"type": "integer"},
+			"rolling_average":    {"type": "number", "format": "float", "description": "Solo con rolling, desde el primer período con notas en la ventana; la ventana no incluye períodos anteriores a start_date"},
+			"cumulative_average": {"type": "number", "format": "float", "description": "Solo con cumulative: promedio de las notas desde start_date hasta el período"},
+		},
+	},
+	"StudentAverageOverTime": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"student_id": {"type": "string"},
+			"averages":   {"type": "array", "items": openapi.Ref("PeriodAverage")},
+			"time_range": openapi.Ref("TimeRange"),
+			"group_by":   {"type": "string"},
+		},
+	},
+	"CourseAverageOverTime": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"course_id":  {"type": "string"},
+			"averages":   {"type": "array", "items": openapi.Ref("PeriodAverage")},
+			"time_range": openapi.Ref("TimeRange"),
+			"group_by":   {"type": "string"},
+		},
+	},
+	"StudentCourseStats": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"course_id": {"type": "string"},
+			"result": {
+				"type": "object",
+				"properties": map[string]openapi.Schema{
+					"average_grade": {"type": "number", "format": "float"},
+					"tbd":           {"type": "number", "format": "float"},
+				},
+			},
+		},
+	},
+	"StudentTaskStats": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"course_id": {"type": "string"},
+			"task_id":   {"type": "string"},
+			"result": {
+				"type": "object",
+				"properties": map[string]openapi.Schema{
+					"average_grade": {"type": "number", "format": "float"},
+				},
+			},
+		},
+	},
+	"StudentAverage": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"student_id":    {"type": "string"},
+			"average_grade": {"type": "number", "format": "float"},
+			"task_count":    {"type": "integer"},
+			"grade_count":   {"type": "integer"},
+		},
+	},
+	"StudentCourseTasksAverage": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"student_id":      {"type": "string"},
+			"course_id":       {"type": "string"},
+			"student_average": {"type": "number", "format": "float"},
+			"other_students":  {"type": "array", "items": openapi.Ref("StudentAverage")},
+			"pagination":      openapi.Ref("Pagination"),
+			"warning":         {"type": "string"},
+		},
+	},
+	"TaskAverages": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"course_id":     {"type": "string"},
+			"task_id":       {"type": "string"},
+			"group_average": {"type": "number", "format": "float"},
+			"students":      {"type": "array", "items": openapi.Ref("StudentAverage")},
+			"pagination":    openapi.Ref("Pagination"),
+		},
+	},
+	"Pagination": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"limit":       {"type": "integer"},
+			"total":       {"type": "integer", "description": "Estudiantes que cumplen los filtros, en todas las páginas"},
+			"next_cursor": {"type": "string", "nullable": true, "description": "null en la última página"},
+		},
+	},
+	"OnTimePercentageDataItem": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"period":        {"type": "string"},
+			"on_time_count": {"type": "integer"},
+			"total_count":   {"type": "integer"},
+			"percentage":    {"type": "number", "format": "float"},
+		},
+	},
+	"AcademicTerm": {
+		"type":     "object",
+		"required": []string{"name", "starts_on", "ends_on"},
+		"properties": map[string]openapi.Schema{
+			"name":      {"type": "string", "example": "2026-1C"},
+			"starts_on": {"type": "string", "format": "date"},
+			"ends_on":   {"type": "string", "format": "date", "description": "Último día del período, incluido"},
+		},
+	},
+	"AcademicCalendar": {
+		"type":     "object",
+		"required": []string{"terms"},
+		"properties": map[string]openapi.Schema{
+			"institution_id": {"type": "string", "readOnly": true},
+			"terms":          {"type": "array", "items": openapi.Ref("AcademicTerm")},
+		},
+	},
+	"DeadLetter": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"id":         {"type": "integer"},
+			"task_id":    {"type": "string", "description": "ID de la tarea en asynq"},
+			"queue":      {"type": "string"},
+			"task_type":  {"type": "string"},
+			"payload":    {"description": "Payload de la tarea; un string cuando no es JSON válido"},
+			"error":      {"type": "string"},
+			"retried":    {"type": "integer"},
+			"permanent":  {"type": "boolean", "description": "El error no se resolvía reintentando (payload inválido, restricción violada)"},
+			"status":     {"type": "string", "enum": []string{"pending", "replayed", "discarded"}},
+			"failed_at":  {"type": "string", "format": "date-time"},
+			"updated_at": {"type": "string", "format": "date-time"},
+		},
+	},
+	"DeadLetterList": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"dead_letters": {"type": "array", "items": openapi.Ref("DeadLetter")},
+			"pagination": {
+				"type": "object",
+				"properties": map[string]openapi.Schema{
+					"limit":       {"type": "integer"},
+					"next_cursor": {"type": "string", "nullable": true},
+				},
+			},
+		},
+	},
+	"WebhookSubscription": {
+		"type":     "object",
+		"required": []string{"url", "events"},
+		"properties": map[string]openapi.Schema{
+			"id":  {"type": "integer", "readOnly": true},
+			"url": {"type": "string", "format": "uri"},
+			"events": {"type": "array", "items": openapi.Schema{
+				"type": "string",
+				"enum": []string{"grade.updated", "student.average_below_threshold", "task.average_ready"},
+			}},
+			"course_id":  {"type": "string", "description": "Limita los eventos a un curso; vacío para todos"},
+			"threshold":  {"type": "number", "nullable": true, "description": "Promedio por debajo del cual se envía student.average_below_threshold"},
+			"secret":     {"type": "string", "description": "Clave del HMAC de X-Webhook-Signature; solo se devuelve al crear la suscripción"},
+			"created_at": {"type": "string", "format": "date-time", "readOnly": true},
+		},
+	},
+	"WebhookDelivery": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"id":              {"type": "integer"},
+			"subscription_id": {"type": "integer"},
+			"event_id":        {"type": "string"},
+			"event_type":      {"type": "string"},
+			"payload":         {"type": "object", "description": "Cuerpo enviado a la URL"},
+			"status":          {"type": "string", "enum": []string{"pending", "delivered", "failed"}},
+			"attempts":        {"type": "integer"},
+			"response_status": {"type": "integer", "description": "Código del último intento; 0 si no hubo respuesta"},
+			"last_error":      {"type": "string"},
+			"created_at":      {"type": "string", "format": "date-time"},
+			"updated_at":      {"type": "string", "format": "date-time"},
+		},
+	},
+	"WebhookDeliveryList": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"deliveries": {"type": "array", "items": openapi.Ref("WebhookDelivery")},
+			"pagination": {
+				"type": "object",
+				"properties": map[string]openapi.Schema{
+					"limit":       {"type": "integer"},
+					"next_cursor": {"type": "string", "nullable": true},
+				},
+			},
+		},
+	},
+	"GraphQLRequest": {
+		"type":     "object",
+		"required": []string{"query"},
+		"properties": map[string]openapi.Schema{
+			"query":         {"type": "string", "example": "{ course(id: \"c1\") { gradeAverage onTimePercentage } }"},
+			"operationName": {"type": "string"},
+			"variables":     {"type": "object"},
+		},
+	},
+	"GraphQLResponse": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"data": {"type": "object", "description": "Ausente si la consulta es inválida o supera los límites"},
+			"errors": {
+				"type": "array",
+				"items": openapi.Schema{
+					"type": "object",
+					"properties": map[string]openapi.Schema{
+						"message":    {"type": "string"},
+						"path":       {"type": "array", "items": openapi.Schema{}},
+						"extensions": {"type": "object", "description": "code es MaxDepthExceeded o MaxComplexityExceeded para las consultas que superan los límites"},
+					},
+				},
+			},
+		},
+	},
+	"StatsUpdate": {
+		"type":        "object",
+		"description": "Nota guardada y agregados leídos después de guardarla; los promedios son null mientras no hay notas",
+		"properties": map[string]openapi.Schema{
+			"course_id":  {"type": "string"},
+			"student_id": {"type": "string"},
+			"task_id":    {"type": "string", "description": "Ausente para las notas del curso"},
+			"grade":      {"type": "number"},
+			"on_time":    {"type": "boolean"},
+			"created_at": {"type": "string", "format": "date-time"},
+			"student": {
+				"type": "object",
+				"properties": map[string]openapi.Schema{
+					"grade_average": {"type": "number", "nullable": true},
+					"grade_count":   {"type": "integer"},
+					"task_average":  {"type": "number", "nullable": true},
+					"task_count":    {"type": "integer"},
+				},
+			},
+			"course": {
+				"type": "object",
+				"properties": map[string]openapi.Schema{
+					"students":           {"type": "integer"},
+					"grade_average":      {"type": "number", "nullable": true},
+					"grade_count":        {"type": "integer"},
+					"task_average":       {"type": "number", "nullable": true},
+					"task_count":         {"type": "integer"},
+					"on_time_percentage": {"type": "number", "nullable": true},
+				},
+			},
+			"task": {
+				"type":        "object",
+				"description": "Solo para las notas de tareas",
+				"properties": map[string]openapi.Schema{
+					"average":       {"type": "number"},
+					"grade_count":   {"type": "integer"},
+					"on_time_count": {"type": "integer"},
+				},
+			},
+		},
+	},
+	"OnTimePercentageResponse": {
+		"type": "object",
+		"properties": map[string]openapi.Schema{
+			"course_id":  {"type": "string"},
+			"student_id": {"type": "string"},
+			"data":       {"type": "array", "items": openapi.Ref("OnTimePercentageDataItem")},
+			"time_range": openapi.Ref("TimeRange"),
+			"group_by":   {"type": "string"},
+		},
+	},
+}
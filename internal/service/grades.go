@@ -0,0 +1,208 @@
+// Package service holds the logic that stores grades. The worker runs it
+// for every task, and the API runs it inline when WRITE_MODE is sync, so a
+// grade is validated and stored the same way on both paths.
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+	"unicode"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/cache"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+)
+
+var (
+	InsertGrade          = database.InsertGrade
+	InsertGradeTask      = database.InsertGradeTask
+	UpdateGradeTask      = database.UpdateGradeTask
+	CheckGradeTaskExists = database.CheckGradeTaskExists
+	// GetAvgGradeTaskForStudent reads the grade a task grade replaces.
+	GetAvgGradeTaskForStudent = database.GetAvgGradeTaskForStudent
+)
+
+// ErrInvalidGrade is returned for grades that can never be stored.
+var ErrInvalidGrade = errors.New("invalid grade")
+
+// ValidID reports whether id can be the ID of a student, course, task or
+// institution: 1 to 50 letters, digits or dashes.
+func ValidID(id string) bool {
+	if len(id) < 1 || len(id) > 50 {
+		return false
+	}
+	for _, c := range id {
+		if !unicode.IsLetter(c) && !unicode.IsNumber(c) && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func ValidateGrade(p model.Grade) error {
+	if p.StudentID == "" || p.CourseID == "" {
+		return fmt.Errorf("%w: student_id and course_id are required", ErrInvalidGrade)
+	}
+	if p.Grade < 0 {
+		return fmt.Errorf("%w: negative grade", ErrInvalidGrade)
+	}
+	return nil
+}
+
+func ValidateGradeTask(p model.GradeTask) error {
+	if p.TaskID == "" {
+		return fmt.Errorf("%w: task_id is required", ErrInvalidGrade)
+	}
+	return ValidateGrade(model.Grade{StudentID: p.StudentID, CourseID: p.CourseID, Grade: p.Grade})
+}
+
+// Change describes a grade the service stored.
+type Change struct {
+	StudentID string
+	CourseID  string
+	// TaskID is empty for the grades stored by AddGrade.
+	TaskID    string
+	Grade     float64
+	OnTime    bool
+	CreatedAt time.Time
+	// Replaced is set when a task grade replaced an earlier one, and
+	// Previous holds that grade when it could be read.
+	Replaced bool
+	Previous *float64
+}
+
+// Listener is told about every grade the service stores, once it is
+// committed. The grade stays stored whatever the listener does, so it logs
+// its own failures instead of returning them.
+type Listener interface {
+	GradeStored(ctx context.Context, change Change)
+}
+
+// Listeners tells each of its listeners in turn. Nil entries are skipped.
+type Listeners []Listener
+
+func (l Listeners) GradeStored(ctx context.Context, change Change) {
+	for _, listener := range l {
+		if listener != nil {
+			listener.GradeStored(ctx, change)
+		}
+	}
+}
+
+// InvalidateResponses is the Listener that drops the cached responses for
+// the student and course of a committed grade. A failure is only logged:
+// the grade is stored, and retrying the task would insert it twice. Stale
+// entries expire with the cache TTL.
+type InvalidateResponses struct {
+	Responses cache.Invalidator
+}
+
+func (i InvalidateResponses) GradeStored(ctx context.Context, change Change) {
+	if i.Responses == nil {
+		return
+	}
+	if err := i.Responses.Invalidate(ctx, cache.GradeTags(change.StudentID, change.CourseID)...); err != nil {
+		slog.WarnContext(ctx, "error invalidating cached responses", "student_id", change.StudentID, "course_id", change.CourseID, "error", err)
+	}
+}
+
+// AddGrade validates and stores a grade, then tells listener about it.
+// listener may be nil. It returns the grade as stored.
+func AddGrade(ctx context.Context, DB *sql.DB, listener Listener, p model.Grade) (model.Grade, error) {
+	if err := ValidateGrade(p); err != nil {
+		return model.Grade{}, err
+	}
+
+	slog.DebugContext(ctx, "processing grade", "student_id", p.StudentID, "course_id", p.CourseID)
+
+	stored, err := InsertGrade(ctx, DB, p)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to insert grade", "student_id", p.StudentID, "course_id", p.CourseID, "error", err)
+		return model.Grade{}, err
+	}
+
+	slog.InfoContext(ctx, "grade inserted", "student_id", p.StudentID, "course_id", p.CourseID)
+	notify(ctx, listener, Change{
+		StudentID: stored.StudentID,
+		CourseID:  stored.CourseID,
+		Grade:     stored.Grade,
+		OnTime:    stored.OnTime,
+		CreatedAt: stored.CreatedAt,
+	})
+	return stored, nil
+}
+
+// SaveGradeTask validates and stores the grade of a task, replacing the
+// previous grade of the same task, then tells listener about it. listener
+// may be nil. It returns the grade as stored.
+func SaveGradeTask(ctx context.Context, DB *sql.DB, listener Listener, p model.GradeTask) (model.GradeTask, error) {
+	if err := ValidateGradeTask(p); err != nil {
+		return model.GradeTask{}, err
+	}
+
+	slog.DebugContext(ctx, "processing grade task", "student_id", p.StudentID, "course_id", p.CourseID, "task_id", p.TaskID)
+
+	exists, err := CheckGradeTaskExists(ctx, DB, p.StudentID, p.CourseID, p.TaskID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error checking grade task existence", "error", err)
+		return model.GradeTask{}, err
+	}
+
+	var stored model.GradeTask
+	var previous *float64
+	if exists {
+		if listener != nil {
+			previous = previousGrade(ctx, DB, p)
+		}
+		stored, err = UpdateGradeTask(ctx, DB, p)
+		if err != nil {
+			slog.ErrorContext(ctx, "error updating grade task", "error", err)
+			return model.GradeTask{}, err
+		}
+		slog.InfoContext(ctx, "grade task updated",
+			"student_id", p.StudentID, "course_id", p.CourseID, "task_id", p.TaskID, "on_time", p.OnTime)
+	} else {
+		stored, err = InsertGradeTask(ctx, DB, p)
+		if err != nil {
+			slog.ErrorContext(ctx, "error inserting grade task", "error", err)
+			return model.GradeTask{}, err
+		}
+		slog.InfoContext(ctx, "grade task inserted",
+			"student_id", p.StudentID, "course_id", p.CourseID, "task_id", p.TaskID, "on_time", p.OnTime)
+	}
+
+	notify(ctx, listener, Change{
+		StudentID: stored.StudentID,
+		CourseID:  stored.CourseID,
+		TaskID:    stored.TaskID,
+		Grade:     stored.Grade,
+		OnTime:    stored.OnTime,
+		CreatedAt: stored.CreatedAt,
+		Replaced:  exists,
+		Previous:  previous,
+	})
+	return stored, nil
+}
+
+// previousGrade reads the grade a task grade is about to replace. It is
+// only told to listeners, so a failure leaves it out instead of failing
+// the write.
+func previousGrade(ctx context.Context, DB *sql.DB, p model.GradeTask) *float64 {
+	grade, status, err := GetAvgGradeTaskForStudent(ctx, DB, p.StudentID, p.CourseID, p.TaskID)
+	if err != nil || status != http.StatusOK {
+		slog.WarnContext(ctx, "error reading the replaced grade task", "status", status, "error", err)
+		return nil
+	}
+	return &grade
+}
+
+func notify(ctx context.Context, listener Listener, change Change) {
+	if listener != nil {
+		listener.GradeStored(ctx, change)
+	}
+}
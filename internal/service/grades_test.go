@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingInvalidator struct {
+	tags []string
+}
+
+func (r *recordingInvalidator) Invalidate(ctx context.Context, tags ...string) error {
+	r.tags = append(r.tags, tags...)
+	return nil
+}
+
+func TestValidateGrade(t *testing.T) {
+	assert.NoError(t, ValidateGrade(model.Grade{StudentID: "s1", CourseID: "c1", Grade: 0}))
+	assert.ErrorIs(t, ValidateGrade(model.Grade{StudentID: "s1", Grade: 7}), ErrInvalidGrade)
+	assert.ErrorIs(t, ValidateGrade(model.Grade{StudentID: "s1", CourseID: "c1", Grade: -1}), ErrInvalidGrade)
+	assert.ErrorIs(t, ValidateGradeTask(model.GradeTask{StudentID: "s1", CourseID: "c1", Grade: 7}), ErrInvalidGrade)
+}
+
+func TestAddGrade(t *testing.T) {
+	createdAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		g.CreatedAt = createdAt
+		return g, nil
+	}
+	defer func() { InsertGrade = database.InsertGrade }()
+
+	invalidator := &recordingInvalidator{}
+	stored, err := AddGrade(context.Background(), nil, InvalidateResponses{Responses: invalidator}, model.Grade{StudentID: "s1", CourseID: "c1", Grade: 7})
+	require.NoError(t, err)
+	assert.Equal(t, model.Grade{StudentID: "s1", CourseID: "c1", Grade: 7, CreatedAt: createdAt}, stored)
+	assert.Equal(t, []string{"course:c1", "student:s1"}, invalidator.tags)
+}
+
+func TestAddGrade_Invalid(t *testing.T) {
+	InsertGrade = func(ctx context.Context, db *sql.DB, g model.Grade) (model.Grade, error) {
+		t.Fatal("an invalid grade must not be inserted")
+		return g, nil
+	}
+	defer func() { InsertGrade = database.InsertGrade }()
+
+	_, err := AddGrade(context.Background(), nil, nil, model.Grade{StudentID: "s1"})
+	assert.ErrorIs(t, err, ErrInvalidGrade)
+}
+
+func TestSaveGradeTask(t *testing.T) {
+	var called []string
+	CheckGradeTaskExists = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (bool, error) {
+		return taskID == "t2", nil
+	}
+	InsertGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		called = append(called, "insert "+gt.TaskID)
+		return gt, nil
+	}
+	UpdateGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		called = append(called, "update "+gt.TaskID)
+		return gt, nil
+	}
+	defer func() {
+		CheckGradeTaskExists = database.CheckGradeTaskExists
+		InsertGradeTask = database.InsertGradeTask
+		UpdateGradeTask = database.UpdateGradeTask
+	}()
+
+	for _, taskID := range []string{"t1", "t2"} {
+		stored, err := SaveGradeTask(context.Background(), nil, nil, model.GradeTask{StudentID: "s1", CourseID: "c1", TaskID: taskID, Grade: 9})
+		require.NoError(t, err)
+		assert.Equal(t, taskID, stored.TaskID)
+	}
+	assert.Equal(t, []string{"insert t1", "update t2"}, called)
+}
+
+func TestSaveGradeTask_DoesNotInvalidateOnFailure(t *testing.T) {
+	CheckGradeTaskExists = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (bool, error) {
+		return false, errors.New("db down")
+	}
+	defer func() { CheckGradeTaskExists = database.CheckGradeTaskExists }()
+
+	invalidator := &recordingInvalidator{}
+	_, err := SaveGradeTask(context.Background(), nil, InvalidateResponses{Responses: invalidator}, model.GradeTask{StudentID: "s1", CourseID: "c1", TaskID: "t1"})
+	assert.EqualError(t, err, "db down")
+	assert.Empty(t, invalidator.tags)
+}
+
+type recordingListener struct {
+	changes []Change
+}
+
+func (r *recordingListener) GradeStored(ctx context.Context, change Change) {
+	r.changes = append(r.changes, change)
+}
+
+func TestSaveGradeTask_ToldTheReplacedGrade(t *testing.T) {
+	CheckGradeTaskExists = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (bool, error) {
+		return true, nil
+	}
+	GetAvgGradeTaskForStudent = func(ctx context.Context, db *sql.DB, studentID, courseID, taskID string) (float64, int, error) {
+		return 4, 200, nil
+	}
+	UpdateGradeTask = func(ctx context.Context, db *sql.DB, gt model.GradeTask) (model.GradeTask, error) {
+		return gt, nil
+	}
+	defer func() {
+		CheckGradeTaskExists = database.CheckGradeTaskExists
+		GetAvgGradeTaskForStudent = database.GetAvgGradeTaskForStudent
+		UpdateGradeTask = database.UpdateGradeTask
+	}()
+
+	listener := &recordingListener{}
+	_, err := SaveGradeTask(context.Background(), nil, Listeners{nil, listener}, model.GradeTask{StudentID: "s1", CourseID: "c1", TaskID: "t1", Grade: 9})
+	require.NoError(t, err)
+	require.Len(t, listener.changes, 1)
+	assert.True(t, listener.changes[0].Replaced)
+	require.NotNil(t, listener.changes[0].Previous)
+	assert.Equal(t, 4.0, *listener.changes[0].Previous)
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInit_None(t *testing.T) {
+	shutdown, err := Init(context.Background(), "test", ExporterNone)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestInit_Stdout(t *testing.T) {
+	shutdown, err := Init(context.Background(), "test", ExporterStdout)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestInit_UnknownExporter(t *testing.T) {
+	_, err := Init(context.Background(), "test", "jaeger")
+	assert.Error(t, err)
+}
+
+func TestInjectExtract_RoundTrip(t *testing.T) {
+	_, err := Init(context.Background(), "test", ExporterNone)
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "parent")
+	defer span.End()
+
+	carrier := map[string]string{}
+	Inject(ctx, carrier)
+	assert.Contains(t, carrier, "traceparent")
+
+	extracted := trace.SpanContextFromContext(Extract(context.Background(), carrier))
+	assert.Equal(t, span.SpanContext().TraceID(), extracted.TraceID())
+	assert.True(t, extracted.IsRemote())
+}
+
+func TestStartDBSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	_, span := StartDBSpan(context.Background(), "InsertGrade")
+	span.End()
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, "db.InsertGrade", ended[0].Name())
+	assert.Equal(t, trace.SpanKindClient, ended[0].SpanKind())
+}
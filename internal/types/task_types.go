@@ -7,8 +7,13 @@ package types
 2. Go to queue/handler.go, and add a new handler function for the task type
 3. Register the new handler function in the NewMux function
 4. Add into the API requeset to the queue the new task type
+5. Add a fixture for it to every version in queue/testdata/envelopes
 
 And you're done! Regards lucas!
 */
 const TaskAddStudentGrade = "task:add_student_grade"
 const TaskAddStudentGradeTask = "task:add_student_grade_task"
+
+// TaskDeliverWebhook posts a statistics event to a webhook subscription. It
+// is enqueued without the processing delay of the grades.
+const TaskDeliverWebhook = "task:deliver_webhook"
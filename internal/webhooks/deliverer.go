@@ -0,0 +1,128 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/events"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+)
+
+var (
+	GetWebhookDelivery     = database.GetWebhookDelivery
+	GetWebhookSubscription = database.GetWebhookSubscription
+	RecordWebhookAttempt   = database.RecordWebhookAttempt
+)
+
+// Headers of a delivery. The signature is computed like the one checked on
+// the events received by webhook (events.Sign), so both directions share
+// one scheme.
+const (
+	IDHeader        = "X-Webhook-Id"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// maxErrorBody bounds the part of a failed response kept in last_error.
+const maxErrorBody = 512
+
+// Deliverer posts the deliveries enqueued by the Notifier.
+type Deliverer struct {
+	DB     *sql.DB
+	Client *http.Client
+}
+
+func NewDeliverer(DB *sql.DB, timeout time.Duration) *Deliverer {
+	return &Deliverer{DB: DB, Client: &http.Client{Timeout: timeout}}
+}
+
+// Deliver makes one attempt of delivery id. An error means the attempt
+// failed and should be retried; on the last attempt the delivery is marked
+// as failed and nil is returned instead, since its history already tells
+// what went wrong.
+func (d *Deliverer) Deliver(ctx context.Context, id int64, lastAttempt bool) error {
+	delivery, err := GetWebhookDelivery(ctx, d.DB, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Its subscription was deleted
+		slog.InfoContext(ctx, "webhook delivery no longer exists", "delivery_id", id)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status != database.WebhookDeliveryPending {
+		slog.InfoContext(ctx, "webhook delivery already finished", "delivery_id", id, "status", delivery.Status)
+		return nil
+	}
+
+	subscription, err := GetWebhookSubscription(ctx, d.DB, delivery.SubscriptionID)
+	if errors.Is(err, database.ErrWebhookNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	responseStatus, attemptErr := d.post(ctx, subscription, delivery)
+
+	status, outcome, lastError := database.WebhookDeliveryDelivered, metrics.WebhookDelivered, ""
+	if attemptErr != nil {
+		status, outcome, lastError = database.WebhookDeliveryPending, metrics.WebhookRetrying, attemptErr.Error()
+		if lastAttempt {
+			status, outcome = database.WebhookDeliveryFailed, metrics.WebhookFailed
+		}
+	}
+	if err := RecordWebhookAttempt(ctx, d.DB, id, status, responseStatus, lastError); err != nil {
+		slog.ErrorContext(ctx, "error recording webhook attempt", "delivery_id", id, "error", err)
+	}
+	metrics.ObserveWebhookDelivery(delivery.EventType, outcome)
+
+	if attemptErr != nil {
+		slog.WarnContext(ctx, "webhook delivery failed", "delivery_id", id, "subscription_id", subscription.ID, "status", responseStatus, "last_attempt", lastAttempt, "error", attemptErr)
+		if lastAttempt {
+			return nil
+		}
+		return attemptErr
+	}
+	slog.InfoContext(ctx, "webhook delivered", "delivery_id", id, "subscription_id", subscription.ID, "status", responseStatus)
+	return nil
+}
+
+// post sends delivery to subscription and returns the response status, zero
+// when no response came back.
+func (d *Deliverer) post(ctx context.Context, subscription *database.WebhookSubscription, delivery *database.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, delivery.EventID)
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, events.Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,96 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type attempt struct {
+	status         string
+	responseStatus int
+	lastError      string
+}
+
+// mockDelivererDB serves one pending delivery to url and records the
+// attempts made.
+func mockDelivererDB(t *testing.T, url string) *[]attempt {
+	attempts := &[]attempt{}
+	GetWebhookDelivery = func(ctx context.Context, DB *sql.DB, id int64) (*database.WebhookDelivery, error) {
+		return &database.WebhookDelivery{
+			ID: id, SubscriptionID: 1, EventID: "e1", EventType: GradeUpdated,
+			Payload: []byte(`{"id":"e1"}`), Status: database.WebhookDeliveryPending,
+		}, nil
+	}
+	GetWebhookSubscription = func(ctx context.Context, DB *sql.DB, id int64) (*database.WebhookSubscription, error) {
+		return &database.WebhookSubscription{ID: id, URL: url, Events: []string{GradeUpdated}, Secret: "s3cret"}, nil
+	}
+	RecordWebhookAttempt = func(ctx context.Context, DB *sql.DB, id int64, status string, responseStatus int, lastError string) error {
+		*attempts = append(*attempts, attempt{status, responseStatus, lastError})
+		return nil
+	}
+	t.Cleanup(func() {
+		GetWebhookDelivery = database.GetWebhookDelivery
+		GetWebhookSubscription = database.GetWebhookSubscription
+		RecordWebhookAttempt = database.RecordWebhookAttempt
+	})
+	return attempts
+}
+
+func TestDeliverer_SignsAndRecords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "e1", r.Header.Get(IDHeader))
+		assert.Equal(t, GradeUpdated, r.Header.Get(EventHeader))
+		assert.Equal(t, "7", r.Header.Get(DeliveryHeader))
+		assert.NoError(t, events.VerifySignature("s3cret", r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body, time.Minute, time.Now()))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	attempts := mockDelivererDB(t, server.URL)
+
+	require.NoError(t, NewDeliverer(nil, time.Second).Deliver(context.Background(), 7, false))
+	assert.Equal(t, []attempt{{database.WebhookDeliveryDelivered, http.StatusNoContent, ""}}, *attempts)
+}
+
+func TestDeliverer_FailureIsRetriedUntilTheLastAttempt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer server.Close()
+	attempts := mockDelivererDB(t, server.URL)
+	deliverer := NewDeliverer(nil, time.Second)
+
+	assert.Error(t, deliverer.Deliver(context.Background(), 7, false))
+	assert.NoError(t, deliverer.Deliver(context.Background(), 7, true))
+
+	require.Len(t, *attempts, 2)
+	assert.Equal(t, database.WebhookDeliveryPending, (*attempts)[0].status)
+	assert.Equal(t, database.WebhookDeliveryFailed, (*attempts)[1].status)
+	assert.Equal(t, http.StatusBadGateway, (*attempts)[1].responseStatus)
+	assert.Contains(t, (*attempts)[1].lastError, "boom")
+}
+
+func TestDeliverer_SkipsFinishedDeliveries(t *testing.T) {
+	attempts := mockDelivererDB(t, "http://127.0.0.1:1")
+	GetWebhookDelivery = func(ctx context.Context, DB *sql.DB, id int64) (*database.WebhookDelivery, error) {
+		return &database.WebhookDelivery{ID: id, Status: database.WebhookDeliveryDelivered}, nil
+	}
+	require.NoError(t, NewDeliverer(nil, time.Second).Deliver(context.Background(), 7, false))
+
+	GetWebhookDelivery = func(ctx context.Context, DB *sql.DB, id int64) (*database.WebhookDelivery, error) {
+		return nil, sql.ErrNoRows
+	}
+	require.NoError(t, NewDeliverer(nil, time.Second).Deliver(context.Background(), 7, false))
+	assert.Empty(t, *attempts)
+}
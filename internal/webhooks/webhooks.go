@@ -0,0 +1,296 @@
+// Package webhooks tells downstream services about the statistics: each
+// committed grade is turned into events, and every subscription interested
+// in one of them gets a delivery, posted by the worker as a
+// task:deliver_webhook task.
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ListWebhookSubscriptions = database.ListWebhookSubscriptions
+	InsertWebhookDelivery    = database.InsertWebhookDelivery
+	FailWebhookDelivery      = database.FailWebhookDelivery
+	GetStudentCourseStats    = database.GetStudentCourseStats
+	GetTaskSummary           = database.GetTaskSummary
+)
+
+// Event types a subscription can ask for.
+const (
+	// GradeUpdated is sent for every stored grade.
+	GradeUpdated = "grade.updated"
+	// StudentAverageBelowThreshold is sent when a grade takes the average
+	// of a student in a course below the threshold of the subscription.
+	StudentAverageBelowThreshold = "student.average_below_threshold"
+	// TaskAverageReady is sent when a task grade is stored, with the
+	// average of the task across the course.
+	TaskAverageReady = "task.average_ready"
+)
+
+// EventTypes lists every event type, in the order they are built.
+var EventTypes = []string{GradeUpdated, StudentAverageBelowThreshold, TaskAverageReady}
+
+// Event is the body of a delivery.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// GradeData is the data of GradeUpdated.
+type GradeData struct {
+	StudentID string `json:"student_id"`
+	CourseID  string `json:"course_id"`
+	// TaskID is empty for course grades.
+	TaskID string  `json:"task_id,omitempty"`
+	Grade  float64 `json:"grade"`
+	// PreviousGrade is the task grade this one replaced.
+	PreviousGrade *float64  `json:"previous_grade,omitempty"`
+	OnTime        bool      `json:"on_time"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AverageData is the data of StudentAverageBelowThreshold.
+type AverageData struct {
+	StudentID string `json:"student_id"`
+	CourseID  string `json:"course_id"`
+	// Kind is "tasks" when the average is of task grades and "grades"
+	// otherwise, like the grade that triggered the event.
+	Kind    string  `json:"kind"`
+	Average float64 `json:"average"`
+	// PreviousAverage is the average before the grade, absent for the
+	// first grade of the student.
+	PreviousAverage *float64 `json:"previous_average,omitempty"`
+	Threshold       float64  `json:"threshold"`
+}
+
+// TaskAverageData is the data of TaskAverageReady.
+type TaskAverageData struct {
+	CourseID    string  `json:"course_id"`
+	TaskID      string  `json:"task_id"`
+	Average     float64 `json:"average"`
+	GradeCount  int     `json:"grade_count"`
+	OnTimeCount int     `json:"on_time_count"`
+}
+
+// ErrInvalidSubscription is returned for subscriptions that can't be
+// stored.
+var ErrInvalidSubscription = errors.New("invalid webhook subscription")
+
+// ValidateSubscription checks a subscription before it is stored.
+func ValidateSubscription(s database.WebhookSubscription) error {
+	target, err := url.Parse(s.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidSubscription)
+	}
+	if len(s.Events) == 0 {
+		return fmt.Errorf("%w: events must list at least one event type", ErrInvalidSubscription)
+	}
+	for _, eventType := range s.Events {
+		if !slices.Contains(EventTypes, eventType) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidSubscription, eventType)
+		}
+	}
+	if slices.Contains(s.Events, StudentAverageBelowThreshold) && s.Threshold == nil {
+		return fmt.Errorf("%w: %s needs a threshold", ErrInvalidSubscription, StudentAverageBelowThreshold)
+	}
+	return nil
+}
+
+// NewSecret returns a random secret for a subscription created without
+// one.
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// DeliveryTask is the payload of a task:deliver_webhook task.
+type DeliveryTask struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// Enqueuer is the part of the task queue the notifier needs.
+type Enqueuer interface {
+	EnqueueNow(ctx context.Context, taskType string, payload interface{}) error
+}
+
+// Notifier is the service.Listener that creates the deliveries of each
+// committed grade.
+type Notifier struct {
+	DB       *sql.DB
+	Enqueuer Enqueuer
+}
+
+func NewNotifier(DB *sql.DB, enqueuer Enqueuer) *Notifier {
+	return &Notifier{DB: DB, Enqueuer: enqueuer}
+}
+
+// GradeStored sends the events of change to the subscriptions that ask for
+// them. Failures are logged: the grade is stored either way.
+func (n *Notifier) GradeStored(ctx context.Context, change service.Change) {
+	subscriptions, err := ListWebhookSubscriptions(ctx, n.DB)
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing webhook subscriptions", "error", err)
+		return
+	}
+	subscriptions = slices.DeleteFunc(subscriptions, func(s database.WebhookSubscription) bool {
+		return s.CourseID != "" && s.CourseID != change.CourseID
+	})
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	wants := func(eventType string) bool {
+		return slices.ContainsFunc(subscriptions, func(s database.WebhookSubscription) bool {
+			return slices.Contains(s.Events, eventType)
+		})
+	}
+	now := time.Now().UTC()
+
+	if wants(GradeUpdated) {
+		event := newEvent(GradeUpdated, now, GradeData{
+			StudentID:     change.StudentID,
+			CourseID:      change.CourseID,
+			TaskID:        change.TaskID,
+			Grade:         change.Grade,
+			PreviousGrade: change.Previous,
+			OnTime:        change.OnTime,
+			CreatedAt:     change.CreatedAt,
+		})
+		n.sendAll(ctx, subscriptions, event)
+	}
+
+	if wants(StudentAverageBelowThreshold) {
+		if average, previous, kind, ok := n.studentAverage(ctx, change); ok {
+			for _, s := range subscriptions {
+				if !slices.Contains(s.Events, StudentAverageBelowThreshold) || s.Threshold == nil || !droppedBelow(average, previous, *s.Threshold) {
+					continue
+				}
+				n.send(ctx, s, newEvent(StudentAverageBelowThreshold, now, AverageData{
+					StudentID:       change.StudentID,
+					CourseID:        change.CourseID,
+					Kind:            kind,
+					Average:         average,
+					PreviousAverage: previous,
+					Threshold:       *s.Threshold,
+				}))
+			}
+		}
+	}
+
+	if change.TaskID != "" && wants(TaskAverageReady) {
+		average, count, onTime, err := GetTaskSummary(ctx, n.DB, change.CourseID, change.TaskID)
+		if err != nil {
+			slog.ErrorContext(ctx, "error reading the task average for webhooks", "error", err)
+		} else {
+			n.sendAll(ctx, subscriptions, newEvent(TaskAverageReady, now, TaskAverageData{
+				CourseID:    change.CourseID,
+				TaskID:      change.TaskID,
+				Average:     average,
+				GradeCount:  count,
+				OnTimeCount: onTime,
+			}))
+		}
+	}
+}
+
+func newEvent(eventType string, now time.Time, data interface{}) Event {
+	return Event{ID: uuid.NewString(), Type: eventType, CreatedAt: now, Data: data}
+}
+
+// studentAverage returns the average of the student in the course after
+// change, of the same kind as the grade, and the average before it. ok is
+// false when it can't be told.
+func (n *Notifier) studentAverage(ctx context.Context, change service.Change) (average float64, previous *float64, kind string, ok bool) {
+	stats, err := GetStudentCourseStats(ctx, n.DB, change.StudentID, change.CourseID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error reading the student average for webhooks", "error", err)
+		return 0, nil, "", false
+	}
+
+	kind, sum, count := "grades", stats.GradeSum, stats.GradeCount
+	if change.TaskID != "" {
+		kind, sum, count = "tasks", stats.TaskGradeSum, stats.TaskCount
+	}
+	if count == 0 {
+		return 0, nil, "", false
+	}
+	average = sum / float64(count)
+
+	switch {
+	case change.Replaced && change.Previous == nil:
+		// The replaced grade couldn't be read
+		return 0, nil, "", false
+	case change.Replaced:
+		before := (sum - change.Grade + *change.Previous) / float64(count)
+		previous = &before
+	case count > 1:
+		before := (sum - change.Grade) / float64(count-1)
+		previous = &before
+	}
+	return average, previous, kind, true
+}
+
+// droppedBelow reports whether an average went from threshold or above (or
+// from no average at all) to below it.
+func droppedBelow(average float64, previous *float64, threshold float64) bool {
+	return average < threshold && (previous == nil || *previous >= threshold)
+}
+
+func (n *Notifier) sendAll(ctx context.Context, subscriptions []database.WebhookSubscription, event Event) {
+	for _, s := range subscriptions {
+		if slices.Contains(s.Events, event.Type) {
+			n.send(ctx, s, event)
+		}
+	}
+}
+
+// send records a delivery of event to s and enqueues it.
+func (n *Notifier) send(ctx context.Context, s database.WebhookSubscription, event Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding webhook event", "event_type", event.Type, "error", err)
+		return
+	}
+
+	id, err := InsertWebhookDelivery(ctx, n.DB, database.WebhookDelivery{
+		SubscriptionID: s.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        payload,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "error recording webhook delivery", "subscription_id", s.ID, "event_type", event.Type, "error", err)
+		return
+	}
+
+	if err := n.Enqueuer.EnqueueNow(ctx, types.TaskDeliverWebhook, DeliveryTask{DeliveryID: id}); err != nil {
+		slog.ErrorContext(ctx, "error enqueueing webhook delivery", "delivery_id", id, "error", err)
+		if err := FailWebhookDelivery(ctx, n.DB, id, "enqueue: "+err.Error()); err != nil {
+			slog.ErrorContext(ctx, "error recording failed webhook delivery", "delivery_id", id, "error", err)
+		}
+		return
+	}
+	slog.DebugContext(ctx, "webhook delivery enqueued", "delivery_id", id, "subscription_id", s.ID, "event_type", event.Type)
+}
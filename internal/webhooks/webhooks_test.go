@@ -0,0 +1,186 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEnqueuer struct {
+	tasks []DeliveryTask
+	err   error
+}
+
+func (f *fakeEnqueuer) EnqueueNow(ctx context.Context, taskType string, payload interface{}) error {
+	if f.err != nil {
+		return f.err
+	}
+	if taskType != types.TaskDeliverWebhook {
+		return errors.New("unexpected task type " + taskType)
+	}
+	f.tasks = append(f.tasks, payload.(DeliveryTask))
+	return nil
+}
+
+func float(v float64) *float64 { return &v }
+
+// mockNotifierDB serves subscriptions and stats, and records the deliveries
+// inserted, by event type.
+func mockNotifierDB(t *testing.T, subscriptions []database.WebhookSubscription, stats database.StudentCourseStats) map[string][]Event {
+	inserted := map[string][]Event{}
+	ListWebhookSubscriptions = func(ctx context.Context, DB *sql.DB) ([]database.WebhookSubscription, error) {
+		return subscriptions, nil
+	}
+	GetStudentCourseStats = func(ctx context.Context, DB *sql.DB, studentID, courseID string) (database.StudentCourseStats, error) {
+		return stats, nil
+	}
+	GetTaskSummary = func(ctx context.Context, DB *sql.DB, courseID, taskID string) (float64, int, int, error) {
+		return 7.5, 4, 3, nil
+	}
+	var nextID int64
+	InsertWebhookDelivery = func(ctx context.Context, DB *sql.DB, d database.WebhookDelivery) (int64, error) {
+		var e Event
+		require.NoError(t, json.Unmarshal(d.Payload, &e))
+		assert.Equal(t, d.EventType, e.Type)
+		inserted[d.EventType] = append(inserted[d.EventType], e)
+		nextID++
+		return nextID, nil
+	}
+	t.Cleanup(func() {
+		ListWebhookSubscriptions = database.ListWebhookSubscriptions
+		GetStudentCourseStats = database.GetStudentCourseStats
+		GetTaskSummary = database.GetTaskSummary
+		InsertWebhookDelivery = database.InsertWebhookDelivery
+		FailWebhookDelivery = database.FailWebhookDelivery
+	})
+	return inserted
+}
+
+func TestNotifier_GradeUpdatedFiltersByCourse(t *testing.T) {
+	inserted := mockNotifierDB(t, []database.WebhookSubscription{
+		{ID: 1, Events: []string{GradeUpdated}},
+		{ID: 2, Events: []string{GradeUpdated}, CourseID: "course2"},
+		{ID: 3, Events: []string{TaskAverageReady}},
+	}, database.StudentCourseStats{})
+	enqueuer := &fakeEnqueuer{}
+
+	NewNotifier(nil, enqueuer).GradeStored(context.Background(), service.Change{StudentID: "s1", CourseID: "course1", Grade: 8})
+
+	require.Len(t, inserted[GradeUpdated], 1)
+	assert.Empty(t, inserted[TaskAverageReady], "course grades have no task average")
+	assert.Equal(t, []DeliveryTask{{DeliveryID: 1}}, enqueuer.tasks)
+}
+
+func TestNotifier_TaskAverageReady(t *testing.T) {
+	inserted := mockNotifierDB(t, []database.WebhookSubscription{
+		{ID: 1, Events: []string{TaskAverageReady}},
+	}, database.StudentCourseStats{})
+
+	NewNotifier(nil, &fakeEnqueuer{}).GradeStored(context.Background(), service.Change{StudentID: "s1", CourseID: "course1", TaskID: "task1", Grade: 8})
+
+	require.Len(t, inserted[TaskAverageReady], 1)
+	data := inserted[TaskAverageReady][0].Data.(map[string]interface{})
+	assert.Equal(t, "task1", data["task_id"])
+	assert.Equal(t, 7.5, data["average"])
+	assert.Equal(t, 4.0, data["grade_count"])
+}
+
+func TestNotifier_AverageBelowThreshold(t *testing.T) {
+	subscriptions := []database.WebhookSubscription{
+		{ID: 1, Events: []string{StudentAverageBelowThreshold}, Threshold: float(6)},
+		{ID: 2, Events: []string{StudentAverageBelowThreshold}, Threshold: float(4)},
+	}
+
+	for name, tc := range map[string]struct {
+		change service.Change
+		stats  database.StudentCourseStats
+		want   []float64
+	}{
+		// 8 and 3 average 5.5, down from 8
+		"drops below one threshold": {
+			change: service.Change{Grade: 3},
+			stats:  database.StudentCourseStats{GradeSum: 11, GradeCount: 2},
+			want:   []float64{6},
+		},
+		// 5 and 5.5 were already below 6
+		"already below": {
+			change: service.Change{Grade: 5},
+			stats:  database.StudentCourseStats{GradeSum: 16.5, GradeCount: 3},
+		},
+		"first grade below both": {
+			change: service.Change{Grade: 3},
+			stats:  database.StudentCourseStats{GradeSum: 3, GradeCount: 1},
+			want:   []float64{6, 4},
+		},
+		// Task 7 replaced by 2: 7+9 averaged 8, 2+9 average 5.5
+		"replaced task grade": {
+			change: service.Change{TaskID: "task1", Grade: 2, Replaced: true, Previous: float(7)},
+			stats:  database.StudentCourseStats{TaskGradeSum: 11, TaskCount: 2},
+			want:   []float64{6},
+		},
+		"replaced grade unknown": {
+			change: service.Change{TaskID: "task1", Grade: 2, Replaced: true},
+			stats:  database.StudentCourseStats{TaskGradeSum: 11, TaskCount: 2},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			inserted := mockNotifierDB(t, subscriptions, tc.stats)
+			tc.change.StudentID, tc.change.CourseID = "s1", "course1"
+
+			NewNotifier(nil, &fakeEnqueuer{}).GradeStored(context.Background(), tc.change)
+
+			var thresholds []float64
+			for _, e := range inserted[StudentAverageBelowThreshold] {
+				thresholds = append(thresholds, e.Data.(map[string]interface{})["threshold"].(float64))
+			}
+			assert.Equal(t, tc.want, thresholds)
+		})
+	}
+}
+
+func TestNotifier_EnqueueFailureFailsDelivery(t *testing.T) {
+	mockNotifierDB(t, []database.WebhookSubscription{{ID: 1, Events: []string{GradeUpdated}}}, database.StudentCourseStats{})
+	var failed []int64
+	FailWebhookDelivery = func(ctx context.Context, DB *sql.DB, id int64, lastError string) error {
+		failed = append(failed, id)
+		assert.Contains(t, lastError, "redis down")
+		return nil
+	}
+
+	NewNotifier(nil, &fakeEnqueuer{err: errors.New("redis down")}).GradeStored(context.Background(), service.Change{StudentID: "s1", CourseID: "course1", Grade: 8})
+
+	assert.Equal(t, []int64{1}, failed)
+}
+
+func TestValidateSubscription(t *testing.T) {
+	valid := database.WebhookSubscription{URL: "https://example.com/hook", Events: []string{GradeUpdated}}
+	assert.NoError(t, ValidateSubscription(valid))
+
+	for name, s := range map[string]database.WebhookSubscription{
+		"relative url":      {URL: "/hook", Events: []string{GradeUpdated}},
+		"ftp url":           {URL: "ftp://example.com", Events: []string{GradeUpdated}},
+		"no events":         {URL: "https://example.com"},
+		"unknown event":     {URL: "https://example.com", Events: []string{"grade.deleted"}},
+		"missing threshold": {URL: "https://example.com", Events: []string{StudentAverageBelowThreshold}},
+	} {
+		assert.ErrorIs(t, ValidateSubscription(s), ErrInvalidSubscription, name)
+	}
+}
+
+func TestEvent_JSON(t *testing.T) {
+	e := newEvent(GradeUpdated, time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), GradeData{StudentID: "s1", CourseID: "c1", Grade: 8})
+	data, err := json.Marshal(e)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"`+e.ID+`","type":"grade.updated","created_at":"2026-03-02T10:00:00Z",
+		"data":{"student_id":"s1","course_id":"c1","grade":8,"on_time":false,"created_at":"0001-01-01T00:00:00Z"}}`, string(data))
+}
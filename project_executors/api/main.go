@@ -1,17 +1,40 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"database/sql"
+	"errors"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
-	"service_stats/internal/database"
-	"service_stats/internal/handlers"
-	"service_stats/internal/model"
-	"service_stats/internal/queue"
+	_ "time/tzdata" // the alpine image has no zoneinfo for the tz parameter
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/cache"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/config"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/events"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/graph"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/grpcapi"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/handlers"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/health"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/lifecycle"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/logging"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/queue"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/realtime"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/routes"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/tracing"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/hibiken/asynq"
 	"github.com/newrelic/go-agent/v3/newrelic"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
 )
 
 /*
@@ -54,123 +77,340 @@ func main() {
 
 func main() {
 
-	// Load environment variables from .env file
-	err_env := godotenv.Load()
-
-	if err_env != nil {
-		log.Printf("[Stats Service] It seems no .env file loaded, so working with default ENV variables")
+	// Configuration comes from the defaults, CONFIG_FILE, .env and the
+	// environment; see internal/config
+	cfg, err_config := config.Load("service_stats_api")
+	if err_config != nil {
+		log.Fatalf("[Stats Service] %v", err_config)
 	}
 
-	// Initialize New Relic
-	newRelicApp, err_relic := newrelic.NewApplication(
-		newrelic.ConfigAppName(os.Getenv("NEW_RELIC_APP_NAME")),
-		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
-		newrelic.ConfigDistributedTracerEnabled(true),
-		func(c *newrelic.Config) {
-			c.Enabled = true
-		},
-	)
-	if err_relic != nil {
-		log.Fatal("[Stats Service] Error initializing New Relic: ", err_relic)
+	err_logging := logging.Init(os.Stdout, cfg.Logging.Config())
+	if err_logging != nil {
+		log.Fatalf("[Stats Service] Invalid logging configuration: %v", err_logging)
 	}
 
-	router := gin.Default()
+	slog.Info("configuration loaded", "sources", cfg.Sources, "config", cfg.Redacted())
 
-	router.Use(func(c *gin.Context) {
-		// Start a new New Relic transaction
-		txn := newRelicApp.StartTransaction(c.FullPath())
+	// Tracing exporter is chosen with OTEL_TRACES_EXPORTER (otlp, stdout or none)
+	service_name := cfg.Tracing.ServiceName
 
-		defer txn.End()
+	shutdown_tracing, err_tracing := tracing.Init(context.Background(), service_name, cfg.Tracing.Exporter)
+	if err_tracing != nil {
+		fatal("error initializing tracing", "error", err_tracing)
+	}
 
-		// Set the transaction in the context
-		c.Set("newrelic.Transaction", txn)
+	router := gin.New()
+
+	router.Use(logging.RequestIDMiddleware())
+	router.Use(otelgin.Middleware(service_name))
+	router.Use(logging.GinLogger())
+	router.Use(metrics.GinMiddleware())
+	router.Use(gin.Recovery())
+
+	// New Relic is optional: without a license key the agent isn't started
+	if cfg.NewRelic.Enabled() {
+		newRelicApp, err_relic := newrelic.NewApplication(
+			newrelic.ConfigAppName(cfg.NewRelic.AppName),
+			newrelic.ConfigLicense(cfg.NewRelic.LicenseKey),
+			newrelic.ConfigDistributedTracerEnabled(true),
+			func(c *newrelic.Config) {
+				c.Enabled = true
+			},
+		)
+		if err_relic != nil {
+			fatal("error initializing New Relic", "error", err_relic)
+		}
+
+		router.Use(func(c *gin.Context) {
+			// Start a new New Relic transaction
+			txn := newRelicApp.StartTransaction(c.FullPath())
+
+			defer txn.End()
+
+			// Set the transaction in the context
+			c.Set("newrelic.Transaction", txn)
+
+			// Continue with the next handler
+			c.Next()
+		})
+	} else {
+		slog.Info("New Relic disabled, NEW_RELIC_LICENSE_KEY is not set")
+	}
 
-		// Continue with the next handler
-		c.Next()
-	})
+	enqueuer := queue.NewEnqueuer(cfg.Redis.ClientOpt(), cfg.Worker.EnqueueQueue)
 
-	server_ip := fmt.Sprintf("%s:%s", os.Getenv("ASYNC_QUEUE_HOST"), os.Getenv("ASYNC_QUEUE_PORT"))
-	enqueuer := queue.NewEnqueuer(server_ip)
+	// Initialize the database connection with internal/database/db.go
+
+	database.QueryTimeout = cfg.Database.QueryTimeout
 
-	database_url := os.Getenv("SERVICE_STATS_POSTGRES_URL")
+	slog.Info("initializing database connection")
+	db_ref, err_creating := database.InitDB(cfg.Database.URL, cfg.Database.Pool())
 
-	if database_url == "" {
-		log.Fatal("[Stats Service] SERVICE_STATS_POSTGRES_URL environment variable is not set")
+	if err_creating != nil {
+		fatal("failed to initialize database", "error", err_creating)
 	}
 
-	// Initialize the database connection with internal/database/db.go
+	// Read-only endpoints can be served from a replica; without one (or
+	// while it is unhealthy or lagging) they use the primary
+	var replica_ref *sql.DB
+	if cfg.Database.ReplicaURL != "" {
+		replica_ref, err_creating = database.OpenReplica(cfg.Database.ReplicaURL, cfg.Database.Pool())
+		if err_creating != nil {
+			fatal("failed to initialize read replica", "error", err_creating)
+		}
+	}
+	reads := database.NewReadRouter(db_ref, replica_ref, cfg.Database.ReplicaMaxLag)
+
+	inspector := asynq.NewInspector(cfg.Redis.ClientOpt())
+
+	// In outbox mode grades are written to Postgres and the relay enqueues
+	// them, and in sync mode the API stores them itself, so in both Redis
+	// being down doesn't make the API unready. The relay always runs to
+	// drain what is left after disabling the outbox
+	var task_enqueuer handlers.Enqueuer = enqueuer
+	redis_check := health.RedisCheck(enqueuer)
+	// A backlog is the worker falling behind, and taking the API out of
+	// rotation wouldn't drain it, so the API only reports it
+	backlog_check := health.Optional(health.QueueBacklogCheck(inspector, cfg.Worker.EnqueueQueue, cfg.Readiness.MaxPendingTasks, cfg.Readiness.MaxQueueLatency))
+	if cfg.Outbox.Enabled {
+		slog.Info("outbox mode enabled, grades are written to task_outbox")
+		task_enqueuer = queue.NewOutbox(db_ref)
+	}
+	if cfg.Outbox.Enabled || cfg.Writes.Sync() {
+		redis_check = health.Optional(redis_check)
+	}
+	if cfg.Writes.Sync() {
+		slog.Info("sync write mode enabled, grades are stored before answering")
+	}
+	relay := queue.NewRelay(db_ref, enqueuer, cfg.Outbox.BatchSize)
+
+	readiness := health.NewChecker(cfg.Readiness.CheckTimeout,
+		health.DatabaseCheck(db_ref),
+		health.MigrationsCheck(db_ref),
+		redis_check,
+		backlog_check,
+		health.OutboxCheck(db_ref),
+		health.ReplicaCheck(reads),
+	)
 
-	log.Printf("[Main APP] Initializing database connection to [%s]", database_url)
-	db_ref, err_creating := database.InitDB(database_url)
+	// The cache, the event stream and the realtime updates share one
+	// connection pool to the Redis of the queue. It connects lazily, so it
+	// costs nothing while they are disabled
+	redis_client := cfg.Redis.Client()
 
-	if err_creating != nil {
-		log.Fatalf("Failed to initialize database: %v", err_creating)
+	// GET responses are cached in Redis, falling back to memory while Redis
+	// is unreachable. The worker invalidates them as grades come in
+	var response_cache cache.Store
+	if cfg.Cache.Enabled {
+		response_cache = cache.Fallback{Primary: cache.NewRedisStore(redis_client), Secondary: cache.NewLRU(cfg.Cache.LocalSize)}
 	}
 
-	{
-		routing := router.Group("/stats")
+	// Events of other services become the same tasks as the POST
+	// endpoints, through the same enqueuer (the outbox when enabled)
+	event_consumer := events.NewConsumer(task_enqueuer)
+	var event_stream *events.RedisStream
+	if cfg.Events.Stream != "" {
+		consumer_name, _ := os.Hostname()
+		event_stream = events.NewRedisStream(redis_client, cfg.Events.Stream, cfg.Events.StreamGroup, consumer_name, cfg.Events.StreamClaimIdle)
+	}
 
-		routing.GET("/health", handlers.HealthCheckHandler)
+	// Every replica relays the updates the worker publishes to its own SSE
+	// clients
+	var realtime_hub *realtime.Hub
+	if cfg.Realtime.Enabled {
+		realtime_hub = realtime.NewHub(redis_client, cfg.Realtime.Channel)
+	}
 
-		//routing.POST("/student/grade", handlers.APIHandlerInsertGrade)
+	// In sync mode the API stores the grades, so it also creates their
+	// webhook deliveries and publishes their updates; the worker does it
+	// in async mode
+	grade_listeners := service.Listeners{}
+	if cfg.Writes.Sync() && cfg.Webhooks.Enabled {
+		grade_listeners = append(grade_listeners, webhooks.NewNotifier(db_ref, enqueuer))
+	}
+	if cfg.Writes.Sync() && cfg.Realtime.Enabled {
+		grade_listeners = append(grade_listeners, realtime.NewPublisher(db_ref, redis_client, cfg.Realtime.Channel))
+	}
 
-		// For each POST, we will enqueue a task to process the student grade
-		routing.POST("/student/grade", func(c *gin.Context) {
-			var grade model.Grade
-			if err := c.ShouldBindJSON(&grade); err != nil {
-				c.JSON(400, gin.H{"error": "Invalid input"})
-				return
-			}
-			log.Printf("[Stats Service] Received task grade: %+v", grade)
-			// Enqueue the task to add student grade
-			handlers.EnqueueAddStadisticForStudent(c, enqueuer, grade)
+	var graphql_schema *graph.Schema
+	if cfg.GraphQL.Enabled {
+		graphql_schema, err_creating = graph.New(graph.Limits{
+			MaxDepth:      cfg.GraphQL.MaxDepth,
+			MaxComplexity: cfg.GraphQL.MaxComplexity,
 		})
+		if err_creating != nil {
+			fatal("failed to initialize GraphQL schema", "error", err_creating)
+		}
+	}
 
-		routing.GET("/student/:student_id/course/:course_id", func(c *gin.Context) {
-			handlers.APIHandlerGetStatsForStudent(db_ref, c)
-		})
+	routes.Register(router, routes.Dependencies{
+		DB:         db_ref,
+		Enqueuer:   task_enqueuer,
+		Replayer:   enqueuer,
+		Readiness:  readiness,
+		Reads:      reads,
+		Features:   cfg.Features,
+		AdminToken: cfg.Admin.Token,
+		SyncWrites: cfg.Writes.Sync(),
+		Cache:      response_cache,
 
-		// Endpoints individuales
-		routing.GET("/student/:student_id/average", func(c *gin.Context) {
-			handlers.APIHandlerGetStudentAverageOverTime(db_ref, c)
-		})
-		routing.GET("/course/:course_id/average", func(c *gin.Context) {
-			handlers.APIHandlerGetCourseAverageOverTime(db_ref, c)
-		})
+		GradeListener: grade_listeners,
 
-		routing.POST("/student/task/grade", func(c *gin.Context) {
-			var gradeTask model.GradeTask
-			if err := c.ShouldBindJSON(&gradeTask); err != nil {
-				c.JSON(400, gin.H{"error": "Invalid input"})
-				return
-			}
-			log.Printf("[Stats Service] Received task grade: %+v", gradeTask)
-			handlers.EnqueueAddGradeTask(c, enqueuer, gradeTask)
+		Realtime:          realtime_hub,
+		RealtimeHeartbeat: cfg.Realtime.Heartbeat,
 
-			//routing.GET("/student/:student_id/course/:course_id/task/:task_id", handlers.APIHandlerGetStatsForStudentTask)
-		})
+		GraphQL: graphql_schema,
 
-		routing.GET("/student/:student_id/course/:course_id/task/average", func(c *gin.Context) {
-			handlers.APIHandlerGetStudentCourseTasksAverage(db_ref, c)
-		})
+		Events:          event_consumer,
+		EventsSecret:    cfg.Events.WebhookSecret,
+		EventsTolerance: cfg.Events.WebhookTolerance,
 
-		routing.GET("/course/:course_id/task/:task_id/averages", func(c *gin.Context) {
-			handlers.APIHandlerGetTaskAverages(db_ref, c)
-		})
+		CacheOptions: cache.Options{
+			TTL:    cfg.Cache.TTL,
+			MaxAge: cfg.Cache.MaxAge,
+		},
+	})
 
-		routing.GET("/course/:course_id/on_time_percentage", func(c *gin.Context) {
-			handlers.APIHandlerGetCourseOnTimePercentage(db_ref, c)
-		})
+	// The gRPC API serves the same reads and writes on its own port. Its
+	// sync writes invalidate the cached REST responses like the POST
+	// endpoints do
+	var grpc_server *grpc.Server
+	var grpc_health *grpchealth.Server
+	if cfg.GRPC.Enabled {
+		grpc_listener := service.Listeners{grade_listeners}
+		if response_cache != nil {
+			grpc_listener = append(grpc_listener, service.InvalidateResponses{Responses: response_cache})
+		}
+		grpc_health = grpchealth.NewServer()
+		grpc_server = grpcapi.New(grpcapi.Dependencies{
+			DB:            db_ref,
+			Enqueuer:      task_enqueuer,
+			Reads:         reads,
+			SyncWrites:    cfg.Writes.Sync(),
+			GradeListener: grpc_listener,
+		}, grpc_health)
+	}
 
-		routing.GET("/course/:course_id/student/:student_id/on_time_percentage", func(c *gin.Context) {
-			handlers.APIHandlerGetStudentOnTimePercentage(db_ref, c)
-		})
+	shutdown_timeout := cfg.ShutdownTimeout
+
+	server := &http.Server{
+		Addr:              cfg.HTTP.Addr(),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+	if realtime_hub != nil {
+		// SSE streams never go idle, so Shutdown would wait for them
+		// until its timeout
+		server.RegisterOnShutdown(realtime_hub.Disconnect)
+	}
+
+	signal_ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+
+	go reads.Run(signal_ctx, cfg.Database.ReplicaCheckInterval)
+
+	relay_done := make(chan struct{})
+	go func() {
+		defer close(relay_done)
+		relay.Run(signal_ctx, cfg.Outbox.RelayInterval)
+	}()
+
+	stream_done := make(chan struct{})
+	go func() {
+		defer close(stream_done)
+		if event_stream == nil {
+			return
+		}
+		slog.Info("reading events", "stream", cfg.Events.Stream, "group", cfg.Events.StreamGroup)
+		event_stream.Run(signal_ctx, event_consumer)
+	}()
+
+	if realtime_hub != nil {
+		go realtime_hub.Run(signal_ctx)
+	}
+
+	go func() {
+		// Lets log the server start
+		slog.Info("server started", "addr", server.Addr)
+
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("server stopped", "error", err)
+		}
+	}()
+
+	if grpc_server != nil {
+		grpc_listener, err_listening := net.Listen("tcp", cfg.GRPC.Addr())
+		if err_listening != nil {
+			fatal("failed to listen for gRPC", "addr", cfg.GRPC.Addr(), "error", err_listening)
+		}
+		go func() {
+			slog.Info("grpc server started", "addr", cfg.GRPC.Addr())
+
+			if err := grpc_server.Serve(grpc_listener); err != nil {
+				fatal("grpc server stopped", "error", err)
+			}
+		}()
+	}
+
+	<-signal_ctx.Done()
+	slog.Info("shutdown signal received, draining", "timeout", shutdown_timeout.String())
+
+	// Fail readiness first so no new requests are routed here, then wait for
+	// the in-flight ones before closing what they depend on
+	readiness.Drain()
+	if grpc_health != nil {
+		grpc_health.Shutdown()
 	}
 
-	// Lets log the server start
-	logger := log.New(gin.DefaultWriter, "INFO: ", log.LstdFlags)
+	err_shutdown := lifecycle.Shutdown(shutdown_timeout,
+		lifecycle.Hook{Name: "http server", Run: server.Shutdown},
+		lifecycle.Hook{Name: "grpc server", Run: func(ctx context.Context) error {
+			if grpc_server == nil {
+				return nil
+			}
+			return grpcapi.GracefulStop(ctx, grpc_server)
+		}},
+		lifecycle.Hook{Name: "outbox relay", Run: func(ctx context.Context) error {
+			select {
+			case <-relay_done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}},
+		lifecycle.Hook{Name: "event stream", Run: func(ctx context.Context) error {
+			select {
+			case <-stream_done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}},
+		lifecycle.Close("queue client", enqueuer),
+		lifecycle.Close("queue inspector", inspector),
+		lifecycle.Close("redis client", redis_client),
+		lifecycle.Close("database", db_ref),
+		lifecycle.Hook{Name: "read replica", Run: func(context.Context) error {
+			if replica_ref == nil {
+				return nil
+			}
+			return replica_ref.Close()
+		}},
+		lifecycle.Hook{Name: "tracing", Run: shutdown_tracing},
+	)
+	if err_shutdown != nil {
+		fatal("shutdown finished with errors", "error", err_shutdown)
+	}
 
-	logger.Println("Server started on port 8080")
+	slog.Info("shutdown complete")
+}
 
-	router.Run("0.0.0.0:8080")
+// fatal logs at error level and exits, like log.Fatal does for the
+// standard logger.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
@@ -1,50 +1,185 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"strconv"
+	"time"
 
-	"service_stats/internal/database"
-	"service_stats/internal/queue"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/cache"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/config"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/health"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/lifecycle"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/logging"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/metrics"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/queue"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/realtime"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/service"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/tracing"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/webhooks"
 
 	"github.com/hibiken/asynq"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load environment variables from .env file
-	err_env := godotenv.Load()
-	if err_env != nil {
-		log.Printf("[Worker queue] No .env file, working with default environment variables")
+	// Configuration comes from the defaults, CONFIG_FILE, .env and the
+	// environment; see internal/config
+	cfg, err_config := config.Load("service_stats_worker")
+	if err_config != nil {
+		log.Fatalf("[Worker queue] %v", err_config)
 	}
 
-	database_url := os.Getenv("SERVICE_STATS_POSTGRES_URL")
+	err_logging := logging.Init(os.Stdout, cfg.Logging.Config())
+	if err_logging != nil {
+		log.Fatalf("[Worker queue] Invalid logging configuration: %v", err_logging)
+	}
+
+	slog.Info("configuration loaded", "sources", cfg.Sources, "config", cfg.Redacted())
 
-	if database_url == "" {
-		log.Fatal("[Stats Service] SERVICE_STATS_POSTGRES_URL environment variable is not set")
+	shutdown_tracing, err_tracing := tracing.Init(context.Background(), cfg.Tracing.ServiceName, cfg.Tracing.Exporter)
+	if err_tracing != nil {
+		fatal("error initializing tracing", "error", err_tracing)
 	}
 
 	// Initialize the database connection with internal/database/db.go
 
-	log.Printf("[Worker queue] Initializing database connection to [%s]", database_url)
-	db_ref, err := database.InitDB(database_url)
+	database.QueryTimeout = cfg.Database.QueryTimeout
+
+	slog.Info("initializing database connection")
+	db_ref, err := database.InitDB(cfg.Database.URL, cfg.Database.Pool())
 
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("failed to initialize database", "error", err)
 	}
 
-	ip_server := fmt.Sprintf("%s:%s", os.Getenv("ASYNC_QUEUE_HOST"), os.Getenv("ASYNC_QUEUE_PORT"))
+	// How long active tasks get to finish on SIGTERM before asynq puts them
+	// back in the queue
+	shutdown_timeout := cfg.ShutdownTimeout
 
-	log.Printf("[Worker queue] Starting worker on {%s}", ip_server)
+	slog.Info("starting worker",
+		"redis_addr", cfg.Redis.Addr(),
+		"concurrency", cfg.Worker.Concurrency,
+		"queues", cfg.Worker.Queues.String(),
+	)
 	srv := asynq.NewServer(
-		asynq.RedisClientOpt{Addr: ip_server},
-		asynq.Config{Concurrency: 10},
+		cfg.Redis.ClientOpt(),
+		asynq.Config{
+			Concurrency:     cfg.Worker.Concurrency,
+			Queues:          cfg.Worker.Queues,
+			StrictPriority:  cfg.Worker.StrictPriority,
+			Logger:          logging.AsynqLogger{},
+			ShutdownTimeout: shutdown_timeout,
+			// Retries back off per task type, and the tasks asynq gives up
+			// on are kept in dead_letter_tasks to be replayed from the API
+			RetryDelayFunc: queue.RetryDelay,
+			ErrorHandler:   queue.NewDeadLetterHandler(db_ref),
+		},
 	)
 
-	mux := queue.NewMux(db_ref)
+	// The cache and the realtime updates share one connection pool to the
+	// Redis of the queue
+	redis_client := cfg.Redis.Client()
+
+	// Committed grades invalidate the API's cached responses, which live in
+	// the same Redis as the queue
+	var invalidator cache.Invalidator
+	if cfg.Cache.Enabled {
+		invalidator = cache.NewRedisStore(redis_client)
+	}
+
+	listeners := service.Listeners{service.InvalidateResponses{Responses: invalidator}}
 
-	if err := srv.Run(mux); err != nil {
-		log.Fatalf("[Worker queue] Could not run worker: %v", err)
+	// Committed grades also become webhook deliveries, enqueued back to this
+	// same queue and posted by the worker
+	var webhook_enqueuer *queue.Enqueuer
+	var deliverer *webhooks.Deliverer
+	if cfg.Webhooks.Enabled {
+		webhook_enqueuer = queue.NewEnqueuer(cfg.Redis.ClientOpt(), cfg.Worker.EnqueueQueue)
+		listeners = append(listeners, webhooks.NewNotifier(db_ref, webhook_enqueuer))
+		deliverer = webhooks.NewDeliverer(db_ref, cfg.Webhooks.Timeout)
 	}
+
+	// And their aggregates are published for the SSE clients of the API
+	if cfg.Realtime.Enabled {
+		listeners = append(listeners, realtime.NewPublisher(db_ref, redis_client, cfg.Realtime.Channel))
+	}
+
+	mux := queue.NewMux(db_ref, listeners, deliverer)
+
+	metrics_port := strconv.Itoa(cfg.Worker.MetricsPort)
+
+	// The backlog thresholds are enforced here rather than in the API: a
+	// worker that can't keep up with its queue isn't ready
+	inspector := asynq.NewInspector(cfg.Redis.ClientOpt())
+
+	readiness := health.NewChecker(cfg.Readiness.CheckTimeout,
+		health.DatabaseCheck(db_ref),
+		health.MigrationsCheck(db_ref),
+		health.RedisCheck(srv),
+		health.QueueBacklogCheck(inspector, cfg.Worker.EnqueueQueue, cfg.Readiness.MaxPendingTasks, cfg.Readiness.MaxQueueLatency),
+	)
+
+	// The worker has no API, so metrics and probes get their own small server
+	probes_mux := http.NewServeMux()
+	probes_mux.Handle("/metrics", metrics.Handler())
+	probes_mux.Handle("/health/live", health.LiveHandler())
+	probes_mux.Handle("/health/ready", health.ReadyHandler(readiness))
+	probes_server := &http.Server{Addr: "0.0.0.0:" + metrics_port, Handler: probes_mux}
+
+	go func() {
+		slog.Info("serving metrics and probes", "port", metrics_port)
+		if err := probes_server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("metrics server stopped", "error", err)
+		}
+	}()
+
+	signal_ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+
+	if err := srv.Start(mux); err != nil {
+		fatal("could not run worker", "error", err)
+	}
+
+	<-signal_ctx.Done()
+	slog.Info("shutdown signal received, draining active tasks", "timeout", shutdown_timeout.String())
+
+	// Stop pulling new tasks right away; Shutdown then waits for the active
+	// ones (up to ShutdownTimeout) and requeues whatever didn't finish
+	srv.Stop()
+	readiness.Drain()
+
+	err_shutdown := lifecycle.Shutdown(shutdown_timeout+5*time.Second,
+		lifecycle.Hook{Name: "worker", Run: func(context.Context) error {
+			srv.Shutdown()
+			return nil
+		}},
+		lifecycle.Hook{Name: "probes server", Run: probes_server.Shutdown},
+		lifecycle.Hook{Name: "webhook enqueuer", Run: func(context.Context) error {
+			if webhook_enqueuer == nil {
+				return nil
+			}
+			return webhook_enqueuer.Close()
+		}},
+		lifecycle.Close("queue inspector", inspector),
+		lifecycle.Close("database", db_ref),
+		lifecycle.Close("redis client", redis_client),
+		lifecycle.Hook{Name: "tracing", Run: shutdown_tracing},
+	)
+	if err_shutdown != nil {
+		fatal("shutdown finished with errors", "error", err_shutdown)
+	}
+
+	slog.Info("shutdown complete")
+}
+
+// fatal logs at error level and exits, like log.Fatal does for the
+// standard logger.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/config"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/database"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/lifecycle"
+	"github.com/1c2025-IngSoftware2-g7/service_stats/internal/logging"
+)
+
+// rebuild_aggregates recomputes the stats aggregate tables from grades and
+// grades_tasks. Run it after fixing data by hand or restoring a backup; the
+// worker keeps the aggregates up to date otherwise.
+func main() {
+	cfg, err_config := config.Load("service_stats_rebuild")
+	if err_config != nil {
+		log.Fatalf("[Rebuild aggregates] %v", err_config)
+	}
+
+	err_logging := logging.Init(os.Stdout, cfg.Logging.Config())
+	if err_logging != nil {
+		log.Fatalf("[Rebuild aggregates] Invalid logging configuration: %v", err_logging)
+	}
+
+	// InitDB applies pending migrations, which creates the aggregate tables
+	// on a fresh database
+	db_ref, err := database.InitDB(cfg.Database.URL, cfg.Database.Pool())
+	if err != nil {
+		fatal("failed to initialize database", "error", err)
+	}
+	defer db_ref.Close()
+
+	// SIGTERM cancels the rebuild, which rolls back and leaves the previous
+	// aggregates in place
+	signal_ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+
+	if err := database.RebuildAggregates(signal_ctx, db_ref); err != nil {
+		fatal("failed to rebuild aggregates", "error", err)
+	}
+}
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
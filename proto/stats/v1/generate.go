@@ -0,0 +1,5 @@
+// Package statsv1 holds the protobuf messages and the gRPC client and
+// server of the statistics service, generated from stats.proto.
+package statsv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative stats/v1/stats.proto
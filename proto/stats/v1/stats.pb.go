@@ -0,0 +1,2135 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.28.3
+// source: stats/v1/stats.proto
+
+// The statistics service over gRPC, for internal consumers. Every RPC
+// mirrors an endpoint of the REST API under /stats and goes through the
+// same validation and repository code, so both return the same numbers.
+
+package statsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Grade struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	StudentId string                 `protobuf:"bytes,1,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	CourseId  string                 `protobuf:"bytes,2,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	Grade     float64                `protobuf:"fixed64,3,opt,name=grade,proto3" json:"grade,omitempty"`
+	OnTime    bool                   `protobuf:"varint,4,opt,name=on_time,json=onTime,proto3" json:"on_time,omitempty"`
+	// Optional in requests, like in the REST API.
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Grade) Reset() {
+	*x = Grade{}
+	mi := &file_stats_v1_stats_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Grade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Grade) ProtoMessage() {}
+
+func (x *Grade) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Grade.ProtoReflect.Descriptor instead.
+func (*Grade) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Grade) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *Grade) GetCourseId() string {
+	if x != nil {
+		return x.CourseId
+	}
+	return ""
+}
+
+func (x *Grade) GetGrade() float64 {
+	if x != nil {
+		return x.Grade
+	}
+	return 0
+}
+
+func (x *Grade) GetOnTime() bool {
+	if x != nil {
+		return x.OnTime
+	}
+	return false
+}
+
+func (x *Grade) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type GradeTask struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	StudentId string                 `protobuf:"bytes,1,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	CourseId  string                 `protobuf:"bytes,2,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	TaskId    string                 `protobuf:"bytes,3,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Grade     float64                `protobuf:"fixed64,4,opt,name=grade,proto3" json:"grade,omitempty"`
+	OnTime    bool                   `protobuf:"varint,5,opt,name=on_time,json=onTime,proto3" json:"on_time,omitempty"`
+	// Optional in requests, like in the REST API.
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GradeTask) Reset() {
+	*x = GradeTask{}
+	mi := &file_stats_v1_stats_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GradeTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GradeTask) ProtoMessage() {}
+
+func (x *GradeTask) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GradeTask.ProtoReflect.Descriptor instead.
+func (*GradeTask) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GradeTask) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *GradeTask) GetCourseId() string {
+	if x != nil {
+		return x.CourseId
+	}
+	return ""
+}
+
+func (x *GradeTask) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *GradeTask) GetGrade() float64 {
+	if x != nil {
+		return x.Grade
+	}
+	return 0
+}
+
+func (x *GradeTask) GetOnTime() bool {
+	if x != nil {
+		return x.OnTime
+	}
+	return false
+}
+
+func (x *GradeTask) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// Queued is the answer to a grade sent to the queue.
+type Queued struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// How long the queue expects to take to process the grade.
+	ExpectedDelay *durationpb.Duration `protobuf:"bytes,1,opt,name=expected_delay,json=expectedDelay,proto3" json:"expected_delay,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Queued) Reset() {
+	*x = Queued{}
+	mi := &file_stats_v1_stats_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Queued) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Queued) ProtoMessage() {}
+
+func (x *Queued) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Queued.ProtoReflect.Descriptor instead.
+func (*Queued) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Queued) GetExpectedDelay() *durationpb.Duration {
+	if x != nil {
+		return x.ExpectedDelay
+	}
+	return nil
+}
+
+type AddGradeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Grade         *Grade                 `protobuf:"bytes,1,opt,name=grade,proto3" json:"grade,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddGradeRequest) Reset() {
+	*x = AddGradeRequest{}
+	mi := &file_stats_v1_stats_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddGradeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddGradeRequest) ProtoMessage() {}
+
+func (x *AddGradeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddGradeRequest.ProtoReflect.Descriptor instead.
+func (*AddGradeRequest) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AddGradeRequest) GetGrade() *Grade {
+	if x != nil {
+		return x.Grade
+	}
+	return nil
+}
+
+type AddGradeResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Result:
+	//
+	//	*AddGradeResponse_Queued
+	//	*AddGradeResponse_Stored
+	Result        isAddGradeResponse_Result `protobuf_oneof:"result"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddGradeResponse) Reset() {
+	*x = AddGradeResponse{}
+	mi := &file_stats_v1_stats_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddGradeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddGradeResponse) ProtoMessage() {}
+
+func (x *AddGradeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddGradeResponse.ProtoReflect.Descriptor instead.
+func (*AddGradeResponse) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AddGradeResponse) GetResult() isAddGradeResponse_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *AddGradeResponse) GetQueued() *Queued {
+	if x != nil {
+		if x, ok := x.Result.(*AddGradeResponse_Queued); ok {
+			return x.Queued
+		}
+	}
+	return nil
+}
+
+func (x *AddGradeResponse) GetStored() *Grade {
+	if x != nil {
+		if x, ok := x.Result.(*AddGradeResponse_Stored); ok {
+			return x.Stored
+		}
+	}
+	return nil
+}
+
+type isAddGradeResponse_Result interface {
+	isAddGradeResponse_Result()
+}
+
+type AddGradeResponse_Queued struct {
+	Queued *Queued `protobuf:"bytes,1,opt,name=queued,proto3,oneof"`
+}
+
+type AddGradeResponse_Stored struct {
+	// The grade as stored, when WRITE_MODE is sync.
+	Stored *Grade `protobuf:"bytes,2,opt,name=stored,proto3,oneof"`
+}
+
+func (*AddGradeResponse_Queued) isAddGradeResponse_Result() {}
+
+func (*AddGradeResponse_Stored) isAddGradeResponse_Result() {}
+
+type AddGradeTaskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Grade         *GradeTask             `protobuf:"bytes,1,opt,name=grade,proto3" json:"grade,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddGradeTaskRequest) Reset() {
+	*x = AddGradeTaskRequest{}
+	mi := &file_stats_v1_stats_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddGradeTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddGradeTaskRequest) ProtoMessage() {}
+
+func (x *AddGradeTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddGradeTaskRequest.ProtoReflect.Descriptor instead.
+func (*AddGradeTaskRequest) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AddGradeTaskRequest) GetGrade() *GradeTask {
+	if x != nil {
+		return x.Grade
+	}
+	return nil
+}
+
+type AddGradeTaskResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Result:
+	//
+	//	*AddGradeTaskResponse_Queued
+	//	*AddGradeTaskResponse_Stored
+	Result        isAddGradeTaskResponse_Result `protobuf_oneof:"result"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddGradeTaskResponse) Reset() {
+	*x = AddGradeTaskResponse{}
+	mi := &file_stats_v1_stats_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddGradeTaskResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddGradeTaskResponse) ProtoMessage() {}
+
+func (x *AddGradeTaskResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddGradeTaskResponse.ProtoReflect.Descriptor instead.
+func (*AddGradeTaskResponse) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AddGradeTaskResponse) GetResult() isAddGradeTaskResponse_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *AddGradeTaskResponse) GetQueued() *Queued {
+	if x != nil {
+		if x, ok := x.Result.(*AddGradeTaskResponse_Queued); ok {
+			return x.Queued
+		}
+	}
+	return nil
+}
+
+func (x *AddGradeTaskResponse) GetStored() *GradeTask {
+	if x != nil {
+		if x, ok := x.Result.(*AddGradeTaskResponse_Stored); ok {
+			return x.Stored
+		}
+	}
+	return nil
+}
+
+type isAddGradeTaskResponse_Result interface {
+	isAddGradeTaskResponse_Result()
+}
+
+type AddGradeTaskResponse_Queued struct {
+	Queued *Queued `protobuf:"bytes,1,opt,name=queued,proto3,oneof"`
+}
+
+type AddGradeTaskResponse_Stored struct {
+	// The grade as stored, when WRITE_MODE is sync.
+	Stored *GradeTask `protobuf:"bytes,2,opt,name=stored,proto3,oneof"`
+}
+
+func (*AddGradeTaskResponse_Queued) isAddGradeTaskResponse_Result() {}
+
+func (*AddGradeTaskResponse_Stored) isAddGradeTaskResponse_Result() {}
+
+type GetStudentCourseAverageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudentId     string                 `protobuf:"bytes,1,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	CourseId      string                 `protobuf:"bytes,2,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStudentCourseAverageRequest) Reset() {
+	*x = GetStudentCourseAverageRequest{}
+	mi := &file_stats_v1_stats_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStudentCourseAverageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStudentCourseAverageRequest) ProtoMessage() {}
+
+func (x *GetStudentCourseAverageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStudentCourseAverageRequest.ProtoReflect.Descriptor instead.
+func (*GetStudentCourseAverageRequest) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetStudentCourseAverageRequest) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *GetStudentCourseAverageRequest) GetCourseId() string {
+	if x != nil {
+		return x.CourseId
+	}
+	return ""
+}
+
+type GetStudentTaskAverageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudentId     string                 `protobuf:"bytes,1,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	CourseId      string                 `protobuf:"bytes,2,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	TaskId        string                 `protobuf:"bytes,3,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStudentTaskAverageRequest) Reset() {
+	*x = GetStudentTaskAverageRequest{}
+	mi := &file_stats_v1_stats_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStudentTaskAverageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStudentTaskAverageRequest) ProtoMessage() {}
+
+func (x *GetStudentTaskAverageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStudentTaskAverageRequest.ProtoReflect.Descriptor instead.
+func (*GetStudentTaskAverageRequest) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetStudentTaskAverageRequest) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *GetStudentTaskAverageRequest) GetCourseId() string {
+	if x != nil {
+		return x.CourseId
+	}
+	return ""
+}
+
+func (x *GetStudentTaskAverageRequest) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+type AverageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Average       float64                `protobuf:"fixed64,1,opt,name=average,proto3" json:"average,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AverageResponse) Reset() {
+	*x = AverageResponse{}
+	mi := &file_stats_v1_stats_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AverageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AverageResponse) ProtoMessage() {}
+
+func (x *AverageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AverageResponse.ProtoReflect.Descriptor instead.
+func (*AverageResponse) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AverageResponse) GetAverage() float64 {
+	if x != nil {
+		return x.Average
+	}
+	return 0
+}
+
+// TimeRange holds the query parameters of the series: dates are
+// YYYY-MM-DD, group_by a unit ("day", "week"...), an interval ("3 days")
+// or "term". Without group_by a single all_time period is returned.
+type TimeRange struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	StartDate string                 `protobuf:"bytes,1,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate   string                 `protobuf:"bytes,2,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	GroupBy   string                 `protobuf:"bytes,3,opt,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	Tz        string                 `protobuf:"bytes,4,opt,name=tz,proto3" json:"tz,omitempty"`
+	// "iso" (Monday) or "sunday".
+	WeekStart string `protobuf:"bytes,5,opt,name=week_start,json=weekStart,proto3" json:"week_start,omitempty"`
+	// Academic calendar for group_by "term".
+	Institution   string `protobuf:"bytes,6,opt,name=institution,proto3" json:"institution,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeRange) Reset() {
+	*x = TimeRange{}
+	mi := &file_stats_v1_stats_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeRange) ProtoMessage() {}
+
+func (x *TimeRange) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeRange.ProtoReflect.Descriptor instead.
+func (*TimeRange) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TimeRange) GetStartDate() string {
+	if x != nil {
+		return x.StartDate
+	}
+	return ""
+}
+
+func (x *TimeRange) GetEndDate() string {
+	if x != nil {
+		return x.EndDate
+	}
+	return ""
+}
+
+func (x *TimeRange) GetGroupBy() string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return ""
+}
+
+func (x *TimeRange) GetTz() string {
+	if x != nil {
+		return x.Tz
+	}
+	return ""
+}
+
+func (x *TimeRange) GetWeekStart() string {
+	if x != nil {
+		return x.WeekStart
+	}
+	return ""
+}
+
+func (x *TimeRange) GetInstitution() string {
+	if x != nil {
+		return x.Institution
+	}
+	return ""
+}
+
+// SeriesOptions fill the periods without grades and add running averages.
+type SeriesOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "null", "zero" or "previous"; empty leaves the periods out.
+	Fill string `protobuf:"bytes,1,opt,name=fill,proto3" json:"fill,omitempty"`
+	// Periods of the rolling average; zero leaves it out. Both running
+	// averages only count the grades from the start of the range.
+	Rolling       int32 `protobuf:"varint,2,opt,name=rolling,proto3" json:"rolling,omitempty"`
+	Cumulative    bool  `protobuf:"varint,3,opt,name=cumulative,proto3" json:"cumulative,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SeriesOptions) Reset() {
+	*x = SeriesOptions{}
+	mi := &file_stats_v1_stats_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SeriesOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeriesOptions) ProtoMessage() {}
+
+func (x *SeriesOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeriesOptions.ProtoReflect.Descriptor instead.
+func (*SeriesOptions) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SeriesOptions) GetFill() string {
+	if x != nil {
+		return x.Fill
+	}
+	return ""
+}
+
+func (x *SeriesOptions) GetRolling() int32 {
+	if x != nil {
+		return x.Rolling
+	}
+	return 0
+}
+
+func (x *SeriesOptions) GetCumulative() bool {
+	if x != nil {
+		return x.Cumulative
+	}
+	return false
+}
+
+// QueriedRange is the range a series covers once the dates are parsed in
+// its time zone. Unset ends are open.
+type QueriedRange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	Tz            string                 `protobuf:"bytes,3,opt,name=tz,proto3" json:"tz,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueriedRange) Reset() {
+	*x = QueriedRange{}
+	mi := &file_stats_v1_stats_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueriedRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueriedRange) ProtoMessage() {}
+
+func (x *QueriedRange) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueriedRange.ProtoReflect.Descriptor instead.
+func (*QueriedRange) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *QueriedRange) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *QueriedRange) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+func (x *QueriedRange) GetTz() string {
+	if x != nil {
+		return x.Tz
+	}
+	return ""
+}
+
+type AveragePeriod struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Period string                 `protobuf:"bytes,1,opt,name=period,proto3" json:"period,omitempty"`
+	// Unset for the periods without grades when fill is "null".
+	Average    *float64 `protobuf:"fixed64,2,opt,name=average,proto3,oneof" json:"average,omitempty"`
+	GradeCount int32    `protobuf:"varint,3,opt,name=grade_count,json=gradeCount,proto3" json:"grade_count,omitempty"`
+	// Set when rolling is.
+	RollingAverage *float64 `protobuf:"fixed64,4,opt,name=rolling_average,json=rollingAverage,proto3,oneof" json:"rolling_average,omitempty"`
+	// Set when cumulative is.
+	CumulativeAverage *float64 `protobuf:"fixed64,5,opt,name=cumulative_average,json=cumulativeAverage,proto3,oneof" json:"cumulative_average,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *AveragePeriod) Reset() {
+	*x = AveragePeriod{}
+	mi := &file_stats_v1_stats_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AveragePeriod) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AveragePeriod) ProtoMessage() {}
+
+func (x *AveragePeriod) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AveragePeriod.ProtoReflect.Descriptor instead.
+func (*AveragePeriod) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AveragePeriod) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *AveragePeriod) GetAverage() float64 {
+	if x != nil && x.Average != nil {
+		return *x.Average
+	}
+	return 0
+}
+
+func (x *AveragePeriod) GetGradeCount() int32 {
+	if x != nil {
+		return x.GradeCount
+	}
+	return 0
+}
+
+func (x *AveragePeriod) GetRollingAverage() float64 {
+	if x != nil && x.RollingAverage != nil {
+		return *x.RollingAverage
+	}
+	return 0
+}
+
+func (x *AveragePeriod) GetCumulativeAverage() float64 {
+	if x != nil && x.CumulativeAverage != nil {
+		return *x.CumulativeAverage
+	}
+	return 0
+}
+
+type GetStudentAveragesOverTimeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudentId     string                 `protobuf:"bytes,1,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	Range         *TimeRange             `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`
+	Series        *SeriesOptions         `protobuf:"bytes,3,opt,name=series,proto3" json:"series,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStudentAveragesOverTimeRequest) Reset() {
+	*x = GetStudentAveragesOverTimeRequest{}
+	mi := &file_stats_v1_stats_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStudentAveragesOverTimeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStudentAveragesOverTimeRequest) ProtoMessage() {}
+
+func (x *GetStudentAveragesOverTimeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStudentAveragesOverTimeRequest.ProtoReflect.Descriptor instead.
+func (*GetStudentAveragesOverTimeRequest) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetStudentAveragesOverTimeRequest) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *GetStudentAveragesOverTimeRequest) GetRange() *TimeRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+func (x *GetStudentAveragesOverTimeRequest) GetSeries() *SeriesOptions {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+type GetCourseAveragesOverTimeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CourseId      string                 `protobuf:"bytes,1,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	Range         *TimeRange             `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`
+	Series        *SeriesOptions         `protobuf:"bytes,3,opt,name=series,proto3" json:"series,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCourseAveragesOverTimeRequest) Reset() {
+	*x = GetCourseAveragesOverTimeRequest{}
+	mi := &file_stats_v1_stats_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCourseAveragesOverTimeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCourseAveragesOverTimeRequest) ProtoMessage() {}
+
+func (x *GetCourseAveragesOverTimeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCourseAveragesOverTimeRequest.ProtoReflect.Descriptor instead.
+func (*GetCourseAveragesOverTimeRequest) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetCourseAveragesOverTimeRequest) GetCourseId() string {
+	if x != nil {
+		return x.CourseId
+	}
+	return ""
+}
+
+func (x *GetCourseAveragesOverTimeRequest) GetRange() *TimeRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+func (x *GetCourseAveragesOverTimeRequest) GetSeries() *SeriesOptions {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
+type AveragesOverTimeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Averages      []*AveragePeriod       `protobuf:"bytes,1,rep,name=averages,proto3" json:"averages,omitempty"`
+	Range         *QueriedRange          `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`
+	GroupBy       string                 `protobuf:"bytes,3,opt,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AveragesOverTimeResponse) Reset() {
+	*x = AveragesOverTimeResponse{}
+	mi := &file_stats_v1_stats_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AveragesOverTimeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AveragesOverTimeResponse) ProtoMessage() {}
+
+func (x *AveragesOverTimeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AveragesOverTimeResponse.ProtoReflect.Descriptor instead.
+func (*AveragesOverTimeResponse) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AveragesOverTimeResponse) GetAverages() []*AveragePeriod {
+	if x != nil {
+		return x.Averages
+	}
+	return nil
+}
+
+func (x *AveragesOverTimeResponse) GetRange() *QueriedRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+func (x *AveragesOverTimeResponse) GetGroupBy() string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return ""
+}
+
+// ListOptions page, sort and filter the lists of students.
+type ListOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Zero is the default page size.
+	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// next_cursor of the previous page.
+	Cursor string `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// "average", "count" or "student_id".
+	Sort string `protobuf:"bytes,3,opt,name=sort,proto3" json:"sort,omitempty"`
+	// "asc" or "desc".
+	Order      string   `protobuf:"bytes,4,opt,name=order,proto3" json:"order,omitempty"`
+	MinAverage *float64 `protobuf:"fixed64,5,opt,name=min_average,json=minAverage,proto3,oneof" json:"min_average,omitempty"`
+	MaxAverage *float64 `protobuf:"fixed64,6,opt,name=max_average,json=maxAverage,proto3,oneof" json:"max_average,omitempty"`
+	// Only counts the grades submitted on time.
+	OnTime bool `protobuf:"varint,7,opt,name=on_time,json=onTime,proto3" json:"on_time,omitempty"`
+	// Only counts the grades created from this date, YYYY-MM-DD.
+	GradedAfter   string `protobuf:"bytes,8,opt,name=graded_after,json=gradedAfter,proto3" json:"graded_after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOptions) Reset() {
+	*x = ListOptions{}
+	mi := &file_stats_v1_stats_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOptions) ProtoMessage() {}
+
+func (x *ListOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOptions.ProtoReflect.Descriptor instead.
+func (*ListOptions) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListOptions) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListOptions) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListOptions) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListOptions) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+func (x *ListOptions) GetMinAverage() float64 {
+	if x != nil && x.MinAverage != nil {
+		return *x.MinAverage
+	}
+	return 0
+}
+
+func (x *ListOptions) GetMaxAverage() float64 {
+	if x != nil && x.MaxAverage != nil {
+		return *x.MaxAverage
+	}
+	return 0
+}
+
+func (x *ListOptions) GetOnTime() bool {
+	if x != nil {
+		return x.OnTime
+	}
+	return false
+}
+
+func (x *ListOptions) GetGradedAfter() string {
+	if x != nil {
+		return x.GradedAfter
+	}
+	return ""
+}
+
+type Pagination struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Limit int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Number of students matching the filters across all pages.
+	Total int32 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	// Empty on the last page.
+	NextCursor    string `protobuf:"bytes,3,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pagination) Reset() {
+	*x = Pagination{}
+	mi := &file_stats_v1_stats_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pagination) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pagination) ProtoMessage() {}
+
+func (x *Pagination) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pagination.ProtoReflect.Descriptor instead.
+func (*Pagination) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Pagination) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *Pagination) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Pagination) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type StudentAverage struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	StudentId string                 `protobuf:"bytes,1,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	Average   float64                `protobuf:"fixed64,2,opt,name=average,proto3" json:"average,omitempty"`
+	// Number of grades averaged.
+	Count         int32 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StudentAverage) Reset() {
+	*x = StudentAverage{}
+	mi := &file_stats_v1_stats_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StudentAverage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StudentAverage) ProtoMessage() {}
+
+func (x *StudentAverage) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StudentAverage.ProtoReflect.Descriptor instead.
+func (*StudentAverage) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StudentAverage) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *StudentAverage) GetAverage() float64 {
+	if x != nil {
+		return x.Average
+	}
+	return 0
+}
+
+func (x *StudentAverage) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type GetStudentCourseTasksAverageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StudentId     string                 `protobuf:"bytes,1,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	CourseId      string                 `protobuf:"bytes,2,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	List          *ListOptions           `protobuf:"bytes,3,opt,name=list,proto3" json:"list,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStudentCourseTasksAverageRequest) Reset() {
+	*x = GetStudentCourseTasksAverageRequest{}
+	mi := &file_stats_v1_stats_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStudentCourseTasksAverageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStudentCourseTasksAverageRequest) ProtoMessage() {}
+
+func (x *GetStudentCourseTasksAverageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStudentCourseTasksAverageRequest.ProtoReflect.Descriptor instead.
+func (*GetStudentCourseTasksAverageRequest) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetStudentCourseTasksAverageRequest) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *GetStudentCourseTasksAverageRequest) GetCourseId() string {
+	if x != nil {
+		return x.CourseId
+	}
+	return ""
+}
+
+func (x *GetStudentCourseTasksAverageRequest) GetList() *ListOptions {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+type GetStudentCourseTasksAverageResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unset when the student has no task grades in the course.
+	StudentAverage *float64          `protobuf:"fixed64,1,opt,name=student_average,json=studentAverage,proto3,oneof" json:"student_average,omitempty"`
+	OtherStudents  []*StudentAverage `protobuf:"bytes,2,rep,name=other_students,json=otherStudents,proto3" json:"other_students,omitempty"`
+	Pagination     *Pagination       `protobuf:"bytes,3,opt,name=pagination,proto3" json:"pagination,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetStudentCourseTasksAverageResponse) Reset() {
+	*x = GetStudentCourseTasksAverageResponse{}
+	mi := &file_stats_v1_stats_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStudentCourseTasksAverageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStudentCourseTasksAverageResponse) ProtoMessage() {}
+
+func (x *GetStudentCourseTasksAverageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStudentCourseTasksAverageResponse.ProtoReflect.Descriptor instead.
+func (*GetStudentCourseTasksAverageResponse) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetStudentCourseTasksAverageResponse) GetStudentAverage() float64 {
+	if x != nil && x.StudentAverage != nil {
+		return *x.StudentAverage
+	}
+	return 0
+}
+
+func (x *GetStudentCourseTasksAverageResponse) GetOtherStudents() []*StudentAverage {
+	if x != nil {
+		return x.OtherStudents
+	}
+	return nil
+}
+
+func (x *GetStudentCourseTasksAverageResponse) GetPagination() *Pagination {
+	if x != nil {
+		return x.Pagination
+	}
+	return nil
+}
+
+type GetTaskAveragesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CourseId      string                 `protobuf:"bytes,1,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	TaskId        string                 `protobuf:"bytes,2,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	List          *ListOptions           `protobuf:"bytes,3,opt,name=list,proto3" json:"list,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTaskAveragesRequest) Reset() {
+	*x = GetTaskAveragesRequest{}
+	mi := &file_stats_v1_stats_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTaskAveragesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTaskAveragesRequest) ProtoMessage() {}
+
+func (x *GetTaskAveragesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTaskAveragesRequest.ProtoReflect.Descriptor instead.
+func (*GetTaskAveragesRequest) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetTaskAveragesRequest) GetCourseId() string {
+	if x != nil {
+		return x.CourseId
+	}
+	return ""
+}
+
+func (x *GetTaskAveragesRequest) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *GetTaskAveragesRequest) GetList() *ListOptions {
+	if x != nil {
+		return x.List
+	}
+	return nil
+}
+
+type GetTaskAveragesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Average of every grade of the task; zero while it has none.
+	GroupAverage  float64           `protobuf:"fixed64,1,opt,name=group_average,json=groupAverage,proto3" json:"group_average,omitempty"`
+	Students      []*StudentAverage `protobuf:"bytes,2,rep,name=students,proto3" json:"students,omitempty"`
+	Pagination    *Pagination       `protobuf:"bytes,3,opt,name=pagination,proto3" json:"pagination,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTaskAveragesResponse) Reset() {
+	*x = GetTaskAveragesResponse{}
+	mi := &file_stats_v1_stats_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTaskAveragesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTaskAveragesResponse) ProtoMessage() {}
+
+func (x *GetTaskAveragesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTaskAveragesResponse.ProtoReflect.Descriptor instead.
+func (*GetTaskAveragesResponse) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetTaskAveragesResponse) GetGroupAverage() float64 {
+	if x != nil {
+		return x.GroupAverage
+	}
+	return 0
+}
+
+func (x *GetTaskAveragesResponse) GetStudents() []*StudentAverage {
+	if x != nil {
+		return x.Students
+	}
+	return nil
+}
+
+func (x *GetTaskAveragesResponse) GetPagination() *Pagination {
+	if x != nil {
+		return x.Pagination
+	}
+	return nil
+}
+
+type OnTimePeriod struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Period        string                 `protobuf:"bytes,1,opt,name=period,proto3" json:"period,omitempty"`
+	OnTimeCount   int32                  `protobuf:"varint,2,opt,name=on_time_count,json=onTimeCount,proto3" json:"on_time_count,omitempty"`
+	TotalCount    int32                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	Percentage    float64                `protobuf:"fixed64,4,opt,name=percentage,proto3" json:"percentage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OnTimePeriod) Reset() {
+	*x = OnTimePeriod{}
+	mi := &file_stats_v1_stats_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OnTimePeriod) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OnTimePeriod) ProtoMessage() {}
+
+func (x *OnTimePeriod) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OnTimePeriod.ProtoReflect.Descriptor instead.
+func (*OnTimePeriod) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *OnTimePeriod) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *OnTimePeriod) GetOnTimeCount() int32 {
+	if x != nil {
+		return x.OnTimeCount
+	}
+	return 0
+}
+
+func (x *OnTimePeriod) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *OnTimePeriod) GetPercentage() float64 {
+	if x != nil {
+		return x.Percentage
+	}
+	return 0
+}
+
+type GetCourseOnTimePercentageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CourseId      string                 `protobuf:"bytes,1,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	Range         *TimeRange             `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCourseOnTimePercentageRequest) Reset() {
+	*x = GetCourseOnTimePercentageRequest{}
+	mi := &file_stats_v1_stats_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCourseOnTimePercentageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCourseOnTimePercentageRequest) ProtoMessage() {}
+
+func (x *GetCourseOnTimePercentageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCourseOnTimePercentageRequest.ProtoReflect.Descriptor instead.
+func (*GetCourseOnTimePercentageRequest) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetCourseOnTimePercentageRequest) GetCourseId() string {
+	if x != nil {
+		return x.CourseId
+	}
+	return ""
+}
+
+func (x *GetCourseOnTimePercentageRequest) GetRange() *TimeRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+type GetStudentOnTimePercentageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CourseId      string                 `protobuf:"bytes,1,opt,name=course_id,json=courseId,proto3" json:"course_id,omitempty"`
+	StudentId     string                 `protobuf:"bytes,2,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	Range         *TimeRange             `protobuf:"bytes,3,opt,name=range,proto3" json:"range,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStudentOnTimePercentageRequest) Reset() {
+	*x = GetStudentOnTimePercentageRequest{}
+	mi := &file_stats_v1_stats_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStudentOnTimePercentageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStudentOnTimePercentageRequest) ProtoMessage() {}
+
+func (x *GetStudentOnTimePercentageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStudentOnTimePercentageRequest.ProtoReflect.Descriptor instead.
+func (*GetStudentOnTimePercentageRequest) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetStudentOnTimePercentageRequest) GetCourseId() string {
+	if x != nil {
+		return x.CourseId
+	}
+	return ""
+}
+
+func (x *GetStudentOnTimePercentageRequest) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *GetStudentOnTimePercentageRequest) GetRange() *TimeRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+type OnTimePercentageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Periods       []*OnTimePeriod        `protobuf:"bytes,1,rep,name=periods,proto3" json:"periods,omitempty"`
+	Range         *QueriedRange          `protobuf:"bytes,2,opt,name=range,proto3" json:"range,omitempty"`
+	GroupBy       string                 `protobuf:"bytes,3,opt,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OnTimePercentageResponse) Reset() {
+	*x = OnTimePercentageResponse{}
+	mi := &file_stats_v1_stats_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OnTimePercentageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OnTimePercentageResponse) ProtoMessage() {}
+
+func (x *OnTimePercentageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stats_v1_stats_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OnTimePercentageResponse.ProtoReflect.Descriptor instead.
+func (*OnTimePercentageResponse) Descriptor() ([]byte, []int) {
+	return file_stats_v1_stats_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *OnTimePercentageResponse) GetPeriods() []*OnTimePeriod {
+	if x != nil {
+		return x.Periods
+	}
+	return nil
+}
+
+func (x *OnTimePercentageResponse) GetRange() *QueriedRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+func (x *OnTimePercentageResponse) GetGroupBy() string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return ""
+}
+
+var File_stats_v1_stats_proto protoreflect.FileDescriptor
+
+const file_stats_v1_stats_proto_rawDesc = "" +
+	"\n" +
+	"\x14stats/v1/stats.proto\x12\bstats.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xad\x01\n" +
+	"\x05Grade\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x01 \x01(\tR\tstudentId\x12\x1b\n" +
+	"\tcourse_id\x18\x02 \x01(\tR\bcourseId\x12\x14\n" +
+	"\x05grade\x18\x03 \x01(\x01R\x05grade\x12\x17\n" +
+	"\aon_time\x18\x04 \x01(\bR\x06onTime\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\xca\x01\n" +
+	"\tGradeTask\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x01 \x01(\tR\tstudentId\x12\x1b\n" +
+	"\tcourse_id\x18\x02 \x01(\tR\bcourseId\x12\x17\n" +
+	"\atask_id\x18\x03 \x01(\tR\x06taskId\x12\x14\n" +
+	"\x05grade\x18\x04 \x01(\x01R\x05grade\x12\x17\n" +
+	"\aon_time\x18\x05 \x01(\bR\x06onTime\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"J\n" +
+	"\x06Queued\x12@\n" +
+	"\x0eexpected_delay\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\rexpectedDelay\"8\n" +
+	"\x0fAddGradeRequest\x12%\n" +
+	"\x05grade\x18\x01 \x01(\v2\x0f.stats.v1.GradeR\x05grade\"s\n" +
+	"\x10AddGradeResponse\x12*\n" +
+	"\x06queued\x18\x01 \x01(\v2\x10.stats.v1.QueuedH\x00R\x06queued\x12)\n" +
+	"\x06stored\x18\x02 \x01(\v2\x0f.stats.v1.GradeH\x00R\x06storedB\b\n" +
+	"\x06result\"@\n" +
+	"\x13AddGradeTaskRequest\x12)\n" +
+	"\x05grade\x18\x01 \x01(\v2\x13.stats.v1.GradeTaskR\x05grade\"{\n" +
+	"\x14AddGradeTaskResponse\x12*\n" +
+	"\x06queued\x18\x01 \x01(\v2\x10.stats.v1.QueuedH\x00R\x06queued\x12-\n" +
+	"\x06stored\x18\x02 \x01(\v2\x13.stats.v1.GradeTaskH\x00R\x06storedB\b\n" +
+	"\x06result\"\\\n" +
+	"\x1eGetStudentCourseAverageRequest\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x01 \x01(\tR\tstudentId\x12\x1b\n" +
+	"\tcourse_id\x18\x02 \x01(\tR\bcourseId\"s\n" +
+	"\x1cGetStudentTaskAverageRequest\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x01 \x01(\tR\tstudentId\x12\x1b\n" +
+	"\tcourse_id\x18\x02 \x01(\tR\bcourseId\x12\x17\n" +
+	"\atask_id\x18\x03 \x01(\tR\x06taskId\"+\n" +
+	"\x0fAverageResponse\x12\x18\n" +
+	"\aaverage\x18\x01 \x01(\x01R\aaverage\"\xb1\x01\n" +
+	"\tTimeRange\x12\x1d\n" +
+	"\n" +
+	"start_date\x18\x01 \x01(\tR\tstartDate\x12\x19\n" +
+	"\bend_date\x18\x02 \x01(\tR\aendDate\x12\x19\n" +
+	"\bgroup_by\x18\x03 \x01(\tR\agroupBy\x12\x0e\n" +
+	"\x02tz\x18\x04 \x01(\tR\x02tz\x12\x1d\n" +
+	"\n" +
+	"week_start\x18\x05 \x01(\tR\tweekStart\x12 \n" +
+	"\vinstitution\x18\x06 \x01(\tR\vinstitution\"]\n" +
+	"\rSeriesOptions\x12\x12\n" +
+	"\x04fill\x18\x01 \x01(\tR\x04fill\x12\x18\n" +
+	"\arolling\x18\x02 \x01(\x05R\arolling\x12\x1e\n" +
+	"\n" +
+	"cumulative\x18\x03 \x01(\bR\n" +
+	"cumulative\"~\n" +
+	"\fQueriedRange\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
+	"\x03end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\x12\x0e\n" +
+	"\x02tz\x18\x03 \x01(\tR\x02tz\"\x80\x02\n" +
+	"\rAveragePeriod\x12\x16\n" +
+	"\x06period\x18\x01 \x01(\tR\x06period\x12\x1d\n" +
+	"\aaverage\x18\x02 \x01(\x01H\x00R\aaverage\x88\x01\x01\x12\x1f\n" +
+	"\vgrade_count\x18\x03 \x01(\x05R\n" +
+	"gradeCount\x12,\n" +
+	"\x0frolling_average\x18\x04 \x01(\x01H\x01R\x0erollingAverage\x88\x01\x01\x122\n" +
+	"\x12cumulative_average\x18\x05 \x01(\x01H\x02R\x11cumulativeAverage\x88\x01\x01B\n" +
+	"\n" +
+	"\b_averageB\x12\n" +
+	"\x10_rolling_averageB\x15\n" +
+	"\x13_cumulative_average\"\x9e\x01\n" +
+	"!GetStudentAveragesOverTimeRequest\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x01 \x01(\tR\tstudentId\x12)\n" +
+	"\x05range\x18\x02 \x01(\v2\x13.stats.v1.TimeRangeR\x05range\x12/\n" +
+	"\x06series\x18\x03 \x01(\v2\x17.stats.v1.SeriesOptionsR\x06series\"\x9b\x01\n" +
+	" GetCourseAveragesOverTimeRequest\x12\x1b\n" +
+	"\tcourse_id\x18\x01 \x01(\tR\bcourseId\x12)\n" +
+	"\x05range\x18\x02 \x01(\v2\x13.stats.v1.TimeRangeR\x05range\x12/\n" +
+	"\x06series\x18\x03 \x01(\v2\x17.stats.v1.SeriesOptionsR\x06series\"\x98\x01\n" +
+	"\x18AveragesOverTimeResponse\x123\n" +
+	"\baverages\x18\x01 \x03(\v2\x17.stats.v1.AveragePeriodR\baverages\x12,\n" +
+	"\x05range\x18\x02 \x01(\v2\x16.stats.v1.QueriedRangeR\x05range\x12\x19\n" +
+	"\bgroup_by\x18\x03 \x01(\tR\agroupBy\"\x8d\x02\n" +
+	"\vListOptions\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\x12\x12\n" +
+	"\x04sort\x18\x03 \x01(\tR\x04sort\x12\x14\n" +
+	"\x05order\x18\x04 \x01(\tR\x05order\x12$\n" +
+	"\vmin_average\x18\x05 \x01(\x01H\x00R\n" +
+	"minAverage\x88\x01\x01\x12$\n" +
+	"\vmax_average\x18\x06 \x01(\x01H\x01R\n" +
+	"maxAverage\x88\x01\x01\x12\x17\n" +
+	"\aon_time\x18\a \x01(\bR\x06onTime\x12!\n" +
+	"\fgraded_after\x18\b \x01(\tR\vgradedAfterB\x0e\n" +
+	"\f_min_averageB\x0e\n" +
+	"\f_max_average\"Y\n" +
+	"\n" +
+	"Pagination\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x1f\n" +
+	"\vnext_cursor\x18\x03 \x01(\tR\n" +
+	"nextCursor\"_\n" +
+	"\x0eStudentAverage\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x01 \x01(\tR\tstudentId\x12\x18\n" +
+	"\aaverage\x18\x02 \x01(\x01R\aaverage\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\"\x8c\x01\n" +
+	"#GetStudentCourseTasksAverageRequest\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x01 \x01(\tR\tstudentId\x12\x1b\n" +
+	"\tcourse_id\x18\x02 \x01(\tR\bcourseId\x12)\n" +
+	"\x04list\x18\x03 \x01(\v2\x15.stats.v1.ListOptionsR\x04list\"\xdf\x01\n" +
+	"$GetStudentCourseTasksAverageResponse\x12,\n" +
+	"\x0fstudent_average\x18\x01 \x01(\x01H\x00R\x0estudentAverage\x88\x01\x01\x12?\n" +
+	"\x0eother_students\x18\x02 \x03(\v2\x18.stats.v1.StudentAverageR\rotherStudents\x124\n" +
+	"\n" +
+	"pagination\x18\x03 \x01(\v2\x14.stats.v1.PaginationR\n" +
+	"paginationB\x12\n" +
+	"\x10_student_average\"y\n" +
+	"\x16GetTaskAveragesRequest\x12\x1b\n" +
+	"\tcourse_id\x18\x01 \x01(\tR\bcourseId\x12\x17\n" +
+	"\atask_id\x18\x02 \x01(\tR\x06taskId\x12)\n" +
+	"\x04list\x18\x03 \x01(\v2\x15.stats.v1.ListOptionsR\x04list\"\xaa\x01\n" +
+	"\x17GetTaskAveragesResponse\x12#\n" +
+	"\rgroup_average\x18\x01 \x01(\x01R\fgroupAverage\x124\n" +
+	"\bstudents\x18\x02 \x03(\v2\x18.stats.v1.StudentAverageR\bstudents\x124\n" +
+	"\n" +
+	"pagination\x18\x03 \x01(\v2\x14.stats.v1.PaginationR\n" +
+	"pagination\"\x8b\x01\n" +
+	"\fOnTimePeriod\x12\x16\n" +
+	"\x06period\x18\x01 \x01(\tR\x06period\x12\"\n" +
+	"\ron_time_count\x18\x02 \x01(\x05R\vonTimeCount\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x05R\n" +
+	"totalCount\x12\x1e\n" +
+	"\n" +
+	"percentage\x18\x04 \x01(\x01R\n" +
+	"percentage\"j\n" +
+	" GetCourseOnTimePercentageRequest\x12\x1b\n" +
+	"\tcourse_id\x18\x01 \x01(\tR\bcourseId\x12)\n" +
+	"\x05range\x18\x02 \x01(\v2\x13.stats.v1.TimeRangeR\x05range\"\x8a\x01\n" +
+	"!GetStudentOnTimePercentageRequest\x12\x1b\n" +
+	"\tcourse_id\x18\x01 \x01(\tR\bcourseId\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x02 \x01(\tR\tstudentId\x12)\n" +
+	"\x05range\x18\x03 \x01(\v2\x13.stats.v1.TimeRangeR\x05range\"\x95\x01\n" +
+	"\x18OnTimePercentageResponse\x120\n" +
+	"\aperiods\x18\x01 \x03(\v2\x16.stats.v1.OnTimePeriodR\aperiods\x12,\n" +
+	"\x05range\x18\x02 \x01(\v2\x16.stats.v1.QueriedRangeR\x05range\x12\x19\n" +
+	"\bgroup_by\x18\x03 \x01(\tR\agroupBy2\xeb\a\n" +
+	"\fStatsService\x12A\n" +
+	"\bAddGrade\x12\x19.stats.v1.AddGradeRequest\x1a\x1a.stats.v1.AddGradeResponse\x12M\n" +
+	"\fAddGradeTask\x12\x1d.stats.v1.AddGradeTaskRequest\x1a\x1e.stats.v1.AddGradeTaskResponse\x12^\n" +
+	"\x17GetStudentCourseAverage\x12(.stats.v1.GetStudentCourseAverageRequest\x1a\x19.stats.v1.AverageResponse\x12Z\n" +
+	"\x15GetStudentTaskAverage\x12&.stats.v1.GetStudentTaskAverageRequest\x1a\x19.stats.v1.AverageResponse\x12m\n" +
+	"\x1aGetStudentAveragesOverTime\x12+.stats.v1.GetStudentAveragesOverTimeRequest\x1a\".stats.v1.AveragesOverTimeResponse\x12k\n" +
+	"\x19GetCourseAveragesOverTime\x12*.stats.v1.GetCourseAveragesOverTimeRequest\x1a\".stats.v1.AveragesOverTimeResponse\x12}\n" +
+	"\x1cGetStudentCourseTasksAverage\x12-.stats.v1.GetStudentCourseTasksAverageRequest\x1a..stats.v1.GetStudentCourseTasksAverageResponse\x12V\n" +
+	"\x0fGetTaskAverages\x12 .stats.v1.GetTaskAveragesRequest\x1a!.stats.v1.GetTaskAveragesResponse\x12k\n" +
+	"\x19GetCourseOnTimePercentage\x12*.stats.v1.GetCourseOnTimePercentageRequest\x1a\".stats.v1.OnTimePercentageResponse\x12m\n" +
+	"\x1aGetStudentOnTimePercentage\x12+.stats.v1.GetStudentOnTimePercentageRequest\x1a\".stats.v1.OnTimePercentageResponseBHZFgithub.com/1c2025-IngSoftware2-g7/service_stats/proto/stats/v1;statsv1b\x06proto3"
+
+var (
+	file_stats_v1_stats_proto_rawDescOnce sync.Once
+	file_stats_v1_stats_proto_rawDescData []byte
+)
+
+func file_stats_v1_stats_proto_rawDescGZIP() []byte {
+	file_stats_v1_stats_proto_rawDescOnce.Do(func() {
+		file_stats_v1_stats_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_stats_v1_stats_proto_rawDesc), len(file_stats_v1_stats_proto_rawDesc)))
+	})
+	return file_stats_v1_stats_proto_rawDescData
+}
+
+var file_stats_v1_stats_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
+var file_stats_v1_stats_proto_goTypes = []any{
+	(*Grade)(nil),                                // 0: stats.v1.Grade
+	(*GradeTask)(nil),                            // 1: stats.v1.GradeTask
+	(*Queued)(nil),                               // 2: stats.v1.Queued
+	(*AddGradeRequest)(nil),                      // 3: stats.v1.AddGradeRequest
+	(*AddGradeResponse)(nil),                     // 4: stats.v1.AddGradeResponse
+	(*AddGradeTaskRequest)(nil),                  // 5: stats.v1.AddGradeTaskRequest
+	(*AddGradeTaskResponse)(nil),                 // 6: stats.v1.AddGradeTaskResponse
+	(*GetStudentCourseAverageRequest)(nil),       // 7: stats.v1.GetStudentCourseAverageRequest
+	(*GetStudentTaskAverageRequest)(nil),         // 8: stats.v1.GetStudentTaskAverageRequest
+	(*AverageResponse)(nil),                      // 9: stats.v1.AverageResponse
+	(*TimeRange)(nil),                            // 10: stats.v1.TimeRange
+	(*SeriesOptions)(nil),                        // 11: stats.v1.SeriesOptions
+	(*QueriedRange)(nil),                         // 12: stats.v1.QueriedRange
+	(*AveragePeriod)(nil),                        // 13: stats.v1.AveragePeriod
+	(*GetStudentAveragesOverTimeRequest)(nil),    // 14: stats.v1.GetStudentAveragesOverTimeRequest
+	(*GetCourseAveragesOverTimeRequest)(nil),     // 15: stats.v1.GetCourseAveragesOverTimeRequest
+	(*AveragesOverTimeResponse)(nil),             // 16: stats.v1.AveragesOverTimeResponse
+	(*ListOptions)(nil),                          // 17: stats.v1.ListOptions
+	(*Pagination)(nil),                           // 18: stats.v1.Pagination
+	(*StudentAverage)(nil),                       // 19: stats.v1.StudentAverage
+	(*GetStudentCourseTasksAverageRequest)(nil),  // 20: stats.v1.GetStudentCourseTasksAverageRequest
+	(*GetStudentCourseTasksAverageResponse)(nil), // 21: stats.v1.GetStudentCourseTasksAverageResponse
+	(*GetTaskAveragesRequest)(nil),               // 22: stats.v1.GetTaskAveragesRequest
+	(*GetTaskAveragesResponse)(nil),              // 23: stats.v1.GetTaskAveragesResponse
+	(*OnTimePeriod)(nil),                         // 24: stats.v1.OnTimePeriod
+	(*GetCourseOnTimePercentageRequest)(nil),     // 25: stats.v1.GetCourseOnTimePercentageRequest
+	(*GetStudentOnTimePercentageRequest)(nil),    // 26: stats.v1.GetStudentOnTimePercentageRequest
+	(*OnTimePercentageResponse)(nil),             // 27: stats.v1.OnTimePercentageResponse
+	(*timestamppb.Timestamp)(nil),                // 28: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),                  // 29: google.protobuf.Duration
+}
+var file_stats_v1_stats_proto_depIdxs = []int32{
+	28, // 0: stats.v1.Grade.created_at:type_name -> google.protobuf.Timestamp
+	28, // 1: stats.v1.GradeTask.created_at:type_name -> google.protobuf.Timestamp
+	29, // 2: stats.v1.Queued.expected_delay:type_name -> google.protobuf.Duration
+	0,  // 3: stats.v1.AddGradeRequest.grade:type_name -> stats.v1.Grade
+	2,  // 4: stats.v1.AddGradeResponse.queued:type_name -> stats.v1.Queued
+	0,  // 5: stats.v1.AddGradeResponse.stored:type_name -> stats.v1.Grade
+	1,  // 6: stats.v1.AddGradeTaskRequest.grade:type_name -> stats.v1.GradeTask
+	2,  // 7: stats.v1.AddGradeTaskResponse.queued:type_name -> stats.v1.Queued
+	1,  // 8: stats.v1.AddGradeTaskResponse.stored:type_name -> stats.v1.GradeTask
+	28, // 9: stats.v1.QueriedRange.start:type_name -> google.protobuf.Timestamp
+	28, // 10: stats.v1.QueriedRange.end:type_name -> google.protobuf.Timestamp
+	10, // 11: stats.v1.GetStudentAveragesOverTimeRequest.range:type_name -> stats.v1.TimeRange
+	11, // 12: stats.v1.GetStudentAveragesOverTimeRequest.series:type_name -> stats.v1.SeriesOptions
+	10, // 13: stats.v1.GetCourseAveragesOverTimeRequest.range:type_name -> stats.v1.TimeRange
+	11, // 14: stats.v1.GetCourseAveragesOverTimeRequest.series:type_name -> stats.v1.SeriesOptions
+	13, // 15: stats.v1.AveragesOverTimeResponse.averages:type_name -> stats.v1.AveragePeriod
+	12, // 16: stats.v1.AveragesOverTimeResponse.range:type_name -> stats.v1.QueriedRange
+	17, // 17: stats.v1.GetStudentCourseTasksAverageRequest.list:type_name -> stats.v1.ListOptions
+	19, // 18: stats.v1.GetStudentCourseTasksAverageResponse.other_students:type_name -> stats.v1.StudentAverage
+	18, // 19: stats.v1.GetStudentCourseTasksAverageResponse.pagination:type_name -> stats.v1.Pagination
+	17, // 20: stats.v1.GetTaskAveragesRequest.list:type_name -> stats.v1.ListOptions
+	19, // 21: stats.v1.GetTaskAveragesResponse.students:type_name -> stats.v1.StudentAverage
+	18, // 22: stats.v1.GetTaskAveragesResponse.pagination:type_name -> stats.v1.Pagination
+	10, // 23: stats.v1.GetCourseOnTimePercentageRequest.range:type_name -> stats.v1.TimeRange
+	10, // 24: stats.v1.GetStudentOnTimePercentageRequest.range:type_name -> stats.v1.TimeRange
+	24, // 25: stats.v1.OnTimePercentageResponse.periods:type_name -> stats.v1.OnTimePeriod
+	12, // 26: stats.v1.OnTimePercentageResponse.range:type_name -> stats.v1.QueriedRange
+	3,  // 27: stats.v1.StatsService.AddGrade:input_type -> stats.v1.AddGradeRequest
+	5,  // 28: stats.v1.StatsService.AddGradeTask:input_type -> stats.v1.AddGradeTaskRequest
+	7,  // 29: stats.v1.StatsService.GetStudentCourseAverage:input_type -> stats.v1.GetStudentCourseAverageRequest
+	8,  // 30: stats.v1.StatsService.GetStudentTaskAverage:input_type -> stats.v1.GetStudentTaskAverageRequest
+	14, // 31: stats.v1.StatsService.GetStudentAveragesOverTime:input_type -> stats.v1.GetStudentAveragesOverTimeRequest
+	15, // 32: stats.v1.StatsService.GetCourseAveragesOverTime:input_type -> stats.v1.GetCourseAveragesOverTimeRequest
+	20, // 33: stats.v1.StatsService.GetStudentCourseTasksAverage:input_type -> stats.v1.GetStudentCourseTasksAverageRequest
+	22, // 34: stats.v1.StatsService.GetTaskAverages:input_type -> stats.v1.GetTaskAveragesRequest
+	25, // 35: stats.v1.StatsService.GetCourseOnTimePercentage:input_type -> stats.v1.GetCourseOnTimePercentageRequest
+	26, // 36: stats.v1.StatsService.GetStudentOnTimePercentage:input_type -> stats.v1.GetStudentOnTimePercentageRequest
+	4,  // 37: stats.v1.StatsService.AddGrade:output_type -> stats.v1.AddGradeResponse
+	6,  // 38: stats.v1.StatsService.AddGradeTask:output_type -> stats.v1.AddGradeTaskResponse
+	9,  // 39: stats.v1.StatsService.GetStudentCourseAverage:output_type -> stats.v1.AverageResponse
+	9,  // 40: stats.v1.StatsService.GetStudentTaskAverage:output_type -> stats.v1.AverageResponse
+	16, // 41: stats.v1.StatsService.GetStudentAveragesOverTime:output_type -> stats.v1.AveragesOverTimeResponse
+	16, // 42: stats.v1.StatsService.GetCourseAveragesOverTime:output_type -> stats.v1.AveragesOverTimeResponse
+	21, // 43: stats.v1.StatsService.GetStudentCourseTasksAverage:output_type -> stats.v1.GetStudentCourseTasksAverageResponse
+	23, // 44: stats.v1.StatsService.GetTaskAverages:output_type -> stats.v1.GetTaskAveragesResponse
+	27, // 45: stats.v1.StatsService.GetCourseOnTimePercentage:output_type -> stats.v1.OnTimePercentageResponse
+	27, // 46: stats.v1.StatsService.GetStudentOnTimePercentage:output_type -> stats.v1.OnTimePercentageResponse
+	37, // [37:47] is the sub-list for method output_type
+	27, // [27:37] is the sub-list for method input_type
+	27, // [27:27] is the sub-list for extension type_name
+	27, // [27:27] is the sub-list for extension extendee
+	0,  // [0:27] is the sub-list for field type_name
+}
+
+func init() { file_stats_v1_stats_proto_init() }
+func file_stats_v1_stats_proto_init() {
+	if File_stats_v1_stats_proto != nil {
+		return
+	}
+	file_stats_v1_stats_proto_msgTypes[4].OneofWrappers = []any{
+		(*AddGradeResponse_Queued)(nil),
+		(*AddGradeResponse_Stored)(nil),
+	}
+	file_stats_v1_stats_proto_msgTypes[6].OneofWrappers = []any{
+		(*AddGradeTaskResponse_Queued)(nil),
+		(*AddGradeTaskResponse_Stored)(nil),
+	}
+	file_stats_v1_stats_proto_msgTypes[13].OneofWrappers = []any{}
+	file_stats_v1_stats_proto_msgTypes[17].OneofWrappers = []any{}
+	file_stats_v1_stats_proto_msgTypes[21].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_stats_v1_stats_proto_rawDesc), len(file_stats_v1_stats_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   28,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_stats_v1_stats_proto_goTypes,
+		DependencyIndexes: file_stats_v1_stats_proto_depIdxs,
+		MessageInfos:      file_stats_v1_stats_proto_msgTypes,
+	}.Build()
+	File_stats_v1_stats_proto = out.File
+	file_stats_v1_stats_proto_goTypes = nil
+	file_stats_v1_stats_proto_depIdxs = nil
+}
@@ -0,0 +1,511 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: stats/v1/stats.proto
+
+// The statistics service over gRPC, for internal consumers. Every RPC
+// mirrors an endpoint of the REST API under /stats and goes through the
+// same validation and repository code, so both return the same numbers.
+
+package statsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	StatsService_AddGrade_FullMethodName                     = "/stats.v1.StatsService/AddGrade"
+	StatsService_AddGradeTask_FullMethodName                 = "/stats.v1.StatsService/AddGradeTask"
+	StatsService_GetStudentCourseAverage_FullMethodName      = "/stats.v1.StatsService/GetStudentCourseAverage"
+	StatsService_GetStudentTaskAverage_FullMethodName        = "/stats.v1.StatsService/GetStudentTaskAverage"
+	StatsService_GetStudentAveragesOverTime_FullMethodName   = "/stats.v1.StatsService/GetStudentAveragesOverTime"
+	StatsService_GetCourseAveragesOverTime_FullMethodName    = "/stats.v1.StatsService/GetCourseAveragesOverTime"
+	StatsService_GetStudentCourseTasksAverage_FullMethodName = "/stats.v1.StatsService/GetStudentCourseTasksAverage"
+	StatsService_GetTaskAverages_FullMethodName              = "/stats.v1.StatsService/GetTaskAverages"
+	StatsService_GetCourseOnTimePercentage_FullMethodName    = "/stats.v1.StatsService/GetCourseOnTimePercentage"
+	StatsService_GetStudentOnTimePercentage_FullMethodName   = "/stats.v1.StatsService/GetStudentOnTimePercentage"
+)
+
+// StatsServiceClient is the client API for StatsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type StatsServiceClient interface {
+	// Registers a course grade (POST /stats/student/grade). It is queued for
+	// the worker, or stored before answering when WRITE_MODE is sync.
+	AddGrade(ctx context.Context, in *AddGradeRequest, opts ...grpc.CallOption) (*AddGradeResponse, error)
+	// Registers the grade of a task, replacing the previous grade of the
+	// same task (POST /stats/student/task/grade).
+	AddGradeTask(ctx context.Context, in *AddGradeTaskRequest, opts ...grpc.CallOption) (*AddGradeTaskResponse, error)
+	// Average of the course grades of a student
+	// (GET /stats/student/{student_id}/course/{course_id}). NOT_FOUND when
+	// the student has no grades in the course.
+	GetStudentCourseAverage(ctx context.Context, in *GetStudentCourseAverageRequest, opts ...grpc.CallOption) (*AverageResponse, error)
+	// Grade of a student in a task
+	// (GET /stats/student/{student_id}/course/{course_id}/task/{task_id}).
+	// NOT_FOUND when the task has no grade.
+	GetStudentTaskAverage(ctx context.Context, in *GetStudentTaskAverageRequest, opts ...grpc.CallOption) (*AverageResponse, error)
+	// Averages of the course grades of a student over time
+	// (GET /stats/student/{student_id}/average).
+	GetStudentAveragesOverTime(ctx context.Context, in *GetStudentAveragesOverTimeRequest, opts ...grpc.CallOption) (*AveragesOverTimeResponse, error)
+	// Averages of the course grades of a course over time
+	// (GET /stats/course/{course_id}/average).
+	GetCourseAveragesOverTime(ctx context.Context, in *GetCourseAveragesOverTimeRequest, opts ...grpc.CallOption) (*AveragesOverTimeResponse, error)
+	// Task average of a student next to those of the rest of the course
+	// (GET /stats/student/{student_id}/course/{course_id}/task/average).
+	GetStudentCourseTasksAverage(ctx context.Context, in *GetStudentCourseTasksAverageRequest, opts ...grpc.CallOption) (*GetStudentCourseTasksAverageResponse, error)
+	// Averages of the students in a task
+	// (GET /stats/course/{course_id}/task/{task_id}/averages).
+	GetTaskAverages(ctx context.Context, in *GetTaskAveragesRequest, opts ...grpc.CallOption) (*GetTaskAveragesResponse, error)
+	// Share of the task grades of a course submitted on time
+	// (GET /stats/course/{course_id}/on_time_percentage).
+	GetCourseOnTimePercentage(ctx context.Context, in *GetCourseOnTimePercentageRequest, opts ...grpc.CallOption) (*OnTimePercentageResponse, error)
+	// Share of the task grades of a student submitted on time
+	// (GET /stats/course/{course_id}/student/{student_id}/on_time_percentage).
+	GetStudentOnTimePercentage(ctx context.Context, in *GetStudentOnTimePercentageRequest, opts ...grpc.CallOption) (*OnTimePercentageResponse, error)
+}
+
+type statsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStatsServiceClient(cc grpc.ClientConnInterface) StatsServiceClient {
+	return &statsServiceClient{cc}
+}
+
+func (c *statsServiceClient) AddGrade(ctx context.Context, in *AddGradeRequest, opts ...grpc.CallOption) (*AddGradeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddGradeResponse)
+	err := c.cc.Invoke(ctx, StatsService_AddGrade_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) AddGradeTask(ctx context.Context, in *AddGradeTaskRequest, opts ...grpc.CallOption) (*AddGradeTaskResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddGradeTaskResponse)
+	err := c.cc.Invoke(ctx, StatsService_AddGradeTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) GetStudentCourseAverage(ctx context.Context, in *GetStudentCourseAverageRequest, opts ...grpc.CallOption) (*AverageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AverageResponse)
+	err := c.cc.Invoke(ctx, StatsService_GetStudentCourseAverage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) GetStudentTaskAverage(ctx context.Context, in *GetStudentTaskAverageRequest, opts ...grpc.CallOption) (*AverageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AverageResponse)
+	err := c.cc.Invoke(ctx, StatsService_GetStudentTaskAverage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) GetStudentAveragesOverTime(ctx context.Context, in *GetStudentAveragesOverTimeRequest, opts ...grpc.CallOption) (*AveragesOverTimeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AveragesOverTimeResponse)
+	err := c.cc.Invoke(ctx, StatsService_GetStudentAveragesOverTime_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) GetCourseAveragesOverTime(ctx context.Context, in *GetCourseAveragesOverTimeRequest, opts ...grpc.CallOption) (*AveragesOverTimeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AveragesOverTimeResponse)
+	err := c.cc.Invoke(ctx, StatsService_GetCourseAveragesOverTime_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) GetStudentCourseTasksAverage(ctx context.Context, in *GetStudentCourseTasksAverageRequest, opts ...grpc.CallOption) (*GetStudentCourseTasksAverageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStudentCourseTasksAverageResponse)
+	err := c.cc.Invoke(ctx, StatsService_GetStudentCourseTasksAverage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) GetTaskAverages(ctx context.Context, in *GetTaskAveragesRequest, opts ...grpc.CallOption) (*GetTaskAveragesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTaskAveragesResponse)
+	err := c.cc.Invoke(ctx, StatsService_GetTaskAverages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) GetCourseOnTimePercentage(ctx context.Context, in *GetCourseOnTimePercentageRequest, opts ...grpc.CallOption) (*OnTimePercentageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OnTimePercentageResponse)
+	err := c.cc.Invoke(ctx, StatsService_GetCourseOnTimePercentage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *statsServiceClient) GetStudentOnTimePercentage(ctx context.Context, in *GetStudentOnTimePercentageRequest, opts ...grpc.CallOption) (*OnTimePercentageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OnTimePercentageResponse)
+	err := c.cc.Invoke(ctx, StatsService_GetStudentOnTimePercentage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StatsServiceServer is the server API for StatsService service.
+// All implementations must embed UnimplementedStatsServiceServer
+// for forward compatibility.
+type StatsServiceServer interface {
+	// Registers a course grade (POST /stats/student/grade). It is queued for
+	// the worker, or stored before answering when WRITE_MODE is sync.
+	AddGrade(context.Context, *AddGradeRequest) (*AddGradeResponse, error)
+	// Registers the grade of a task, replacing the previous grade of the
+	// same task (POST /stats/student/task/grade).
+	AddGradeTask(context.Context, *AddGradeTaskRequest) (*AddGradeTaskResponse, error)
+	// Average of the course grades of a student
+	// (GET /stats/student/{student_id}/course/{course_id}). NOT_FOUND when
+	// the student has no grades in the course.
+	GetStudentCourseAverage(context.Context, *GetStudentCourseAverageRequest) (*AverageResponse, error)
+	// Grade of a student in a task
+	// (GET /stats/student/{student_id}/course/{course_id}/task/{task_id}).
+	// NOT_FOUND when the task has no grade.
+	GetStudentTaskAverage(context.Context, *GetStudentTaskAverageRequest) (*AverageResponse, error)
+	// Averages of the course grades of a student over time
+	// (GET /stats/student/{student_id}/average).
+	GetStudentAveragesOverTime(context.Context, *GetStudentAveragesOverTimeRequest) (*AveragesOverTimeResponse, error)
+	// Averages of the course grades of a course over time
+	// (GET /stats/course/{course_id}/average).
+	GetCourseAveragesOverTime(context.Context, *GetCourseAveragesOverTimeRequest) (*AveragesOverTimeResponse, error)
+	// Task average of a student next to those of the rest of the course
+	// (GET /stats/student/{student_id}/course/{course_id}/task/average).
+	GetStudentCourseTasksAverage(context.Context, *GetStudentCourseTasksAverageRequest) (*GetStudentCourseTasksAverageResponse, error)
+	// Averages of the students in a task
+	// (GET /stats/course/{course_id}/task/{task_id}/averages).
+	GetTaskAverages(context.Context, *GetTaskAveragesRequest) (*GetTaskAveragesResponse, error)
+	// Share of the task grades of a course submitted on time
+	// (GET /stats/course/{course_id}/on_time_percentage).
+	GetCourseOnTimePercentage(context.Context, *GetCourseOnTimePercentageRequest) (*OnTimePercentageResponse, error)
+	// Share of the task grades of a student submitted on time
+	// (GET /stats/course/{course_id}/student/{student_id}/on_time_percentage).
+	GetStudentOnTimePercentage(context.Context, *GetStudentOnTimePercentageRequest) (*OnTimePercentageResponse, error)
+	mustEmbedUnimplementedStatsServiceServer()
+}
+
+// UnimplementedStatsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStatsServiceServer struct{}
+
+func (UnimplementedStatsServiceServer) AddGrade(context.Context, *AddGradeRequest) (*AddGradeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddGrade not implemented")
+}
+func (UnimplementedStatsServiceServer) AddGradeTask(context.Context, *AddGradeTaskRequest) (*AddGradeTaskResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddGradeTask not implemented")
+}
+func (UnimplementedStatsServiceServer) GetStudentCourseAverage(context.Context, *GetStudentCourseAverageRequest) (*AverageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStudentCourseAverage not implemented")
+}
+func (UnimplementedStatsServiceServer) GetStudentTaskAverage(context.Context, *GetStudentTaskAverageRequest) (*AverageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStudentTaskAverage not implemented")
+}
+func (UnimplementedStatsServiceServer) GetStudentAveragesOverTime(context.Context, *GetStudentAveragesOverTimeRequest) (*AveragesOverTimeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStudentAveragesOverTime not implemented")
+}
+func (UnimplementedStatsServiceServer) GetCourseAveragesOverTime(context.Context, *GetCourseAveragesOverTimeRequest) (*AveragesOverTimeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCourseAveragesOverTime not implemented")
+}
+func (UnimplementedStatsServiceServer) GetStudentCourseTasksAverage(context.Context, *GetStudentCourseTasksAverageRequest) (*GetStudentCourseTasksAverageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStudentCourseTasksAverage not implemented")
+}
+func (UnimplementedStatsServiceServer) GetTaskAverages(context.Context, *GetTaskAveragesRequest) (*GetTaskAveragesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTaskAverages not implemented")
+}
+func (UnimplementedStatsServiceServer) GetCourseOnTimePercentage(context.Context, *GetCourseOnTimePercentageRequest) (*OnTimePercentageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCourseOnTimePercentage not implemented")
+}
+func (UnimplementedStatsServiceServer) GetStudentOnTimePercentage(context.Context, *GetStudentOnTimePercentageRequest) (*OnTimePercentageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStudentOnTimePercentage not implemented")
+}
+func (UnimplementedStatsServiceServer) mustEmbedUnimplementedStatsServiceServer() {}
+func (UnimplementedStatsServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeStatsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StatsServiceServer will
+// result in compilation errors.
+type UnsafeStatsServiceServer interface {
+	mustEmbedUnimplementedStatsServiceServer()
+}
+
+func RegisterStatsServiceServer(s grpc.ServiceRegistrar, srv StatsServiceServer) {
+	// If the following call pancis, it indicates UnimplementedStatsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&StatsService_ServiceDesc, srv)
+}
+
+func _StatsService_AddGrade_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddGradeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).AddGrade(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_AddGrade_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).AddGrade(ctx, req.(*AddGradeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_AddGradeTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddGradeTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).AddGradeTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_AddGradeTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).AddGradeTask(ctx, req.(*AddGradeTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_GetStudentCourseAverage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStudentCourseAverageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).GetStudentCourseAverage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_GetStudentCourseAverage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).GetStudentCourseAverage(ctx, req.(*GetStudentCourseAverageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_GetStudentTaskAverage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStudentTaskAverageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).GetStudentTaskAverage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_GetStudentTaskAverage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).GetStudentTaskAverage(ctx, req.(*GetStudentTaskAverageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_GetStudentAveragesOverTime_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStudentAveragesOverTimeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).GetStudentAveragesOverTime(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_GetStudentAveragesOverTime_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).GetStudentAveragesOverTime(ctx, req.(*GetStudentAveragesOverTimeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_GetCourseAveragesOverTime_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCourseAveragesOverTimeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).GetCourseAveragesOverTime(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_GetCourseAveragesOverTime_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).GetCourseAveragesOverTime(ctx, req.(*GetCourseAveragesOverTimeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_GetStudentCourseTasksAverage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStudentCourseTasksAverageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).GetStudentCourseTasksAverage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_GetStudentCourseTasksAverage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).GetStudentCourseTasksAverage(ctx, req.(*GetStudentCourseTasksAverageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_GetTaskAverages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaskAveragesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).GetTaskAverages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_GetTaskAverages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).GetTaskAverages(ctx, req.(*GetTaskAveragesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_GetCourseOnTimePercentage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCourseOnTimePercentageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).GetCourseOnTimePercentage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_GetCourseOnTimePercentage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).GetCourseOnTimePercentage(ctx, req.(*GetCourseOnTimePercentageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatsService_GetStudentOnTimePercentage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStudentOnTimePercentageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatsServiceServer).GetStudentOnTimePercentage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StatsService_GetStudentOnTimePercentage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatsServiceServer).GetStudentOnTimePercentage(ctx, req.(*GetStudentOnTimePercentageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StatsService_ServiceDesc is the grpc.ServiceDesc for StatsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StatsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "stats.v1.StatsService",
+	HandlerType: (*StatsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddGrade",
+			Handler:    _StatsService_AddGrade_Handler,
+		},
+		{
+			MethodName: "AddGradeTask",
+			Handler:    _StatsService_AddGradeTask_Handler,
+		},
+		{
+			MethodName: "GetStudentCourseAverage",
+			Handler:    _StatsService_GetStudentCourseAverage_Handler,
+		},
+		{
+			MethodName: "GetStudentTaskAverage",
+			Handler:    _StatsService_GetStudentTaskAverage_Handler,
+		},
+		{
+			MethodName: "GetStudentAveragesOverTime",
+			Handler:    _StatsService_GetStudentAveragesOverTime_Handler,
+		},
+		{
+			MethodName: "GetCourseAveragesOverTime",
+			Handler:    _StatsService_GetCourseAveragesOverTime_Handler,
+		},
+		{
+			MethodName: "GetStudentCourseTasksAverage",
+			Handler:    _StatsService_GetStudentCourseTasksAverage_Handler,
+		},
+		{
+			MethodName: "GetTaskAverages",
+			Handler:    _StatsService_GetTaskAverages_Handler,
+		},
+		{
+			MethodName: "GetCourseOnTimePercentage",
+			Handler:    _StatsService_GetCourseOnTimePercentage_Handler,
+		},
+		{
+			MethodName: "GetStudentOnTimePercentage",
+			Handler:    _StatsService_GetStudentOnTimePercentage_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "stats/v1/stats.proto",
+}